@@ -1,6 +1,13 @@
 package cli
 
-import "github.com/AlexsanderHamir/prof/internal/app"
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/AlexsanderHamir/prof/internal/app"
+)
 
 // Execute runs the CLI application with default (production) services.
 func Execute() error {
@@ -8,6 +15,9 @@ func Execute() error {
 }
 
 // ExecuteWith runs the CLI using the given composition root. Pass nil to use [app.Default].
+// Interrupt and SIGTERM cancel the command context, which stops in-flight go test and pprof runs.
 func ExecuteWith(services *app.Services) error {
-	return CreateRootCmd(services).Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return CreateRootCmd(services).ExecuteContext(ctx)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/AlexsanderHamir/prof/internal/app"
+	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 	"github.com/spf13/cobra"
 )
@@ -13,10 +14,18 @@ type manualCollectFlags struct {
 }
 
 type autoCollectFlags struct {
-	benchmarks []string
-	profiles   []string
-	tag        string
-	count      int
+	benchmarks  []string
+	profiles    []string
+	tag         string
+	count       int
+	sampleIndex map[string]string
+	goTest      config.GoTestFlags
+	race        bool
+	env         []string
+	appendTag   bool
+	resume      bool
+	parallel    int
+	perIter     bool
 }
 
 func newManualCollectCmd(svc *app.Services) *cobra.Command {
@@ -26,10 +35,12 @@ func newManualCollectCmd(svc *app.Services) *cobra.Command {
 		Short:   fmt.Sprintf("Ingest existing pprof profile binaries and organize them under %s/<tag>/ (does not run go test).", workspace.MainDirOutput),
 		Args:    cobra.MinimumNArgs(1),
 		Example: fmt.Sprintf("prof %s --tag tagName cpu.prof memory.prof block.prof mutex.prof", CmdManual),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return svc.Collect.RunManual(app.CollectManualOptions{
-				Files: args,
-				Tag:   f.tag,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return svc.Collect.RunManual(cmd.Context(), app.CollectManualOptions{
+				Files:       args,
+				Tag:         f.tag,
+				ProfVersion: svc.Version,
+				Out:         svc.Out,
 			})
 		},
 	}
@@ -43,26 +54,73 @@ func newAutoBenchmarkCmd(svc *app.Services) *cobra.Command {
 	benchFlag := "benchmarks"
 	profileFlag := "profiles"
 	countFlag := "count"
-	example := fmt.Sprintf(`prof %s --%s "BenchmarkGenPool" --%s "cpu,memory" --%s 10 --%s "tag1"`,
-		CmdAuto, benchFlag, profileFlag, countFlag, tagFlag)
+	sampleIndexFlag := "sample-index"
+	raceFlag := "race"
+	goTestArgFlag := "go-test-arg"
+	envFlag := "env"
+	appendFlag := "append"
+	resumeFlag := "resume"
+	parallelFlag := "parallel"
+	perIterationFlag := "per-iteration"
+	example := fmt.Sprintf(`prof %[1]s --%[2]s "BenchmarkGenPool" --%[3]s "cpu,memory" --%[4]s 10 --%[5]s "tag1"
+prof %[1]s --%[2]s "BenchmarkGenPool" --%[3]s "cpu,memory" --%[4]s 10 --%[5]s "allocs" --%[6]s memory=alloc_space
+prof %[1]s --%[2]s "BenchmarkGenPool" --%[3]s "cpu" --%[4]s 5 --%[5]s "scaling" --benchtime 2s --cpu 1,4,8 --tags integration
+prof %[1]s --%[2]s "BenchmarkA,BenchmarkB" --%[3]s "cpu" --%[4]s 10 --%[5]s "tag1" --resume
+prof %[1]s --%[2]s "./codec/json.BenchmarkEncode,./codec/xml.BenchmarkEncode" --%[3]s "cpu" --%[4]s 10 --%[5]s "codecs" --parallel 2`,
+		CmdAuto, benchFlag, profileFlag, countFlag, tagFlag, sampleIndexFlag)
 
 	cmd := &cobra.Command{
 		Use:     CmdAuto,
 		Short:   "Wraps `go test` and `pprof` to benchmark code and gather profiling data for performance investigations.",
 		Example: example,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return svc.Collect.RunAuto(app.CollectAutoOptions{
-				Benchmarks: f.benchmarks,
-				Profiles:   f.profiles,
-				Tag:        f.tag,
-				Count:      f.count,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			goTest := f.goTest
+			if cmd.Flags().Changed(raceFlag) {
+				goTest.Race = &f.race
+			}
+			return svc.Collect.RunAuto(cmd.Context(), app.CollectAutoOptions{
+				Benchmarks:   f.benchmarks,
+				Profiles:     f.profiles,
+				Tag:          f.tag,
+				Count:        f.count,
+				SampleIndex:  f.sampleIndex,
+				GoTest:       goTest,
+				Env:          f.env,
+				Append:       f.appendTag,
+				Resume:       f.resume,
+				Parallel:     f.parallel,
+				PerIteration: f.perIter,
+				ProfVersion:  svc.Version,
+				Out:          svc.Out,
 			})
 		},
 	}
-	cmd.Flags().StringSliceVar(&f.benchmarks, benchFlag, []string{}, `Benchmarks to run (e.g., "BenchmarkGenPool")"`)
+	cmd.Flags().StringSliceVar(&f.benchmarks, benchFlag, []string{}, `Benchmarks to run (e.g., "BenchmarkGenPool", "./internal/codec.BenchmarkEncode" for one package's benchmark, or "BenchmarkCodec/json/small" for a sub-benchmark)`)
 	cmd.Flags().StringSliceVar(&f.profiles, profileFlag, []string{}, `Profiles to use (e.g., "cpu,memory,mutex")`)
 	cmd.Flags().StringVar(&f.tag, tagFlag, "", "The tag is used to organize the results")
 	cmd.Flags().IntVar(&f.count, countFlag, 0, "Number of runs")
+	cmd.Flags().StringToStringVar(&f.sampleIndex, sampleIndexFlag, nil,
+		`pprof sample type to rank a profile by, as profile=type (e.g. "memory=alloc_space"); overrides collection.sample_index in prof.json`)
+	cmd.Flags().StringVar(&f.goTest.Benchtime, "benchtime", "", `go test -benchtime (e.g. "2s" or "1000x")`)
+	cmd.Flags().StringVar(&f.goTest.CPU, "cpu", "", `go test -cpu, a GOMAXPROCS list (e.g. "1,4,8")`)
+	cmd.Flags().StringVar(&f.goTest.Timeout, "timeout", "", `go test -timeout (e.g. "30m")`)
+	cmd.Flags().StringVar(&f.goTest.Tags, "tags", "", `go test -tags (e.g. "integration")`)
+	cmd.Flags().StringVar(&f.goTest.Gcflags, "gcflags", "", "go test -gcflags")
+	cmd.Flags().StringVar(&f.goTest.Ldflags, "ldflags", "", "go test -ldflags")
+	cmd.Flags().BoolVar(&f.race, raceFlag, false, "go test -race")
+	cmd.Flags().StringArrayVar(&f.goTest.ExtraArgs, goTestArgFlag, nil,
+		`Extra go test argument, passed verbatim after all others (repeatable, e.g. --go-test-arg=-shuffle=on)`)
+	cmd.Flags().StringArrayVar(&f.env, envFlag, nil,
+		`Env matrix variable as NAME=v1,v2,... (repeatable); every combination runs into .prof/<tag>/<variant>/ (e.g. --env GOGC=50,100,200 --env GOMAXPROCS=2,8); values holding commas go in prof.json collection.env_matrix`)
+	cmd.Flags().BoolVar(&f.appendTag, appendFlag, false,
+		"Add the benchmarks to an existing tag instead of replacing it; other benchmarks in the tag are kept")
+	cmd.Flags().BoolVar(&f.resume, resumeFlag, false,
+		"Like --append, but skip benchmarks whose map.json in the tag is already complete")
+	cmd.Flags().IntVar(&f.parallel, parallelFlag, 1,
+		"Benchmark up to N packages at once, each pinned to its own share of the CPUs; trades measurement accuracy for time")
+	cmd.Flags().BoolVar(&f.perIter, perIterationFlag, false,
+		"Run each --count iteration as its own go test and keep every run's profiles as profiles/<bench>/<kind>.<n>.out; the headline artifacts use their merge")
+	cmd.MarkFlagsMutuallyExclusive(appendFlag, resumeFlag)
 	_ = cmd.MarkFlagRequired(benchFlag)
 	_ = cmd.MarkFlagRequired(profileFlag)
 	_ = cmd.MarkFlagRequired(tagFlag)
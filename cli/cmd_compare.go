@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/AlexsanderHamir/prof/internal/app"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+type compareFlags struct {
+	base string
+	head string
+	top  int
+}
+
+func newCompareCmd(svc *app.Services) *cobra.Command {
+	f := &compareFlags{}
+	baseFlag := "base"
+	headFlag := "head"
+	topFlag := "top"
+	cmd := &cobra.Command{
+		Use:   CmdCompare,
+		Short: fmt.Sprintf("Diff two tags under %s/: benchmark ns/op, B/op, allocs/op and per-function flat/cum deltas.", workspace.MainDirOutput),
+		Long: fmt.Sprintf(`Compare walks %[1]s/<base>/ and %[1]s/<head>/ and reports, for every benchmark:
+  - ns/op, B/op and allocs/op deltas parsed from measurements/<bench>/run.txt
+  - per-function flat/cum deltas for each profile, aggregated in-process from profiles/<bench>/<profile>.out
+
+A summary is printed to stdout and the full result is written to %[1]s/%[2]s/<base>_vs_<head>/%[3]s,
+next to go tool pprof -diff_base hotspots, call trees and (with Graphviz) call-graph PNGs per profile.`,
+			workspace.MainDirOutput, workspace.ComparisonsDir, workspace.CompareReportFile),
+		Example: fmt.Sprintf(`prof %s --%s baseline --%s optimized`, CmdCompare, baseFlag, headFlag),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return svc.Compare.Run(cmd.Context(), app.CompareOptions{
+				Base: f.base,
+				Head: f.head,
+				Top:  f.top,
+				Out:  svc.Out,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&f.base, baseFlag, "", "Tag used as the baseline")
+	cmd.Flags().StringVar(&f.head, headFlag, "", "Tag compared against the baseline")
+	cmd.Flags().IntVar(&f.top, topFlag, 0, "Changed functions printed per profile (0 uses the default)")
+	_ = cmd.MarkFlagRequired(baseFlag)
+	_ = cmd.MarkFlagRequired(headFlag)
+	return cmd
+}
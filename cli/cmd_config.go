@@ -25,8 +25,8 @@ func newConfigInitCmd(svc *app.Services) *cobra.Command {
 		Use:    "init",
 		Short:  "Create default prof.json beside go.mod.",
 		Hidden: false,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return intent.RunValidated(&intent.ConfigCreateIntent{}, svc)
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return intent.RunValidated(cmd.Context(), &intent.ConfigCreateIntent{}, svc)
 		},
 	}
 }
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/app"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+type exportFlags struct {
+	format  string
+	tag     string
+	bench   string
+	profile string
+	output  string
+}
+
+func newExportCmd(svc *app.Services) *cobra.Command {
+	f := &exportFlags{}
+	formatFlag := "format"
+	benchFlag := "bench"
+	profileFlag := "profile"
+	outputFlag := "output"
+	cmd := &cobra.Command{
+		Use:   CmdExport,
+		Short: fmt.Sprintf("Convert a collected profile under %s/<tag>/ to speedscope, folded stacks, or a flame graph.", workspace.MainDirOutput),
+		Long: fmt.Sprintf(`Export reads %[1]s/<tag>/profiles/<bench>/<profile>.out and renders it in-process, so tags
+collected before a format existed can be converted without re-running benchmarks:
+  - speedscope  %[1]s/<tag>/%[2]s/<bench>/<profile>.%[3]s (one profile per sample type, one frame per function)
+  - folded      %[1]s/<tag>/%[4]s/<bench>/<profile>.%[5]s
+  - flamegraph  %[1]s/<tag>/%[6]s/<bench>/<profile>.%[7]s
+
+--profile may name a variant such as memory.alloc_objects to select its sample type.
+Use --output to write elsewhere, or --output - to write to stdout.`,
+			workspace.MainDirOutput, workspace.SpeedscopeDir, workspace.SpeedscopeExtension,
+			workspace.FoldedStacksDir, workspace.FoldedExtension, workspace.FlameGraphsDir, workspace.FlameGraphExtension),
+		Example: fmt.Sprintf(`prof %s --%s speedscope --%s baseline --%s BenchmarkFoo --%s cpu`,
+			CmdExport, formatFlag, tagFlag, benchFlag, profileFlag),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return svc.Export.Run(app.ExportOptions{
+				Format:  f.format,
+				Tag:     f.tag,
+				Bench:   f.bench,
+				Profile: f.profile,
+				Output:  f.output,
+				Out:     svc.Out,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&f.format, formatFlag, "", "Export format: "+strings.Join(svc.Export.Formats(), ", ")+" (default speedscope)")
+	cmd.Flags().StringVar(&f.tag, tagFlag, "", "Tag holding the collected profile")
+	cmd.Flags().StringVar(&f.bench, benchFlag, "", "Benchmark name (e.g. BenchmarkFoo)")
+	cmd.Flags().StringVar(&f.profile, profileFlag, "", "Profile kind or variant (e.g. cpu, memory.alloc_objects)")
+	cmd.Flags().StringVar(&f.output, outputFlag, "", "Destination file (default: the tag layout path; - for stdout)")
+	_ = cmd.MarkFlagRequired(tagFlag)
+	_ = cmd.MarkFlagRequired(benchFlag)
+	_ = cmd.MarkFlagRequired(profileFlag)
+	return cmd
+}
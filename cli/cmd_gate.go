@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/AlexsanderHamir/prof/internal/app"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+type gateFlags struct {
+	base string
+	head string
+}
+
+func newGateCmd(svc *app.Services) *cobra.Command {
+	f := &gateFlags{}
+	baseFlag := "base"
+	headFlag := "head"
+	cmd := &cobra.Command{
+		Use:   CmdGate,
+		Short: fmt.Sprintf("Fail when the head tag regresses past the gate limits in %s.", config.Filename),
+		Long: fmt.Sprintf(`Gate compares %[1]s/<base>/ and %[1]s/<head>/ like prof compare and checks the result
+against the "gate" section of %[2]s:
+  - max_ns_per_op_regression_pct, max_bytes_per_op_regression_pct, max_allocs_per_op_regression_pct
+    fail on statistically significant regressions above the limit (or on the raw delta when there are
+    too few samples to test significance)
+  - functions[].max_flat_pct fails when a named function's flat%% in the head profile exceeds the limit
+
+Limits under gate.defaults apply to every benchmark; gate.benchmarks.<name> overrides them per field.
+The command prints a short violation report and exits non-zero when any limit is exceeded.`,
+			workspace.MainDirOutput, config.Filename),
+		Example:      fmt.Sprintf(`prof %s --%s main --%s pr`, CmdGate, baseFlag, headFlag),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return svc.Compare.Gate(app.GateOptions{
+				Base: f.base,
+				Head: f.head,
+				Out:  svc.Out,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&f.base, baseFlag, "", "Tag used as the baseline")
+	cmd.Flags().StringVar(&f.head, headFlag, "", "Tag checked against the baseline")
+	_ = cmd.MarkFlagRequired(baseFlag)
+	_ = cmd.MarkFlagRequired(headFlag)
+	return cmd
+}
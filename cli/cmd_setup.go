@@ -12,8 +12,8 @@ func newSetupCmd(svc *app.Services) *cobra.Command {
 		Short:                 "Creates prof.json (alias for prof config init).",
 		Hidden:                true,
 		DisableFlagsInUseLine: true,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return intent.RunValidated(&intent.ConfigCreateIntent{}, svc)
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return intent.RunValidated(cmd.Context(), &intent.ConfigCreateIntent{}, svc)
 		},
 	}
 	return cmd
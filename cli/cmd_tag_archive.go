@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/AlexsanderHamir/prof/internal/app"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newExportTagCmd(svc *app.Services) *cobra.Command {
+	var output string
+	outputFlag := "output"
+	cmd := &cobra.Command{
+		Use:   CmdExportTag + " <tag>",
+		Short: "Pack a tag into a .zip or .tar.zst archive another machine can import.",
+		Long: fmt.Sprintf(`Export-tag packs %s/<tag>/ into one archive. Its first entry, prof-archive.json, records
+the tag, the prof version and the size and SHA-256 of every file, so import-tag can verify the
+archive before it writes anything. The format follows the --%s extension; .tar.zst needs the
+zstd binary on PATH.`, workspace.MainDirOutput, outputFlag),
+		Example: fmt.Sprintf(`prof %s baseline
+prof %s baseline --%s /tmp/baseline.tar.zst`, CmdExportTag, CmdExportTag, outputFlag),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return svc.Tags.Export(cmd.Context(), app.TagExportOptions{
+				Tag:         args[0],
+				Output:      output,
+				ProfVersion: svc.Version,
+				Out:         svc.Out,
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&output, outputFlag, "o", "", "Archive path ending in .zip or .tar.zst (default <tag>.zip)")
+	return cmd
+}
+
+func newImportTagCmd(svc *app.Services) *cobra.Command {
+	var tag string
+	var force bool
+	tagFlag := "tag"
+	cmd := &cobra.Command{
+		Use:   CmdImportTag + " <archive>",
+		Short: fmt.Sprintf("Unpack a tag archive from export-tag into %s/ of this module.", workspace.MainDirOutput),
+		Long: `Import-tag verifies every file of an export-tag archive against its checksums and then
+places the tag beside the module's own tags, ready for prof compare and prof tags. An existing
+tag of the same name is only replaced with --force; --tag imports under another name.`,
+		Example: fmt.Sprintf(`prof %s baseline.zip
+prof %s ci-main.tar.zst --%s main-ci`, CmdImportTag, CmdImportTag, tagFlag),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return svc.Tags.Import(cmd.Context(), app.TagImportOptions{
+				Archive: args[0],
+				Tag:     tag,
+				Force:   force,
+				Out:     svc.Out,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&tag, tagFlag, "", "Import as this tag instead of the archived name")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an existing tag of the same name")
+	return cmd
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/AlexsanderHamir/prof/internal/app"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+type tagsPruneFlags struct {
+	keep      int
+	olderThan string
+	dryRun    bool
+}
+
+func newTagsCmd(svc *app.Services) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CmdTags,
+		Short: fmt.Sprintf("List, inspect, remove, rename and prune the tags under %s/.", workspace.MainDirOutput),
+	}
+	cmd.AddCommand(newTagsListCmd(svc))
+	cmd.AddCommand(newTagsShowCmd(svc))
+	cmd.AddCommand(newTagsRemoveCmd(svc))
+	cmd.AddCommand(newTagsRenameCmd(svc))
+	cmd.AddCommand(newTagsPruneCmd(svc))
+	return cmd
+}
+
+func newTagsListCmd(svc *app.Services) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tags, newest first, with their date, state, benchmarks, profiles and size.",
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return svc.Tags.List(svc.Out)
+		},
+	}
+}
+
+func newTagsShowCmd(svc *app.Services) *cobra.Command {
+	var top int
+	topFlag := "top"
+	cmd := &cobra.Command{
+		Use:   "show <tag>",
+		Short: "Summarize a tag: its manifest, and each benchmark's measurements and top hotspots.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return svc.Tags.Show(app.TagShowOptions{Tag: args[0], Top: top, Out: svc.Out})
+		},
+	}
+	cmd.Flags().IntVar(&top, topFlag, 0, "Hotspots printed per profile (0 uses the default)")
+	return cmd
+}
+
+func newTagsRemoveCmd(svc *app.Services) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <tag>...",
+		Aliases: []string{"remove"},
+		Short:   fmt.Sprintf("Delete tags from %s/.", workspace.MainDirOutput),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return svc.Tags.Remove(svc.Out, args)
+		},
+	}
+}
+
+func newTagsRenameCmd(svc *app.Services) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <tag> <new-tag>",
+		Short: "Rename a tag.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return svc.Tags.Rename(svc.Out, args[0], args[1])
+		},
+	}
+}
+
+func newTagsPruneCmd(svc *app.Services) *cobra.Command {
+	f := &tagsPruneFlags{}
+	keepFlag := "keep"
+	olderThanFlag := "older-than"
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old tags, keeping the newest ones.",
+		Long: `Prune deletes the tags a retention policy does not keep. --keep N always keeps the N newest
+tags; --older-than only deletes tags collected longer ago than the age. With both, a tag is
+deleted when it is neither among the newest N nor younger than the age.`,
+		Example: fmt.Sprintf(`prof %s prune --%s 20 --%s 30d
+prof %s prune --%s 7d --dry-run`, CmdTags, keepFlag, olderThanFlag, CmdTags, olderThanFlag),
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return svc.Tags.Prune(app.TagPruneOptions{
+				Keep:      f.keep,
+				OlderThan: f.olderThan,
+				DryRun:    f.dryRun,
+				Out:       svc.Out,
+			})
+		},
+	}
+	cmd.Flags().IntVar(&f.keep, keepFlag, 0, "Newest tags to keep")
+	cmd.Flags().StringVar(&f.olderThan, olderThanFlag, "", `Only delete tags older than this age (e.g. "30d" or "12h")`)
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "Print the tags that would be deleted without deleting them")
+	return cmd
+}
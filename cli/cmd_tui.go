@@ -10,6 +10,6 @@ func newTuiCmd(svc *app.Services) *cobra.Command {
 		Use:     "tui",
 		Short:   "Interactive selection of benchmarks and profiles, then runs prof auto",
 		Example: "prof tui",
-		RunE:    func(c *cobra.Command, args []string) error { return runTUI(svc, c, args) },
+		RunE:    func(c *cobra.Command, _ []string) error { return runTUI(c.Context(), svc) },
 	}
 }
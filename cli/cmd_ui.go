@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -24,8 +25,8 @@ func newUICmd(svc *app.Services) *cobra.Command {
 Use this when you prefer prompts to typing flags. For scripts and automation, use prof auto and other subcommands directly.
 
 Documentation: ` + profDocumentationURL,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return runUILauncher(svc)
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runUILauncher(cmd.Context(), svc)
 		},
 	}
 }
@@ -37,13 +38,13 @@ func requireInteractiveTerminal() error {
 	return nil
 }
 
-func runUILauncher(svc *app.Services) error {
+func runUILauncher(ctx context.Context, svc *app.Services) error {
 	if err := requireInteractiveTerminal(); err != nil {
 		return err
 	}
 
 	for {
-		if err := runUILauncherOnce(svc); err != nil {
+		if err := runUILauncherOnce(ctx, svc); err != nil {
 			if errors.Is(err, errUILoopExit) {
 				return nil
 			}
@@ -52,7 +53,7 @@ func runUILauncher(svc *app.Services) error {
 	}
 }
 
-func runUILauncherOnce(svc *app.Services) error {
+func runUILauncherOnce(ctx context.Context, svc *app.Services) error {
 	choice, err := tui.RunMainMenu()
 	if err != nil {
 		return err
@@ -61,9 +62,9 @@ func runUILauncherOnce(svc *app.Services) error {
 	var runErr error
 	switch choice {
 	case tui.MainCollect:
-		runErr = runTUI(svc, nil, nil)
+		runErr = runTUI(ctx, svc)
 	case tui.MainConfig:
-		runErr = runUIConfigCreate(svc)
+		runErr = runUIConfigCreate(ctx, svc)
 	case tui.MainQuit, tui.MainNone:
 		return errUILoopExit
 	default:
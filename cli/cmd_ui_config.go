@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -10,7 +11,7 @@ import (
 	"github.com/AlexsanderHamir/prof/internal/intent"
 )
 
-func runUIConfigCreate(svc *app.Services) error {
+func runUIConfigCreate(ctx context.Context, svc *app.Services) error {
 	path, err := svc.Config.Path()
 	if err != nil {
 		return err
@@ -35,7 +36,7 @@ func runUIConfigCreate(svc *app.Services) error {
 	if !create {
 		return errors.New("configuration cancelled")
 	}
-	if err = intent.RunValidated(&intent.ConfigCreateIntent{}, svc); err != nil {
+	if err = intent.RunValidated(ctx, &intent.ConfigCreateIntent{}, svc); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/AlexsanderHamir/prof/internal/app"
 	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
 const (
@@ -18,10 +20,11 @@ const (
 
 type noopCollect struct{}
 
-func (noopCollect) RunAuto(_ app.CollectAutoOptions) error        { return nil }
-func (noopCollect) RunManual(_ app.CollectManualOptions) error    { return nil }
-func (noopCollect) DiscoverBenchmarks(_ string) ([]string, error) { return nil, nil }
-func (noopCollect) SupportedProfiles() []string                   { return nil }
+func (noopCollect) RunAuto(context.Context, app.CollectAutoOptions) error       { return nil }
+func (noopCollect) RunManual(context.Context, app.CollectManualOptions) error   { return nil }
+func (noopCollect) DiscoverBenchmarks(_ string) ([]string, error)               { return nil, nil }
+func (noopCollect) ListSubBenchmarks(context.Context, string) ([]string, error) { return nil, nil }
+func (noopCollect) SupportedProfiles() []string                                 { return nil }
 
 type captureConfig struct{ createCalls int }
 
@@ -38,18 +41,98 @@ type captureCollect struct {
 	auto   app.CollectAutoOptions
 }
 
-func (c *captureCollect) RunAuto(opts app.CollectAutoOptions) error {
+func (c *captureCollect) RunAuto(_ context.Context, opts app.CollectAutoOptions) error {
 	c.auto = opts
 	return nil
 }
 
-func (c *captureCollect) RunManual(opts app.CollectManualOptions) error {
+func (c *captureCollect) RunManual(_ context.Context, opts app.CollectManualOptions) error {
 	c.manual = opts
 	return nil
 }
 
-func (*captureCollect) DiscoverBenchmarks(_ string) ([]string, error) { return nil, nil }
-func (*captureCollect) SupportedProfiles() []string                   { return nil }
+func (*captureCollect) DiscoverBenchmarks(_ string) ([]string, error)               { return nil, nil }
+func (*captureCollect) ListSubBenchmarks(context.Context, string) ([]string, error) { return nil, nil }
+func (*captureCollect) SupportedProfiles() []string                                 { return nil }
+
+type captureCompare struct {
+	opts app.CompareOptions
+	gate app.GateOptions
+}
+
+func (c *captureCompare) Run(_ context.Context, opts app.CompareOptions) error {
+	c.opts = opts
+	return nil
+}
+
+func (c *captureCompare) Gate(opts app.GateOptions) error {
+	c.gate = opts
+	return nil
+}
+
+type captureExport struct{ opts app.ExportOptions }
+
+func (c *captureExport) Run(opts app.ExportOptions) error {
+	c.opts = opts
+	return nil
+}
+
+func (*captureExport) Formats() []string { return []string{"speedscope"} }
+
+type captureTags struct {
+	calls  []string
+	show   app.TagShowOptions
+	prune  app.TagPruneOptions
+	tags   []string
+	rename [2]string
+	export app.TagExportOptions
+	imp    app.TagImportOptions
+	out    string // out of the last List, Remove or Rename
+}
+
+func (c *captureTags) List(out string) error {
+	c.calls = append(c.calls, "list")
+	c.out = out
+	return nil
+}
+
+func (c *captureTags) Show(opts app.TagShowOptions) error {
+	c.calls = append(c.calls, "show")
+	c.show = opts
+	return nil
+}
+
+func (c *captureTags) Remove(out string, tags []string) error {
+	c.calls = append(c.calls, "rm")
+	c.out = out
+	c.tags = tags
+	return nil
+}
+
+func (c *captureTags) Rename(out, from, to string) error {
+	c.calls = append(c.calls, "rename")
+	c.out = out
+	c.rename = [2]string{from, to}
+	return nil
+}
+
+func (c *captureTags) Prune(opts app.TagPruneOptions) error {
+	c.calls = append(c.calls, "prune")
+	c.prune = opts
+	return nil
+}
+
+func (c *captureTags) Export(_ context.Context, opts app.TagExportOptions) error {
+	c.calls = append(c.calls, "export")
+	c.export = opts
+	return nil
+}
+
+func (c *captureTags) Import(_ context.Context, opts app.TagImportOptions) error {
+	c.calls = append(c.calls, "import")
+	c.imp = opts
+	return nil
+}
 
 type errDiscoverCollect struct{ noopCollect }
 
@@ -172,6 +255,144 @@ func TestCmdAutoBenchmarkRunE(t *testing.T) {
 	if len(captured.auto.Profiles) != 2 || captured.auto.Profiles[0] != testProfCPU || captured.auto.Profiles[1] != testProfMemory {
 		t.Fatalf("%+v", captured.auto)
 	}
+	if captured.auto.SampleIndex != nil {
+		t.Fatalf("sample index should be unset: %+v", captured.auto.SampleIndex)
+	}
+	if !config.GoTestFlagsEmpty(captured.auto.GoTest) {
+		t.Fatalf("go test flags should be unset: %+v", captured.auto.GoTest)
+	}
+}
+
+func TestCmdAutoBenchmarkRunE_goTestFlags(t *testing.T) {
+	captured := &captureCollect{}
+	root := CreateRootCmd(&app.Services{
+		Collect: captured,
+	})
+	root.SetArgs([]string{
+		CmdAuto,
+		"--benchmarks", "B1",
+		"--profiles", testProfCPU,
+		"--tag", "tg",
+		"--count", "1",
+		"--benchtime", "2s",
+		"--cpu", "1,4,8",
+		"--tags", "integration",
+		"--race",
+		"--go-test-arg", "-shuffle=on",
+		"--go-test-arg", "-failfast",
+	})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	g := captured.auto.GoTest
+	if g.Benchtime != "2s" || g.CPU != "1,4,8" || g.Tags != "integration" || g.Race == nil || !*g.Race {
+		t.Fatalf("%+v", g)
+	}
+	if len(g.ExtraArgs) != 2 || g.ExtraArgs[0] != "-shuffle=on" || g.ExtraArgs[1] != "-failfast" {
+		t.Fatalf("extra args=%v", g.ExtraArgs)
+	}
+}
+
+func TestCmdAutoBenchmarkRunE_envMatrix(t *testing.T) {
+	captured := &captureCollect{}
+	root := CreateRootCmd(&app.Services{
+		Collect: captured,
+	})
+	root.SetArgs([]string{
+		CmdAuto,
+		"--benchmarks", "B1",
+		"--profiles", testProfCPU,
+		"--tag", "tg",
+		"--count", "1",
+		"--env", "GOGC=50,100",
+		"--env", "GOMAXPROCS=2,8",
+	})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if env := captured.auto.Env; len(env) != 2 || env[0] != "GOGC=50,100" || env[1] != "GOMAXPROCS=2,8" {
+		t.Fatalf("env=%v", env)
+	}
+}
+
+func TestCmdAutoBenchmarkRunE_resume(t *testing.T) {
+	captured := &captureCollect{}
+	root := CreateRootCmd(&app.Services{
+		Collect: captured,
+	})
+	args := []string{CmdAuto, "--benchmarks", "B1", "--profiles", testProfCPU, "--tag", "tg", "--count", "1"}
+	root.SetArgs(append(args, "--resume"))
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !captured.auto.Resume || captured.auto.Append {
+		t.Fatalf("append=%v resume=%v", captured.auto.Append, captured.auto.Resume)
+	}
+
+	root = CreateRootCmd(&app.Services{Collect: &captureCollect{}})
+	root.SetArgs(append(args, "--append", "--resume"))
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected --append and --resume to be mutually exclusive")
+	}
+}
+
+func TestCmdAutoBenchmarkRunE_parallel(t *testing.T) {
+	captured := &captureCollect{}
+	root := CreateRootCmd(&app.Services{
+		Collect: captured,
+	})
+	args := []string{CmdAuto, "--benchmarks", "B1,B2", "--profiles", testProfCPU, "--tag", "tg", "--count", "1"}
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if captured.auto.Parallel != 1 {
+		t.Fatalf("default parallel=%d, want 1", captured.auto.Parallel)
+	}
+
+	root = CreateRootCmd(&app.Services{Collect: captured})
+	root.SetArgs(append(args, "--parallel", "4"))
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if captured.auto.Parallel != 4 {
+		t.Fatalf("parallel=%d, want 4", captured.auto.Parallel)
+	}
+}
+
+func TestCmdAutoBenchmarkRunE_perIteration(t *testing.T) {
+	captured := &captureCollect{}
+	root := CreateRootCmd(&app.Services{
+		Collect: captured,
+	})
+	root.SetArgs([]string{CmdAuto, "--benchmarks", "B1", "--profiles", testProfCPU, "--tag", "tg", "--count", "5", "--per-iteration"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !captured.auto.PerIteration || captured.auto.Count != 5 {
+		t.Fatalf("auto=%+v, want per-iteration with count 5", captured.auto)
+	}
+}
+
+func TestCmdAutoBenchmarkRunE_sampleIndex(t *testing.T) {
+	captured := &captureCollect{}
+	root := CreateRootCmd(&app.Services{
+		Collect: captured,
+	})
+	root.SetArgs([]string{
+		CmdAuto,
+		"--benchmarks", "B1",
+		"--profiles", testProfMemory,
+		"--tag", "tg",
+		"--count", "1",
+		"--sample-index", testProfMemory + "=alloc_space",
+	})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := captured.auto.SampleIndex[testProfMemory]; got != "alloc_space" {
+		t.Fatalf("sample index=%+v", captured.auto.SampleIndex)
+	}
 }
 
 func TestCmdTuiRunEDiscoverError(t *testing.T) {
@@ -203,3 +424,120 @@ func TestCmdTuiRunENoBenchmarks(t *testing.T) {
 		t.Fatalf("got %v", err)
 	}
 }
+
+func TestCmdCompareRunE(t *testing.T) {
+	captured := &captureCompare{}
+	root := CreateRootCmd(&app.Services{
+		Collect: noopCollect{},
+		Compare: captured,
+	})
+	root.SetArgs([]string{CmdCompare, "--base", "b1", "--head", "h1", "--top", "5"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if captured.opts.Base != "b1" || captured.opts.Head != "h1" || captured.opts.Top != 5 {
+		t.Fatalf("%+v", captured.opts)
+	}
+}
+
+func TestCmdGateRunE(t *testing.T) {
+	captured := &captureCompare{}
+	root := CreateRootCmd(&app.Services{
+		Collect: noopCollect{},
+		Compare: captured,
+	})
+	root.SetArgs([]string{CmdGate, "--base", "main", "--head", "pr"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if captured.gate.Base != "main" || captured.gate.Head != "pr" {
+		t.Fatalf("%+v", captured.gate)
+	}
+}
+
+func TestCmdExportRunE(t *testing.T) {
+	captured := &captureExport{}
+	root := CreateRootCmd(&app.Services{
+		Collect: noopCollect{},
+		Export:  captured,
+	})
+	root.SetArgs([]string{CmdExport, "--format", "speedscope", "--tag", "old", "--bench", "BenchmarkFoo", "--profile", testProfCPU})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := app.ExportOptions{Format: "speedscope", Tag: "old", Bench: "BenchmarkFoo", Profile: testProfCPU}
+	if captured.opts != want {
+		t.Fatalf("%+v", captured.opts)
+	}
+}
+
+func TestCmdTagsRunE(t *testing.T) {
+	captured := &captureTags{}
+	for _, args := range [][]string{
+		{"list"},
+		{"show", "base", "--top", "3"},
+		{"rm", "a", "b"},
+		{"rename", "a", "c"},
+		{"prune", "--keep", "20", "--older-than", "30d", "--dry-run"},
+	} {
+		root := CreateRootCmd(&app.Services{Collect: noopCollect{}, Tags: captured})
+		root.SetArgs(append([]string{CmdTags}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if strings.Join(captured.calls, ",") != "list,show,rm,rename,prune" {
+		t.Fatalf("calls=%v", captured.calls)
+	}
+	if captured.show != (app.TagShowOptions{Tag: "base", Top: 3}) || strings.Join(captured.tags, ",") != "a,b" || captured.rename != [2]string{"a", "c"} {
+		t.Fatalf("%+v", captured)
+	}
+	if captured.prune != (app.TagPruneOptions{Keep: 20, OlderThan: "30d", DryRun: true}) {
+		t.Fatalf("prune=%+v", captured.prune)
+	}
+}
+
+func TestRootOutFlagPassesOutputDir(t *testing.T) {
+	t.Setenv(workspace.OutputDirEnv, "")
+	dir := t.TempDir()
+	t.Chdir(dir)
+	out := filepath.Join(dir, "scratch")
+	collect, cmp, tags := &captureCollect{}, &captureCompare{}, &captureTags{}
+	for _, args := range [][]string{
+		{CmdAuto, "--benchmarks", "BenchmarkFoo", "--profiles", testProfCPU, "--count", "1", "--tag", "t"},
+		{CmdCompare, "--base", "a", "--head", "b"},
+		{CmdTags, "list"},
+	} {
+		root := CreateRootCmd(&app.Services{Collect: collect, Compare: cmp, Tags: tags})
+		root.SetArgs(append([]string{"--out", "scratch"}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if collect.auto.Out != out || cmp.opts.Out != out || tags.out != out {
+		t.Fatalf("out: auto=%q compare=%q tags=%q want %q", collect.auto.Out, cmp.opts.Out, tags.out, out)
+	}
+	if got := os.Getenv(workspace.OutputDirEnv); got != "" {
+		t.Fatalf("--out set %s=%q", workspace.OutputDirEnv, got)
+	}
+}
+
+func TestCmdTagArchiveRunE(t *testing.T) {
+	captured := &captureTags{}
+	for _, args := range [][]string{
+		{CmdExportTag, "base", "--output", "base.tar.zst"},
+		{CmdImportTag, "base.zip", "--tag", "ci-base", "--force"},
+	} {
+		root := CreateRootCmd(&app.Services{Collect: noopCollect{}, Tags: captured, Version: "v1.2.3"})
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if captured.export != (app.TagExportOptions{Tag: "base", Output: "base.tar.zst", ProfVersion: "v1.2.3"}) {
+		t.Fatalf("export=%+v", captured.export)
+	}
+	if captured.imp != (app.TagImportOptions{Archive: "base.zip", Tag: "ci-base", Force: true}) {
+		t.Fatalf("import=%+v", captured.imp)
+	}
+}
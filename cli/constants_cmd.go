@@ -6,5 +6,19 @@ const (
 	CmdManual = "manual"
 )
 
+// Analysis subcommand names.
+const (
+	CmdCompare = "compare"
+	CmdGate    = "gate"
+	CmdExport  = "export"
+)
+
+// Tag management subcommand names.
+const (
+	CmdTags      = "tags"
+	CmdExportTag = "export-tag"
+	CmdImportTag = "import-tag"
+)
+
 // InfoCollectionSuccess matches workspace success message for tests.
 const InfoCollectionSuccess = "All benchmarks and profile processing completed successfully!"
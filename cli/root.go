@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/AlexsanderHamir/prof/internal/app"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
@@ -17,12 +18,15 @@ var Version = "devel"
 // services may be nil; nil fields are filled via [app.Services.WithDefaults].
 func CreateRootCmd(services *app.Services) *cobra.Command {
 	svc := services.WithDefaults()
+	if svc.Version == "" {
+		svc.Version = Version
+	}
 
 	root := &cobra.Command{
 		Use:   "prof",
 		Short: fmt.Sprintf("Go benchmark profiling: collect pprof-backed runs under %s/<tag>/.", workspace.MainDirOutput),
-		Long: fmt.Sprintf(`Prof wraps go test and pprof so you can capture CPU, memory, mutex, and block profiles in one
-workflow and store artifacts in a predictable %s/<tag>/ tree.
+		Long: fmt.Sprintf(`Prof wraps go test and pprof so you can capture CPU, memory, mutex, and block profiles
+and execution traces in one workflow and store artifacts in a predictable %s/<tag>/ tree.
 
 Start interactively (no flags to memorize):
 
@@ -35,16 +39,52 @@ Documentation: https://alexsanderhamir.github.io/prof/`, workspace.MainDirOutput
   prof ui
 
   # Collect profiles (non-interactive)
-  prof auto --benchmarks "BenchmarkFoo" --profiles "cpu,memory" --count 5 --tag baseline`,
+  prof auto --benchmarks "BenchmarkFoo" --profiles "cpu,memory" --count 5 --tag baseline
+
+  # Diff two collected tags
+  prof compare --base baseline --head optimized
+
+  # Fail CI when head exceeds the gate limits in prof.json
+  prof gate --base main --head pr
+
+  # Convert a collected profile for speedscope
+  prof export --format speedscope --tag baseline --bench BenchmarkFoo --profile cpu`,
 		Version: Version,
 	}
+	root.PersistentFlags().StringVar(&svc.Out, outFlag, "",
+		fmt.Sprintf("Directory holding each project's tags in a subdirectory, instead of %s/ beside go.mod or go.work (default $%s)", workspace.MainDirOutput, workspace.OutputDirEnv))
+	root.PersistentPreRunE = func(_ *cobra.Command, _ []string) error {
+		return resolveOutputDir(svc)
+	}
 
 	root.AddCommand(newUICmd(svc))
 	root.AddCommand(newManualCollectCmd(svc))
 	root.AddCommand(newAutoBenchmarkCmd(svc))
+	root.AddCommand(newCompareCmd(svc))
+	root.AddCommand(newGateCmd(svc))
+	root.AddCommand(newExportCmd(svc))
+	root.AddCommand(newTagsCmd(svc))
+	root.AddCommand(newExportTagCmd(svc))
+	root.AddCommand(newImportTagCmd(svc))
 	root.AddCommand(newTuiCmd(svc))
 	root.AddCommand(newConfigCmd(svc))
 	root.AddCommand(newSetupCmd(svc))
 
 	return root
 }
+
+const outFlag = "out"
+
+// resolveOutputDir makes svc.Out absolute, so every command of this process resolves --out
+// against the directory prof started in. The engine falls back to PROF_HOME when it is empty.
+func resolveOutputDir(svc *app.Services) error {
+	if svc.Out == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(svc.Out)
+	if err != nil {
+		return fmt.Errorf("--%s %s: %w", outFlag, svc.Out, err)
+	}
+	svc.Out = abs
+	return nil
+}
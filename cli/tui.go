@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -12,11 +13,10 @@ import (
 	"github.com/AlexsanderHamir/prof/internal/intent"
 	"github.com/AlexsanderHamir/prof/internal/termui"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
-	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
-func runTUI(svc *app.Services, _ *cobra.Command, _ []string) error {
+func runTUI(ctx context.Context, svc *app.Services) error {
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get current working directory: %w", err)
@@ -45,6 +45,9 @@ func runTUI(svc *app.Services, _ *cobra.Command, _ []string) error {
 	if err = survey.AskOne(benchPrompt, &selectedBenches, survey.WithValidator(survey.Required)); err != nil {
 		return err
 	}
+	if selectedBenches, err = pickSubBenchmarks(ctx, svc, selectedBenches); err != nil {
+		return err
+	}
 
 	missingConfigWarnShown := printCollectionFilterPreview(os.Stdout, surveyTTY, svc, selectedBenches)
 
@@ -82,5 +85,39 @@ func runTUI(svc *app.Services, _ *cobra.Command, _ []string) error {
 		MissingConfigWarnShown: missingConfigWarnShown,
 	}
 	collect.Normalize()
-	return intent.RunValidated(collect, svc)
+	return intent.RunValidated(ctx, collect, svc)
+}
+
+// pickSubBenchmarks offers to narrow the selection to individual b.Run cases. Listing them
+// runs each selected benchmark once, so it is opt-in.
+func pickSubBenchmarks(ctx context.Context, svc *app.Services, benches []string) ([]string, error) {
+	var expand bool
+	if err := survey.AskOne(&survey.Confirm{
+		Message: "Pick individual sub-benchmarks? (runs each selected benchmark once to list them)",
+		Default: false,
+	}, &expand); err != nil {
+		return nil, err
+	}
+	if !expand {
+		return benches, nil
+	}
+
+	var options []string
+	for _, bench := range benches {
+		subs, err := svc.Collect.ListSubBenchmarks(ctx, bench)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sub-benchmarks of %s: %w", bench, err)
+		}
+		options = append(options, subs...)
+	}
+	var picked []string
+	prompt := &survey.MultiSelect{
+		Message:  "Select sub-benchmarks to run:",
+		Options:  options,
+		PageSize: tuiPageSize,
+	}
+	if err := survey.AskOne(prompt, &picked, survey.WithValidator(survey.Required)); err != nil {
+		return nil, err
+	}
+	return picked, nil
 }
@@ -2,51 +2,16 @@ package collect
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/AlexsanderHamir/prof/engine/tooling"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
-func getExpectedProfileFileName(profile string) (string, bool) {
-	expectedFileName, exists := ExpectedFiles[profile]
-	if !exists {
-		return "", false
-	}
-	return expectedFileName, true
-}
-
-func findMostRecentFile(rootDir, fileName string) (string, error) {
-	var latestPath string
-	var latestMod time.Time
-	err := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		if filepath.Base(path) != fileName {
-			return nil
-		}
-		info, statErr := d.Info()
-		if statErr != nil {
-			return statErr
-		}
-		if info.ModTime().After(latestMod) {
-			latestMod = info.ModTime()
-			latestPath = path
-		}
-		return nil
-	})
-	if err != nil {
-		return "", err
-	}
-	return latestPath, nil
-}
-
 func isGoTestBinary(name string) bool {
 	if strings.HasSuffix(name, ".test") {
 		return true
@@ -54,27 +19,63 @@ func isGoTestBinary(name string) bool {
 	return strings.HasSuffix(strings.ToLower(name), ".test.exe")
 }
 
-func moveProfileFiles(profiles []string, rootDir string, binDir string) error {
+// moveProfileFiles moves the profile files the go test run started at since wrote to
+// dest(profile). go test writes its own profiles to runDir (-outputdir); a collection.profile_kinds
+// flag handled by the benchmark's TestMain writes relative to the package directory pkgDir
+// instead. Only those kinds are looked up in pkgDir, and only files modified since the run
+// started, so a stale cpu.out left there by someone else is never taken for this run's. A
+// profile found in neither is left for processProfiles to report missing.
+func moveProfileFiles(catalog *tooling.Catalog, profiles []string, runDir, pkgDir string, since time.Time, dest func(profile string) string) error {
+	// File systems with coarse modification times may round a file written right after since down.
+	since = since.Truncate(time.Second)
 	for _, profile := range profiles {
-		profileFile, ok := getExpectedProfileFileName(profile)
+		profileFile, ok := catalog.OutFileName(profile)
 		if !ok {
 			continue
 		}
-		latestPath, err := findMostRecentFile(rootDir, profileFile)
-		if err != nil {
-			return fmt.Errorf("failed to search for profile files: %w", err)
-		}
-		if latestPath == "" {
-			continue
+		dirs := []string{runDir}
+		if catalog.WrittenByTestMain(profile) {
+			dirs = append(dirs, pkgDir)
 		}
-		destPath := filepath.Join(binDir, fmt.Sprintf("%s.%s", profile, workspace.ProfileArtifactExtension))
-		if err = os.Rename(latestPath, destPath); err != nil {
-			return fmt.Errorf("failed to move profile file %s: %w", latestPath, err)
+		for _, dir := range dirs {
+			src := filepath.Join(dir, profileFile)
+			info, err := os.Stat(src)
+			if err != nil || dir == pkgDir && info.ModTime().Before(since) {
+				continue
+			}
+			if err := moveFile(src, dest(profile)); err != nil {
+				return fmt.Errorf("failed to move profile file %s: %w", src, err)
+			}
+			break
 		}
 	}
 	return nil
 }
 
+// moveFile renames src to dst, copying across file systems (PROF_HOME on another disk).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
 func moveTestFiles(benchmarkName, rootDir, binDir string) error {
 	var testFiles []string
 	err := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
@@ -93,8 +94,8 @@ func moveTestFiles(benchmarkName, rootDir, binDir string) error {
 		return fmt.Errorf("WalkDir Failed: %w", err)
 	}
 	for _, file := range testFiles {
-		newPath := filepath.Join(binDir, fmt.Sprintf("%s_%s", benchmarkName, filepath.Base(file)))
-		if err = os.Rename(file, newPath); err != nil {
+		newPath := filepath.Join(binDir, fmt.Sprintf("%s_%s", workspace.BenchmarkDir(benchmarkName), filepath.Base(file)))
+		if err = moveFile(file, newPath); err != nil {
 			return fmt.Errorf("failed to move test file %s: %w", file, err)
 		}
 	}
@@ -1,6 +1,7 @@
 package collect
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -9,16 +10,17 @@ import (
 	"regexp"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/pprofreport"
 	"github.com/AlexsanderHamir/prof/internal/termui"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 	"github.com/AlexsanderHamir/prof/parser"
 )
 
-func runPprofReport(runner tooling.Runner, argv []string, outputFile string) error {
+func runPprofReport(ctx context.Context, runner tooling.Runner, argv []string, outputFile string) error {
 	if runner == nil {
 		return errors.New("tooling runner is nil")
 	}
-	ctx := context.Background()
 	out, err := runner.Run(ctx, argv, tooling.RunOpts{})
 	if err != nil {
 		return fmt.Errorf("pprof command failed: %w", err)
@@ -26,12 +28,11 @@ func runPprofReport(runner tooling.Runner, argv []string, outputFile string) err
 	return writeArtifactFile(outputFile, out)
 }
 
-func getPNGOutput(runner tooling.Runner, binaryFile, outputFile string) error {
+func getPNGOutput(ctx context.Context, runner tooling.Runner, binaryFile, sampleIndex, outputFile string) error {
 	if runner == nil {
 		return errors.New("tooling runner is nil")
 	}
-	ctx := context.Background()
-	out, err := runner.Run(ctx, tooling.PprofPNGArgs(binaryFile), tooling.RunOpts{})
+	out, err := runner.Run(ctx, tooling.WithSampleIndex(tooling.PprofPNGArgs(binaryFile), sampleIndex), tooling.RunOpts{})
 	if err != nil {
 		return fmt.Errorf("pprof PNG generation failed: %w", err)
 	}
@@ -65,14 +66,14 @@ func listPatternCandidates(shortStem, fullSymbol string) []string {
 	return out
 }
 
-func writeFunctionListPprof(runner tooling.Runner, shortStem, fullSymbol, binaryFile, outputFile string) error {
+func writeFunctionListPprof(ctx context.Context, runner tooling.Runner, shortStem, fullSymbol, binaryFile, sampleIndex, outputFile string) error {
 	if runner == nil {
 		return errors.New("tooling runner is nil")
 	}
-	ctx := context.Background()
 	var lastErr error
 	for _, pattern := range listPatternCandidates(shortStem, fullSymbol) {
-		out, err := runner.Run(ctx, tooling.PprofListArgs(binaryFile, pattern), tooling.RunOpts{Combined: true})
+		argv := tooling.WithSampleIndex(tooling.PprofListArgs(binaryFile, pattern), sampleIndex)
+		out, err := runner.Run(ctx, argv, tooling.RunOpts{Combined: true})
 		if err != nil {
 			lastErr = fmt.Errorf("pprof list (pattern %q): %w: %s", pattern, err, string(out))
 			continue
@@ -85,6 +86,53 @@ func writeFunctionListPprof(runner tooling.Runner, shortStem, fullSymbol, binary
 	return lastErr
 }
 
+// writeFunctionListSource annotates one function from an already-built source view, trying
+// the same patterns as writeFunctionListPprof. A missing source file fails immediately.
+func writeFunctionListSource(src *pprofreport.Source, shortStem, fullSymbol, outputFile string) error {
+	var lastErr error
+	for _, pattern := range listPatternCandidates(shortStem, fullSymbol) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			lastErr = fmt.Errorf("list pattern %q: %w", pattern, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err = src.List(&buf, re); err != nil {
+			if errors.Is(err, pprofreport.ErrNoMatch) {
+				lastErr = err
+				continue
+			}
+			return err
+		}
+		if err = os.WriteFile(outputFile, buf.Bytes(), workspace.PermFile); err != nil {
+			return fmt.Errorf("write function content: %w", err)
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// functionListWriter returns the per-entry source_lines writer: in-process annotation of
+// one parsed profile, or one go tool pprof -list per entry when renderer is config.RendererPprof.
+func functionListWriter(ctx context.Context, runner tooling.Runner, binaryPath, sampleIndex, renderer string) (func(e parser.FunctionListEntry, outputFile string) error, error) {
+	if renderer == config.RendererPprof {
+		return func(e parser.FunctionListEntry, outputFile string) error {
+			return writeFunctionListPprof(ctx, runner, e.OutputStem, e.FullSymbol, binaryPath, sampleIndex, outputFile)
+		}, nil
+	}
+	p, err := parser.ParseProfileFromPath(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	src, err := pprofreport.NewSource(p, sampleIndex, "")
+	if err != nil {
+		return nil, err
+	}
+	return func(e parser.FunctionListEntry, outputFile string) error {
+		return writeFunctionListSource(src, e.OutputStem, e.FullSymbol, outputFile)
+	}, nil
+}
+
 // ListResult summarizes per-function pprof -list collection for one profile.
 type ListResult struct {
 	Collected   int
@@ -92,14 +140,20 @@ type ListResult struct {
 	FailedStems map[string]struct{}
 }
 
-func getFunctionsOutput(runner tooling.Runner, entries []parser.FunctionListEntry, binaryPath, basePath string, session *termui.Session) ListResult {
+func getFunctionsOutput(ctx context.Context, runner tooling.Runner, entries []parser.FunctionListEntry, binaryPath, sampleIndex, renderer, basePath string, session *termui.Session) ListResult {
 	const maxPerFunctionWarnings = 3
 
 	result := ListResult{FailedStems: make(map[string]struct{})}
+	write, writerErr := functionListWriter(ctx, runner, binaryPath, sampleIndex, renderer)
 	errs := parallelFor(len(entries), sourceLinesWorkers(len(entries)), func(i int) error {
+		if writerErr != nil {
+			return writerErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		e := entries[i]
-		out := filepath.Join(basePath, e.OutputStem+"."+workspace.TextExtension)
-		return writeFunctionListPprof(runner, e.OutputStem, e.FullSymbol, binaryPath, out)
+		return write(e, filepath.Join(basePath, e.OutputStem+"."+workspace.TextExtension))
 	})
 
 	for i, err := range errs {
@@ -110,7 +164,7 @@ func getFunctionsOutput(runner tooling.Runner, entries []parser.FunctionListEntr
 		result.Skipped++
 		result.FailedStems[entries[i].OutputStem] = struct{}{}
 		if session != nil && session.Interactive() && result.Skipped <= maxPerFunctionWarnings {
-			session.Warn(fmt.Sprintf("skipping per-function pprof list for %s: %v", entries[i].OutputStem, err))
+			session.Warn(fmt.Sprintf("skipping source lines for %s: %v", entries[i].OutputStem, err))
 		}
 	}
 	if result.Skipped > maxPerFunctionWarnings && session != nil && session.Interactive() {
@@ -119,8 +173,8 @@ func getFunctionsOutput(runner tooling.Runner, entries []parser.FunctionListEntr
 	return result
 }
 
-// FunctionsOutput runs pprof -list for each entry (exported for integration tests).
-func FunctionsOutput(runner tooling.Runner, entries []parser.FunctionListEntry, binaryPath, basePath string) error {
-	_ = getFunctionsOutput(runner, entries, binaryPath, basePath, nil)
+// FunctionsOutput writes the source_lines extract of each entry (exported for integration tests).
+func FunctionsOutput(ctx context.Context, runner tooling.Runner, entries []parser.FunctionListEntry, binaryPath, basePath string) error {
+	_ = getFunctionsOutput(ctx, runner, entries, binaryPath, "", "", basePath, nil)
 	return nil
 }
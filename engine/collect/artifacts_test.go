@@ -45,7 +45,7 @@ func TestRunPprofReport_fakeRunner(t *testing.T) {
 		Out: [][]byte{[]byte("flat  flat%   sum%        cum   cum%")},
 	}
 	out := filepath.Join(t.TempDir(), "top.txt")
-	if err := runPprofReport(runner, tooling.PprofTextReportArgs("top", "cpu.out"), out); err != nil {
+	if err := runPprofReport(t.Context(), runner, tooling.PprofTextReportArgs("top", "cpu.out"), out); err != nil {
 		t.Fatal(err)
 	}
 	if len(runner.Runs) != 1 {
@@ -58,7 +58,7 @@ func TestRunPprofReport_fakeRunner(t *testing.T) {
 
 func TestRunPprofReport_nilRunner(t *testing.T) {
 	t.Parallel()
-	if err := runPprofReport(nil, tooling.PprofTextReportArgs("top", "cpu.out"), "out.txt"); err == nil {
+	if err := runPprofReport(t.Context(), nil, tooling.PprofTextReportArgs("top", "cpu.out"), "out.txt"); err == nil {
 		t.Fatal("expected error for nil runner")
 	}
 }
@@ -69,7 +69,7 @@ func TestWriteFunctionListPprof_fakeRunner(t *testing.T) {
 		Out: [][]byte{[]byte("ROUTINE ======================== ProcessStrings")},
 	}
 	out := filepath.Join(t.TempDir(), "fn.txt")
-	if err := writeFunctionListPprof(runner, "ProcessStrings", "pkg.ProcessStrings", "cpu.out", out); err != nil {
+	if err := writeFunctionListPprof(t.Context(), runner, "ProcessStrings", "pkg.ProcessStrings", "cpu.out", "", out); err != nil {
 		t.Fatal(err)
 	}
 	if len(runner.Runs) != 1 {
@@ -93,7 +93,7 @@ func TestGetFunctionsOutput_fakeRunner(t *testing.T) {
 		Out: [][]byte{[]byte("list output for " + pick.OutputStem)},
 	}
 	dir := t.TempDir()
-	result := getFunctionsOutput(runner, []parser.FunctionListEntry{pick}, cpuPath, dir, nil)
+	result := getFunctionsOutput(t.Context(), runner, []parser.FunctionListEntry{pick}, cpuPath, "", config.RendererPprof, dir, nil)
 	if result.Collected != 1 || result.Skipped != 0 {
 		t.Fatalf("result=%+v", result)
 	}
@@ -122,7 +122,7 @@ func TestGetFunctionsOutput_parallelFakeRunner(t *testing.T) {
 	}
 	runner := &tooling.FakeRunner{Out: outs}
 	dir := t.TempDir()
-	result := getFunctionsOutput(runner, entries, cpuPath, dir, nil)
+	result := getFunctionsOutput(t.Context(), runner, entries, cpuPath, "", config.RendererPprof, dir, nil)
 	if result.Collected != len(entries) {
 		t.Fatalf("collected=%d want=%d", result.Collected, len(entries))
 	}
@@ -140,3 +140,67 @@ func TestGetFunctionsOutput_parallelFakeRunner(t *testing.T) {
 		}
 	}
 }
+
+// fixtureUtilsEntries returns list entries for the fixture's test-environment/utils functions.
+func fixtureUtilsEntries(t *testing.T, cpuPath string) []parser.FunctionListEntry {
+	t.Helper()
+	entries, err := parser.GetFunctionListEntriesV2(cpuPath, config.FunctionFilter{IncludePrefixes: []string{"test-environment/utils."}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("fixture should yield test-environment/utils entries")
+	}
+	return entries
+}
+
+func TestGetFunctionsOutput_inProcess(t *testing.T) {
+	cpuPath := testpaths.MustAsset(t, "fixtures", filterFixtureCPU)
+	source := mustReadFile(t, testpaths.MustAsset(t, "utils.go.txt"))
+	entries := fixtureUtilsEntries(t, cpuPath)
+
+	// The fixture records C:/.../utils/utils.go; pprof's path heuristic resolves it from a
+	// working directory named utils.
+	srcDir := filepath.Join(t.TempDir(), "utils")
+	if err := os.MkdirAll(srcDir, workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "utils.go"), source, workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(srcDir)
+
+	runner := &tooling.FakeRunner{}
+	dir := t.TempDir()
+	result := getFunctionsOutput(t.Context(), runner, entries, cpuPath, "", "", dir, nil)
+	if result.Collected != len(entries) || result.Skipped != 0 {
+		t.Fatalf("result=%+v want %d collected", result, len(entries))
+	}
+	if len(runner.Runs) != 0 {
+		t.Fatalf("expected no subprocesses, got %d", len(runner.Runs))
+	}
+	for _, e := range entries {
+		data := string(mustReadFile(t, filepath.Join(dir, e.OutputStem+"."+workspace.TextExtension)))
+		if !strings.Contains(data, "ROUTINE ======================== "+e.FullSymbol) || strings.Contains(data, "Error:") {
+			t.Fatalf("unexpected annotation for %s:\n%s", e.FullSymbol, data)
+		}
+	}
+}
+
+func TestGetFunctionsOutput_missingSourceSkipped(t *testing.T) {
+	cpuPath := testpaths.MustAsset(t, "fixtures", filterFixtureCPU)
+	entries := fixtureUtilsEntries(t, cpuPath)[:1]
+	t.Chdir(t.TempDir())
+
+	dir := t.TempDir()
+	result := getFunctionsOutput(t.Context(), &tooling.FakeRunner{}, entries, cpuPath, "", "", dir, nil)
+	if result.Skipped != 1 || result.Collected != 0 {
+		t.Fatalf("result=%+v", result)
+	}
+	if _, ok := result.FailedStems[entries[0].OutputStem]; !ok {
+		t.Fatalf("expected %s in FailedStems", entries[0].OutputStem)
+	}
+	if _, err := os.Stat(filepath.Join(dir, entries[0].OutputStem+"."+workspace.TextExtension)); !os.IsNotExist(err) {
+		t.Fatalf("skipped function should not be written: %v", err)
+	}
+}
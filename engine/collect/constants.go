@@ -2,18 +2,13 @@ package collect
 
 import "github.com/AlexsanderHamir/prof/engine/tooling"
 
-const (
-	moduleNotFoundMsg = "go: cannot find main module"
-	minCaptureGroups  = 2
-)
+const moduleNotFoundMsg = "go: cannot find main module"
 
-var profileCatalog = tooling.DefaultCatalog()
+// ProfileFlags maps built-in profile names to go test profiling flags.
+var ProfileFlags = buildProfileFlags(tooling.DefaultCatalog())
 
-// ProfileFlags maps profile names to go test profiling flags.
-var ProfileFlags = buildProfileFlags(profileCatalog)
-
-// ExpectedFiles maps profile names to expected pprof output filenames before moves.
-var ExpectedFiles = buildExpectedFiles(profileCatalog)
+// ExpectedFiles maps built-in profile names to expected pprof output filenames before moves.
+var ExpectedFiles = buildExpectedFiles(tooling.DefaultCatalog())
 
 func buildProfileFlags(c *tooling.Catalog) map[string]string {
 	m := make(map[string]string)
@@ -0,0 +1,17 @@
+package collect
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+)
+
+// cpuModel returns the machdep.cpu.brand_string sysctl, or "" when sysctl fails.
+func cpuModel(ctx context.Context, runner tooling.Runner) string {
+	out, err := runner.Run(ctx, tooling.SysctlArgs("machdep.cpu.brand_string"), tooling.RunOpts{})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,28 @@
+package collect
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+)
+
+// cpuModel returns the first "model name" of /proc/cpuinfo, or "" when it has none (as on
+// some arm64 kernels).
+func cpuModel(context.Context, tooling.Runner) string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package collect
+
+import (
+	"context"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+)
+
+// cpuModel returns "": the CPU model is only read on Linux and macOS.
+func cpuModel(context.Context, tooling.Runner) string {
+	return ""
+}
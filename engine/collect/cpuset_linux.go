@@ -0,0 +1,19 @@
+package collect
+
+import "golang.org/x/sys/unix"
+
+// availableCPUs returns the CPU ids prof may run on, in ascending order, or nil when the
+// affinity mask cannot be read.
+func availableCPUs() []int {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return nil
+	}
+	var cpus []int
+	for cpu := 0; len(cpus) < set.Count(); cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus
+}
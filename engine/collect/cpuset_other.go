@@ -0,0 +1,9 @@
+//go:build !linux
+
+package collect
+
+// availableCPUs returns nil: CPU ids are only known (and pinnable with taskset) on Linux.
+// Parallel benchmarks are then partitioned by GOMAXPROCS alone.
+func availableCPUs() []int {
+	return nil
+}
@@ -1,12 +1,9 @@
 package collect
 
 import (
-	"bufio"
 	"fmt"
 	"log/slog"
-	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/datamap"
@@ -18,17 +15,24 @@ type emitMapParams struct {
 	Tag              string
 	Benchmark        string
 	Profiles         []string
+	Traces           []string // execution trace kinds collected; their views are in Profiles
 	Filter           config.FunctionFilter
 	BenchCount       int
+	SampleIndex      map[string]string
+	GoTest           config.GoTestFlags
+	GoTestCommand    []string // go test argv of an auto run
+	Env              []string // env matrix overrides of the go test run
+	PerIteration     bool     // GoTestCommand ran once per BenchCount iteration
 	CollectionMode   string
 	PerProfile       []datamap.ProfileSnapshot
+	Producers        []config.ArtifactProducer // prof.json producers that ran on each profile
 	IncludeMeasuring bool
 }
 
 func emitBenchmarkMap(session *termui.Session, layout workspace.TagLayout, params emitMapParams) {
-	pkg := ""
+	var pkg, module string
 	if params.CollectionMode == datamapCollectionAuto {
-		pkg = benchmarkImportPath(params.Benchmark)
+		pkg, module = benchmarkImportPath(params.Benchmark, params.GoTest)
 	}
 
 	m, err := datamap.Build(datamap.BuildInput{
@@ -36,10 +40,18 @@ func emitBenchmarkMap(session *termui.Session, layout workspace.TagLayout, param
 		Tag:              params.Tag,
 		Benchmark:        params.Benchmark,
 		Package:          pkg,
+		Module:           module,
 		CollectionMode:   params.CollectionMode,
 		Profiles:         params.Profiles,
+		Traces:           params.Traces,
+		Producers:        params.Producers,
 		Filter:           params.Filter,
 		BenchCount:       params.BenchCount,
+		SampleIndex:      params.SampleIndex,
+		GoTest:           params.GoTest,
+		GoTestCommand:    params.GoTestCommand,
+		Env:              params.Env,
+		PerIteration:     params.PerIteration,
 		PerProfile:       params.PerProfile,
 		IncludeMeasuring: params.IncludeMeasuring,
 	})
@@ -52,7 +64,9 @@ func emitBenchmarkMap(session *termui.Session, layout workspace.TagLayout, param
 		warnMapEmit(session, fmt.Sprintf("benchmark map write failed for %s: %v", params.Benchmark, writeErr))
 		return
 	}
-	slog.Info("Wrote benchmark map", "path", path, "benchmark", params.Benchmark)
+	if !session.Interactive() {
+		slog.Info("Wrote benchmark map", "path", path, "benchmark", params.Benchmark)
+	}
 }
 
 func warnMapEmit(session *termui.Session, msg string) {
@@ -68,42 +82,32 @@ const (
 	datamapCollectionManual = "manual"
 )
 
-func benchmarkImportPath(benchmarkName string) string {
+// benchmarkImportPath returns the import path of the benchmark's package and the path of the
+// module holding it, or empty strings when either cannot be determined.
+func benchmarkImportPath(benchmarkName string, goTest config.GoTestFlags) (pkg, module string) {
 	moduleRoot, err := workspace.FindModuleRoot()
 	if err != nil {
-		return ""
+		return "", ""
 	}
-	pkgDir, err := findBenchmarkPackageDir(moduleRoot, benchmarkName)
+	pkgDir, err := findBenchmarkPackageDir(buildContext(goTest), moduleRoot, benchmarkName)
 	if err != nil {
-		return ""
-	}
-	modPath := readModulePath(filepath.Join(moduleRoot, "go.mod"))
-	if modPath == "" {
-		return ""
+		return "", ""
 	}
-	rel, err := filepath.Rel(moduleRoot, pkgDir)
+	project, err := workspace.LoadProject(moduleRoot)
 	if err != nil {
-		return modPath
+		return "", ""
 	}
-	rel = filepath.ToSlash(rel)
-	if rel == "." {
-		return modPath
+	mod, ok := project.ModuleFor(pkgDir)
+	if !ok || mod.Path == "" {
+		return "", ""
 	}
-	return modPath + "/" + rel
-}
-
-func readModulePath(goModPath string) string {
-	f, err := os.Open(goModPath)
+	rel, err := filepath.Rel(mod.Dir, pkgDir)
 	if err != nil {
-		return ""
+		return mod.Path, mod.Path
 	}
-	defer f.Close()
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if strings.HasPrefix(line, "module ") {
-			return strings.TrimSpace(strings.TrimPrefix(line, "module "))
-		}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return mod.Path, mod.Path
 	}
-	return ""
+	return mod.Path + "/" + rel, mod.Path
 }
@@ -19,14 +19,8 @@ func TestEmitBenchmarkMap_writesMapJSON(t *testing.T) {
 	layout, fixture := setupProcessProfilesEnv(t, tag, []string{"cpu"})
 	copyFixtureToProfile(t, layout, bench, "cpu", fixture)
 
-	runner := &tooling.FakeRunner{
-		Out: [][]byte{
-			[]byte("flat profile text"),
-			[]byte("tree profile text"),
-			[]byte("png-bytes"),
-		},
-	}
-	processed, err := processProfiles(runner, bench, []string{"cpu"}, tag, nil)
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("png-bytes")}}
+	processed, err := processProfiles(t.Context(), runner, builtins, bench, []string{"cpu"}, "", tag, nil, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
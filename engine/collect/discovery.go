@@ -1,46 +1,255 @@
 package collect
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
-func scanForBenchmarks(root string) ([]string, error) {
-	pattern := regexp.MustCompile(`(?m)^\s*func\s+(Benchmark[\w\d_]+)\s*\(\s*b\s*\*\s*testing\.B\s*\)\s*{`)
-	seen := make(map[string]struct{})
-	var names []string
+// benchmarkFunc is one BenchmarkXxx function declared in a package's test files.
+type benchmarkFunc struct {
+	dir  string // absolute package directory
+	pkg  string // module-relative package path ("." or "./internal/codec")
+	name string
+}
 
-	err := walkTestGoFiles(root, root, func(_ string, data []byte) error {
-		matches := pattern.FindAllSubmatch(data, -1)
-		for _, m := range matches {
-			if len(m) >= minCaptureGroups {
-				name := string(m[1])
-				if _, ok := seen[name]; !ok {
-					seen[name] = struct{}{}
-					names = append(names, name)
-				}
-			}
+// qualified returns the package-qualified identity, e.g. ./internal/codec.BenchmarkEncode.
+func (f benchmarkFunc) qualified() string {
+	return workspace.QualifyBenchmark(f.pkg, f.name)
+}
+
+// scanForBenchmarks returns the identities of every benchmark declared under root, in walk
+// order: the bare name when a single package under moduleRoot declares it, so tags keep the
+// names they had before discovery knew packages, else the package-qualified name.
+func scanForBenchmarks(root, moduleRoot string) ([]string, error) {
+	funcs, err := scanBenchmarkFuncs(&build.Default, root, moduleRoot)
+	if err != nil {
+		return nil, err
+	}
+	all := funcs
+	if root != moduleRoot {
+		// A bare name is resolved across the whole module, so count its packages there.
+		if all, err = scanBenchmarkFuncs(&build.Default, moduleRoot, moduleRoot); err != nil {
+			return nil, err
 		}
-		return nil
+	}
+	packages := make(map[string]int, len(all))
+	for _, f := range all {
+		packages[f.name]++
+	}
+	names := make([]string, len(funcs))
+	for i, f := range funcs {
+		names[i] = f.name
+		if packages[f.name] > 1 {
+			names[i] = f.qualified()
+		}
+	}
+	return names, nil
+}
+
+func scanBenchmarkFuncs(bctx *build.Context, root, moduleRoot string) ([]benchmarkFunc, error) {
+	var funcs []benchmarkFunc
+	err := walkPackageDirs(root, moduleRoot, func(dir string) error {
+		found, err := packageBenchmarks(bctx, dir, moduleRoot)
+		funcs = append(funcs, found...)
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
+	return funcs, nil
+}
 
-	return names, nil
+// packageBenchmarks parses the *_test.go files in dir that match bctx (GOOS, GOARCH, build
+// tags, and //go:build constraints) and returns the benchmarks they declare.
+// Files that do not parse are skipped; go test reports them when the package is run.
+func packageBenchmarks(bctx *build.Context, dir, moduleRoot string) ([]benchmarkFunc, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := modulePackage(moduleRoot, dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	seen := make(map[string]struct{})
+	var funcs []benchmarkFunc
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		match, matchErr := bctx.MatchFile(dir, e.Name())
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if !match {
+			continue
+		}
+		file, parseErr := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.SkipObjectResolution)
+		if parseErr != nil {
+			slog.Debug("Skipping unparsable test file", "file", filepath.Join(dir, e.Name()), "err", parseErr)
+			continue
+		}
+		for _, name := range benchmarkNames(file) {
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				funcs = append(funcs, benchmarkFunc{dir: dir, pkg: pkg, name: name})
+			}
+		}
+	}
+	return funcs, nil
 }
 
-func handleDirectory(path, moduleRoot string) error {
-	base := filepath.Base(path)
-	if strings.HasPrefix(base, ".") || base == "vendor" || base == "tests" || base == "bench" {
-		return filepath.SkipDir
+// modulePackage returns dir as a module-relative package path: "." or "./internal/codec".
+func modulePackage(moduleRoot, dir string) (string, error) {
+	rel, err := filepath.Rel(moduleRoot, dir)
+	if err != nil {
+		return "", err
 	}
-	if path != moduleRoot {
-		if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
-			return filepath.SkipDir
+	if rel == "." {
+		return workspace.RootPackage, nil
+	}
+	return workspace.RootPackage + workspace.SubBenchmarkSeparator + filepath.ToSlash(rel), nil
+}
+
+// benchmarkNames returns the top-level functions of file that go test runs as benchmarks:
+// func BenchmarkXxx(<any name> *testing.B), with testing imported under any name or dot-imported.
+func benchmarkNames(file *ast.File) []string {
+	testingNames := make(map[string]bool)
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err != nil || path != "testing" {
+			continue
+		}
+		if imp.Name == nil {
+			testingNames["testing"] = true
+		} else {
+			testingNames[imp.Name.Name] = true
 		}
 	}
-	return nil
+	if len(testingNames) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Type.TypeParams != nil || fn.Type.Results != nil {
+			continue
+		}
+		if !isBenchmarkName(fn.Name.Name) {
+			continue
+		}
+		params := fn.Type.Params.List
+		if len(params) != 1 || len(params[0].Names) > 1 || !isTestingB(params[0].Type, testingNames) {
+			continue
+		}
+		names = append(names, fn.Name.Name)
+	}
+	return names
+}
+
+// isBenchmarkName applies go test's rule: the Benchmark prefix followed by nothing or by a
+// character that is not a lower-case letter.
+func isBenchmarkName(name string) bool {
+	rest, ok := strings.CutPrefix(name, workspace.BenchmarkPrefix)
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
+
+// isTestingB reports whether expr is *testing.B under one of the file's names for the
+// testing package ("." for a dot import).
+func isTestingB(expr ast.Expr, testingNames map[string]bool) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	switch x := star.X.(type) {
+	case *ast.SelectorExpr:
+		pkg, isIdent := x.X.(*ast.Ident)
+		return isIdent && x.Sel.Name == "B" && testingNames[pkg.Name]
+	case *ast.Ident:
+		return x.Name == "B" && testingNames["."]
+	default:
+		return false
+	}
+}
+
+// benchResultLine matches a go test -bench result line: name, then the iteration count.
+var benchResultLine = regexp.MustCompile(`(?m)^(Benchmark\S*)\s+\d+\s`)
+
+// buildSubBenchmarkListCommand runs every leaf of benchmarkName once, built with goTest's
+// build flags so the same benchmarks compile. -cpu=1 keeps the GOMAXPROCS suffix off the
+// printed names.
+func buildSubBenchmarkListCommand(benchmarkName string, goTest config.GoTestFlags) []string {
+	cmd := []string{
+		workspace.GoBinaryName, workspace.GoTestSubcommand, "-run=^$",
+		"-bench=" + benchPattern(benchmarkName),
+		"-benchtime=1x", "-cpu=1", "-count=1",
+	}
+	return append(cmd, goTestBuildArgs(goTest)...)
+}
+
+// parseBenchmarkNames returns the distinct benchmark names reported in go test -bench output,
+// in first-seen order.
+func parseBenchmarkNames(output []byte) []string {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, m := range benchResultLine.FindAllSubmatch(output, -1) {
+		name := string(m[1])
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// listSubBenchmarks enumerates the leaf benchmarks under benchmarkName by running each of
+// them for a single iteration in its package. A benchmark without b.Run lists only itself.
+// Leaves of a package-qualified benchmark are qualified with the same package.
+func listSubBenchmarks(ctx context.Context, runner tooling.Runner, moduleRoot, benchmarkName string, goTest config.GoTestFlags) ([]string, error) {
+	if runner == nil {
+		return nil, errors.New("tooling runner is nil")
+	}
+	pkgDir, err := findBenchmarkPackageDir(buildContext(goTest), moduleRoot, benchmarkName)
+	if err != nil {
+		return nil, err
+	}
+	output, err := runner.Run(ctx, buildSubBenchmarkListCommand(benchmarkName, goTest), tooling.RunOpts{Dir: pkgDir, Combined: true})
+	if err != nil {
+		return nil, fmt.Errorf("listing sub-benchmarks of %s failed:\n%s", benchmarkName, string(output))
+	}
+	names := parseBenchmarkNames(output)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("benchmark %s reported no results", benchmarkName)
+	}
+	if pkg, _ := workspace.SplitQualifiedBenchmark(benchmarkName); pkg != "" {
+		for i, name := range names {
+			names[i] = workspace.QualifyBenchmark(pkg, name)
+		}
+	}
+	return names, nil
 }
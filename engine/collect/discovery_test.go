@@ -1,40 +1,119 @@
 package collect
 
 import (
+	"go/build"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/testpaths"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
+// writeTestGo writes a _test.go file with the given body below a package clause; the
+// package name does not matter to discovery.
+func writeTestGo(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	src := "package p\n\n" + body
+	if err := os.WriteFile(path, []byte(src), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestScanForBenchmarks_skipsNestedModule(t *testing.T) {
 	t.Parallel()
 	root := t.TempDir()
 	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module rootmod\n"), workspace.PermFile); err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(root, "root_bench_test.go"), []byte("func BenchmarkRoot(b *testing.B) {}\n"), workspace.PermFile); err != nil {
+	writeTestGo(t, filepath.Join(root, "root_bench_test.go"), "import \"testing\"\n\nfunc BenchmarkRoot(b *testing.B) {}\n")
+	nested := filepath.Join(root, "tests", "nested")
+	writeTestGo(t, filepath.Join(nested, "bench_test.go"), "import \"testing\"\n\nfunc BenchmarkNested(b *testing.B) {}\n")
+	if err := os.WriteFile(filepath.Join(nested, "go.mod"), []byte("module nested\n"), workspace.PermFile); err != nil {
 		t.Fatal(err)
 	}
-	nested := filepath.Join(root, "tests", "nested")
-	if err := os.MkdirAll(nested, 0o755); err != nil {
+
+	names, err := scanForBenchmarks(root, root)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(nested, "go.mod"), []byte("module nested\n"), workspace.PermFile); err != nil {
+	if len(names) != 1 || names[0] != "BenchmarkRoot" {
+		t.Fatalf("got %v want [BenchmarkRoot]", names)
+	}
+}
+
+func TestScanForBenchmarks_qualifiesOnlyAmbiguousNames(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module rootmod\n"), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	writeTestGo(t, filepath.Join(root, "root_bench_test.go"), "import \"testing\"\n\nfunc BenchmarkEncode(b *testing.B) {}\n")
+	writeTestGo(t, filepath.Join(root, "codec", "bench_test.go"), "import \"testing\"\n\nfunc BenchmarkEncode(b *testing.B) {}\nfunc BenchmarkDecode(b *testing.B) {}\n")
+
+	names, err := scanForBenchmarks(root, root)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(nested, "bench_test.go"), []byte("func BenchmarkNested(b *testing.B) {}\n"), workspace.PermFile); err != nil {
+	if want := []string{".BenchmarkEncode", "./codec.BenchmarkEncode", "BenchmarkDecode"}; !slices.Equal(names, want) {
+		t.Fatalf("got %v want %v", names, want)
+	}
+	// Ambiguity is judged across the module, not only the scanned scope.
+	if names, err = scanForBenchmarks(filepath.Join(root, "codec"), root); err != nil {
 		t.Fatal(err)
 	}
+	if want := []string{"./codec.BenchmarkEncode", "BenchmarkDecode"}; !slices.Equal(names, want) {
+		t.Fatalf("scoped: got %v want %v", names, want)
+	}
+	if dir, dirErr := findBenchmarkPackageDir(&build.Default, root, ".BenchmarkEncode"); dirErr != nil || dir != root {
+		t.Fatalf("root package dir=%q err=%v", dir, dirErr)
+	}
+}
 
-	names, err := scanForBenchmarks(root)
+func TestScanForBenchmarks_goWorkModules(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(workspace.GoWorkEnv, "")
+	goWork := "go 1.24\n\nuse (\n\t./svc/a\n\t./svc/b // second module\n)\n"
+	if err := os.WriteFile(filepath.Join(root, workspace.GoWorkFile), []byte(goWork), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	for _, mod := range []string{"a", "b", "unused"} {
+		dir := filepath.Join(root, "svc", mod)
+		writeTestGo(t, filepath.Join(dir, "codec", "bench_test.go"), "import \"testing\"\n\nfunc BenchmarkEncode(b *testing.B) {}\n")
+		if err := os.WriteFile(filepath.Join(dir, workspace.GoModFile), []byte("module example.com/"+mod+"\n"), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moduleRoot, err := workspace.FindModuleRootFrom(filepath.Join(root, "svc", "a", "codec"))
+	if err != nil || moduleRoot != root {
+		t.Fatalf("root=%q err=%v, want the go.work directory %q", moduleRoot, err, root)
+	}
+	names, err := scanForBenchmarks(root, root)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(names) != 1 || names[0] != "BenchmarkRoot" {
-		t.Fatalf("got %v want [BenchmarkRoot]", names)
+	want := []string{"./svc/a/codec.BenchmarkEncode", "./svc/b/codec.BenchmarkEncode"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got %v want %v", names, want)
+	}
+	dir, err := findBenchmarkPackageDir(&build.Default, root, want[1])
+	if err != nil || dir != filepath.Join(root, "svc", "b", "codec") {
+		t.Fatalf("dir=%q err=%v", dir, err)
+	}
+
+	t.Chdir(root)
+	pkg, module := benchmarkImportPath(want[1], config.GoTestFlags{})
+	if pkg != "example.com/b/codec" || module != "example.com/b" {
+		t.Fatalf("pkg=%q module=%q", pkg, module)
 	}
 }
 
@@ -44,20 +123,93 @@ func TestScanForBenchmarks_findsBenchmarksDir(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module rootmod\n"), workspace.PermFile); err != nil {
 		t.Fatal(err)
 	}
-	benchDir := filepath.Join(root, "benchmarks")
-	if err := os.MkdirAll(benchDir, 0o755); err != nil {
+	writeTestGo(t, filepath.Join(root, "benchmarks", "benchmark_test.go"), "import \"testing\"\n\nfunc BenchmarkExample(b *testing.B) {}\n")
+
+	names, err := scanForBenchmarks(root, root)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(benchDir, "benchmark_test.go"), []byte("func BenchmarkExample(b *testing.B) {}\n"), workspace.PermFile); err != nil {
+	if len(names) != 1 || names[0] != "BenchmarkExample" {
+		t.Fatalf("got %v want [BenchmarkExample]", names)
+	}
+}
+
+func TestScanForBenchmarks_parsesDeclarations(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	otherOS := "windows"
+	if runtime.GOOS == otherOS {
+		otherOS = "plan9"
+	}
+	writeTestGo(t, filepath.Join(root, "codec", "codec_test.go"), `import tst "testing"
+
+func BenchmarkAliased(bb *tst.B)  {}
+func BenchmarkUnnamed(*tst.B)     {}
+func Benchmark_underscore(*tst.B) {}
+func Benchmarklower(b *tst.B)     {}
+func BenchmarkTwoArgs(b *tst.B, n int) {}
+func BenchmarkWrongType(t *tst.T) {}
+
+type suite struct{}
+
+func (suite) BenchmarkMethod(b *tst.B) {}
+`)
+	writeTestGo(t, filepath.Join(root, "codec", "dot_test.go"), "import . \"testing\"\n\nfunc BenchmarkDot(b *B) {}\n")
+	ignored := "//go:build ignore\n\npackage p\n\nimport \"testing\"\n\nfunc BenchmarkIgnored(b *testing.B) {}\n"
+	if err := os.WriteFile(filepath.Join(root, "codec", "ignored_test.go"), []byte(ignored), workspace.PermFile); err != nil {
 		t.Fatal(err)
 	}
+	writeTestGo(t, filepath.Join(root, "codec", "codec_"+otherOS+"_test.go"), "import \"testing\"\n\nfunc BenchmarkOtherOS(b *testing.B) {}\n")
+	writeTestGo(t, filepath.Join(root, "codec", "broken_test.go"), "func BenchmarkBroken(\n")
+	writeTestGo(t, filepath.Join(root, "tests", "tests_test.go"), "import \"testing\"\n\nfunc BenchmarkInTests(b *testing.B) {}\n")
+	writeTestGo(t, filepath.Join(root, "bench", "bench_test.go"), "import \"testing\"\n\nfunc BenchmarkInBench(b *testing.B) {}\n")
+	writeTestGo(t, filepath.Join(root, "testdata", "data_test.go"), "import \"testing\"\n\nfunc BenchmarkTestdata(b *testing.B) {}\n")
 
-	names, err := scanForBenchmarks(root)
+	names, err := scanForBenchmarks(root, root)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(names) != 1 || names[0] != "BenchmarkExample" {
-		t.Fatalf("got %v want [BenchmarkExample]", names)
+	want := []string{
+		"BenchmarkInBench",
+		"BenchmarkAliased",
+		"BenchmarkUnnamed",
+		"Benchmark_underscore",
+		"BenchmarkDot",
+		"BenchmarkInTests",
+	}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got %v want %v", names, want)
+	}
+}
+
+func TestFindBenchmarkPackageDir_disambiguatesPackages(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	for _, pkg := range []string{"json", "xml"} {
+		writeTestGo(t, filepath.Join(root, "codec", pkg, "codec_test.go"), "import \"testing\"\n\nfunc BenchmarkEncode(b *testing.B) {}\n")
+	}
+
+	_, err := findBenchmarkPackageDir(&build.Default, root, "BenchmarkEncode")
+	if err == nil || !strings.Contains(err.Error(), "./codec/json.BenchmarkEncode, ./codec/xml.BenchmarkEncode") {
+		t.Fatalf("expected ambiguity error listing both packages, got %v", err)
+	}
+	for name, want := range map[string]string{
+		"./codec/xml.BenchmarkEncode":        filepath.Join(root, "codec", "xml"),
+		"./codec/json.BenchmarkEncode/small": filepath.Join(root, "codec", "json"),
+	} {
+		dir, findErr := findBenchmarkPackageDir(&build.Default, root, name)
+		if findErr != nil {
+			t.Fatal(findErr)
+		}
+		if dir != want {
+			t.Fatalf("%s: dir=%q want %q", name, dir, want)
+		}
+	}
+	if _, err = findBenchmarkPackageDir(&build.Default, root, "./codec/yaml.BenchmarkEncode"); err == nil {
+		t.Fatal("expected error for missing package")
+	}
+	if _, err = findBenchmarkPackageDir(&build.Default, root, "./codec/json.BenchmarkDecode"); err == nil {
+		t.Fatal("expected error for benchmark missing from package")
 	}
 }
 
@@ -94,3 +246,108 @@ func TestDiscoverBenchmarks_repoBenchmarksFixture(t *testing.T) {
 		}
 	}
 }
+
+func TestListSubBenchmarks_parsesLeafNames(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	pkg := filepath.Join(root, "codec")
+	writeTestGo(t, filepath.Join(pkg, "codec_test.go"), "import \"testing\"\n\nfunc BenchmarkCodec(b *testing.B) {}\n")
+	out := "goos: linux\ngoarch: amd64\n" +
+		"BenchmarkCodec/json/small \t       1\t      2100 ns/op\n" +
+		"BenchmarkCodec/json/large \t       1\t     91000 ns/op\n" +
+		"BenchmarkCodec/size=1024  \t       1\t       800 ns/op\nPASS\nok  \tcodec\t0.01s\n"
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte(out), []byte(out)}}
+
+	names, err := listSubBenchmarks(t.Context(), runner, root, "BenchmarkCodec/json", config.GoTestFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"BenchmarkCodec/json/small", "BenchmarkCodec/json/large", "BenchmarkCodec/size=1024"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got %v want %v", names, want)
+	}
+	run := runner.Runs[0]
+	if run.Opts.Dir != pkg || !slices.Contains(run.Argv, "-bench=^BenchmarkCodec$/^json$") || !slices.Contains(run.Argv, "-benchtime=1x") {
+		t.Fatalf("run=%+v", run)
+	}
+
+	qualified, err := listSubBenchmarks(t.Context(), runner, root, "./codec.BenchmarkCodec/json", config.GoTestFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qualified) != len(want) || qualified[0] != "./codec.BenchmarkCodec/json/small" {
+		t.Fatalf("qualified leaves=%v", qualified)
+	}
+}
+
+func TestBuildBenchmarkCommand_anchorsEveryLevel(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]string{
+		"BenchmarkFoo":                "-bench=^BenchmarkFoo$",
+		"BenchmarkCodec/json/small":   "-bench=^BenchmarkCodec$/^json$/^small$",
+		"BenchmarkPool/size=1.5":      `-bench=^BenchmarkPool$/^size=1\.5$`,
+		"./codec.BenchmarkCodec/json": "-bench=^BenchmarkCodec$/^json$",
+	} {
+		cmd, err := buildBenchmarkCommand(builtins.catalog, name, []string{"cpu"}, 1, config.GoTestFlags{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(cmd, want) {
+			t.Fatalf("%s: argv %v missing %s", name, cmd, want)
+		}
+	}
+	for _, name := range []string{"BenchmarkCodec/json/small", "./internal/codec.BenchmarkCodec/json"} {
+		if got := topLevelBenchmark(name); got != "BenchmarkCodec" {
+			t.Fatalf("topLevelBenchmark(%q)=%q", name, got)
+		}
+	}
+}
+
+func TestBuildBenchmarkCommand_goTestFlags(t *testing.T) {
+	t.Parallel()
+	race := true
+	cmd, err := buildBenchmarkCommand(builtins.catalog, "BenchmarkFoo", []string{"cpu"}, 3, config.GoTestFlags{
+		Benchtime: "2s",
+		CPU:       "1,4,8",
+		Timeout:   "30m",
+		Tags:      "integration",
+		Gcflags:   "all=-N -l",
+		Ldflags:   "-s",
+		Race:      &race,
+		ExtraArgs: []string{"-shuffle=on"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"go", "test", "-run=^$", "-bench=^BenchmarkFoo$", "-benchmem", "-count=3",
+		"-benchtime=2s", "-cpu=1,4,8", "-timeout=30m",
+		"-tags=integration", "-gcflags=all=-N -l", "-ldflags=-s", "-race",
+		ProfileFlags["cpu"], "-shuffle=on",
+	}
+	if !slices.Equal(cmd, want) {
+		t.Fatalf("got  %q\nwant %q", cmd, want)
+	}
+	if _, err = buildBenchmarkCommand(builtins.catalog, "BenchmarkFoo", []string{"cpu"}, 1, config.GoTestFlags{ExtraArgs: []string{"-count=5"}}); err == nil {
+		t.Fatal("expected error for an extra arg prof manages")
+	}
+}
+
+func TestFindBenchmarkPackageDir_buildTags(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	src := "//go:build integration\n\npackage p\n\nimport \"testing\"\n\nfunc BenchmarkSlow(b *testing.B) {}\n"
+	if err := os.WriteFile(filepath.Join(root, "slow_test.go"), []byte(src), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findBenchmarkPackageDir(buildContext(config.GoTestFlags{}), root, "BenchmarkSlow"); err == nil {
+		t.Fatal("expected BenchmarkSlow to be excluded without -tags=integration")
+	}
+	dir, err := findBenchmarkPackageDir(buildContext(config.GoTestFlags{Tags: "fast,integration"}), root, "BenchmarkSlow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != root {
+		t.Fatalf("dir=%q want %q", dir, root)
+	}
+}
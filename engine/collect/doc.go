@@ -1,5 +1,6 @@
 // Package collect runs auto and manual profile collection under .prof/<tag>/.
 // Artifacts are grouped by data domain: profiles, measurements, hotspots,
 // source_lines, and call_graphs (see internal/workspace.TagLayout).
-// source_lines extraction fans out go tool pprof -list subprocesses with bounded parallelism.
+// source_lines extraction annotates every function from one parsed profile with bounded parallelism
+// (go tool pprof -list subprocesses when collection.renderer is "pprof").
 package collect
@@ -78,17 +78,31 @@ func TestEdge_functionListCollection_fixture(t *testing.T) {
 
 	var pick parser.FunctionListEntry
 	for _, e := range entries {
-		if strings.Contains(e.FullSymbol, "(") && strings.Contains(e.FullSymbol, ")") {
+		if strings.HasPrefix(e.FullSymbol, "test-environment/utils.") && strings.Contains(e.FullSymbol, "(") && strings.Contains(e.FullSymbol, ")") {
 			pick = e
 			break
 		}
 	}
 	if pick.FullSymbol == "" {
-		t.Fatal("fixture should include at least one symbol with '(' and ')' in FullSymbol")
+		t.Fatal("fixture should include at least one utils symbol with '(' and ')' in FullSymbol")
 	}
 
+	// Resolve the fixture's recorded utils/utils.go from a working directory named utils.
+	srcDir := filepath.Join(t.TempDir(), "utils")
+	if err := os.MkdirAll(srcDir, workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	source, readErr := os.ReadFile(testpaths.MustAsset(t, "utils.go.txt"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "utils.go"), source, workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(srcDir)
+
 	dir := t.TempDir()
-	if outErr := FunctionsOutput(tooling.NewExecRunner(), []parser.FunctionListEntry{pick}, cpuPath, dir); outErr != nil {
+	if outErr := FunctionsOutput(t.Context(), tooling.NewExecRunner(), []parser.FunctionListEntry{pick}, cpuPath, dir); outErr != nil {
 		t.Fatalf("FunctionsOutput: %v", outErr)
 	}
 	out := filepath.Join(dir, pick.OutputStem+"."+workspace.TextExtension)
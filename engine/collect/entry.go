@@ -1,10 +1,13 @@
 package collect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
 	"github.com/AlexsanderHamir/prof/internal/config"
@@ -13,7 +16,8 @@ import (
 )
 
 // RunAuto validates flags, loads optional repo config, prepares bench layout, then runs the full pipeline.
-func RunAuto(runner tooling.Runner, opts AutoOptions) error {
+// Canceling ctx stops the running go test or pprof subprocess and marks the tag incomplete.
+func RunAuto(ctx context.Context, runner tooling.Runner, opts AutoOptions) error {
 	if runner == nil {
 		return errors.New("tooling runner is nil")
 	}
@@ -26,21 +30,73 @@ func RunAuto(runner tooling.Runner, opts AutoOptions) error {
 	if opts.Count < 1 {
 		return errors.New("count must be at least 1")
 	}
-
-	session := termui.NewSession(os.Stderr, int(os.Stderr.Fd()))
-	graphvizMissing := !tooling.GraphvizAvailable()
-
+	if opts.Append && opts.Resume {
+		return errors.New("append and resume cannot be combined")
+	}
+	if opts.PerIteration && opts.Count < 2 {
+		return errors.New("per-iteration needs a count of at least 2")
+	}
+	if opts.Parallel < 0 {
+		return errors.New("parallel cannot be negative")
+	}
+	if err := config.ValidateGoTestFlags(opts.GoTest); err != nil {
+		return fmt.Errorf("go test flags: %w", err)
+	}
+	for profile := range opts.SampleIndex {
+		if !slices.Contains(opts.Profiles, profile) {
+			return fmt.Errorf("sample index set for profile %q, which is not being collected", profile)
+		}
+	}
+	if err := workspace.ValidateTagName(opts.Tag); err != nil {
+		return err
+	}
 	cfg, err := config.Load()
 	cfgMissing := err != nil
 	if cfgMissing {
 		cfg = &config.Config{}
 	}
+	variants, err := expandEnvMatrix(opts.Env, cfg.Collection.EnvMatrix)
+	if err != nil {
+		return err
+	}
+	tagLock, err := lockTag(opts.Out, opts.Tag)
+	if err != nil {
+		return err
+	}
+	defer tagLock.Unlock()
+
+	session := termui.NewSession(os.Stderr, int(os.Stderr.Fd()))
+	graphvizMissing := !tooling.GraphvizAvailable()
+
+	reg, err := newRegistry(cfg)
+	if err != nil {
+		return err
+	}
+	for _, profile := range opts.Profiles {
+		if opts.PerIteration && reg.catalog.Format(profile) == tooling.FormatTrace {
+			return fmt.Errorf("per-iteration cannot merge %s execution traces; collect %s in a run without it", profile, profile)
+		}
+	}
 
 	autoArgs := &config.AutoArgs{
-		Benchmarks: opts.Benchmarks,
-		Profiles:   opts.Profiles,
-		Count:      opts.Count,
-		Tag:        opts.Tag,
+		Benchmarks:   opts.Benchmarks,
+		Profiles:     opts.Profiles,
+		Count:        opts.Count,
+		Tag:          opts.Tag,
+		Out:          opts.Out,
+		SampleIndex:  config.ResolveSampleIndex(cfg, opts.SampleIndex),
+		Renderer:     cfg.Collection.Renderer,
+		GoTest:       config.NormalizeGoTestFlags(opts.GoTest),
+		Timeouts:     cfg.Collection.Timeouts,
+		Append:       opts.Append,
+		Resume:       opts.Resume,
+		Parallel:     opts.Parallel,
+		PerIteration: opts.PerIteration,
+	}
+	info := newRunInfo(ctx, runner, manifestModeAuto, opts.ProfVersion, opts.Out)
+	var parallelWarnings []string
+	if opts.Parallel > 1 && len(opts.Benchmarks) > 1 {
+		parallelWarnings = parallelNotices(opts.Parallel)
 	}
 
 	if session.Interactive() {
@@ -52,39 +108,91 @@ func RunAuto(runner tooling.Runner, opts AutoOptions) error {
 			if graphvizMissing {
 				session.Warn(tooling.SkipPNGNotice)
 			}
-			return setupDirectories(opts.Tag, opts.Benchmarks, opts.Profiles, true)
+			for _, w := range parallelWarnings {
+				session.Warn(w)
+			}
+			return prepareTag(reg.catalog, opts, variants, true)
 		}); prepErr != nil {
 			return finalizeInteractiveErr(session, fmt.Errorf("failed to setup directories: %w", prepErr))
 		}
-		return finalizeInteractiveErr(session, runBenchAndGetProfiles(runner, autoArgs, cfg, session))
+		return finalizeInteractiveErr(session, runPipeline(ctx, runner, reg, autoArgs, cfg, session, variants, info))
 	}
 
 	if cfgMissing {
 		slog.Info("No config file found at repository root; proceeding without function filters.", "expected", config.Filename)
 		slog.Info("You can generate one with 'prof config init' or Create prof.json in prof ui.")
 	}
-	if err = setupDirectories(opts.Tag, opts.Benchmarks, opts.Profiles, false); err != nil {
+	if err = prepareTag(reg.catalog, opts, variants, false); err != nil {
 		return fmt.Errorf("failed to setup directories: %w", err)
 	}
 	config.PrintAutoConfiguration(autoArgs, cfg)
+	if len(variants) > 0 {
+		slog.Info("Env matrix", "Variables", opts.Env, "Variants", len(variants))
+	}
 	if graphvizMissing {
 		fmt.Fprintln(os.Stdout, tooling.SkipPNGNotice)
 		slog.Info(tooling.SkipPNGNotice)
 	}
-	return runBenchAndGetProfiles(runner, autoArgs, cfg, session)
+	for _, w := range parallelWarnings {
+		slog.Warn(w)
+	}
+	return runPipeline(ctx, runner, reg, autoArgs, cfg, session, variants, info)
+}
+
+// prepareTag lays out .prof/<tag>/, or one nested tag per variant when an env matrix is set.
+// --append and --resume keep what the tag already holds.
+func prepareTag(catalog *tooling.Catalog, opts AutoOptions, variants []envVariant, quiet bool) error {
+	keep := opts.Append || opts.Resume
+	switch {
+	case len(variants) > 0:
+		return setupVariantDirectories(catalog, opts.Out, opts.Tag, variants, opts.Benchmarks, opts.Profiles, keep, quiet)
+	case keep:
+		return extendTag(opts.Out, opts.Tag, quiet)
+	default:
+		return setupDirectories(catalog, opts.Out, opts.Tag, opts.Benchmarks, opts.Profiles, quiet)
+	}
 }
 
-// DiscoverBenchmarks scans for BenchmarkXxx functions under scope or module root.
+func runPipeline(ctx context.Context, runner tooling.Runner, reg *registry, autoArgs *config.AutoArgs, cfg *config.Config, session *termui.Session, variants []envVariant, info *runInfo) error {
+	if len(variants) == 0 {
+		return runBenchAndGetProfiles(ctx, runner, reg, autoArgs, cfg, session, info)
+	}
+	return runEnvMatrix(ctx, runner, reg, autoArgs, cfg, session, variants, info)
+}
+
+// DiscoverBenchmarks parses the test files under scope (or the module root when empty) and
+// returns every top-level benchmark: its bare name, or, when several packages of the module
+// declare it, its identity qualified with the package relative to the module root, e.g.
+// ./internal/codec.BenchmarkEncode. A scope outside any module is its own root. Sub-benchmarks
+// only exist at run time; see ListSubBenchmarks.
 func DiscoverBenchmarks(scope string) ([]string, error) {
-	var searchRoot string
-	var err error
-	if scope != "" {
-		searchRoot = scope
-	} else {
-		searchRoot, err = workspace.FindModuleRoot()
+	if scope == "" {
+		moduleRoot, err := workspace.FindModuleRoot()
 		if err != nil {
 			return nil, fmt.Errorf("failed to locate module root: %w", err)
 		}
+		return scanForBenchmarks(moduleRoot, moduleRoot)
+	}
+	searchRoot, err := filepath.Abs(scope)
+	if err != nil {
+		return nil, err
+	}
+	moduleRoot, err := workspace.FindModuleRootFrom(searchRoot)
+	if err != nil {
+		moduleRoot = searchRoot
+	}
+	return scanForBenchmarks(searchRoot, moduleRoot)
+}
+
+// ListSubBenchmarks returns the leaf sub-benchmarks of benchmark (a bare or package-qualified
+// BenchmarkXxx name, or a slash path below it) by running each once with -benchtime=1x. The names can be passed
+// back as AutoOptions.Benchmarks to profile a single sub-benchmark. Build flags from
+// collection.go_test in prof.json apply to the listing run.
+func ListSubBenchmarks(ctx context.Context, runner tooling.Runner, benchmark string) ([]string, error) {
+	moduleRoot, err := workspace.FindModuleRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate module root: %w", err)
 	}
-	return scanForBenchmarks(searchRoot)
+	cfg, _ := config.Load() // without a usable prof.json, list with go test defaults
+	return listSubBenchmarks(ctx, runner, moduleRoot, benchmark, config.ResolveGoTestFlags(cfg, benchmark, config.GoTestFlags{}))
 }
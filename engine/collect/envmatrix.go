@@ -0,0 +1,210 @@
+package collect
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// envVariant is one combination of an env matrix.
+type envVariant struct {
+	name string   // directory under the tag, e.g. GOGC=50+GOMAXPROCS=2
+	env  []string // NAME=value overrides in matrix order
+}
+
+// envMatrixVar is one variable of an env matrix with the values it takes.
+type envMatrixVar struct {
+	name   string
+	values []string
+}
+
+// expandEnvMatrix returns every combination of the values of the collection.env_matrix
+// variables in fromConfig (in name order) and the NAME=v1,v2,... specs (in order), the first
+// variable varying slowest. A spec replaces the prof.json entry of its variable. Values are
+// split on commas only in specs, so a prof.json value may hold one (GOEXPERIMENT=arenas,loopvar).
+// An empty matrix returns nil.
+func expandEnvMatrix(specs []string, fromConfig map[string][]string) ([]envVariant, error) {
+	vars := make([]envMatrixVar, 0, len(fromConfig)+len(specs))
+	for _, name := range slices.Sorted(maps.Keys(fromConfig)) {
+		vars = append(vars, envMatrixVar{name: name, values: fromConfig[name]})
+	}
+	fromSpec := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		name, list, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("env matrix entry %q: want NAME=value[,value...]", spec)
+		}
+		if fromSpec[name] {
+			return nil, fmt.Errorf("env matrix variable %s is set more than once", name)
+		}
+		fromSpec[name] = true
+		v := envMatrixVar{name: name, values: strings.Split(list, ",")}
+		if i := slices.IndexFunc(vars, func(c envMatrixVar) bool { return c.name == name }); i >= 0 {
+			vars[i] = v
+		} else {
+			vars = append(vars, v)
+		}
+	}
+	if len(vars) == 0 {
+		return nil, nil
+	}
+
+	variants := []envVariant{{}}
+	for _, mv := range vars {
+		if strings.ContainsAny(mv.name, " \t=") || mv.name == "" {
+			return nil, fmt.Errorf("env matrix variable %q is not a valid name", mv.name)
+		}
+		if len(mv.values) == 0 {
+			return nil, fmt.Errorf("env matrix variable %s has no values", mv.name)
+		}
+		var values []string
+		seenValues := make(map[string]bool)
+		for _, v := range mv.values {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return nil, fmt.Errorf("env matrix variable %s has an empty value", mv.name)
+			}
+			if seenValues[v] {
+				return nil, fmt.Errorf("env matrix variable %s lists %q more than once", mv.name, v)
+			}
+			seenValues[v] = true
+			values = append(values, v)
+		}
+
+		next := make([]envVariant, 0, len(variants)*len(values))
+		for _, base := range variants {
+			for _, v := range values {
+				env := append(append([]string(nil), base.env...), mv.name+"="+v)
+				next = append(next, envVariant{env: env})
+			}
+		}
+		variants = next
+	}
+
+	seenNames := make(map[string]bool, len(variants))
+	for i := range variants {
+		variants[i].name = workspace.EnvVariant(variants[i].env)
+		if seenNames[variants[i].name] {
+			return nil, fmt.Errorf("env matrix variants collide on directory %s", variants[i].name)
+		}
+		seenNames[variants[i].name] = true
+	}
+	return variants, nil
+}
+
+// variantEnv returns the environment of a go test run under overrides: the current process
+// environment with overrides appended, so they win. No overrides inherit the environment as is.
+func variantEnv(overrides []string) []string {
+	if len(overrides) == 0 {
+		return nil
+	}
+	return append(os.Environ(), overrides...)
+}
+
+// writeVariantSummary writes the ns/op, B/op and allocs/op medians of every benchmark under
+// every variant to the tag's variants.txt and to w. Deltas are relative to the first variant
+// that measured the benchmark.
+func writeVariantSummary(w io.Writer, out, tag string, benchmarks []string, variants []envVariant) error {
+	layout, err := workspace.TagLayoutFromCWD(out, tag)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if err = renderVariantSummary(&b, out, tag, benchmarks, variants); err != nil {
+		return err
+	}
+	if err = os.WriteFile(layout.VariantSummary(), []byte(b.String()), workspace.PermFile); err != nil {
+		return fmt.Errorf("failed to write variant summary: %w", err)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// Units of the variant summary columns, as printed by go test -benchmem.
+var variantSummaryUnits = []string{"ns/op", "B/op", "allocs/op"}
+
+func renderVariantSummary(w io.Writer, out, tag string, benchmarks []string, variants []envVariant) error {
+	fmt.Fprintf(w, "Env matrix for %s\n", tag)
+	for _, bench := range benchmarks {
+		// nil marks a variant without measurements for bench.
+		sums := make([]*datamap.MeasurementSummary, len(variants))
+		baselineName := ""
+		for i, v := range variants {
+			layout, err := workspace.TagLayoutFromCWD(out, workspace.VariantTag(tag, v.name))
+			if err != nil {
+				return err
+			}
+			sum, err := datamap.ParseMeasurementSummary(layout.Measurement(bench))
+			if err != nil {
+				continue
+			}
+			sums[i] = sum
+			if baselineName == "" && hasVariantBaseline(sum) {
+				baselineName = v.name
+			}
+		}
+		if baselineName == "" {
+			fmt.Fprintf(w, "\n%s\n", bench)
+		} else {
+			fmt.Fprintf(w, "\n%s (Δ vs %s)\n", bench, baselineName)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  variant\tns/op\tΔ\tB/op\tallocs/op\t")
+		baseline := map[string]float64{} // ns/op median of the first variant measuring each result name
+		for i, v := range variants {
+			if sums[i] == nil {
+				fmt.Fprintf(tw, "  %s\tn/a\t\t\t\t\n", v.name)
+				continue
+			}
+			groups := sums[i].Groups()
+			for _, g := range groups {
+				// A lone result is compared across variants whatever its -N suffix, since
+				// variants may set GOMAXPROCS; several results are matched by name.
+				label, key := v.name, ""
+				if len(groups) > 1 {
+					label, key = v.name+" "+g.Name, g.Name
+				}
+				cells := make([]string, len(variantSummaryUnits))
+				for j, unit := range variantSummaryUnits {
+					cells[j] = "-"
+					if m, ok := g.Metric(unit); ok {
+						cells[j] = fmt.Sprintf("%s ± %.0f%%", strconv.FormatFloat(m.Median, 'f', -1, 64), m.VariationPct)
+					}
+				}
+				delta := ""
+				if m, ok := g.Metric(variantSummaryUnits[0]); ok {
+					if base, seen := baseline[key]; !seen {
+						baseline[key] = m.Median
+					} else if base != 0 {
+						delta = fmt.Sprintf("%+.2f%%", (m.Median-base)/base*100)
+					}
+				}
+				fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t\n", label, cells[0], delta, cells[1], cells[2])
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasVariantBaseline reports whether sum holds an ns/op median a variant delta can be taken from.
+func hasVariantBaseline(sum *datamap.MeasurementSummary) bool {
+	for _, g := range sum.Groups() {
+		if _, ok := g.Metric(variantSummaryUnits[0]); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func TestExpandEnvMatrix_cartesianProduct(t *testing.T) {
+	t.Parallel()
+	variants, err := expandEnvMatrix([]string{"GOGC=50,100,200", "GOMAXPROCS=2, 8"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range variants {
+		names = append(names, v.name)
+	}
+	want := []string{
+		"GOGC=50+GOMAXPROCS=2", "GOGC=50+GOMAXPROCS=8",
+		"GOGC=100+GOMAXPROCS=2", "GOGC=100+GOMAXPROCS=8",
+		"GOGC=200+GOMAXPROCS=2", "GOGC=200+GOMAXPROCS=8",
+	}
+	if !slices.Equal(names, want) {
+		t.Fatalf("variants=%v want %v", names, want)
+	}
+	if !slices.Equal(variants[3].env, []string{"GOGC=100", "GOMAXPROCS=8"}) {
+		t.Fatalf("env=%v", variants[3].env)
+	}
+
+	if variants, err = expandEnvMatrix(nil, nil); err != nil || variants != nil {
+		t.Fatalf("empty matrix: %v, %v", variants, err)
+	}
+}
+
+func TestExpandEnvMatrix_rejectsInvalid(t *testing.T) {
+	t.Parallel()
+	cases := map[string][]string{
+		"no value list":      {"GOGC"},
+		"empty name":         {"=50"},
+		"empty value":        {"GOGC=50,,100"},
+		"duplicate variable": {"GOGC=50", "GOGC=100"},
+		"duplicate value":    {"GOGC=50,50"},
+		"colliding dirs":     {"GODEBUG=a b,a_b"},
+	}
+	for name, specs := range cases {
+		if _, err := expandEnvMatrix(specs, nil); err == nil {
+			t.Errorf("%s: expected error for %q", name, specs)
+		}
+	}
+}
+
+func TestExpandEnvMatrix_configValuesKeepCommas(t *testing.T) {
+	t.Parallel()
+	fromConfig := map[string][]string{
+		"GOEXPERIMENT": {"arenas,loopvar", "loopvar"},
+		"GOGC":         {"50"},
+	}
+	variants, err := expandEnvMatrix([]string{"GOGC=100,200"}, fromConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envs [][]string
+	for _, v := range variants {
+		envs = append(envs, v.env)
+	}
+	want := [][]string{
+		{"GOEXPERIMENT=arenas,loopvar", "GOGC=100"}, {"GOEXPERIMENT=arenas,loopvar", "GOGC=200"},
+		{"GOEXPERIMENT=loopvar", "GOGC=100"}, {"GOEXPERIMENT=loopvar", "GOGC=200"},
+	}
+	if !slices.EqualFunc(envs, want, slices.Equal) {
+		t.Fatalf("envs=%v want %v", envs, want)
+	}
+	if variants[0].name != "GOEXPERIMENT=arenas,loopvar+GOGC=100" {
+		t.Fatalf("name=%s", variants[0].name)
+	}
+
+	for name, m := range map[string]map[string][]string{
+		"no values":   {"GOGC": nil},
+		"empty value": {"GOGC": {""}},
+		"bad name":    {"GO GC": {"50"}},
+	} {
+		if _, err = expandEnvMatrix(nil, m); err == nil {
+			t.Errorf("%s: expected error for %v", name, m)
+		}
+	}
+}
+
+func TestRunBenchmarkCommand_variantEnv(t *testing.T) {
+	t.Parallel()
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("PASS\n"), []byte("PASS\n")}}
+	out := filepath.Join(t.TempDir(), "run.txt")
+	if err := runBenchmarkCommand(t.Context(), runner, []string{"go", "test"}, out, t.TempDir(), []string{"GOGC=50"}); err != nil {
+		t.Fatal(err)
+	}
+	env := runner.Runs[0].Opts.Env
+	if len(env) == 0 || env[len(env)-1] != "GOGC=50" {
+		t.Fatalf("env should end with the override: %v", env)
+	}
+
+	if err := runBenchmarkCommand(t.Context(), runner, []string{"go", "test"}, out, t.TempDir(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if runner.Runs[1].Opts.Env != nil {
+		t.Fatalf("no overrides should inherit the environment, got %v", runner.Runs[1].Opts.Env)
+	}
+}
+
+func TestWriteVariantSummary(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+
+	const tag, bench = "gc", "BenchmarkFoo"
+	variants, err := expandEnvMatrix([]string{"GOGC=50,200,400"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = setupVariantDirectories(builtins.catalog, "", tag, variants, []string{bench}, []string{"cpu"}, false, true); err != nil {
+		t.Fatal(err)
+	}
+	runs := map[string]string{
+		"GOGC=50":  "BenchmarkFoo-8  1000  200 ns/op  64 B/op  2 allocs/op\nPASS\n",
+		"GOGC=200": "BenchmarkFoo-8  1000  150 ns/op  64 B/op  2 allocs/op\nPASS\n",
+	}
+	for variant, run := range runs {
+		layout := workspace.NewTagLayout(modRoot, workspace.VariantTag(tag, variant))
+		if err = os.WriteFile(layout.Measurement(bench), []byte(run), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got strings.Builder
+	if err = writeVariantSummary(&got, "", tag, []string{bench}, variants); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(workspace.NewTagLayout(modRoot, tag).VariantSummary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != got.String() {
+		t.Fatalf("variants.txt differs from printed summary:\n%s\n---\n%s", saved, got.String())
+	}
+	for _, want := range []string{"BenchmarkFoo (Δ vs GOGC=50)", "GOGC=50   200 ± 0%", "GOGC=200  150 ± 0%  -25.00%  64 ± 0%", "GOGC=400  n/a"} {
+		if !strings.Contains(got.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, got.String())
+		}
+	}
+}
+
+func TestWriteVariantSummary_baselineSkipsVariantsWithoutData(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+
+	const tag, bench = "gc", "BenchmarkFoo"
+	variants, err := expandEnvMatrix([]string{"GOGC=50,200,400"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = setupVariantDirectories(builtins.catalog, "", tag, variants, []string{bench}, []string{"cpu"}, false, true); err != nil {
+		t.Fatal(err)
+	}
+	runs := map[string]string{
+		"GOGC=200": "BenchmarkFoo-8  1000  200 ns/op\nPASS\n",
+		"GOGC=400": "BenchmarkFoo-8  1000  150 ns/op\nPASS\n",
+	}
+	for variant, run := range runs {
+		layout := workspace.NewTagLayout(modRoot, workspace.VariantTag(tag, variant))
+		if err = os.WriteFile(layout.Measurement(bench), []byte(run), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got strings.Builder
+	if err = writeVariantSummary(&got, "", tag, []string{bench}, variants); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"BenchmarkFoo (Δ vs GOGC=200)", "GOGC=50   n/a", "GOGC=400  150 ± 0%  -25.00%"} {
+		if !strings.Contains(got.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, got.String())
+		}
+	}
+}
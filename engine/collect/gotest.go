@@ -1,72 +1,191 @@
 package collect
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"go/build"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/AlexsanderHamir/prof/parser"
 )
 
-func findBenchmarkPackageDir(moduleRoot, benchmarkName string) (string, error) {
-	pattern := regexp.MustCompile(`(?m)^\s*func\s+` + regexp.QuoteMeta(benchmarkName) + `\s*\(\s*b\s*\*\s*testing\.B\s*\)\s*{`)
+// findBenchmarkPackageDir returns the directory of the package that declares benchmarkName,
+// a bare or package-qualified name optionally followed by a sub-benchmark path. A bare name
+// declared in more than one package is an error that lists the qualified alternatives.
+func findBenchmarkPackageDir(bctx *build.Context, moduleRoot, benchmarkName string) (string, error) {
+	pkg, name := workspace.SplitQualifiedBenchmark(benchmarkName)
+	top := topLevelBenchmark(name)
 
-	var foundDir string
-	err := walkTestGoFiles(moduleRoot, moduleRoot, func(path string, data []byte) error {
-		if pattern.Find(data) != nil {
-			foundDir = filepath.Dir(path)
+	var funcs []benchmarkFunc
+	var err error
+	if pkg != "" {
+		funcs, err = packageBenchmarks(bctx, filepath.Join(moduleRoot, filepath.FromSlash(pkg)), moduleRoot)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("package %s not found in module", pkg)
 		}
-		return nil
-	})
+	} else {
+		funcs, err = scanBenchmarkFuncs(bctx, moduleRoot, moduleRoot)
+	}
 	if err != nil {
 		return "", err
 	}
-	if foundDir == "" {
-		return "", fmt.Errorf("benchmark %s not found in module", benchmarkName)
+
+	var matches []benchmarkFunc
+	for _, f := range funcs {
+		if f.name == top {
+			matches = append(matches, f)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if pkg != "" {
+			return "", fmt.Errorf("benchmark %s not found in package %s", top, pkg)
+		}
+		return "", fmt.Errorf("benchmark %s not found in module", top)
+	case 1:
+		return matches[0].dir, nil
+	default:
+		qualified := make([]string, len(matches))
+		for i, f := range matches {
+			qualified[i] = f.qualified()
+		}
+		return "", fmt.Errorf("benchmark %s is declared in %d packages; use a qualified name: %s",
+			top, len(matches), strings.Join(qualified, ", "))
 	}
-	return foundDir, nil
 }
 
-func buildBenchmarkCommand(benchmarkName string, profiles []string, count int) ([]string, error) {
+// topLevelBenchmark returns the BenchmarkXxx function name of a possibly package-qualified,
+// slash-separated sub-benchmark path.
+func topLevelBenchmark(benchmarkName string) string {
+	_, name := workspace.SplitQualifiedBenchmark(benchmarkName)
+	top, _, _ := strings.Cut(name, workspace.SubBenchmarkSeparator)
+	return top
+}
+
+// benchPattern anchors every level of a benchmark path for go test -bench, so
+// BenchmarkCodec/json matches only that sub-benchmark and its children. A package
+// qualifier is dropped; the command runs in that package's directory.
+func benchPattern(benchmarkName string) string {
+	_, name := workspace.SplitQualifiedBenchmark(benchmarkName)
+	levels := strings.Split(name, workspace.SubBenchmarkSeparator)
+	for i, level := range levels {
+		levels[i] = "^" + regexp.QuoteMeta(level) + "$"
+	}
+	return strings.Join(levels, workspace.SubBenchmarkSeparator)
+}
+
+// buildBenchmarkCommand returns the go test argv for one benchmark: the flags prof manages,
+// the pass-through goTest flags, the profile flags, then goTest.ExtraArgs.
+func buildBenchmarkCommand(catalog *tooling.Catalog, benchmarkName string, profiles []string, count int, goTest config.GoTestFlags) ([]string, error) {
+	if err := config.ValidateGoTestFlags(goTest); err != nil {
+		return nil, err
+	}
 	cmd := []string{
 		workspace.GoBinaryName, workspace.GoTestSubcommand, "-run=^$",
-		fmt.Sprintf("-bench=^%s$", benchmarkName),
+		"-bench=" + benchPattern(benchmarkName),
 		"-benchmem",
 		fmt.Sprintf("-count=%d", count),
 	}
-	flags, err := profileCatalog.GoTestProfileArgs(profiles)
+	cmd = append(cmd, goTestRunArgs(goTest)...)
+	cmd = append(cmd, goTestBuildArgs(goTest)...)
+	flags, err := catalog.GoTestProfileArgs(profiles)
 	if err != nil {
 		return nil, err
 	}
-	return append(cmd, flags...), nil
+	cmd = append(cmd, flags...)
+	return append(cmd, goTest.ExtraArgs...), nil
+}
+
+// goTestRunArgs returns the flags of f that only affect how benchmarks run.
+func goTestRunArgs(f config.GoTestFlags) []string {
+	var args []string
+	if f.Benchtime != "" {
+		args = append(args, "-benchtime="+f.Benchtime)
+	}
+	if f.CPU != "" {
+		args = append(args, "-cpu="+f.CPU)
+	}
+	if f.Timeout != "" {
+		args = append(args, "-timeout="+f.Timeout)
+	}
+	return args
+}
+
+// goTestBuildArgs returns the flags of f that change how the test binary is built.
+func goTestBuildArgs(f config.GoTestFlags) []string {
+	var args []string
+	if f.Tags != "" {
+		args = append(args, "-tags="+f.Tags)
+	}
+	if f.Gcflags != "" {
+		args = append(args, "-gcflags="+f.Gcflags)
+	}
+	if f.Ldflags != "" {
+		args = append(args, "-ldflags="+f.Ldflags)
+	}
+	if f.Race != nil && *f.Race {
+		args = append(args, "-race")
+	}
+	return args
+}
+
+// buildContext returns the build context go test uses with f's -tags and -race, so discovery
+// parses the same files the benchmark run compiles.
+func buildContext(f config.GoTestFlags) *build.Context {
+	bctx := build.Default
+	bctx.BuildTags = slices.Clone(bctx.BuildTags)
+	bctx.BuildTags = append(bctx.BuildTags, strings.FieldsFunc(f.Tags, func(r rune) bool { return r == ',' || r == ' ' })...)
+	if f.Race != nil && *f.Race {
+		bctx.BuildTags = append(bctx.BuildTags, "race")
+	}
+	return &bctx
+}
+
+func runBenchmarkCommand(ctx context.Context, runner tooling.Runner, cmd []string, outputFile string, rootDir string, env []string) error {
+	output, err := benchmarkOutput(ctx, runner, cmd, rootDir, env)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputFile, output, workspace.PermFile)
 }
 
-func runBenchmarkCommand(runner tooling.Runner, cmd []string, outputFile string, rootDir string) error {
+// benchmarkOutput runs one go test invocation and returns its combined output.
+func benchmarkOutput(ctx context.Context, runner tooling.Runner, cmd []string, rootDir string, env []string) ([]byte, error) {
 	if runner == nil {
-		return errors.New("tooling runner is nil")
+		return nil, errors.New("tooling runner is nil")
 	}
-	ctx := context.Background()
-	output, err := runner.Run(ctx, cmd, tooling.RunOpts{Dir: rootDir, Combined: true})
+	output, err := runner.Run(ctx, cmd, tooling.RunOpts{Dir: rootDir, Env: variantEnv(env), Combined: true})
 	if err != nil {
 		if strings.Contains(string(output), moduleNotFoundMsg) {
-			return fmt.Errorf("%s - ensure you're in a Go project directory", moduleNotFoundMsg)
+			return nil, fmt.Errorf("%s - ensure you're in a Go project directory", moduleNotFoundMsg)
 		}
-		return fmt.Errorf("benchmark command failed:\n%s", string(output))
+		return nil, fmt.Errorf("benchmark command failed:\n%s", string(output))
 	}
-	return os.WriteFile(outputFile, output, workspace.PermFile)
+	return output, nil
 }
 
-func runBenchmark(runner tooling.Runner, benchmarkName string, profiles []string, count int, tag string) error {
-	cmd, err := buildBenchmarkCommand(benchmarkName, profiles, count)
+// runBenchmark runs one benchmark in its package directory and moves the measurement and
+// profiles into tag. go test writes the profiles and test binary to a run directory of its
+// own, and the package lock keeps other prof runs out of the package meanwhile. A non-nil
+// slot pins the run to that share of the machine. With perIteration, each of the count runs
+// is its own go test invocation; see runBenchmarkIterations.
+func runBenchmark(ctx context.Context, runner tooling.Runner, catalog *tooling.Catalog, benchmarkName string, profiles []string, count int, out, tag string, goTest config.GoTestFlags, env []string, slot *cpuSlot, perIteration bool) error {
+	cmd, err := buildBenchmarkCommand(catalog, benchmarkName, profiles, commandCount(count, perIteration), goTest)
 	if err != nil {
 		return err
 	}
-	layout, err := workspace.TagLayoutFromCWD(tag)
+	layout, err := workspace.TagLayoutFromCWD(out, tag)
 	if err != nil {
 		return err
 	}
@@ -74,17 +193,90 @@ func runBenchmark(runner tooling.Runner, benchmarkName string, profiles []string
 	if err != nil {
 		return fmt.Errorf("failed to find Go module root: %w", err)
 	}
-	pkgDir, err := findBenchmarkPackageDir(moduleRoot, benchmarkName)
+	pkgDir, err := findBenchmarkPackageDir(buildContext(goTest), moduleRoot, benchmarkName)
 	if err != nil {
 		return fmt.Errorf("failed to locate benchmark %s: %w", benchmarkName, err)
 	}
-	outputFile := layout.Measurement(benchmarkName)
-	binDir := filepath.Join(layout.Root, workspace.ProfilesDir, benchmarkName)
-	if err = runBenchmarkCommand(runner, cmd, outputFile, pkgDir); err != nil {
+	pkgLock, err := lockPackage(ctx, pkgDir)
+	if err != nil {
+		return err
+	}
+	defer pkgLock.Unlock()
+	runDir, err := newRunDir(layout)
+	if err != nil {
 		return err
 	}
-	if err = moveProfileFiles(profiles, pkgDir, binDir); err != nil {
+	defer os.RemoveAll(runDir)
+	cmd, env = slot.apply(withRunDir(cmd, runDir, pkgDir), env)
+
+	binDir := filepath.Join(layout.Root, workspace.ProfilesDir, workspace.BenchmarkDir(benchmarkName))
+	if perIteration {
+		err = runBenchmarkIterations(ctx, runner, catalog, layout, benchmarkName, profiles, count, cmd, runDir, pkgDir, env)
+	} else {
+		started := time.Now()
+		err = runBenchmarkCommand(ctx, runner, cmd, layout.Measurement(benchmarkName), pkgDir, env)
+		if err == nil {
+			err = moveProfileFiles(catalog, profiles, runDir, pkgDir, started, func(profile string) string { return layout.ProfileBinary(benchmarkName, profile) })
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return moveTestFiles(benchmarkName, runDir, binDir)
+}
+
+// commandCount returns the go test -count of one invocation: 1 when every iteration is its own run.
+func commandCount(count int, perIteration bool) int {
+	if perIteration {
+		return 1
+	}
+	return count
+}
+
+// runBenchmarkIterations runs cmd, a -count=1 go test command, count times. Each run's profiles
+// are kept as profiles/<bench>/<kind>@<n>.out and merged into <kind>.out for the headline
+// artifacts; the transcripts are concatenated into run.txt, so it holds count samples as usual.
+func runBenchmarkIterations(ctx context.Context, runner tooling.Runner, catalog *tooling.Catalog, layout workspace.TagLayout, benchmarkName string, profiles []string, count int, cmd []string, runDir, pkgDir string, env []string) error {
+	var transcript bytes.Buffer
+	for n := 1; n <= count; n++ {
+		started := time.Now()
+		output, err := benchmarkOutput(ctx, runner, cmd, pkgDir, env)
+		if err != nil {
+			return fmt.Errorf("iteration %d/%d: %w", n, count, err)
+		}
+		transcript.Write(output)
+		if err = moveProfileFiles(catalog, profiles, runDir, pkgDir, started, func(profile string) string { return layout.ProfileIteration(benchmarkName, profile, n) }); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(layout.Measurement(benchmarkName), transcript.Bytes(), workspace.PermFile); err != nil {
 		return err
 	}
-	return moveTestFiles(benchmarkName, pkgDir, binDir)
+	return mergeProfileIterations(layout, benchmarkName, profiles)
+}
+
+// mergeProfileIterations writes the merge of each profile's per-iteration binaries to its
+// ProfileBinary. A profile without iteration files is left for processProfiles to report missing.
+func mergeProfileIterations(layout workspace.TagLayout, benchmarkName string, profiles []string) error {
+	for _, profile := range profiles {
+		paths, err := layout.ProfileIterations(benchmarkName, profile)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		merged, err := parser.MergeProfilesFromPaths(paths)
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", profile, err)
+		}
+		var buf bytes.Buffer
+		if err = merged.Write(&buf); err != nil {
+			return fmt.Errorf("encode merged %s profile: %w", profile, err)
+		}
+		if err = os.WriteFile(layout.ProfileBinary(benchmarkName, profile), buf.Bytes(), workspace.PermFile); err != nil {
+			return fmt.Errorf("write merged %s profile: %w", profile, err)
+		}
+	}
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/AlexsanderHamir/prof/engine/tooling"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
@@ -24,7 +25,7 @@ func createBenchDirectories(tagDir string, benchmarks []string, quiet bool) erro
 
 	for _, b := range benchmarks {
 		for _, dir := range []string{profilesDir, measurementsDir, hotspotsDir, dataMappingDir} {
-			if err := os.Mkdir(filepath.Join(dir, b), workspace.PermDir); err != nil {
+			if err := os.Mkdir(filepath.Join(dir, workspace.BenchmarkDir(b)), workspace.PermDir); err != nil {
 				return fmt.Errorf("failed to create %s subdirectory for %s: %w", filepath.Base(dir), b, err)
 			}
 		}
@@ -40,18 +41,21 @@ func createBenchDirectories(tagDir string, benchmarks []string, quiet bool) erro
 	return nil
 }
 
-func createSourceLinesDirectories(tagDir string, profiles, benchmarks []string, quiet bool) error {
+func createSourceLinesDirectories(catalog *tooling.Catalog, tagDir string, profiles, benchmarks []string, quiet bool) error {
 	sourceLinesRoot := filepath.Join(tagDir, workspace.SourceLinesDir)
 	if err := os.Mkdir(sourceLinesRoot, workspace.PermDir); err != nil {
 		return fmt.Errorf("failed to create source_lines directory: %w", err)
 	}
 	for _, profileName := range profiles {
+		if catalog.Format(profileName) == tooling.FormatTrace {
+			continue // its trace views create their directories as they are processed
+		}
 		profileRoot := filepath.Join(sourceLinesRoot, profileName)
 		if err := os.Mkdir(profileRoot, workspace.PermDir); err != nil {
 			return fmt.Errorf("failed to create source_lines/%s directory: %w", profileName, err)
 		}
 		for _, b := range benchmarks {
-			benchmarkDirPath := filepath.Join(profileRoot, b)
+			benchmarkDirPath := filepath.Join(profileRoot, workspace.BenchmarkDir(b))
 			if err := os.Mkdir(benchmarkDirPath, workspace.PermDir); err != nil {
 				return fmt.Errorf("failed to create benchmark directory %s: %w", benchmarkDirPath, err)
 			}
@@ -63,8 +67,8 @@ func createSourceLinesDirectories(tagDir string, profiles, benchmarks []string,
 	return nil
 }
 
-func setupDirectories(tag string, benchmarks, profiles []string, quiet bool) error {
-	tagDir, err := workspace.TagDirFromCWD(tag)
+func setupDirectories(catalog *tooling.Catalog, out, tag string, benchmarks, profiles []string, quiet bool) error {
+	tagDir, err := workspace.TagDirFromCWD(out, tag)
 	if err != nil {
 		return err
 	}
@@ -74,5 +78,78 @@ func setupDirectories(tag string, benchmarks, profiles []string, quiet bool) err
 	if err = createBenchDirectories(tagDir, benchmarks, quiet); err != nil {
 		return err
 	}
-	return createSourceLinesDirectories(tagDir, profiles, benchmarks, quiet)
+	return createSourceLinesDirectories(catalog, tagDir, profiles, benchmarks, quiet)
+}
+
+// setupVariantDirectories cleans the tag and lays out one nested tag per env matrix variant.
+// With keep set, the tag and its variants are kept as for [extendTag].
+func setupVariantDirectories(catalog *tooling.Catalog, out, tag string, variants []envVariant, benchmarks, profiles []string, keep, quiet bool) error {
+	if keep {
+		if err := extendTag(out, tag, quiet); err != nil {
+			return err
+		}
+		for _, v := range variants {
+			if err := extendTag(out, workspace.VariantTag(tag, v.name), quiet); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tagDir, err := workspace.TagDirFromCWD(out, tag)
+	if err != nil {
+		return err
+	}
+	if err = workspace.CleanOrCreateTag(tagDir); err != nil {
+		return fmt.Errorf("CleanOrCreateTag failed: %w", err)
+	}
+	for _, v := range variants {
+		if err = setupDirectories(catalog, out, workspace.VariantTag(tag, v.name), benchmarks, profiles, quiet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extendTag prepares .prof/<tag>/ for prof auto --append and --resume: the tag is created when
+// missing and nothing in it is removed. Each benchmark is reset by [resetBenchmarkDirectories]
+// right before it runs, so benchmarks that are not run keep their artifacts.
+func extendTag(out, tag string, quiet bool) error {
+	tagDir, err := workspace.TagDirFromCWD(out, tag)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(tagDir, workspace.PermDir); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", tagDir, err)
+	}
+	notesFile := filepath.Join(tagDir, workspace.TagNotesFileName)
+	if _, statErr := os.Stat(notesFile); os.IsNotExist(statErr) {
+		if err = os.WriteFile(notesFile, []byte(workspace.TagNotesPlaceholder), workspace.PermFile); err != nil {
+			return fmt.Errorf("failed to create notes file: %w", err)
+		}
+	}
+	if !quiet {
+		slog.Info("Keeping existing tag", "dir", tagDir)
+	}
+	return nil
+}
+
+// resetBenchmarkDirectories removes what an earlier run left for bench in the tag and creates
+// its empty directories, as setupDirectories does for a fresh tag.
+func resetBenchmarkDirectories(layout workspace.TagLayout, bench string, profiles []string) error {
+	if err := workspace.RemoveBenchmark(layout, bench); err != nil {
+		return err
+	}
+	var dirs []string
+	for _, domain := range []string{workspace.ProfilesDir, workspace.MeasurementsDir, workspace.HotspotsDir, workspace.DataMappingDir} {
+		dirs = append(dirs, filepath.Join(layout.Root, domain, workspace.BenchmarkDir(bench)))
+	}
+	for _, profile := range profiles {
+		dirs = append(dirs, layout.SourceLinesDir(profile, bench))
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, workspace.PermDir); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	return nil
 }
@@ -3,8 +3,12 @@ package collect
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
@@ -65,7 +69,7 @@ func TestCreateSourceLinesDirectories(t *testing.T) {
 	profiles := []string{"cpu", "memory"}
 	benchmarks := []string{"BenchmarkFoo"}
 
-	if err := createSourceLinesDirectories(tagDir, profiles, benchmarks, false); err != nil {
+	if err := createSourceLinesDirectories(builtins.catalog, tagDir, profiles, benchmarks, false); err != nil {
 		t.Fatal(err)
 	}
 
@@ -84,11 +88,11 @@ func TestSetupDirectories_createsTagLayout(t *testing.T) {
 	benchmarks := []string{"BenchmarkFoo"}
 	profiles := []string{"cpu", "memory"}
 
-	if err := setupDirectories(tag, benchmarks, profiles, false); err != nil {
+	if err := setupDirectories(builtins.catalog, "", tag, benchmarks, profiles, false); err != nil {
 		t.Fatal(err)
 	}
 
-	layout, err := workspace.TagLayoutFromCWD(tag)
+	layout, err := workspace.TagLayoutFromCWD("", tag)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -141,3 +145,75 @@ func TestSetupDirectories_createsTagLayout(t *testing.T) {
 		})
 	}
 }
+
+func TestPrepareTag_appendKeepsOtherBenchmarks(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+	const tag = "appended"
+	if err := setupDirectories(builtins.catalog, "", tag, []string{"BenchmarkA", "BenchmarkB"}, []string{"cpu"}, true); err != nil {
+		t.Fatal(err)
+	}
+	layout := workspace.NewTagLayout(modRoot, tag)
+	for _, bench := range []string{"BenchmarkA", "BenchmarkB"} {
+		if err := os.WriteFile(layout.Hotspot(bench, "cpu"), []byte("old"), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := AutoOptions{Tag: tag, Benchmarks: []string{"BenchmarkB", "BenchmarkC"}, Profiles: []string{"cpu"}, Append: true}
+	if err := prepareTag(builtins.catalog, opts, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, bench := range opts.Benchmarks {
+		if err := resetBenchmarkDirectories(layout, bench, opts.Profiles); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(layout.Hotspot("BenchmarkA", "cpu")); err != nil {
+		t.Fatalf("benchmark outside the run should be kept: %v", err)
+	}
+	if _, err := os.Stat(layout.Hotspot("BenchmarkB", "cpu")); !os.IsNotExist(err) {
+		t.Fatalf("rerun benchmark should lose its old artifacts: %v", err)
+	}
+	for _, dir := range []string{filepath.Dir(layout.Measurement("BenchmarkC")), layout.SourceLinesDir("cpu", "BenchmarkB")} {
+		if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+			t.Fatalf("missing %s: %v", dir, err)
+		}
+	}
+}
+
+func TestRunTag_resumeSkipsCompleteBenchmarks(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+	const tag, bench = "resumed", "BenchmarkDone"
+	if err := setupDirectories(builtins.catalog, "", tag, []string{bench}, []string{"cpu"}, true); err != nil {
+		t.Fatal(err)
+	}
+	layout := workspace.NewTagLayout(modRoot, tag)
+	done := datamap.BenchmarkMap{Status: datamap.Status{BenchmarkRun: "ok", Profiles: map[string]string{"cpu": "ok"}}}
+	if err := datamap.WriteJSON(layout.DataMapping(bench), done); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &tooling.FakeRunner{}
+	args := &config.AutoArgs{Tag: tag, Benchmarks: []string{bench}, Profiles: []string{"cpu"}, Count: 1, Resume: true}
+	if err := runTag(t.Context(), runner, builtins, args, &config.Config{}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 0 {
+		t.Fatalf("complete benchmark should not run, got %v", runner.Runs)
+	}
+	status, err := datamap.ReadTagStatus(layout.Status())
+	if err != nil || status.State != datamap.TagStateComplete {
+		t.Fatalf("status=%+v err=%v", status, err)
+	}
+
+	// A map.json without the requested profile is not complete.
+	args.Profiles = []string{"cpu", "memory"}
+	if err = runTag(t.Context(), runner, builtins, args, &config.Config{}, nil, nil); err == nil || !strings.Contains(err.Error(), "failed to run "+bench) {
+		t.Fatal("expected the incomplete benchmark to run")
+	}
+}
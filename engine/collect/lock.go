@@ -0,0 +1,55 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// runDirPattern names the per-invocation directory go test writes its profiles and test
+// binary into, inside the tag being collected. The _ prefix keeps it out of tag listings.
+const runDirPattern = "_run-"
+
+// lockTag takes the lock of tag for the whole run, so a second prof run into the same tag
+// fails instead of cleaning it and interleaving files with this one. out is prof --out.
+func lockTag(out, tag string) (*workspace.Lock, error) {
+	moduleRoot, err := workspace.FindModuleRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate module root: %w", err)
+	}
+	return workspace.LockTag(workspace.ResolveOutputDir(moduleRoot, out), tag)
+}
+
+// lockPackage waits for the lock of pkgDir, so runs into different tags take turns at
+// running go test in the same package instead of competing for its CPU and files.
+func lockPackage(ctx context.Context, pkgDir string) (*workspace.Lock, error) {
+	return workspace.WaitLock(ctx, workspace.PackageLock(pkgDir), func(holder workspace.LockHolder) {
+		slog.Info("Waiting for another prof run in the package", "dir", pkgDir, "holder", holder.String())
+	})
+}
+
+// withRunDir returns cmd writing its profiles (-outputdir) and test binary (-o) to runDir
+// instead of the package directory, so concurrent runs never pick up each other's files.
+func withRunDir(cmd []string, runDir, pkgDir string) []string {
+	binary := filepath.Base(pkgDir) + workspace.ExpectedTestSuffix
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	// After "go test", ahead of collection.go_test.extra_args, which may end in -args.
+	return slices.Insert(slices.Clone(cmd), 2, "-outputdir="+runDir, "-o="+filepath.Join(runDir, binary))
+}
+
+// newRunDir creates an empty run directory in the tag. The caller removes it.
+func newRunDir(layout workspace.TagLayout) (string, error) {
+	dir, err := os.MkdirTemp(layout.Root, runDirPattern)
+	if err != nil {
+		return "", fmt.Errorf("create run directory: %w", err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,106 @@
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func TestWithRunDir_beforeExtraArgs(t *testing.T) {
+	t.Parallel()
+	cmd := []string{"go", "test", "-run=^$", "-bench=^BenchmarkFoo$", "-args", "-x"}
+	got := withRunDir(cmd, "/run", "/mod/codec")
+	if got[2] != "-outputdir=/run" || !strings.HasPrefix(got[3], "-o="+filepath.Join("/run", "codec.test")) {
+		t.Fatalf("got %v", got)
+	}
+	if !slices.Equal(got[len(got)-2:], []string{"-args", "-x"}) || len(cmd) != 6 {
+		t.Fatalf("got %v, cmd %v", got, cmd)
+	}
+}
+
+func TestMoveProfileFiles_runDirThenPackageDir(t *testing.T) {
+	t.Parallel()
+	catalog, err := tooling.ConfiguredCatalog(&config.Config{Collection: config.Collection{ProfileKinds: []config.ProfileKind{
+		{ID: "goroutine", GoTestFlag: "-goroutineprofile=goroutine.out", OutputFile: "goroutine.out"},
+		{ID: "threadcreate", GoTestFlag: "-threadprofile=threadcreate.out", OutputFile: "threadcreate.out"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runDir, pkgDir, dest := t.TempDir(), t.TempDir(), t.TempDir()
+	for path, content := range map[string]string{
+		filepath.Join(runDir, "cpu.out"):          "this run",
+		filepath.Join(pkgDir, "cpu.out"):          "another run",
+		filepath.Join(pkgDir, "memory.out"):       "another run",
+		filepath.Join(pkgDir, "goroutine.out"):    "written by TestMain",
+		filepath.Join(pkgDir, "threadcreate.out"): "written by TestMain before this run",
+	} {
+		if err = os.WriteFile(path, []byte(content), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	started := time.Now()
+	stale := started.Add(-time.Hour)
+	if err = os.Chtimes(filepath.Join(pkgDir, "threadcreate.out"), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	err = moveProfileFiles(catalog, []string{"cpu", "memory", "mutex", "goroutine", "threadcreate"}, runDir, pkgDir, started, func(profile string) string {
+		return filepath.Join(dest, profile)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for profile, want := range map[string]string{"cpu": "this run", "goroutine": "written by TestMain"} {
+		if got, _ := os.ReadFile(filepath.Join(dest, profile)); string(got) != want {
+			t.Fatalf("%s=%q want %q", profile, got, want)
+		}
+	}
+	for _, profile := range []string{"memory", "threadcreate"} {
+		if _, err = os.Stat(filepath.Join(dest, profile)); err == nil {
+			t.Fatalf("%s was taken from the package directory", profile)
+		}
+	}
+	for _, name := range []string{"cpu.out", "memory.out", "threadcreate.out"} {
+		if _, err = os.Stat(filepath.Join(pkgDir, name)); err != nil {
+			t.Fatalf("the package directory's %s belongs to another run and must stay", name)
+		}
+	}
+}
+
+func TestLockTag_secondRunFails(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module locks\n\ngo 1.24.3\n"), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	t.Setenv(workspace.OutputDirEnv, "")
+
+	held, err := lockTag("", "nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lockTag("", workspace.VariantTag("nightly", "GOGC=50")); err == nil || !strings.Contains(err.Error(), "in use by another prof run") {
+		t.Fatalf("err=%v", err)
+	}
+	runner := &tooling.FakeRunner{}
+	err = RunAuto(t.Context(), runner, AutoOptions{Benchmarks: []string{"BenchmarkFoo"}, Profiles: []string{"cpu"}, Count: 1, Tag: "nightly"})
+	if err == nil || !strings.Contains(err.Error(), "in use by another prof run") || len(runner.Runs) != 0 {
+		t.Fatalf("err=%v runs=%d", err, len(runner.Runs))
+	}
+	if err = held.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := lockTag("", "nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = again.Unlock()
+}
@@ -0,0 +1,151 @@
+package collect
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Collection modes recorded in manifest.json.
+const (
+	manifestModeAuto   = "auto"
+	manifestModeManual = "manual"
+)
+
+// fingerprintEnv are the environment variables that change how benchmarks run or build.
+// The manifest records the ones that are set.
+var fingerprintEnv = []string{
+	"GOGC", "GOMEMLIMIT", "GOMAXPROCS", "GODEBUG", "GOEXPERIMENT", "GOFLAGS",
+	"CGO_ENABLED", "GOAMD64", "GOARM", "GOARM64",
+}
+
+// runInfo is what every manifest.json of one prof auto or prof manual run shares.
+type runInfo struct {
+	mode        string
+	profVersion string
+	command     []string
+	git         *datamap.GitState
+	fingerprint datamap.Fingerprint
+	startedAt   time.Time
+}
+
+// newRunInfo records the command, the module's git state and the fingerprint of this
+// machine. Anything that cannot be determined (no git, go env failing) is left empty.
+// out is prof --out, so the output directory is not counted as a change to the work tree.
+func newRunInfo(ctx context.Context, runner tooling.Runner, mode, profVersion, out string) *runInfo {
+	info := &runInfo{
+		mode:        mode,
+		profVersion: profVersion,
+		command:     os.Args,
+		startedAt:   time.Now().UTC(),
+	}
+	moduleRoot, err := workspace.FindModuleRoot()
+	if err != nil {
+		moduleRoot = ""
+	}
+	info.git = gitState(ctx, runner, moduleRoot, workspace.ResolveOutputDir(moduleRoot, out))
+	info.fingerprint = machineFingerprint(ctx, runner, moduleRoot)
+	return info
+}
+
+// forVariant returns info with the env matrix overrides of one variant in its fingerprint.
+func (info *runInfo) forVariant(overrides []string) *runInfo {
+	if info == nil || len(overrides) == 0 {
+		return info
+	}
+	v := *info
+	v.fingerprint.Env = maps.Clone(info.fingerprint.Env)
+	if v.fingerprint.Env == nil {
+		v.fingerprint.Env = map[string]string{}
+	}
+	for _, kv := range overrides {
+		name, value, _ := strings.Cut(kv, "=")
+		v.fingerprint.Env[name] = value
+		if name == "GOMAXPROCS" {
+			if n, err := strconv.Atoi(value); err == nil {
+				v.fingerprint.GOMAXPROCS = n
+			}
+		}
+	}
+	return &v
+}
+
+// gitState returns the commit of the repository holding dir, the module root, and whether its
+// work tree has changes. prof's own output directory outputDir does not count as a change when
+// it is inside the work tree (.prof, or a --out/PROF_HOME under the module).
+func gitState(ctx context.Context, runner tooling.Runner, dir, outputDir string) *datamap.GitState {
+	head, err := runner.Run(ctx, tooling.GitHeadArgs(), tooling.RunOpts{Dir: dir})
+	if err != nil {
+		return nil
+	}
+	var exclude []string
+	if rel, relErr := filepath.Rel(dir, outputDir); relErr == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		exclude = append(exclude, rel)
+	}
+	status, err := runner.Run(ctx, tooling.GitStatusArgs(exclude...), tooling.RunOpts{Dir: dir})
+	if err != nil {
+		return nil
+	}
+	return &datamap.GitState{
+		Commit: strings.TrimSpace(string(head)),
+		Dirty:  strings.TrimSpace(string(status)) != "",
+	}
+}
+
+func machineFingerprint(ctx context.Context, runner tooling.Runner, dir string) datamap.Fingerprint {
+	f := datamap.Fingerprint{
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+	if out, err := runner.Run(ctx, tooling.GoEnvArgs("GOVERSION", "GOOS", "GOARCH"), tooling.RunOpts{Dir: dir}); err == nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(lines) == 3 {
+			f.GoVersion = strings.TrimSpace(lines[0])
+			f.GOOS = strings.TrimSpace(lines[1])
+			f.GOARCH = strings.TrimSpace(lines[2])
+		}
+	}
+	f.CPUModel = cpuModel(ctx, runner)
+	for _, name := range fingerprintEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			if f.Env == nil {
+				f.Env = map[string]string{}
+			}
+			f.Env[name] = value
+		}
+	}
+	// go test runs with the GOMAXPROCS of the environment, not necessarily prof's own.
+	if n, err := strconv.Atoi(f.Env["GOMAXPROCS"]); err == nil {
+		f.GOMAXPROCS = n
+	}
+	return f
+}
+
+// manifest returns the manifest.json of tag with every entry pending.
+func (info *runInfo) manifest(tag string, entries []string) datamap.TagManifest {
+	benchmarks := make(map[string]string, len(entries))
+	for _, e := range entries {
+		benchmarks[e] = datamap.TagEntryPending
+	}
+	return datamap.TagManifest{
+		SchemaVersion:  datamap.ManifestSchemaVersion,
+		Tag:            tag,
+		CollectionMode: info.mode,
+		ProfVersion:    info.profVersion,
+		Command:        info.command,
+		Git:            info.git,
+		Fingerprint:    info.fingerprint,
+		StartedAt:      info.startedAt,
+		State:          datamap.TagStateRunning,
+		Benchmarks:     benchmarks,
+	}
+}
@@ -0,0 +1,123 @@
+package collect
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func TestNewRunInfo_readsGitAndGoEnv(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+	t.Setenv("GOGC", "200")
+	t.Setenv("GOMAXPROCS", "3")
+
+	runner := &tooling.FakeRunner{Out: [][]byte{
+		[]byte("0123abcd\n"),
+		[]byte(" M go.mod\n"),
+		[]byte("go1.24.3\nlinux\namd64\n"),
+	}}
+	info := newRunInfo(t.Context(), runner, manifestModeAuto, "v1.2.3", "")
+	if info.git == nil || info.git.Commit != "0123abcd" || !info.git.Dirty {
+		t.Fatalf("git=%+v", info.git)
+	}
+	f := info.fingerprint
+	if f.GoVersion != "go1.24.3" || f.GOOS != "linux" || f.GOARCH != "amd64" || f.GOMAXPROCS != 3 || f.Env["GOGC"] != "200" {
+		t.Fatalf("fingerprint=%+v", f)
+	}
+	if got, want := runner.Runs[1].Argv, tooling.GitStatusArgs(workspace.MainDirOutput); !slices.Equal(got, want) {
+		t.Fatalf("git status argv=%v, want %v so prof's own output is not a change", got, want)
+	}
+	for _, run := range runner.Runs[:3] {
+		if run.Opts.Dir != modRoot {
+			t.Fatalf("%v ran in %q, want the module root", run.Argv, run.Opts.Dir)
+		}
+	}
+
+	variant := info.forVariant([]string{"GOMAXPROCS=1"})
+	if variant.fingerprint.GOMAXPROCS != 1 || info.fingerprint.Env["GOMAXPROCS"] != "3" {
+		t.Fatalf("variant=%+v base=%+v", variant.fingerprint, info.fingerprint)
+	}
+}
+
+func TestNewRunInfo_withoutGit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	runner := &tooling.FakeRunner{Err: []error{errors.New("not a git repository")}}
+	info := newRunInfo(t.Context(), runner, manifestModeManual, "devel", "")
+	if info.git != nil || info.fingerprint.GoVersion != "" || info.fingerprint.NumCPU < 1 {
+		t.Fatalf("info=%+v", info)
+	}
+}
+
+func TestTagProgress_writesManifest(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "t1")
+	info := &runInfo{mode: manifestModeAuto, profVersion: "v1.2.3", command: []string{"prof", "auto"}}
+	progress := startTagProgress(layout, []string{"BenchmarkA", "BenchmarkB"}, info)
+	progress.done("BenchmarkA")
+
+	m, err := datamap.ReadManifest(layout.Manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.State != datamap.TagStateRunning || m.FinishedAt != nil || m.Benchmarks["BenchmarkA"] != datamap.TagStateComplete || m.Benchmarks["BenchmarkB"] != datamap.TagEntryPending {
+		t.Fatalf("running manifest=%+v", m)
+	}
+
+	_ = progress.finish(errors.New("boom"))
+	if m, err = datamap.ReadManifest(layout.Manifest()); err != nil {
+		t.Fatal(err)
+	}
+	if m.Tag != "t1" || m.CollectionMode != manifestModeAuto || m.ProfVersion != "v1.2.3" || m.State != datamap.TagStateIncomplete || m.FinishedAt == nil {
+		t.Fatalf("finished manifest=%+v", m)
+	}
+}
+
+func TestNewRunInfo_outputOutsideModuleIsNotExcluded(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+	t.Setenv(workspace.OutputDirEnv, t.TempDir())
+
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("0123abcd\n"), nil, nil}}
+	info := newRunInfo(t.Context(), runner, manifestModeAuto, "v1.2.3", "")
+	if info.git == nil || info.git.Dirty {
+		t.Fatalf("git=%+v", info.git)
+	}
+	if got := runner.Runs[1].Argv; !slices.Equal(got, tooling.GitStatusArgs()) {
+		t.Fatalf("git status argv=%v", got)
+	}
+}
+
+func TestTagProgress_mergesManifestOnAppend(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "t1")
+	info := &runInfo{mode: manifestModeAuto, profVersion: "v1.2.3", command: []string{"prof", "auto"}}
+	progress := startTagProgress(layout, []string{"BenchmarkA", "BenchmarkB"}, info)
+	progress.done("BenchmarkA")
+	_ = progress.finish(errors.New("boom"))
+
+	appended := &runInfo{mode: manifestModeAuto, profVersion: "v1.3.0", command: []string{"prof", "auto", "--append"}}
+	progress = startTagProgress(layout, []string{"BenchmarkC"}, appended)
+	progress.done("BenchmarkC")
+	_ = progress.finish(nil)
+
+	m, err := datamap.ReadManifest(layout.Manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"BenchmarkA": datamap.TagStateComplete,
+		"BenchmarkB": datamap.TagEntryPending,
+		"BenchmarkC": datamap.TagStateComplete,
+	}
+	if !maps.Equal(m.Benchmarks, want) || m.ProfVersion != "v1.3.0" || m.State != datamap.TagStateIncomplete {
+		t.Fatalf("manifest=%+v", m)
+	}
+}
@@ -1,6 +1,7 @@
 package collect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -15,18 +16,27 @@ import (
 )
 
 // RunManual organizes manual profile files under .prof/<tag>/ using the auto layout.
-func RunManual(runner tooling.Runner, opts ManualOptions) error {
+// Canceling ctx stops the running pprof subprocess and marks the tag incomplete.
+func RunManual(ctx context.Context, runner tooling.Runner, opts ManualOptions) error {
 	if runner == nil {
 		return errors.New("tooling runner is nil")
 	}
-	if err := ensureDirExists(workspace.MainDirOutput); err != nil {
+	if err := workspace.ValidateTagName(opts.Tag); err != nil {
 		return err
 	}
+	tagLock, err := lockTag(opts.Out, opts.Tag)
+	if err != nil {
+		return err
+	}
+	defer tagLock.Unlock()
 
-	tagDir, err := workspace.TagDirFromCWD(opts.Tag)
+	tagDir, err := workspace.TagDirFromCWD(opts.Out, opts.Tag)
 	if err != nil {
 		return err
 	}
+	if err = ensureDirExists(filepath.Dir(tagDir)); err != nil {
+		return err
+	}
 	if cleanErr := workspace.CleanOrCreateTag(tagDir); cleanErr != nil {
 		return fmt.Errorf("CleanOrCreateTag failed: %w", cleanErr)
 	}
@@ -35,22 +45,29 @@ func RunManual(runner tooling.Runner, opts ManualOptions) error {
 	if err != nil {
 		cfg = &config.Config{}
 	}
+	reg, err := newRegistry(cfg)
+	if err != nil {
+		return err
+	}
 
-	layout, err := workspace.TagLayoutFromCWD(opts.Tag)
+	layout, err := workspace.TagLayoutFromCWD(opts.Out, opts.Tag)
 	if err != nil {
 		return err
 	}
 
+	sampleIndex := config.ResolveSampleIndex(cfg, nil)
+	progress := startTagProgress(layout, opts.Files, newRunInfo(ctx, runner, manifestModeManual, opts.ProfVersion, opts.Out))
 	for _, fullBinaryPath := range opts.Files {
-		if err = processOneManualFile(runner, fullBinaryPath, layout, cfg); err != nil {
-			return err
+		if err = processOneManualFile(ctx, runner, reg, fullBinaryPath, layout, cfg, sampleIndex); err != nil {
+			return progress.finish(err)
 		}
+		progress.done(fullBinaryPath)
 	}
-	return nil
+	return progress.finish(nil)
 }
 
-func processOneManualFile(runner tooling.Runner, fullBinaryPath string, layout workspace.TagLayout, cfg *config.Config) error {
-	benchName, profile := manualBenchAndProfile(fullBinaryPath)
+func processOneManualFile(ctx context.Context, runner tooling.Runner, reg *registry, fullBinaryPath string, layout workspace.TagLayout, cfg *config.Config, sampleIndex map[string]string) error {
+	benchName, profile := manualBenchAndProfile(reg.catalog, fullBinaryPath)
 	stem := stemFromPath(fullBinaryPath)
 	filter := config.ResolveCollectionFilter(cfg, config.CollectionTargetManual(stem))
 
@@ -59,30 +76,44 @@ func processOneManualFile(runner tooling.Runner, fullBinaryPath string, layout w
 		return err
 	}
 
-	if err := emitProfileArtifacts(runner, binDest, layout, benchName, profile); err != nil {
-		return err
-	}
-	snap, err := collectPerFunctionLists(runner, layout, benchName, profile, binDest, filter)
-	if err != nil {
-		return err
+	names := withProfileVariants(reg.catalog, profile)
+	snapshots := make([]datamap.ProfileSnapshot, 0, len(names))
+	for _, name := range names {
+		st := profileSampleIndex(name, sampleIndex)
+		if err := runPhase(ctx, phaseProfiles, config.PhaseTimeout(cfg.Collection.Timeouts.Profiles), func(ctx context.Context) error {
+			return emitProfileArtifacts(ctx, runner, reg, binDest, layout, benchName, name, st, cfg.Collection.Renderer)
+		}); err != nil {
+			return err
+		}
+		var snap datamap.ProfileSnapshot
+		if err := runPhase(ctx, phaseSourceLines, config.PhaseTimeout(cfg.Collection.Timeouts.SourceLines), func(ctx context.Context) error {
+			var listErr error
+			snap, listErr = collectPerFunctionLists(ctx, runner, layout, benchName, name, binDest, st, cfg.Collection.Renderer, filter)
+			return listErr
+		}); err != nil {
+			return err
+		}
+		snapshots = append(snapshots, snap)
 	}
 	emitBenchmarkMap(nil, layout, emitMapParams{
 		Tag:            layout.Tag,
 		Benchmark:      benchName,
-		Profiles:       []string{profile},
+		Profiles:       names,
 		Filter:         filter,
+		SampleIndex:    sampleIndex,
 		CollectionMode: datamapCollectionManual,
-		PerProfile:     []datamap.ProfileSnapshot{snap},
+		PerProfile:     snapshots,
+		Producers:      reg.configured,
 	})
 	return nil
 }
 
-func emitProfileArtifacts(runner tooling.Runner, binPath string, layout workspace.TagLayout, benchName, profile string) error {
-	return emitParsedProfileArtifacts(runner, binPath, layout, benchName, profile, nil)
+func emitProfileArtifacts(ctx context.Context, runner tooling.Runner, reg *registry, binPath string, layout workspace.TagLayout, benchName, profile, sampleIndex, renderer string) error {
+	return emitParsedProfileArtifacts(ctx, runner, reg, binPath, layout, benchName, profile, sampleIndex, renderer, nil)
 }
 
-func collectPerFunctionLists(runner tooling.Runner, layout workspace.TagLayout, benchName, profile, binPath string, functionFilter config.FunctionFilter) (datamap.ProfileSnapshot, error) {
-	listEntries, profileData, err := parser.GetFunctionListEntriesWithProfileData(binPath, functionFilter)
+func collectPerFunctionLists(ctx context.Context, runner tooling.Runner, layout workspace.TagLayout, benchName, profile, binPath, sampleIndex, renderer string, functionFilter config.FunctionFilter) (datamap.ProfileSnapshot, error) {
+	listEntries, profileData, err := parser.GetFunctionListEntriesWithPipeline(parser.SampleIndexPipeline(sampleIndex), binPath, functionFilter)
 	if err != nil {
 		return datamap.ProfileSnapshot{}, fmt.Errorf("extract function names: %w", err)
 	}
@@ -91,7 +122,7 @@ func collectPerFunctionLists(runner tooling.Runner, layout workspace.TagLayout,
 	if err = ensureDirExists(functionDir); err != nil {
 		return datamap.ProfileSnapshot{}, err
 	}
-	listResult := getFunctionsOutput(runner, listEntries, binPath, functionDir, nil)
+	listResult := getFunctionsOutput(ctx, runner, listEntries, binPath, sampleIndex, renderer, functionDir, nil)
 	return datamap.ProfileSnapshot{
 		Profile:              profile,
 		ProfileData:          profileData,
@@ -122,11 +153,11 @@ func copyProfileBinary(src, dest string) error {
 	return nil
 }
 
-func manualBenchAndProfile(fullPath string) (benchName, profile string) {
+func manualBenchAndProfile(catalog *tooling.Catalog, fullPath string) (benchName, profile string) {
 	stem := stemFromPath(fullPath)
 	profile = stem
 	benchName = stem
-	for _, id := range profileCatalog.ProfileIDsSorted() {
+	for _, id := range catalog.ProfileIDsSorted() {
 		suffix := "_" + id
 		if len(stem) > len(suffix) && stem[len(stem)-len(suffix):] == suffix {
 			benchName = stem[:len(stem)-len(suffix)]
@@ -2,9 +2,12 @@ package collect
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
 func TestManualBenchAndProfile(t *testing.T) {
@@ -22,7 +25,7 @@ func TestManualBenchAndProfile(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.path, func(t *testing.T) {
 			t.Parallel()
-			bench, profile := manualBenchAndProfile(tc.path)
+			bench, profile := manualBenchAndProfile(builtins.catalog, tc.path)
 			if bench != tc.wantBench || profile != tc.wantProfile {
 				t.Fatalf("got bench=%q profile=%q want bench=%q profile=%q", bench, profile, tc.wantBench, tc.wantProfile)
 			}
@@ -32,23 +35,52 @@ func TestManualBenchAndProfile(t *testing.T) {
 
 func TestRunAuto_validation(t *testing.T) {
 	t.Parallel()
-	if err := RunAuto(nil, AutoOptions{}); err == nil {
+	if err := RunAuto(t.Context(), nil, AutoOptions{}); err == nil {
 		t.Fatal("expected nil runner error")
 	}
-	if err := RunAuto(noopRunner{}, AutoOptions{}); err == nil {
+	if err := RunAuto(t.Context(), noopRunner{}, AutoOptions{}); err == nil {
 		t.Fatal("expected empty benchmarks error")
 	}
-	if err := RunAuto(noopRunner{}, AutoOptions{Benchmarks: []string{"B"}}); err == nil {
+	if err := RunAuto(t.Context(), noopRunner{}, AutoOptions{Benchmarks: []string{"B"}}); err == nil {
 		t.Fatal("expected empty profiles error")
 	}
-	if err := RunAuto(noopRunner{}, AutoOptions{Benchmarks: []string{"B"}, Profiles: []string{"cpu"}, Count: 0}); err == nil {
+	if err := RunAuto(t.Context(), noopRunner{}, AutoOptions{Benchmarks: []string{"B"}, Profiles: []string{"cpu"}, Count: 0}); err == nil {
 		t.Fatal("expected count error")
 	}
+	if err := RunAuto(t.Context(), noopRunner{}, AutoOptions{Benchmarks: []string{"B"}, Profiles: []string{"cpu", "trace"}, Count: 3, PerIteration: true}); err == nil {
+		t.Fatal("expected per-iteration trace error")
+	}
+}
+
+func TestRunAuto_rejectsInvalidTag(t *testing.T) {
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+	saved := filepath.Join(workspace.OutputDir(modRoot), workspace.ComparisonsDir, "a_vs_b", "report.txt")
+	if err := os.MkdirAll(filepath.Dir(saved), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(saved, []byte("saved"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tag := range []string{workspace.ComparisonsDir, "..", "a/b", ""} {
+		opts := AutoOptions{Tag: tag, Benchmarks: []string{"B"}, Profiles: []string{"cpu"}, Count: 1}
+		if err := RunAuto(t.Context(), noopRunner{}, opts); err == nil {
+			t.Errorf("RunAuto(tag %q) = nil", tag)
+		}
+		if err := RunManual(t.Context(), noopRunner{}, ManualOptions{Tag: tag}); err == nil {
+			t.Errorf("RunManual(tag %q) = nil", tag)
+		}
+	}
+	if _, err := os.Stat(saved); err != nil {
+		t.Fatalf("saved comparison removed: %v", err)
+	}
 }
 
 func TestRunManual_validation(t *testing.T) {
 	t.Parallel()
-	if err := RunManual(nil, ManualOptions{Tag: "t"}); err == nil {
+	if err := RunManual(t.Context(), nil, ManualOptions{Tag: "t"}); err == nil {
 		t.Fatal("expected nil runner error")
 	}
 }
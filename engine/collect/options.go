@@ -2,20 +2,52 @@
 // Output domains: profiles/, measurements/, hotspots/, source_lines/, call_graphs/.
 package collect
 
+import (
+	"slices"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+)
+
 // AutoOptions configures RunAuto.
 type AutoOptions struct {
 	Benchmarks             []string
 	Profiles               []string
 	Tag                    string
 	Count                  int
-	MissingConfigWarnShown bool // survey already printed config.MissingConfigUserWarning
+	SampleIndex            map[string]string  // profile kind → pprof sample type (e.g. memory → alloc_space)
+	GoTest                 config.GoTestFlags // go test pass-through flags; override collection.go_test
+	Env                    []string           // env matrix, one NAME=v1,v2,... per variable; each combination runs as a tag variant
+	Append                 bool               // keep the tag; replace only the requested benchmarks
+	Resume                 bool               // like Append, but skip benchmarks whose map.json is complete
+	Parallel               int                // packages benchmarked at once on disjoint CPU sets; 0 or 1 is sequential
+	PerIteration           bool               // run each -count iteration as its own go test and keep profiles/<bench>/<kind>@<n>.out
+	MissingConfigWarnShown bool               // survey already printed config.MissingConfigUserWarning
+	ProfVersion            string             // recorded in manifest.json
+	Out                    string             // prof --out; empty writes under $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
 }
 
 // ManualOptions configures RunManual.
 type ManualOptions struct {
-	Files []string
-	Tag   string
+	Files       []string
+	Tag         string
+	ProfVersion string // recorded in manifest.json
+	Out         string // prof --out; see AutoOptions.Out
 }
 
-// SupportedProfiles lists profile kinds for auto collection.
-var SupportedProfiles = profileCatalog.ProfileIDsSorted()
+// SupportedProfiles lists the built-in profile kinds for auto collection.
+var SupportedProfiles = tooling.DefaultCatalog().ProfileIDsSorted()
+
+// AvailableProfiles lists SupportedProfiles followed by the profile kinds prof.json declares.
+// A missing or invalid prof.json lists the built-ins.
+func AvailableProfiles() []string {
+	cfg, err := config.Load()
+	if err != nil {
+		return SupportedProfiles
+	}
+	profiles := slices.Clone(SupportedProfiles)
+	for _, k := range cfg.Collection.ProfileKinds {
+		profiles = append(profiles, k.ID)
+	}
+	return profiles
+}
@@ -5,11 +5,11 @@ import (
 	"sync"
 )
 
-// defaultSourceLinesWorkers caps concurrent go tool pprof -list subprocesses.
-// Each worker loads the profile binary into memory.
+// defaultSourceLinesWorkers caps concurrent source_lines jobs (in-process annotation or,
+// with the pprof renderer, go tool pprof -list subprocesses that each load the profile binary).
 const defaultSourceLinesWorkers = 8
 
-// sourceLinesWorkers returns the worker count for source_lines subprocess fan-out.
+// sourceLinesWorkers returns the worker count for source_lines fan-out.
 func sourceLinesWorkers(jobCount int) int {
 	if jobCount <= 0 {
 		return 0
@@ -0,0 +1,157 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Collect phases of one benchmark, named after their collection.timeouts keys.
+const (
+	phaseBenchmark   = "benchmark"
+	phaseProfiles    = "profiles"
+	phaseSourceLines = "source_lines"
+)
+
+// runPhase runs fn with ctx bounded by timeout (zero means no limit). When the phase's
+// context ends, the result wraps the context error, not the error of the killed subprocess,
+// so callers can tell a cancellation or timeout from a failure.
+func runPhase(ctx context.Context, phase string, timeout time.Duration, fn func(context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(ctx)
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return fmt.Errorf("%s phase exceeded collection.timeouts (%s): %w", phase, timeout, ctxErr)
+	case ctxErr != nil:
+		return fmt.Errorf("%s phase interrupted: %w", phase, ctxErr)
+	}
+	return err
+}
+
+// tagProgress keeps .prof/<tag>/status.json, and manifest.json when the run has a runInfo,
+// current while a collect writes into the tag. Parallel benchmarks share one tagProgress.
+type tagProgress struct {
+	mu           sync.Mutex
+	path         string
+	status       datamap.TagStatus
+	manifestPath string
+	manifest     *datamap.TagManifest
+
+	// Why an earlier run into the tag stopped, restored by finish when entries that run left
+	// pending are still pending.
+	prevReason, prevError string
+}
+
+// startTagProgress records the tag as running with every entry pending. Entries of an earlier
+// run into the tag that this run does not repeat (--append, --resume) keep their state in both
+// files; a fresh tag has none left to merge. A nil info writes no manifest.json.
+func startTagProgress(layout workspace.TagLayout, entries []string, info *runInfo) *tagProgress {
+	p := &tagProgress{
+		path: layout.Status(),
+		status: datamap.TagStatus{
+			State:     datamap.TagStateRunning,
+			Completed: []string{},
+			Pending:   slices.Clone(entries),
+		},
+	}
+	prev, err := datamap.ReadTagStatus(p.path)
+	switch {
+	case err == nil:
+		repeated := func(e string) bool { return slices.Contains(entries, e) }
+		p.status.Completed = slices.DeleteFunc(append(p.status.Completed, prev.Completed...), repeated)
+		p.status.Pending = append(slices.DeleteFunc(prev.Pending, repeated), entries...)
+		p.prevReason, p.prevError = prev.Reason, prev.Error
+	case !errors.Is(err, os.ErrNotExist):
+		slog.Warn("Could not read tag status; starting it over", "path", p.path, "err", err)
+	}
+	if info != nil {
+		m := info.manifest(layout.Tag, entries)
+		p.manifestPath = layout.Manifest()
+		p.manifest = &m
+		// The benchmarks of earlier runs keep their state; the rest describes this run.
+		if prevManifest, readErr := datamap.ReadManifest(p.manifestPath); readErr == nil {
+			for entry, state := range prevManifest.Benchmarks {
+				if _, repeated := m.Benchmarks[entry]; !repeated {
+					m.Benchmarks[entry] = state
+				}
+			}
+		}
+	}
+	p.write()
+	return p
+}
+
+// done moves entry from pending to completed.
+func (p *tagProgress) done(entry string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.Pending = slices.DeleteFunc(p.status.Pending, func(e string) bool { return e == entry })
+	p.status.Completed = append(p.status.Completed, entry)
+	if p.manifest != nil {
+		p.manifest.Benchmarks[entry] = datamap.TagStateComplete
+	}
+	p.write()
+}
+
+// finish records the outcome of the run: complete when err is nil and no entry is left pending,
+// otherwise incomplete with the reason it stopped. It returns err unchanged.
+func (p *tagProgress) finish(err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case err == nil && len(p.status.Pending) == 0:
+		p.status.State = datamap.TagStateComplete
+	case err == nil:
+		// This run finished, but an earlier one left entries it did not repeat.
+		p.status.State = datamap.TagStateIncomplete
+		p.status.Reason = p.prevReason
+		p.status.Error = p.prevError
+	default:
+		p.status.State = datamap.TagStateIncomplete
+		p.status.Reason = stopReason(err)
+		p.status.Error = err.Error()
+	}
+	if p.manifest != nil {
+		finished := time.Now().UTC()
+		p.manifest.State = p.status.State
+		p.manifest.FinishedAt = &finished
+	}
+	p.write()
+	return err
+}
+
+func (p *tagProgress) write() {
+	p.status.UpdatedAt = time.Now().UTC()
+	if err := datamap.WriteTagStatus(p.path, p.status); err != nil {
+		slog.Warn("Could not record tag status", "path", p.path, "err", err)
+	}
+	if p.manifest == nil {
+		return
+	}
+	if err := datamap.WriteManifest(p.manifestPath, *p.manifest); err != nil {
+		slog.Warn("Could not record tag manifest", "path", p.manifestPath, "err", err)
+	}
+}
+
+func stopReason(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return datamap.TagStopCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return datamap.TagStopTimeout
+	default:
+		return datamap.TagStopFailed
+	}
+}
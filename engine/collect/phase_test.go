@@ -0,0 +1,114 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func TestRunPhase_classifiesContextEnd(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("signal: killed")
+
+	err := runPhase(t.Context(), phaseBenchmark, time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return boom
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "benchmark phase exceeded collection.timeouts (1ms)") {
+		t.Fatalf("timeout: err = %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+	err = runPhase(canceled, phaseProfiles, 0, func(context.Context) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled: err = %v", err)
+	}
+
+	if err = runPhase(t.Context(), phaseSourceLines, time.Minute, func(context.Context) error { return boom }); !errors.Is(err, boom) {
+		t.Fatalf("failure: err = %v", err)
+	}
+}
+
+func TestTagProgress_recordsOutcome(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "t1")
+	progress := startTagProgress(layout, []string{"BenchmarkA", "BenchmarkB"}, nil)
+	progress.done("BenchmarkA")
+	timeout := runPhase(t.Context(), phaseBenchmark, time.Nanosecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := progress.finish(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("finish should return its error, got %v", err)
+	}
+
+	status, err := datamap.ReadTagStatus(layout.Status())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != datamap.TagStateIncomplete || status.Reason != datamap.TagStopTimeout {
+		t.Fatalf("status=%+v", status)
+	}
+	if !slices.Equal(status.Completed, []string{"BenchmarkA"}) || !slices.Equal(status.Pending, []string{"BenchmarkB"}) {
+		t.Fatalf("completed=%v pending=%v", status.Completed, status.Pending)
+	}
+
+	// --append of BenchmarkA alone leaves BenchmarkB pending from the timed-out run.
+	progress = startTagProgress(layout, []string{"BenchmarkA"}, nil)
+	progress.done("BenchmarkA")
+	if err = progress.finish(nil); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = datamap.ReadTagStatus(layout.Status()); err != nil || status.State != datamap.TagStateIncomplete || status.Reason != datamap.TagStopTimeout {
+		t.Fatalf("status=%+v err=%v", status, err)
+	}
+	if !slices.Equal(status.Completed, []string{"BenchmarkA"}) || !slices.Equal(status.Pending, []string{"BenchmarkB"}) {
+		t.Fatalf("completed=%v pending=%v", status.Completed, status.Pending)
+	}
+
+	// --resume runs what is left and keeps what the earlier runs completed.
+	progress = startTagProgress(layout, []string{"BenchmarkB"}, nil)
+	progress.done("BenchmarkB")
+	if err = progress.finish(nil); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = datamap.ReadTagStatus(layout.Status()); err != nil || status.State != datamap.TagStateComplete || len(status.Pending) != 0 || status.Reason != "" {
+		t.Fatalf("status=%+v err=%v", status, err)
+	}
+	if !slices.Equal(status.Completed, []string{"BenchmarkA", "BenchmarkB"}) {
+		t.Fatalf("completed=%v", status.Completed)
+	}
+}
+
+func TestRunManual_canceledMarksTagIncomplete(t *testing.T) {
+	file, err := filepath.Abs(testpaths.MustAsset(t, "fixtures", filterFixtureCPU))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modRoot := t.TempDir()
+	writeModuleRoot(t, modRoot)
+	t.Chdir(modRoot)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err = RunManual(ctx, noopRunner{}, ManualOptions{Tag: "manual", Files: []string{file}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	status, err := datamap.ReadTagStatus(workspace.NewTagLayout(modRoot, "manual").Status())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != datamap.TagStateIncomplete || status.Reason != datamap.TagStopCanceled || !slices.Equal(status.Pending, []string{file}) {
+		t.Fatalf("status=%+v", status)
+	}
+}
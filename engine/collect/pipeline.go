@@ -1,6 +1,7 @@
 package collect
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -21,104 +22,232 @@ func finalizeInteractiveErr(session *termui.Session, err error) error {
 	return termui.StagedDisplay(err)
 }
 
-func runBenchAndGetProfiles(runner tooling.Runner, autoArgs *config.AutoArgs, cfg *config.Config, session *termui.Session) error {
+func runBenchAndGetProfiles(ctx context.Context, runner tooling.Runner, reg *registry, autoArgs *config.AutoArgs, cfg *config.Config, session *termui.Session, info *runInfo) error {
 	if !session.Interactive() {
 		slog.Info("Starting benchmark pipeline...")
 	}
+	if err := runTag(ctx, runner, reg, autoArgs, cfg, session, info); err != nil {
+		return err
+	}
+	session.Success(workspace.InfoCollectionSuccess)
+	return nil
+}
 
-	total := len(autoArgs.Benchmarks)
-	profileDetail := strings.Join(autoArgs.Profiles, ", ")
-	countDetail := fmt.Sprintf("count=%d", autoArgs.Count)
-
-	for i, benchmarkName := range autoArgs.Benchmarks {
-		base := termui.Progress{
-			Label: benchmarkName,
-			Index: i + 1,
-			Total: total,
+// runEnvMatrix runs every benchmark once per env matrix variant, each into its nested tag,
+// then prints and saves the table comparing the variants.
+func runEnvMatrix(ctx context.Context, runner tooling.Runner, reg *registry, autoArgs *config.AutoArgs, cfg *config.Config, session *termui.Session, variants []envVariant, info *runInfo) error {
+	if !session.Interactive() {
+		slog.Info("Starting benchmark pipeline...", "Variants", len(variants))
+	}
+	layout, err := workspace.TagLayoutFromCWD(autoArgs.Out, autoArgs.Tag)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(variants))
+	for i, v := range variants {
+		names[i] = v.name
+	}
+	progress := startTagProgress(layout, names, info)
+	for _, v := range variants {
+		args := *autoArgs
+		args.Tag = workspace.VariantTag(autoArgs.Tag, v.name)
+		args.Env = v.env
+		if !session.Interactive() {
+			slog.Info("Running env matrix variant", "Variant", v.name)
+		}
+		if err = runTag(ctx, runner, reg, &args, cfg, session, info.forVariant(v.env)); err != nil {
+			return progress.finish(err)
 		}
+		progress.done(v.name)
+	}
+	_ = progress.finish(nil)
+	session.Success(workspace.InfoCollectionSuccess)
+	return writeVariantSummary(session.Output(), autoArgs.Out, autoArgs.Tag, autoArgs.Benchmarks, variants)
+}
 
-		if !session.Interactive() {
-			slog.Info("Running benchmark", "Benchmark", benchmarkName)
+// runTag collects every benchmark into autoArgs.Tag, recording progress in its status.json
+// and, when info is set, its manifest.json.
+func runTag(ctx context.Context, runner tooling.Runner, reg *registry, autoArgs *config.AutoArgs, cfg *config.Config, session *termui.Session, info *runInfo) error {
+	layout, err := workspace.TagLayoutFromCWD(autoArgs.Out, autoArgs.Tag)
+	if err != nil {
+		return err
+	}
+	progress := startTagProgress(layout, autoArgs.Benchmarks, info)
+	return progress.finish(runBenchmarks(ctx, runner, reg, autoArgs, cfg, session, layout, progress))
+}
+
+// benchmarkComplete reports whether the tag holds a finished run of bench with every profile,
+// according to its map.json.
+func benchmarkComplete(layout workspace.TagLayout, bench string, profiles []string) bool {
+	m, err := datamap.ReadJSON(layout.DataMapping(bench))
+	return err == nil && m.Complete(profiles)
+}
+
+// benchmarkRun holds what every benchmark of one runTag shares.
+type benchmarkRun struct {
+	runner   tooling.Runner
+	reg      *registry
+	autoArgs *config.AutoArgs
+	cfg      *config.Config
+	layout   workspace.TagLayout
+	progress *tagProgress
+}
+
+func runBenchmarks(ctx context.Context, runner tooling.Runner, reg *registry, autoArgs *config.AutoArgs, cfg *config.Config, session *termui.Session, layout workspace.TagLayout, progress *tagProgress) error {
+	r := &benchmarkRun{runner: runner, reg: reg, autoArgs: autoArgs, cfg: cfg, layout: layout, progress: progress}
+	if autoArgs.Parallel > 1 && len(autoArgs.Benchmarks) > 1 {
+		return r.runParallel(ctx, session)
+	}
+	return r.runSequential(ctx, session)
+}
+
+func (r *benchmarkRun) runSequential(ctx context.Context, session *termui.Session) error {
+	for i := range r.autoArgs.Benchmarks {
+		if err := r.run(ctx, session, i, nil); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// run collects benchmark i of the tag. slot pins its go test run when benchmarks run in parallel.
+func (r *benchmarkRun) run(ctx context.Context, session *termui.Session, i int, slot *cpuSlot) error {
+	autoArgs := r.autoArgs
+	benchmarkName := autoArgs.Benchmarks[i]
+	variant := ""
+	if len(autoArgs.Env) > 0 {
+		variant = " · " + workspace.EnvVariant(autoArgs.Env)
+	}
+	total := len(autoArgs.Benchmarks)
+	base := termui.Progress{
+		Label: benchmarkName + variant,
+		Index: i + 1,
+		Total: total,
+	}
+
+	if autoArgs.Resume && benchmarkComplete(r.layout, benchmarkName, autoArgs.Profiles) {
 		if session.Interactive() {
-			session.BeginBenchmark(i+1, total, benchmarkName)
+			session.BeginBenchmark(i+1, total, benchmarkName+variant+" · complete, skipped")
+		} else {
+			slog.Info("Skipping complete benchmark", "Benchmark", benchmarkName, "Tag", autoArgs.Tag)
 		}
-		if err := session.RunWhile(base.WithPhase(termui.PhaseRunBenchmark).WithDetail(countDetail), func() error {
-			return runBenchmark(runner, benchmarkName, autoArgs.Profiles, autoArgs.Count, autoArgs.Tag)
-		}); err != nil {
-			return finalizeInteractiveErr(session, fmt.Errorf("failed to run %s: %w", benchmarkName, err))
+		r.progress.done(benchmarkName)
+		return nil
+	}
+	if autoArgs.Append || autoArgs.Resume {
+		if err := resetBenchmarkDirectories(r.layout, benchmarkName, autoArgs.Profiles); err != nil {
+			return err
 		}
+	}
+
+	if !session.Interactive() {
+		slog.Info("Running benchmark", "Benchmark", benchmarkName, "Tag", autoArgs.Tag)
+	}
+	if session.Interactive() {
+		session.BeginBenchmark(i+1, total, benchmarkName+variant)
+	}
+	goTest := config.ResolveGoTestFlags(r.cfg, benchmarkName, autoArgs.GoTest)
+	countDetail := fmt.Sprintf("count=%d", autoArgs.Count)
+	if err := session.RunWhile(base.WithPhase(termui.PhaseRunBenchmark).WithDetail(countDetail), func() error {
+		return runPhase(ctx, phaseBenchmark, config.PhaseTimeout(autoArgs.Timeouts.Benchmark), func(ctx context.Context) error {
+			return runBenchmark(ctx, r.runner, r.reg.catalog, benchmarkName, autoArgs.Profiles, autoArgs.Count, autoArgs.Out, autoArgs.Tag, goTest, autoArgs.Env, slot, autoArgs.PerIteration)
+		})
+	}); err != nil {
+		return finalizeInteractiveErr(session, fmt.Errorf("failed to run %s: %w", benchmarkName, err))
+	}
 
-		filter := config.ResolveCollectionFilter(cfg, config.CollectionTargetAuto(benchmarkName))
+	filter := config.ResolveCollectionFilter(r.cfg, config.CollectionTargetAuto(benchmarkName))
 
-		if !session.Interactive() {
-			slog.Info("Processing profiles", "Benchmark", benchmarkName)
-		}
-		var profilesReady []string
-		if err := session.RunWhile(base.WithPhase(termui.PhaseCollectProfiles).WithDetail(profileDetail), func() error {
+	if !session.Interactive() {
+		slog.Info("Processing profiles", "Benchmark", benchmarkName)
+	}
+	var profilesReady []string
+	profileDetail := strings.Join(autoArgs.Profiles, ", ")
+	if err := session.RunWhile(base.WithPhase(termui.PhaseCollectProfiles).WithDetail(profileDetail), func() error {
+		return runPhase(ctx, phaseProfiles, config.PhaseTimeout(autoArgs.Timeouts.Profiles), func(ctx context.Context) error {
 			var procErr error
-			profilesReady, procErr = processProfiles(runner, benchmarkName, autoArgs.Profiles, autoArgs.Tag, session)
+			profilesReady, procErr = processProfiles(ctx, r.runner, r.reg, benchmarkName, autoArgs.Profiles, autoArgs.Out, autoArgs.Tag, autoArgs.SampleIndex, autoArgs.Renderer, session)
 			return procErr
-		}); err != nil {
-			return finalizeInteractiveErr(session, fmt.Errorf("failed to process profiles for %s: %w", benchmarkName, err))
-		}
-
-		if !session.Interactive() {
-			slog.Info("Collecting function profiles", "Benchmark", benchmarkName)
-		}
-		args := &config.CollectionArgs{
-			Tag:             autoArgs.Tag,
-			Profiles:        profilesReady,
-			BenchmarkName:   benchmarkName,
-			BenchmarkConfig: filter,
-		}
-		if err := session.RunWhile(base.WithPhase(termui.PhaseCollectFunctionProfiles), func() error {
-			return collectFunctionsAndEmitMap(runner, args, session, autoArgs, benchmarkName, filter, profilesReady)
-		}); err != nil {
-			return finalizeInteractiveErr(session, fmt.Errorf("failed to collect function profiles for %s: %w", benchmarkName, err))
-		}
+		})
+	}); err != nil {
+		return finalizeInteractiveErr(session, fmt.Errorf("failed to process profiles for %s: %w", benchmarkName, err))
+	}
 
-		if !session.Interactive() {
-			slog.Info("Completed pipeline for benchmark", "Benchmark", benchmarkName)
-		}
+	if !session.Interactive() {
+		slog.Info("Collecting function profiles", "Benchmark", benchmarkName)
+	}
+	args := &config.CollectionArgs{
+		Tag:             autoArgs.Tag,
+		Out:             autoArgs.Out,
+		Profiles:        profilesReady,
+		BenchmarkName:   benchmarkName,
+		BenchmarkConfig: filter,
+		SampleIndex:     autoArgs.SampleIndex,
+		Renderer:        autoArgs.Renderer,
+		GoTest:          goTest,
+	}
+	if err := session.RunWhile(base.WithPhase(termui.PhaseCollectFunctionProfiles), func() error {
+		return runPhase(ctx, phaseSourceLines, config.PhaseTimeout(autoArgs.Timeouts.SourceLines), func(ctx context.Context) error {
+			return collectFunctionsAndEmitMap(ctx, r.runner, r.reg, args, session, autoArgs, benchmarkName, filter, profilesReady, slot)
+		})
+	}); err != nil {
+		return finalizeInteractiveErr(session, fmt.Errorf("failed to collect function profiles for %s: %w", benchmarkName, err))
 	}
 
-	session.Success(workspace.InfoCollectionSuccess)
+	if !session.Interactive() {
+		slog.Info("Completed pipeline for benchmark", "Benchmark", benchmarkName)
+	}
+	r.progress.done(benchmarkName)
 	return nil
 }
 
 func collectFunctionsAndEmitMap(
+	ctx context.Context,
 	runner tooling.Runner,
+	reg *registry,
 	args *config.CollectionArgs,
 	session *termui.Session,
 	autoArgs *config.AutoArgs,
 	benchmarkName string,
 	filter config.FunctionFilter,
 	profilesReady []string,
+	slot *cpuSlot,
 ) error {
-	snapshots, err := collectProfileFunctions(runner, args, session)
+	snapshots, err := collectProfileFunctions(ctx, runner, args, session)
+	if err != nil {
+		return err
+	}
+	layout, err := workspace.TagLayoutFromCWD(autoArgs.Out, autoArgs.Tag)
 	if err != nil {
 		return err
 	}
-	layout, err := workspace.TagLayoutFromCWD(autoArgs.Tag)
+	goTestCommand, err := buildBenchmarkCommand(reg.catalog, benchmarkName, autoArgs.Profiles, commandCount(autoArgs.Count, autoArgs.PerIteration), args.GoTest)
 	if err != nil {
 		return err
 	}
+	goTestCommand, env := slot.apply(goTestCommand, autoArgs.Env)
 	emitBenchmarkMap(session, layout, emitMapParams{
 		Tag:              autoArgs.Tag,
 		Benchmark:        benchmarkName,
 		Profiles:         profilesReady,
+		Traces:           collectedTraces(reg.catalog, layout, benchmarkName, autoArgs.Profiles),
 		Filter:           filter,
 		BenchCount:       autoArgs.Count,
+		SampleIndex:      autoArgs.SampleIndex,
+		GoTest:           args.GoTest,
+		GoTestCommand:    goTestCommand,
+		Env:              env,
+		PerIteration:     autoArgs.PerIteration,
 		CollectionMode:   datamapCollectionAuto,
 		PerProfile:       snapshots,
+		Producers:        reg.configured,
 		IncludeMeasuring: true,
 	})
 	return nil
 }
 
-func collectProfileFunctions(runner tooling.Runner, args *config.CollectionArgs, session *termui.Session) ([]datamap.ProfileSnapshot, error) {
-	layout, err := workspace.TagLayoutFromCWD(args.Tag)
+func collectProfileFunctions(ctx context.Context, runner tooling.Runner, args *config.CollectionArgs, session *termui.Session) ([]datamap.ProfileSnapshot, error) {
+	layout, err := workspace.TagLayoutFromCWD(args.Out, args.Tag)
 	if err != nil {
 		return nil, err
 	}
@@ -133,12 +262,13 @@ func collectProfileFunctions(runner tooling.Runner, args *config.CollectionArgs,
 		}
 
 		binPath := layout.ProfileBinary(args.BenchmarkName, profile)
-		listEntries, profileData, listErr := parser.GetFunctionListEntriesWithProfileData(binPath, args.BenchmarkConfig)
+		sampleIndex := profileSampleIndex(profile, args.SampleIndex)
+		listEntries, profileData, listErr := parser.GetFunctionListEntriesWithPipeline(parser.SampleIndexPipeline(sampleIndex), binPath, args.BenchmarkConfig)
 		if listErr != nil {
 			return fmt.Errorf("failed to extract function names: %w", listErr)
 		}
 
-		listResult := getFunctionsOutput(runner, listEntries, binPath, fnDir, session)
+		listResult := getFunctionsOutput(ctx, runner, listEntries, binPath, sampleIndex, args.Renderer, fnDir, session)
 		snapshots[i] = datamap.ProfileSnapshot{
 			Profile:              profile,
 			ProfileData:          profileData,
@@ -1,37 +1,41 @@
 package collect
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/pprofreport"
 	"github.com/AlexsanderHamir/prof/internal/termui"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
-)
-
-// FailurePolicy controls whether a profile artifact failure fails collection.
-type FailurePolicy int
-
-const (
-	// Required artifacts fail profile processing on error.
-	Required FailurePolicy = iota
-	// BestEffort artifacts log a warning and continue.
-	BestEffort
+	"github.com/AlexsanderHamir/prof/parser"
+	pprofprofile "github.com/google/pprof/profile"
 )
 
 const (
 	artifactHotspots     = "hotspots"
+	artifactStability    = "hotspot_stability"
 	artifactCallTreeText = "call_tree_text"
 	artifactCallGraphPNG = "call_graph_png"
+	artifactFoldedStacks = "folded_stacks"
+	artifactFlameGraph   = "flame_graph"
+	artifactSpeedscope   = "speedscope"
 )
 
 // ProduceContext carries inputs for one profile artifact producer.
 type ProduceContext struct {
-	Runner  tooling.Runner
-	Layout  workspace.TagLayout
-	Bench   string
-	Profile string
-	BinPath string
-	Session *termui.Session
+	Context     context.Context // cancels the pprof subprocesses of the producer
+	Runner      tooling.Runner
+	Layout      workspace.TagLayout
+	Bench       string
+	Profile     string
+	BinPath     string
+	SampleIndex string // pprof -sample_index; empty keeps pprof's default
+	Renderer    string // config.RendererPprof shells out for text reports; anything else renders in-process
+	Session     *termui.Session
 }
 
 // ArtifactPath resolves the on-disk path for one profile artifact.
@@ -39,49 +43,109 @@ type ArtifactPath func(layout workspace.TagLayout, bench, profile string) string
 
 // ProfileArtifact describes one derived output from a profile binary.
 type ProfileArtifact struct {
-	ID      string
-	Policy  FailurePolicy
-	Path    ArtifactPath
-	Produce func(ProduceContext) error
+	ID        string
+	Policy    tooling.FailurePolicy
+	Path      ArtifactPath
+	AppliesTo func(profile string) bool // nil produces the artifact for every profile
+	Produce   func(ProduceContext) error
 }
 
 func profileArtifacts() []ProfileArtifact {
 	return []ProfileArtifact{
 		{
 			ID:     artifactHotspots,
-			Policy: Required,
+			Policy: tooling.Required,
 			Path:   workspace.TagLayout.Hotspot,
 			Produce: func(ctx ProduceContext) error {
-				out := ctx.Layout.Hotspot(ctx.Bench, ctx.Profile)
-				return runPprofReport(ctx.Runner, tooling.PprofTextReportArgs("top", ctx.BinPath), out)
+				return renderTextReport(ctx, "top", pprofreport.Top, ctx.Layout.Hotspot(ctx.Bench, ctx.Profile))
 			},
 		},
+		{
+			ID:      artifactStability,
+			Policy:  tooling.Required,
+			Path:    workspace.TagLayout.HotspotStability,
+			Produce: writeHotspotStability,
+		},
 		{
 			ID:     artifactCallTreeText,
-			Policy: Required,
+			Policy: tooling.Required,
 			Path:   workspace.TagLayout.CallTreeText,
 			Produce: func(ctx ProduceContext) error {
-				out := ctx.Layout.CallTreeText(ctx.Bench, ctx.Profile)
-				return runPprofReport(ctx.Runner, tooling.PprofTextReportArgs("tree", ctx.BinPath), out)
+				return renderTextReport(ctx, "tree", pprofreport.Tree, ctx.Layout.CallTreeText(ctx.Bench, ctx.Profile))
+			},
+		},
+		{
+			ID:     artifactFoldedStacks,
+			Policy: tooling.Required,
+			Path:   workspace.TagLayout.FoldedStacks,
+			Produce: func(ctx ProduceContext) error {
+				return renderInProcess(ctx, "folded stacks", pprofreport.Folded, ctx.Layout.FoldedStacks(ctx.Bench, ctx.Profile))
+			},
+		},
+		{
+			ID:     artifactFlameGraph,
+			Policy: tooling.Required,
+			Path:   workspace.TagLayout.FlameGraph,
+			Produce: func(ctx ProduceContext) error {
+				return renderInProcess(ctx, "flame graph", pprofreport.FlameGraph, ctx.Layout.FlameGraph(ctx.Bench, ctx.Profile))
+			},
+		},
+		{
+			ID:     artifactSpeedscope,
+			Policy: tooling.Required,
+			Path:   workspace.TagLayout.Speedscope,
+			Produce: func(ctx ProduceContext) error {
+				name := ctx.Bench + " " + ctx.Profile
+				return renderInProcess(ctx, "speedscope profile", func(w io.Writer, p *pprofprofile.Profile, sampleIndex string) error {
+					return pprofreport.Speedscope(w, p, sampleIndex, name)
+				}, ctx.Layout.Speedscope(ctx.Bench, ctx.Profile))
 			},
 		},
 		{
 			ID:     artifactCallGraphPNG,
-			Policy: BestEffort,
+			Policy: tooling.BestEffort,
 			Path:   workspace.TagLayout.CallGraph,
 			Produce: func(ctx ProduceContext) error {
-				return getPNGOutput(ctx.Runner, ctx.BinPath, ctx.Layout.CallGraph(ctx.Profile, ctx.Bench))
+				return getPNGOutput(ctx.Context, ctx.Runner, ctx.BinPath, ctx.SampleIndex, ctx.Layout.CallGraph(ctx.Profile, ctx.Bench))
 			},
 		},
 	}
 }
 
-func emitProfileArtifactsFromCatalog(ctx ProduceContext) error {
-	for _, art := range profileArtifacts() {
+// renderTextReport writes a pprof -top/-tree style report for ctx.BinPath to out, in-process
+// unless ctx.Renderer asks for the go tool pprof subprocess.
+func renderTextReport(ctx ProduceContext, mode string, render func(io.Writer, *pprofprofile.Profile, string) error, out string) error {
+	if ctx.Renderer == config.RendererPprof {
+		return runPprofReport(ctx.Context, ctx.Runner, tooling.WithSampleIndex(tooling.PprofTextReportArgs(mode, ctx.BinPath), ctx.SampleIndex), out)
+	}
+	return renderInProcess(ctx, mode+" report", render, out)
+}
+
+// renderInProcess parses ctx.BinPath and writes what render produces at ctx.SampleIndex to out.
+func renderInProcess(ctx ProduceContext, what string, render func(io.Writer, *pprofprofile.Profile, string) error, out string) error {
+	p, err := parser.ParseProfileFromPath(ctx.BinPath)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err = render(&buf, p, ctx.SampleIndex); err != nil {
+		return fmt.Errorf("render %s: %w", what, err)
+	}
+	return writeArtifactFile(out, buf.Bytes())
+}
+
+// emitProfileArtifactsFromCatalog runs the built-in producers, then the prof.json ones in extra.
+func emitProfileArtifactsFromCatalog(ctx ProduceContext, extra []ProfileArtifact) error {
+	for _, art := range append(profileArtifacts(), extra...) {
+		if art.AppliesTo != nil && !art.AppliesTo(ctx.Profile) {
+			continue
+		}
 		if err := art.Produce(ctx); err != nil {
-			if art.Policy == BestEffort {
+			if art.Policy == tooling.BestEffort {
 				if art.ID == artifactCallGraphPNG {
 					warnSkippedPNG(ctx.Session, ctx.Profile, ctx.Bench, err)
+				} else {
+					warnSkippedArtifact(ctx.Session, art.ID, ctx.Profile, ctx.Bench, err)
 				}
 				continue
 			}
@@ -91,13 +155,16 @@ func emitProfileArtifactsFromCatalog(ctx ProduceContext) error {
 	return nil
 }
 
-func emitParsedProfileArtifacts(runner tooling.Runner, binPath string, layout workspace.TagLayout, bench, profile string, session *termui.Session) error {
+func emitParsedProfileArtifacts(ctx context.Context, runner tooling.Runner, reg *registry, binPath string, layout workspace.TagLayout, bench, profile, sampleIndex, renderer string, session *termui.Session) error {
 	return emitProfileArtifactsFromCatalog(ProduceContext{
-		Runner:  runner,
-		Layout:  layout,
-		Bench:   bench,
-		Profile: profile,
-		BinPath: binPath,
-		Session: session,
-	})
+		Context:     ctx,
+		Runner:      runner,
+		Layout:      layout,
+		Bench:       bench,
+		Profile:     profile,
+		BinPath:     binPath,
+		SampleIndex: sampleIndex,
+		Renderer:    renderer,
+		Session:     session,
+	}, reg.producers)
 }
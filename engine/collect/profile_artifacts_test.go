@@ -4,26 +4,29 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/testpaths"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
 func TestProfileArtifacts_catalogOrder(t *testing.T) {
 	arts := profileArtifacts()
-	if len(arts) != 3 {
-		t.Fatalf("expected 3 artifacts, got %d", len(arts))
+	if len(arts) != 7 {
+		t.Fatalf("expected 7 artifacts, got %d", len(arts))
 	}
-	want := []string{artifactHotspots, artifactCallTreeText, artifactCallGraphPNG}
+	want := []string{artifactHotspots, artifactStability, artifactCallTreeText, artifactFoldedStacks, artifactFlameGraph, artifactSpeedscope, artifactCallGraphPNG}
 	for i, id := range want {
 		if arts[i].ID != id {
 			t.Fatalf("artifact[%d]=%q want %q", i, arts[i].ID, id)
 		}
 	}
-	if arts[2].Policy != BestEffort {
-		t.Fatalf("png policy=%v want BestEffort", arts[2].Policy)
+	if arts[6].Policy != tooling.BestEffort {
+		t.Fatalf("png policy=%v want BestEffort", arts[6].Policy)
 	}
 }
 
@@ -35,10 +38,10 @@ func TestEmitProfileArtifactsFromCatalog_requiredFailure(t *testing.T) {
 	modRoot := t.TempDir()
 	writeModuleRoot(t, modRoot)
 	t.Chdir(modRoot)
-	if err := setupDirectories(tag, []string{bench}, []string{"cpu"}, false); err != nil {
+	if err := setupDirectories(builtins.catalog, "", tag, []string{bench}, []string{"cpu"}, false); err != nil {
 		t.Fatal(err)
 	}
-	layout, err := workspace.TagLayoutFromCWD(tag)
+	layout, err := workspace.TagLayoutFromCWD("", tag)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -46,13 +49,14 @@ func TestEmitProfileArtifactsFromCatalog_requiredFailure(t *testing.T) {
 		Err: []error{errors.New("pprof top failed")},
 	}
 	ctx := ProduceContext{
+		Context: t.Context(),
 		Runner:  runner,
 		Layout:  layout,
 		Bench:   bench,
 		Profile: "cpu",
 		BinPath: "cpu.out",
 	}
-	if emitErr := emitProfileArtifactsFromCatalog(ctx); emitErr == nil {
+	if emitErr := emitProfileArtifactsFromCatalog(ctx, nil); emitErr == nil {
 		t.Fatal("expected required hotspot failure")
 	}
 }
@@ -66,10 +70,10 @@ func TestEmitProfileArtifactsFromCatalog_bestEffortPNG(t *testing.T) {
 	modRoot := t.TempDir()
 	writeModuleRoot(t, modRoot)
 	t.Chdir(modRoot)
-	if err := setupDirectories(tag, []string{bench}, []string{"cpu"}, false); err != nil {
+	if err := setupDirectories(builtins.catalog, "", tag, []string{bench}, []string{"cpu"}, false); err != nil {
 		t.Fatal(err)
 	}
-	layout, err := workspace.TagLayoutFromCWD(tag)
+	layout, err := workspace.TagLayoutFromCWD("", tag)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -85,18 +89,16 @@ func TestEmitProfileArtifactsFromCatalog_bestEffortPNG(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	runner := &tooling.FakeRunner{
-		Out: [][]byte{[]byte("top"), []byte("tree")},
-		Err: []error{nil, nil, errors.New("graphviz unavailable")},
-	}
+	runner := &tooling.FakeRunner{Err: []error{errors.New("graphviz unavailable")}}
 	ctx := ProduceContext{
+		Context: t.Context(),
 		Runner:  runner,
 		Layout:  layout,
 		Bench:   bench,
 		Profile: "cpu",
 		BinPath: dst,
 	}
-	if emitErr := emitProfileArtifactsFromCatalog(ctx); emitErr != nil {
+	if emitErr := emitProfileArtifactsFromCatalog(ctx, nil); emitErr != nil {
 		t.Fatal(emitErr)
 	}
 	for _, path := range []string{
@@ -108,3 +110,86 @@ func TestEmitProfileArtifactsFromCatalog_bestEffortPNG(t *testing.T) {
 		}
 	}
 }
+
+func TestEmitProfileArtifactsFromCatalog_sampleIndexInArgv(t *testing.T) {
+	fixture := testpaths.MustAsset(t, "fixtures", "BenchmarkStringProcessor_memory.out")
+	layout := workspace.NewTagLayout(t.TempDir(), "catalog-sample-index")
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("top"), []byte("tree"), []byte("png")}}
+	ctx := ProduceContext{
+		Context:     t.Context(),
+		Runner:      runner,
+		Layout:      layout,
+		Bench:       "BenchmarkFoo",
+		Profile:     "memory",
+		BinPath:     fixture,
+		SampleIndex: "alloc_space",
+		Renderer:    config.RendererPprof,
+	}
+	if err := emitProfileArtifactsFromCatalog(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 3 {
+		t.Fatalf("runs=%d", len(runner.Runs))
+	}
+	for _, run := range runner.Runs {
+		if !slices.Contains(run.Argv, "-sample_index=alloc_space") {
+			t.Fatalf("argv missing -sample_index: %v", run.Argv)
+		}
+	}
+}
+
+func TestEmitProfileArtifactsFromCatalog_builtinRenderer(t *testing.T) {
+	fixture := testpaths.MustAsset(t, "fixtures", "BenchmarkStringProcessor_memory.out")
+	layout := workspace.NewTagLayout(t.TempDir(), "catalog-builtin")
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("png")}}
+	ctx := ProduceContext{
+		Context:     t.Context(),
+		Runner:      runner,
+		Layout:      layout,
+		Bench:       "BenchmarkFoo",
+		Profile:     "memory",
+		BinPath:     fixture,
+		SampleIndex: "alloc_objects",
+	}
+	if err := emitProfileArtifactsFromCatalog(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 1 || !slices.Contains(runner.Runs[0].Argv, "-png") {
+		t.Fatalf("expected only the png subprocess, got %v", runner.Runs)
+	}
+	top, err := os.ReadFile(layout.Hotspot("BenchmarkFoo", "memory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(top), "Type: alloc_objects") || !strings.Contains(string(top), "flat%") {
+		t.Fatalf("unexpected hotspot report:\n%s", top)
+	}
+	tree, err := os.ReadFile(layout.CallTreeText("BenchmarkFoo", "memory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tree), "calls calls% + context") {
+		t.Fatalf("unexpected call tree report:\n%s", tree)
+	}
+	folded, err := os.ReadFile(layout.FoldedStacks("BenchmarkFoo", "memory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(folded), ";") {
+		t.Fatalf("unexpected folded stacks:\n%s", folded)
+	}
+	svg, err := os.ReadFile(layout.FlameGraph("BenchmarkFoo", "memory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(svg), "Flame Graph — alloc_objects") {
+		t.Fatalf("unexpected flame graph:\n%.200s", svg)
+	}
+	speedscope, err := os.ReadFile(layout.Speedscope("BenchmarkFoo", "memory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(speedscope), `"name":"BenchmarkFoo memory"`) {
+		t.Fatalf("unexpected speedscope file:\n%.200s", speedscope)
+	}
+}
@@ -1,6 +1,7 @@
 package collect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -11,8 +12,8 @@ import (
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
-func processProfiles(runner tooling.Runner, benchmarkName string, profiles []string, tag string, session *termui.Session) ([]string, error) {
-	layout, err := workspace.TagLayoutFromCWD(tag)
+func processProfiles(ctx context.Context, runner tooling.Runner, reg *registry, benchmarkName string, profiles []string, out, tag string, sampleIndex map[string]string, renderer string, session *termui.Session) ([]string, error) {
+	layout, err := workspace.TagLayoutFromCWD(out, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -29,10 +30,21 @@ func processProfiles(runner tooling.Runner, benchmarkName string, profiles []str
 			return nil, fmt.Errorf("failed to stat profile file %s: %w", profileFile, statErr)
 		}
 
-		if procErr := processOneProfile(runner, layout, benchmarkName, profile, profileFile, session); procErr != nil {
-			return nil, procErr
+		if reg.catalog.Format(profile) == tooling.FormatTrace {
+			views, traceErr := processTrace(ctx, runner, reg, layout, benchmarkName, profile, profileFile, renderer, session)
+			if traceErr != nil {
+				return nil, traceErr
+			}
+			processed = append(processed, views...)
+			continue
+		}
+
+		for _, name := range withProfileVariants(reg.catalog, profile) {
+			if procErr := processOneProfile(ctx, runner, reg, layout, benchmarkName, name, profileFile, profileSampleIndex(name, sampleIndex), renderer, session); procErr != nil {
+				return nil, procErr
+			}
+			processed = append(processed, name)
 		}
-		processed = append(processed, profile)
 	}
 
 	if len(processed) == 0 && len(profiles) > 0 {
@@ -42,8 +54,27 @@ func processProfiles(runner tooling.Runner, benchmarkName string, profiles []str
 	return processed, nil
 }
 
-func processOneProfile(runner tooling.Runner, layout workspace.TagLayout, benchmarkName, profile, profileFile string, session *termui.Session) error {
-	if err := emitParsedProfileArtifacts(runner, profileFile, layout, benchmarkName, profile, session); err != nil {
+// withProfileVariants returns profile followed by the variants collected from the same binary
+// (one per catalog sample type, e.g. memory.alloc_space).
+func withProfileVariants(catalog *tooling.Catalog, profile string) []string {
+	names := []string{profile}
+	for _, st := range catalog.SampleTypes(profile) {
+		names = append(names, workspace.ProfileVariant(profile, st))
+	}
+	return names
+}
+
+// profileSampleIndex returns the pprof sample type a profile or variant is ranked by;
+// empty keeps pprof's default.
+func profileSampleIndex(name string, sampleIndex map[string]string) string {
+	if _, st := workspace.SplitProfileVariant(name); st != "" {
+		return st
+	}
+	return sampleIndex[name]
+}
+
+func processOneProfile(ctx context.Context, runner tooling.Runner, reg *registry, layout workspace.TagLayout, benchmarkName, profile, profileFile, sampleIndex, renderer string, session *termui.Session) error {
+	if err := emitParsedProfileArtifacts(ctx, runner, reg, profileFile, layout, benchmarkName, profile, sampleIndex, renderer, session); err != nil {
 		return fmt.Errorf("failed to process profile %s: %w", profile, err)
 	}
 
@@ -75,3 +106,12 @@ func warnSkippedPNG(session *termui.Session, profile, benchmarkName string, pngE
 	}
 	slog.Warn("PNG visualization skipped", "profile", profile, "benchmark", benchmarkName, "err", pngErr)
 }
+
+func warnSkippedArtifact(session *termui.Session, artifact, profile, benchmarkName string, err error) {
+	msg := fmt.Sprintf("%s skipped for %s/%s: %v", artifact, benchmarkName, profile, err)
+	if session.Interactive() {
+		session.Warn(msg)
+		return
+	}
+	slog.Warn("Best-effort artifact skipped", "artifact", artifact, "profile", profile, "benchmark", benchmarkName, "err", err)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/AlexsanderHamir/prof/engine/tooling"
@@ -19,10 +20,10 @@ func setupProcessProfilesEnv(t *testing.T, tag string, profiles []string) (works
 	writeModuleRoot(t, modRoot)
 	t.Chdir(modRoot)
 
-	if err := setupDirectories(tag, []string{bench}, profiles, false); err != nil {
+	if err := setupDirectories(builtins.catalog, "", tag, []string{bench}, profiles, false); err != nil {
 		t.Fatal(err)
 	}
-	layout, err := workspace.TagLayoutFromCWD(tag)
+	layout, err := workspace.TagLayoutFromCWD("", tag)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -52,10 +53,8 @@ func TestProcessProfiles_skipsMissingBinary(t *testing.T) {
 	layout, fixture := setupProcessProfilesEnv(t, tag, []string{"cpu", "memory"})
 	copyFixtureToProfile(t, layout, bench, "cpu", fixture)
 
-	runner := &tooling.FakeRunner{
-		Out: [][]byte{[]byte("flat profile text"), []byte("tree profile text"), []byte("png-bytes")},
-	}
-	processed, err := processProfiles(runner, bench, []string{"cpu", "memory"}, tag, nil)
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("png-bytes")}}
+	processed, err := processProfiles(t.Context(), runner, builtins, bench, []string{"cpu", "memory"}, "", tag, nil, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -71,7 +70,7 @@ func TestProcessProfiles_failsWhenAllMissing(t *testing.T) {
 	)
 	_, _ = setupProcessProfilesEnv(t, tag, []string{"cpu", "memory"})
 
-	_, err := processProfiles(&tooling.FakeRunner{}, bench, []string{"cpu", "memory"}, tag, nil)
+	_, err := processProfiles(t.Context(), &tooling.FakeRunner{}, builtins, bench, []string{"cpu", "memory"}, "", tag, nil, "", nil)
 	if err == nil {
 		t.Fatal("expected error when no profile binaries exist")
 	}
@@ -85,11 +84,8 @@ func TestProcessProfiles_continuesOnPNGFailure(t *testing.T) {
 	layout, fixture := setupProcessProfilesEnv(t, tag, []string{"cpu"})
 	copyFixtureToProfile(t, layout, bench, "cpu", fixture)
 
-	runner := &tooling.FakeRunner{
-		Out: [][]byte{[]byte("flat profile text"), []byte("tree profile text")},
-		Err: []error{nil, nil, errors.New("graphviz unavailable")},
-	}
-	processed, err := processProfiles(runner, bench, []string{"cpu"}, tag, nil)
+	runner := &tooling.FakeRunner{Err: []error{errors.New("graphviz unavailable")}}
+	processed, err := processProfiles(t.Context(), runner, builtins, bench, []string{"cpu"}, "", tag, nil, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -103,3 +99,42 @@ func TestProcessProfiles_continuesOnPNGFailure(t *testing.T) {
 		t.Fatalf("expected call tree text: %v", statErr)
 	}
 }
+
+func TestProcessProfiles_memoryVariants(t *testing.T) {
+	const (
+		tag   = "t4"
+		bench = "BenchmarkFoo"
+	)
+	memFixture := testpaths.MustAsset(t, "fixtures", "BenchmarkStringProcessor_memory.out")
+	layout, _ := setupProcessProfilesEnv(t, tag, []string{"memory"})
+	copyFixtureToProfile(t, layout, bench, "memory", memFixture)
+
+	runner := &tooling.FakeRunner{}
+	for range 5 { // png for memory and each variant; text reports render in-process
+		runner.Out = append(runner.Out, []byte("png"))
+	}
+	processed, err := processProfiles(t.Context(), runner, builtins, bench, []string{"memory"}, "", tag, map[string]string{"memory": "alloc_objects"}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"memory", "memory.alloc_space", "memory.alloc_objects", "memory.inuse_space", "memory.inuse_objects"}
+	if !slices.Equal(processed, want) {
+		t.Fatalf("processed: %#v", processed)
+	}
+	for _, name := range want[1:] {
+		if _, statErr := os.Stat(layout.Hotspot(bench, name)); statErr != nil {
+			t.Fatalf("expected hotspot for %s: %v", name, statErr)
+		}
+	}
+	var sawBase, sawVariant bool
+	for _, run := range runner.Runs {
+		if !slices.Contains(run.Argv, layout.ProfileBinary(bench, "memory")) {
+			t.Fatalf("variant must read the shared binary: %v", run.Argv)
+		}
+		sawBase = sawBase || slices.Contains(run.Argv, "-sample_index=alloc_objects")
+		sawVariant = sawVariant || slices.Contains(run.Argv, "-sample_index=inuse_space")
+	}
+	if !sawBase || !sawVariant {
+		t.Fatalf("expected base and variant sample indexes in %d runs", len(runner.Runs))
+	}
+}
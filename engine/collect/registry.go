@@ -0,0 +1,68 @@
+package collect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// registry holds the profile kinds and extra artifact producers of one collection: the
+// built-in catalog plus what collection.profile_kinds and collection.producers in prof.json
+// declare. RunAuto and RunManual build it from the loaded config and pass it down.
+type registry struct {
+	catalog    *tooling.Catalog
+	producers  []ProfileArtifact
+	configured []config.ArtifactProducer // the prof.json declarations of producers, for map.json
+}
+
+// newRegistry returns the registry cfg declares. A nil cfg holds the built-ins only.
+func newRegistry(cfg *config.Config) (*registry, error) {
+	catalog, err := tooling.ConfiguredCatalog(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return &registry{catalog: catalog}, nil
+	}
+	producers := make([]ProfileArtifact, len(cfg.Collection.Producers))
+	for i, p := range cfg.Collection.Producers {
+		producers[i] = producerArtifact(p)
+	}
+	return &registry{catalog: catalog, producers: producers, configured: cfg.Collection.Producers}, nil
+}
+
+// producerArtifact runs a prof.json artifact producer: go tool pprof with its flags on the
+// profile binary, stdout saved under reports/.
+func producerArtifact(p config.ArtifactProducer) ProfileArtifact {
+	policy := tooling.Required
+	if p.BestEffort() {
+		policy = tooling.BestEffort
+	}
+	path := func(layout workspace.TagLayout, bench, profile string) string {
+		return layout.Report(bench, profile, p.ID, p.FileExtension())
+	}
+	return ProfileArtifact{
+		ID:        p.ID,
+		Policy:    policy,
+		Path:      path,
+		AppliesTo: p.AppliesTo,
+		Produce: func(ctx ProduceContext) error {
+			if ctx.Runner == nil {
+				return errors.New("tooling runner is nil")
+			}
+			args := p.ExpandArgs(topLevelBenchmark(ctx.Bench), ctx.Profile)
+			argv := tooling.WithSampleIndex(tooling.PprofReportArgs(args, ctx.BinPath), ctx.SampleIndex)
+			// pprof explains a bad report flag on stderr, which the error should carry.
+			var stdout, stderr bytes.Buffer
+			if _, err := ctx.Runner.Run(ctx.Context, argv, tooling.RunOpts{Stdout: &stdout, Stderr: &stderr}); err != nil {
+				return fmt.Errorf("go tool pprof %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+			}
+			return writeArtifactFile(path(ctx.Layout, ctx.Bench, ctx.Profile), stdout.Bytes())
+		},
+	}
+}
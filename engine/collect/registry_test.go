@@ -0,0 +1,83 @@
+package collect
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+)
+
+// builtins is the registry of a collection without prof.json.
+var builtins = &registry{catalog: tooling.DefaultCatalog()}
+
+func TestNewRegistry_profileKindsAndProducers(t *testing.T) {
+	const (
+		tag   = "custom"
+		bench = "./codec.BenchmarkFoo"
+	)
+	cfg := &config.Config{Collection: config.Collection{
+		ProfileKinds: []config.ProfileKind{{ID: "goroutine", GoTestFlag: "-goroutineprofile=goroutine.out", OutputFile: "goroutine.out"}},
+		Producers: []config.ArtifactProducer{
+			{ID: "peek", Args: []string{`-peek=\.{benchmark}$`}},
+			{ID: "disasm", Args: []string{"-disasm=Encode"}, Policy: config.ProducerBestEffort},
+			{ID: "raw", Args: []string{"-raw"}, Profiles: []string{"cpu"}},
+		},
+	}}
+	reg, err := newRegistry(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := buildBenchmarkCommand(reg.catalog, bench, []string{"goroutine"}, 1, config.GoTestFlags{})
+	if err != nil || !slices.Contains(cmd, "-goroutineprofile=goroutine.out") {
+		t.Fatalf("go test command=%v err=%v", cmd, err)
+	}
+	if file, ok := reg.catalog.OutFileName("goroutine"); !ok || file != "goroutine.out" {
+		t.Fatalf("expected file=%q ok=%v", file, ok)
+	}
+	if builtins.catalog.ValidateProfile("goroutine") == nil {
+		t.Fatal("a configured kind must not leak into other registries")
+	}
+
+	layout, fixture := setupProcessProfilesEnv(t, tag, []string{"goroutine"})
+	copyFixtureToProfile(t, layout, bench, "goroutine", fixture)
+	// The call graph PNG, then peek; disasm fails but is best effort and raw is cpu only.
+	runner := &tooling.FakeRunner{
+		Out: [][]byte{nil, []byte("peek report"), nil},
+		Err: []error{errors.New("graphviz unavailable"), nil, errors.New("no matches")},
+	}
+	if _, err = processProfiles(t.Context(), runner, reg, bench, []string{"goroutine"}, "", tag, nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 3 {
+		t.Fatalf("runs=%d", len(runner.Runs))
+	}
+	binPath := layout.ProfileBinary(bench, "goroutine")
+	if got, want := runner.Runs[1].Argv, tooling.PprofReportArgs([]string{`-peek=\.BenchmarkFoo$`}, binPath); !slices.Equal(got, want) {
+		t.Fatalf("peek argv=%v want %v", got, want)
+	}
+	if data, readErr := os.ReadFile(layout.Report(bench, "goroutine", "peek", "txt")); readErr != nil || string(data) != "peek report" {
+		t.Fatalf("peek report=%q err=%v", data, readErr)
+	}
+	if _, statErr := os.Stat(layout.Report(bench, "goroutine", "disasm", "txt")); !os.IsNotExist(statErr) {
+		t.Fatalf("failed best-effort producer left a report: %v", statErr)
+	}
+
+	// A required producer failure fails the profile.
+	runner = &tooling.FakeRunner{Err: []error{nil, errors.New("pprof failed")}}
+	if _, err = processProfiles(t.Context(), runner, reg, bench, []string{"goroutine"}, "", tag, nil, "", nil); err == nil {
+		t.Fatal("expected the required peek producer to fail processing")
+	}
+}
+
+func TestNewRegistry_rejectsBuiltinCollision(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Collection: config.Collection{
+		ProfileKinds: []config.ProfileKind{{ID: "heap", GoTestFlag: "-heapprofile=cpu.out", OutputFile: "cpu.out"}},
+	}}
+	if _, err := newRegistry(cfg); err == nil {
+		t.Fatal("expected error for a kind writing the cpu output file")
+	}
+}
@@ -0,0 +1,193 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/termui"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// parallelAccuracyWarning is shown during prepare when prof auto --parallel is set.
+const parallelAccuracyWarning = "--parallel runs benchmarks from different packages at the same time; " +
+	"they share caches, memory bandwidth and thermal headroom, so this trades measurement accuracy for time"
+
+// cpuSlot is the share of the machine one parallel lane runs its benchmarks on.
+type cpuSlot struct {
+	cpus  []int // CPU ids passed to taskset; empty pins by GOMAXPROCS only
+	procs int   // GOMAXPROCS of the go test run
+}
+
+// partitionCPUs splits count CPUs into at most n disjoint slots of near-equal size. cpus lists
+// the CPU ids to pin with taskset; nil partitions by GOMAXPROCS alone. Slots never share a CPU,
+// so a machine with fewer than n CPUs gets fewer than n slots.
+func partitionCPUs(cpus []int, count, n int) []cpuSlot {
+	if len(cpus) > 0 {
+		count = len(cpus)
+	}
+	n = min(n, count)
+	if n < 1 {
+		return nil
+	}
+	slots := make([]cpuSlot, n)
+	start := 0
+	for i := range slots {
+		size := count / n
+		if i < count%n {
+			size++
+		}
+		slots[i].procs = size
+		if len(cpus) > 0 {
+			slots[i].cpus = cpus[start : start+size]
+		}
+		start += size
+	}
+	return slots
+}
+
+// hostCPUSlots partitions this machine into at most n slots, pinned with taskset when it is
+// on PATH and the CPU ids are known.
+func hostCPUSlots(n int) []cpuSlot {
+	var cpus []int
+	if tooling.TasksetAvailable() {
+		cpus = availableCPUs()
+	}
+	return partitionCPUs(cpus, runtime.NumCPU(), n)
+}
+
+// parallelNotices returns the warnings prepare prints for prof auto --parallel n.
+func parallelNotices(n int) []string {
+	notices := []string{parallelAccuracyWarning}
+	if !tooling.TasksetAvailable() || availableCPUs() == nil {
+		notices = append(notices, "taskset is unavailable; parallel benchmarks are limited by GOMAXPROCS but not pinned to CPUs")
+	}
+	if cpus := runtime.NumCPU(); cpus < n {
+		notices = append(notices, fmt.Sprintf("--parallel %d exceeds the %d available CPUs; each benchmark lane needs a CPU of its own", n, cpus))
+	}
+	return notices
+}
+
+// apply pins a go test run to the slot. GOMAXPROCS goes ahead of env so an env matrix value
+// still wins; CPU ids add a taskset prefix to cmd. A nil slot leaves the run unchanged.
+func (c *cpuSlot) apply(cmd, env []string) ([]string, []string) {
+	if c == nil {
+		return cmd, env
+	}
+	env = append([]string{"GOMAXPROCS=" + strconv.Itoa(c.procs)}, env...)
+	if len(c.cpus) > 0 {
+		cmd = append(tooling.TasksetArgs(formatCPUList(c.cpus)), cmd...)
+	}
+	return cmd, env
+}
+
+// formatCPUList renders ascending CPU ids as a taskset list, collapsing runs: 0-3,6.
+func formatCPUList(cpus []int) string {
+	var parts []string
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(cpus[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// benchmarkGroups returns the indexes of benchmarks grouped by the package directory pkgDir
+// resolves, in first-seen order. lockPackage serializes go test runs in one package directory,
+// so the benchmarks of a package share a lane instead of holding other lanes waiting on the lock.
+func benchmarkGroups(benchmarks []string, pkgDir func(string) (string, error)) ([][]int, error) {
+	var groups [][]int
+	byDir := make(map[string]int)
+	for i, name := range benchmarks {
+		dir, err := pkgDir(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate benchmark %s: %w", name, err)
+		}
+		g, ok := byDir[dir]
+		if !ok {
+			g = len(groups)
+			byDir[dir] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups, nil
+}
+
+// runParallel runs the package groups on up to Parallel lanes, one group at a time per lane,
+// each lane pinned to its own CPU slot. The first failure cancels the other lanes.
+func (r *benchmarkRun) runParallel(ctx context.Context, session *termui.Session) error {
+	moduleRoot, err := workspace.FindModuleRoot()
+	if err != nil {
+		return fmt.Errorf("failed to find Go module root: %w", err)
+	}
+	groups, err := benchmarkGroups(r.autoArgs.Benchmarks, func(name string) (string, error) {
+		goTest := config.ResolveGoTestFlags(r.cfg, name, r.autoArgs.GoTest)
+		return findBenchmarkPackageDir(buildContext(goTest), moduleRoot, name)
+	})
+	if err != nil {
+		return err
+	}
+	slots := hostCPUSlots(min(r.autoArgs.Parallel, len(groups)))
+	if len(slots) < 2 {
+		return r.runSequential(ctx, session)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lanes := session.StartLanes(len(slots))
+	defer lanes.Stop()
+
+	jobs := make(chan []int, len(groups))
+	for _, g := range groups {
+		jobs <- g
+	}
+	close(jobs)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	wg.Add(len(slots))
+	for lane := range slots {
+		go func() {
+			defer wg.Done()
+			laneSession := lanes.Lane(lane)
+			for group := range jobs {
+				for _, i := range group {
+					if ctx.Err() != nil {
+						return
+					}
+					if runErr := r.run(ctx, laneSession, i, &slots[lane]); runErr != nil {
+						mu.Lock()
+						if firstErr == nil {
+							firstErr = runErr
+							cancel()
+						}
+						mu.Unlock()
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr == nil {
+		// The parent context ended between benchmarks.
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
@@ -0,0 +1,97 @@
+package collect
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPartitionCPUs(t *testing.T) {
+	t.Parallel()
+	slots := partitionCPUs([]int{0, 1, 2, 3, 4, 6, 7}, 0, 3)
+	want := [][]int{{0, 1, 2}, {3, 4}, {6, 7}}
+	if len(slots) != len(want) {
+		t.Fatalf("slots=%v", slots)
+	}
+	for i, s := range slots {
+		if !slices.Equal(s.cpus, want[i]) || s.procs != len(want[i]) {
+			t.Errorf("slot %d = %+v, want cpus %v", i, s, want[i])
+		}
+	}
+
+	// Without CPU ids the machine is split by GOMAXPROCS alone.
+	slots = partitionCPUs(nil, 8, 3)
+	if len(slots) != 3 || slots[0].procs != 3 || slots[2].procs != 2 || slots[0].cpus != nil {
+		t.Fatalf("GOMAXPROCS-only slots=%+v", slots)
+	}
+
+	// Slots never share a CPU, so fewer CPUs than lanes means fewer lanes.
+	if slots = partitionCPUs([]int{0, 1}, 0, 4); len(slots) != 2 {
+		t.Fatalf("two CPUs should give two slots, got %+v", slots)
+	}
+}
+
+func TestFormatCPUList(t *testing.T) {
+	t.Parallel()
+	cases := map[string][]int{
+		"0":       {0},
+		"0-3":     {0, 1, 2, 3},
+		"0-1,4,6": {0, 1, 4, 6},
+		"2,5-7":   {2, 5, 6, 7},
+	}
+	for want, cpus := range cases {
+		if got := formatCPUList(cpus); got != want {
+			t.Errorf("formatCPUList(%v) = %q, want %q", cpus, got, want)
+		}
+	}
+}
+
+func TestCPUSlotApply(t *testing.T) {
+	t.Parallel()
+	cmd := []string{"go", "test"}
+	env := []string{"GOMAXPROCS=8"}
+
+	var unpinned *cpuSlot
+	if gotCmd, gotEnv := unpinned.apply(cmd, env); !slices.Equal(gotCmd, cmd) || !slices.Equal(gotEnv, env) {
+		t.Fatalf("nil slot changed the run: %v %v", gotCmd, gotEnv)
+	}
+
+	slot := &cpuSlot{cpus: []int{2, 3}, procs: 2}
+	gotCmd, gotEnv := slot.apply(cmd, env)
+	if !slices.Equal(gotCmd, []string{"taskset", "-c", "2-3", "go", "test"}) {
+		t.Fatalf("cmd=%v", gotCmd)
+	}
+	// The env matrix value comes last, so it overrides the slot's GOMAXPROCS.
+	if !slices.Equal(gotEnv, []string{"GOMAXPROCS=2", "GOMAXPROCS=8"}) {
+		t.Fatalf("env=%v", gotEnv)
+	}
+
+	gotCmd, _ = (&cpuSlot{procs: 4}).apply(cmd, nil)
+	if !slices.Equal(gotCmd, cmd) {
+		t.Fatalf("slot without CPU ids should not use taskset: %v", gotCmd)
+	}
+}
+
+func TestBenchmarkGroups(t *testing.T) {
+	t.Parallel()
+	dirs := map[string]string{
+		"./a.BenchmarkX": "/m/a",
+		"./b.BenchmarkY": "/m/b",
+		"./a.BenchmarkZ": "/m/a",
+		"BenchmarkRoot":  "/m",
+	}
+	benchmarks := []string{"./a.BenchmarkX", "./b.BenchmarkY", "./a.BenchmarkZ", "BenchmarkRoot"}
+	groups, err := benchmarkGroups(benchmarks, func(name string) (string, error) { return dirs[name], nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]int{{0, 2}, {1}, {3}}
+	if !slices.EqualFunc(groups, want, slices.Equal[[]int]) {
+		t.Fatalf("groups=%v want %v", groups, want)
+	}
+
+	lookupErr := errors.New("not found")
+	if _, err = benchmarkGroups(benchmarks, func(string) (string, error) { return "", lookupErr }); !errors.Is(err, lookupErr) {
+		t.Fatalf("err=%v", err)
+	}
+}
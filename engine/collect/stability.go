@@ -0,0 +1,75 @@
+package collect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/AlexsanderHamir/prof/internal/stats"
+	"github.com/AlexsanderHamir/prof/parser"
+)
+
+const (
+	// stabilityTopFunctions caps the hotspot stability report at the merged profile's top functions by flat.
+	stabilityTopFunctions = 20
+	// unstableSpreadPct marks a hotspot unstable when (max - min) / median of its per-run flat share exceeds it.
+	unstableSpreadPct = 50.0
+)
+
+// writeHotspotStability writes <profile>_stability.txt: the flat share of the merged profile's top
+// functions in every per-iteration binary. Benchmarks collected without --per-iteration have no
+// iteration binaries and get no report.
+func writeHotspotStability(ctx ProduceContext) error {
+	paths, err := ctx.Layout.ProfileIterations(ctx.Bench, ctx.Profile)
+	if err != nil || len(paths) < 2 {
+		return err
+	}
+	pl := parser.SampleIndexPipeline(ctx.SampleIndex)
+	merged, err := pl.RunFromPath(ctx.BinPath)
+	if err != nil {
+		return err
+	}
+	iterations := make([]*parser.ProfileData, len(paths))
+	for i, path := range paths {
+		if iterations[i], err = pl.RunFromPath(path); err != nil {
+			return fmt.Errorf("iteration %d: %w", i+1, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err = renderHotspotStability(&buf, merged, iterations); err != nil {
+		return err
+	}
+	return writeArtifactFile(ctx.Layout.HotspotStability(ctx.Bench, ctx.Profile), buf.Bytes())
+}
+
+func renderHotspotStability(w io.Writer, merged *parser.ProfileData, iterations []*parser.ProfileData) error {
+	fmt.Fprintf(w, "Hotspot stability across %d runs (flat %% of each run's total)\n", len(iterations))
+	fmt.Fprintf(w, "spread = (max - min) / median; above %.0f%% is marked unstable\n\n", unstableSpreadPct)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "merged\tmedian\tmin\tmax\tspread\t\tfunction")
+	for i, entry := range merged.SortedEntries {
+		if i == stabilityTopFunctions || entry.Flat == 0 {
+			break
+		}
+		shares := make([]float64, len(iterations))
+		for j, it := range iterations {
+			if it.Total != 0 {
+				shares[j] = it.FlatPercentages[entry.Name]
+			}
+		}
+		s := stats.Summarize(shares, stats.DefaultConfidence)
+		spread, flag := "-", "unstable" // absent from at least half the runs
+		if s.Median > 0 {
+			pct := (s.Max - s.Min) / s.Median * 100
+			spread = fmt.Sprintf("%.0f%%", pct)
+			if pct <= unstableSpreadPct {
+				flag = ""
+			}
+		}
+		fmt.Fprintf(tw, "%.2f%%\t%.2f%%\t%.2f%%\t%.2f%%\t%s\t%s\t%s\n",
+			merged.FlatPercentages[entry.Name], s.Median, s.Min, s.Max, spread, flag, entry.Name)
+	}
+	return tw.Flush()
+}
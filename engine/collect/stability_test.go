@@ -0,0 +1,127 @@
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/AlexsanderHamir/prof/parser"
+)
+
+func TestRunBenchmarkIterations_concatenatesTranscripts(t *testing.T) {
+	t.Parallel()
+	const bench = "BenchmarkFoo"
+	layout := workspace.NewTagLayout(t.TempDir(), "iter")
+	if err := os.MkdirAll(filepath.Dir(layout.Measurement(bench)), workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	runner := &tooling.FakeRunner{Out: [][]byte{
+		[]byte("BenchmarkFoo-8  1000  100 ns/op\nok  \tm\t0.5s\n"),
+		[]byte("BenchmarkFoo-8  1000  120 ns/op\nok  \tm\t0.5s\n"),
+		[]byte("BenchmarkFoo-8  1000  110 ns/op\nok  \tm\t0.5s\n"),
+	}}
+	cmd := []string{"go", "test", "-count=1"}
+	if err := runBenchmarkIterations(t.Context(), runner, builtins.catalog, layout, bench, nil, 3, cmd, t.TempDir(), t.TempDir(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 3 {
+		t.Fatalf("runs=%d want one go test per iteration", len(runner.Runs))
+	}
+	data, err := os.ReadFile(layout.Measurement(bench))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "ns/op"); got != 3 {
+		t.Fatalf("run.txt holds %d samples, want 3:\n%s", got, data)
+	}
+}
+
+func TestMergeProfileIterations_writesHotspotStability(t *testing.T) {
+	const bench = "BenchmarkFoo"
+	fixture := testpaths.MustAsset(t, "fixtures", filterFixtureCPU)
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := workspace.NewTagLayout(t.TempDir(), "iter")
+	for n := 1; n <= 3; n++ {
+		path := layout.ProfileIteration(bench, "cpu", n)
+		if err = os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, data, workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = mergeProfileIterations(layout, bench, []string{"cpu", "memory"}); err != nil {
+		t.Fatal(err)
+	}
+	single, err := parser.DefaultPipeline().RunFromPath(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := parser.DefaultPipeline().RunFromPath(layout.ProfileBinary(bench, "cpu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Total != 3*single.Total {
+		t.Fatalf("merged total=%d want %d", merged.Total, 3*single.Total)
+	}
+	if _, err = os.Stat(layout.ProfileBinary(bench, "memory")); !os.IsNotExist(err) {
+		t.Fatalf("memory had no iterations and should have no merged binary: %v", err)
+	}
+
+	err = writeHotspotStability(ProduceContext{Layout: layout, Bench: bench, Profile: "cpu", BinPath: layout.ProfileBinary(bench, "cpu")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(layout.HotspotStability(bench, "cpu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(report), "across 3 runs") || strings.Contains(string(report), "unstable\t") {
+		t.Fatalf("identical runs should be stable:\n%s", report)
+	}
+}
+
+func TestRenderHotspotStability_marksUnstable(t *testing.T) {
+	t.Parallel()
+	run := func(steady, jumpy int64) *parser.ProfileData {
+		total := steady + jumpy
+		return &parser.ProfileData{
+			Total: total,
+			FlatPercentages: map[string]float64{
+				"steady": float64(steady) / float64(total) * 100,
+				"jumpy":  float64(jumpy) / float64(total) * 100,
+			},
+		}
+	}
+	merged := &parser.ProfileData{
+		SortedEntries:   []parser.FuncEntry{{Name: "steady", Flat: 150}, {Name: "jumpy", Flat: 50}, {Name: "idle", Flat: 0}},
+		FlatPercentages: map[string]float64{"steady": 75, "jumpy": 25},
+	}
+	var b strings.Builder
+	if err := renderHotspotStability(&b, merged, []*parser.ProfileData{run(50, 50), run(50, 0), run(50, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	var steady, jumpy string
+	for _, line := range lines {
+		switch {
+		case strings.HasSuffix(line, " steady"):
+			steady = line
+		case strings.HasSuffix(line, " jumpy"):
+			jumpy = line
+		case strings.HasSuffix(line, " idle"):
+			t.Fatalf("functions without flat should be left out:\n%s", b.String())
+		}
+	}
+	if strings.Contains(steady, "unstable") || !strings.Contains(jumpy, "unstable") {
+		t.Fatalf("steady=%q jumpy=%q\n%s", steady, jumpy, b.String())
+	}
+}
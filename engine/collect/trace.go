@@ -0,0 +1,286 @@
+package collect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/termui"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// processTrace extracts the artifacts of an execution trace kind: the summary, then one pprof
+// profile per catalog trace view, each processed like any other profile. It returns the names
+// of the processed views (e.g. trace_sched).
+func processTrace(ctx context.Context, runner tooling.Runner, reg *registry, layout workspace.TagLayout, benchmarkName, kind, tracePath, renderer string, session *termui.Session) ([]string, error) {
+	if err := writeTraceSummary(ctx, runner, tracePath, layout.TraceSummary(benchmarkName, kind)); err != nil {
+		return nil, fmt.Errorf("failed to summarize trace %s: %w", kind, err)
+	}
+	var processed []string
+	for _, view := range reg.catalog.TraceViews(kind) {
+		name := workspace.TraceView(kind, view)
+		binPath := layout.ProfileBinary(benchmarkName, name)
+		if err := extractTraceView(ctx, runner, tracePath, view, binPath); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		if err := processOneProfile(ctx, runner, reg, layout, benchmarkName, name, binPath, "", renderer, session); err != nil {
+			return nil, err
+		}
+		processed = append(processed, name)
+	}
+	return processed, nil
+}
+
+// collectedTraces returns the execution trace kinds among profiles whose trace was collected.
+func collectedTraces(catalog *tooling.Catalog, layout workspace.TagLayout, benchmarkName string, profiles []string) []string {
+	var kinds []string
+	for _, profile := range profiles {
+		if catalog.Format(profile) != tooling.FormatTrace {
+			continue
+		}
+		if _, err := layout.ResolveProfileBinary(benchmarkName, profile); err == nil {
+			kinds = append(kinds, profile)
+		}
+	}
+	return kinds
+}
+
+// extractTraceView writes the go tool trace -pprof=<view> profile of tracePath to out.
+func extractTraceView(ctx context.Context, runner tooling.Runner, tracePath, view, out string) error {
+	if runner == nil {
+		return errors.New("tooling runner is nil")
+	}
+	data, err := runner.Run(ctx, tooling.TracePprofArgs(view, tracePath), tooling.RunOpts{})
+	if err != nil {
+		return fmt.Errorf("go tool trace -pprof=%s failed: %w", view, err)
+	}
+	return writeArtifactFile(out, data)
+}
+
+// writeTraceSummary streams the go tool trace -d=parsed events of tracePath through a
+// traceEvents and writes the rendered summary to out. Event dumps of long benchmarks run to
+// hundreds of megabytes, so they are parsed as they arrive instead of buffered.
+func writeTraceSummary(ctx context.Context, runner tooling.Runner, tracePath, out string) error {
+	if runner == nil {
+		return errors.New("tooling runner is nil")
+	}
+	events := &traceEvents{}
+	var stderr bytes.Buffer
+	if _, err := runner.Run(ctx, tooling.TraceEventsArgs(tracePath), tooling.RunOpts{Stdout: events, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("go tool trace -d=parsed failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	events.flush()
+	var b bytes.Buffer
+	if err := renderTraceSummary(&b, events); err != nil {
+		return err
+	}
+	return writeArtifactFile(out, b.Bytes())
+}
+
+// Range names of go tool trace -d=parsed events the summary reads.
+const (
+	traceStopTheWorld = "stop-the-world ("
+	traceGCMark       = "GC concurrent mark phase"
+)
+
+var (
+	traceTimeRe       = regexp.MustCompile(`\bTime=(\d+)`)
+	traceNameRe       = regexp.MustCompile(`\bName="([^"]*)"`)
+	traceScopeRe      = regexp.MustCompile(`\bScope=(\S+)`)
+	traceTransitionRe = regexp.MustCompile(`\bGoID=(\d+) (\w+)->(\w+)`)
+)
+
+// traceEvents accumulates GC and scheduler timings from go tool trace -d=parsed lines. Event
+// lines start with "M="; the indented stack lines between them are skipped. Times are in
+// nanoseconds.
+type traceEvents struct {
+	partial []byte
+
+	first, last int64
+	open        map[string]int64 // start time of ranges still open, by scope and name
+	runnable    map[string]int64 // time each goroutine became runnable
+
+	pauses       map[string][]time.Duration // stop-the-world ranges by reason
+	gcCycles     int
+	gcMark       time.Duration
+	schedLatency []time.Duration // runnable -> running waits
+}
+
+// Write feeds p to the parser line by line; a trailing partial line waits for the next Write.
+func (e *traceEvents) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			e.partial = append(e.partial, p...)
+			return n, nil
+		}
+		if len(e.partial) > 0 {
+			e.partial = append(e.partial, p[:i]...)
+			e.line(string(e.partial))
+			e.partial = e.partial[:0]
+		} else {
+			e.line(string(p[:i]))
+		}
+		p = p[i+1:]
+	}
+}
+
+// flush parses a last line that had no trailing newline.
+func (e *traceEvents) flush() {
+	if len(e.partial) > 0 {
+		e.line(string(e.partial))
+		e.partial = nil
+	}
+}
+
+func (e *traceEvents) line(line string) {
+	if !strings.HasPrefix(line, "M=") {
+		return
+	}
+	fields := strings.Fields(line)
+	m := traceTimeRe.FindStringSubmatch(line)
+	if len(fields) < 4 || m == nil {
+		return
+	}
+	t, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return
+	}
+	if e.first == 0 || t < e.first {
+		e.first = t
+	}
+	if t > e.last {
+		e.last = t
+	}
+
+	switch fields[3] {
+	case "RangeBegin", "RangeEnd":
+		name, scope := traceNameRe.FindStringSubmatch(line), traceScopeRe.FindStringSubmatch(line)
+		if name == nil || scope == nil {
+			return
+		}
+		key := scope[1] + " " + name[1]
+		if fields[3] == "RangeBegin" {
+			if e.open == nil {
+				e.open = make(map[string]int64)
+			}
+			e.open[key] = t
+			return
+		}
+		start, ok := e.open[key]
+		if !ok {
+			return // began before the trace did
+		}
+		delete(e.open, key)
+		e.endRange(name[1], time.Duration(t-start))
+	case "StateTransition":
+		tr := traceTransitionRe.FindStringSubmatch(line)
+		if tr == nil {
+			return
+		}
+		goID, from, to := tr[1], tr[2], tr[3]
+		if to == "Runnable" {
+			if e.runnable == nil {
+				e.runnable = make(map[string]int64)
+			}
+			e.runnable[goID] = t
+			return
+		}
+		if start, ok := e.runnable[goID]; ok && from == "Runnable" {
+			delete(e.runnable, goID)
+			if to == "Running" {
+				e.schedLatency = append(e.schedLatency, time.Duration(t-start))
+			}
+		}
+	}
+}
+
+func (e *traceEvents) endRange(name string, d time.Duration) {
+	switch {
+	case strings.HasPrefix(name, traceStopTheWorld):
+		if e.pauses == nil {
+			e.pauses = make(map[string][]time.Duration)
+		}
+		reason := strings.TrimSuffix(strings.TrimPrefix(name, traceStopTheWorld), ")")
+		e.pauses[reason] = append(e.pauses[reason], d)
+	case name == traceGCMark:
+		e.gcCycles++
+		e.gcMark += d
+	}
+}
+
+func renderTraceSummary(w io.Writer, e *traceEvents) error {
+	fmt.Fprintf(w, "Execution trace summary (%s traced)\n\n", formatTraceDuration(time.Duration(e.last-e.first)))
+	fmt.Fprintf(w, "GC: %d cycles, %s in concurrent mark\n\n", e.gcCycles, formatTraceDuration(e.gcMark))
+
+	fmt.Fprintln(w, "Stop-the-world pauses")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  reason\tcount\ttotal\tp50\tp99\tmax\t")
+	reasons := make([]string, 0, len(e.pauses))
+	var all []time.Duration
+	for reason, ds := range e.pauses {
+		reasons = append(reasons, reason)
+		all = append(all, ds...)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(tw, "  %s\t%s\n", reason, durationStatsRow(e.pauses[reason]))
+	}
+	fmt.Fprintf(tw, "  all\t%s\n", durationStatsRow(all))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nScheduler latency (runnable -> running)")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  \tcount\ttotal\tp50\tp99\tmax\t")
+	fmt.Fprintf(tw, "  goroutines\t%s\n", durationStatsRow(e.schedLatency))
+	return tw.Flush()
+}
+
+// durationStatsRow returns the count, total, p50, p99 and max cells of ds.
+func durationStatsRow(ds []time.Duration) string {
+	if len(ds) == 0 {
+		return "0\t-\t-\t-\t-\t"
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return fmt.Sprintf("%d\t%s\t%s\t%s\t%s\t", len(sorted), formatTraceDuration(total),
+		formatTraceDuration(durationPercentile(sorted, 50)), formatTraceDuration(durationPercentile(sorted, 99)),
+		formatTraceDuration(sorted[len(sorted)-1]))
+}
+
+// durationPercentile returns the nearest-rank pct percentile of sorted.
+func durationPercentile(sorted []time.Duration, pct int) time.Duration {
+	rank := (pct*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// formatTraceDuration keeps three significant digits or so: 412ns, 12.3µs, 1.25ms.
+func formatTraceDuration(d time.Duration) string {
+	switch {
+	case d < time.Microsecond:
+		return d.String()
+	case d < time.Millisecond:
+		return d.Round(100 * time.Nanosecond).String()
+	case d < time.Second:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
@@ -0,0 +1,128 @@
+package collect
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+)
+
+const parsedTraceEvents = `M=-1 P=-1 G=-1 Sync Time=1000 N=1 Trace=1000 Mono=1000 Wall=2026-01-01T00:00:00Z
+M=1 P=0 G=1 RangeBegin Time=2000 Name="stop-the-world (GC sweep termination)" Scope=Goroutine(1)
+Stack=
+	runtime.gcStart @ 0x1
+		/usr/local/go/src/runtime/mgc.go:1
+M=1 P=0 G=1 RangeEnd Time=2500 Name="stop-the-world (GC sweep termination)" Scope=Goroutine(1) Attributes=[]
+M=1 P=0 G=1 RangeBegin Time=2600 Name="GC concurrent mark phase" Scope=Global(0)
+M=1 P=0 G=1 StateTransition Time=3000 GoID=7 Waiting->Runnable Reason=""
+M=1 P=0 G=-1 StateTransition Time=3400 GoID=7 Runnable->Running Reason=""
+M=1 P=0 G=1 RangeEnd Time=9600 Name="GC concurrent mark phase" Scope=Global(0) Attributes=[]
+M=1 P=0 G=1 RangeBegin Time=10000 Name="stop-the-world (GC mark termination)" Scope=Goroutine(1)
+M=1 P=0 G=1 RangeEnd Time=11000 Name="stop-the-world (GC mark termination)" Scope=Goroutine(1) Attributes=[]
+M=1 P=0 G=1 StateTransition Time=11000 GoID=8 NotExist->Runnable Reason=""
+M=1 P=0 G=-1 StateTransition Time=12000 GoID=8 Runnable->Running Reason=""`
+
+func TestTraceEvents_summary(t *testing.T) {
+	t.Parallel()
+	var e traceEvents
+	// Split mid-line: Write must carry the partial line over.
+	half := len(parsedTraceEvents) / 2
+	for _, chunk := range []string{parsedTraceEvents[:half], parsedTraceEvents[half:]} {
+		if _, err := e.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.flush()
+
+	if e.gcCycles != 1 || e.gcMark != 7*time.Microsecond {
+		t.Fatalf("gc cycles=%d mark=%s", e.gcCycles, e.gcMark)
+	}
+	if got := e.pauses["GC sweep termination"]; !slices.Equal(got, []time.Duration{500}) {
+		t.Fatalf("sweep termination pauses=%v", got)
+	}
+	if got := e.pauses["GC mark termination"]; !slices.Equal(got, []time.Duration{time.Microsecond}) {
+		t.Fatalf("mark termination pauses=%v", got)
+	}
+	if !slices.Equal(e.schedLatency, []time.Duration{400, time.Microsecond}) {
+		t.Fatalf("sched latency=%v", e.schedLatency)
+	}
+
+	var b strings.Builder
+	if err := renderTraceSummary(&b, &e); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(11µs traced)", "GC: 1 cycles, 7µs in concurrent mark", "GC mark termination   1", "all                   2", "goroutines  2"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestDurationPercentile(t *testing.T) {
+	t.Parallel()
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := durationPercentile(sorted, 50); got != 5 {
+		t.Fatalf("p50=%d", got)
+	}
+	if got := durationPercentile(sorted, 99); got != 10 {
+		t.Fatalf("p99=%d", got)
+	}
+	if got := durationPercentile(sorted[:1], 50); got != 1 {
+		t.Fatalf("p50 of one=%d", got)
+	}
+}
+
+func TestProcessProfiles_trace(t *testing.T) {
+	const (
+		tag   = "tr"
+		bench = "BenchmarkFoo"
+	)
+	layout, fixture := setupProcessProfilesEnv(t, tag, []string{"trace"})
+	copyFixtureToProfile(t, layout, bench, "trace", fixture) // contents only reach the fake runner
+	view, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The events dump, then per view: its -pprof output and a failing best-effort PNG.
+	pngErr := errors.New("graphviz unavailable")
+	runner := &tooling.FakeRunner{
+		Out: [][]byte{[]byte(parsedTraceEvents), view, nil, view, nil, view, nil, view, nil},
+		Err: []error{nil, nil, pngErr, nil, pngErr, nil, pngErr, nil, pngErr},
+	}
+	processed, err := processProfiles(t.Context(), runner, builtins, bench, []string{"trace"}, "", tag, nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"trace_net", "trace_sync", "trace_syscall", "trace_sched"}; !slices.Equal(processed, want) {
+		t.Fatalf("processed=%v want %v", processed, want)
+	}
+
+	tracePath := layout.ProfileBinary(bench, "trace")
+	if got := runner.Runs[0].Argv; !slices.Equal(got, tooling.TraceEventsArgs(tracePath)) {
+		t.Fatalf("first run=%v", got)
+	}
+	if got := runner.Runs[7].Argv; !slices.Equal(got, tooling.TracePprofArgs("sched", tracePath)) {
+		t.Fatalf("sched run=%v", got)
+	}
+	summary, err := os.ReadFile(layout.TraceSummary(bench, "trace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(summary), "GC: 1 cycles") {
+		t.Fatalf("summary:\n%s", summary)
+	}
+	if _, err = os.Stat(layout.Hotspot(bench, "trace_sched")); err != nil {
+		t.Fatalf("expected trace_sched hotspots: %v", err)
+	}
+	if _, err = os.Stat(layout.SourceLinesDir("trace", bench)); !os.IsNotExist(err) {
+		t.Fatalf("trace itself has no source_lines directory: %v", err)
+	}
+	if got := collectedTraces(builtins.catalog, layout, bench, []string{"cpu", "trace"}); !slices.Equal(got, []string{"trace"}) {
+		t.Fatalf("collected traces=%v", got)
+	}
+}
@@ -5,25 +5,48 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
 )
 
-// walkTestGoFiles walks root like scanForBenchmarks / findBenchmarkPackageDir: skip hidden dirs and vendor,
-// then invokes fn for each *_test.go file body.
-func walkTestGoFiles(root, moduleRoot string, fn func(path string, data []byte) error) error {
+// walkPackageDirs calls fn for root and every directory below it that the go tool would
+// treat as a package of the project rooted at moduleRoot: hidden, "_"-prefixed, testdata,
+// and vendor directories are skipped, as are nested modules that its go.work does not use.
+// Directories outside every module of the project (a go.work root without go.mod) are
+// walked but not passed to fn.
+func walkPackageDirs(root, moduleRoot string, fn func(dir string) error) error {
+	project, err := workspace.LoadProject(moduleRoot)
+	if err != nil {
+		return err
+	}
 	return filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
-		if d.IsDir() {
-			return handleDirectory(path, moduleRoot)
-		}
-		if !strings.HasSuffix(path, "_test.go") {
+		if !d.IsDir() {
 			return nil
 		}
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
+		if path != root {
+			if err := handleDirectory(path, project); err != nil {
+				return err
+			}
+		}
+		if _, ok := project.ModuleFor(path); !ok {
+			return nil
 		}
-		return fn(path, data)
+		return fn(path)
 	})
 }
+
+func handleDirectory(path string, project workspace.Project) error {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") || base == "testdata" || base == "vendor" {
+		return filepath.SkipDir
+	}
+	if path != project.Root && !project.IsModuleDir(path) {
+		if _, err := os.Stat(filepath.Join(path, workspace.GoModFile)); err == nil {
+			return filepath.SkipDir
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Run diffs the base and head tags under the current module's .prof/, writes compare.json and
+// go tool pprof -diff_base artifacts under the comparison directory, and prints a terminal summary to w.
+// Canceling ctx stops the pprof subprocess in flight.
+func Run(ctx context.Context, runner tooling.Runner, opts Options, w io.Writer) error {
+	outputDir, base, head, err := resolveTags(opts.Out, opts.Base, opts.Head)
+	if err != nil {
+		return err
+	}
+
+	// prof.json is optional here; without it only the built-in profile kinds are known.
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = nil
+	}
+	catalog, err := tooling.ConfiguredCatalog(cfg)
+	if err != nil {
+		return err
+	}
+	report, err := Build(catalog, base, head)
+	if err != nil {
+		return err
+	}
+
+	out := workspace.ComparisonLayoutIn(outputDir, opts.Base, opts.Head)
+	if err = os.RemoveAll(out.Root); err != nil {
+		return fmt.Errorf("clean comparison dir: %w", err)
+	}
+	if err = emitAllDiffArtifacts(ctx, runner, &report, base, head, out); err != nil {
+		return err
+	}
+	if err = WriteJSON(out.Report(), report); err != nil {
+		return err
+	}
+	top := opts.Top
+	if top <= 0 {
+		top = DefaultTop
+	}
+	if err = WriteText(w, report, top); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nDiff reports (go tool pprof -diff_base): %s\n", out.Root)
+	slog.Info("Wrote comparison report", "path", out.Report())
+	return nil
+}
+
+// resolveTags validates the tag pair and returns the directory holding the tags (see
+// workspace.ResolveOutputDir) with both existing tag layouts.
+func resolveTags(out, baseTag, headTag string) (string, workspace.TagLayout, workspace.TagLayout, error) {
+	var base, head workspace.TagLayout
+	if baseTag == "" || headTag == "" {
+		return "", base, head, errors.New("both base and head tags are required")
+	}
+	if baseTag == headTag {
+		return "", base, head, errors.New("base and head tags must differ")
+	}
+	moduleRoot, err := workspace.FindModuleRoot()
+	if err != nil {
+		return "", base, head, fmt.Errorf("failed to locate module root: %w", err)
+	}
+	outputDir := workspace.ResolveOutputDir(moduleRoot, out)
+	base = workspace.TagLayoutIn(outputDir, baseTag)
+	head = workspace.TagLayoutIn(outputDir, headTag)
+	for _, l := range []workspace.TagLayout{base, head} {
+		if !l.Exists() {
+			return "", base, head, fmt.Errorf("tag %q not found at %s", l.Tag, l.Root)
+		}
+		warnIfIncomplete(l)
+	}
+	warnIfFingerprintsDiffer(base, head)
+	return outputDir, base, head, nil
+}
+
+// warnIfIncomplete logs when the last collect into l did not finish. Tags without status.json
+// predate it and are assumed complete.
+func warnIfIncomplete(l workspace.TagLayout) {
+	status, err := datamap.ReadTagStatus(l.Status())
+	if err != nil || status.State == datamap.TagStateComplete {
+		return
+	}
+	slog.Warn("Tag is incomplete; its missing benchmarks are reported as present in one tag only",
+		"tag", l.Tag, "state", status.State, "reason", status.Reason, "pending", status.Pending)
+}
+
+// warnIfFingerprintsDiffer logs when base and head were collected with a different toolchain,
+// machine or runtime environment, so their deltas are not only the code's. Tags without
+// manifest.json predate it and are not checked.
+func warnIfFingerprintsDiffer(base, head workspace.TagLayout) {
+	baseManifest, err := datamap.ReadManifest(base.Manifest())
+	if err != nil {
+		return
+	}
+	headManifest, err := datamap.ReadManifest(head.Manifest())
+	if err != nil {
+		return
+	}
+	if diffs := baseManifest.Fingerprint.Diff(headManifest.Fingerprint); len(diffs) > 0 {
+		slog.Warn("Tags were collected in different environments; deltas may not come from the code alone",
+			"base", base.Tag, "head", head.Tag, "differences", diffs)
+	}
+}
+
+// Build walks both tag layouts and computes measurement and per-function deltas for every benchmark.
+// catalog tells execution traces, which are not pprof, from the profiles it diffs.
+func Build(catalog *tooling.Catalog, base, head workspace.TagLayout) (Report, error) {
+	baseBenches, err := base.BenchmarkNames()
+	if err != nil {
+		return Report{}, err
+	}
+	headBenches, err := head.BenchmarkNames()
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		SchemaVersion: ReportSchemaVersion,
+		Base:          base.Tag,
+		Head:          head.Tag,
+	}
+	for _, dir := range unionSorted(baseBenches, headBenches) {
+		bd, benchErr := compareBenchmark(catalog, base, head, dir, presence(baseBenches, headBenches, dir))
+		if benchErr != nil {
+			return Report{}, fmt.Errorf("benchmark %s: %w", bd.Name, benchErr)
+		}
+		report.Benchmarks = append(report.Benchmarks, bd)
+	}
+	return report, nil
+}
+
+// benchmarkName returns the benchmark identity recorded in the map.json of dir, read from the
+// first layout that has one. Tags without map.json fall back to the directory name.
+func benchmarkName(dir string, layouts ...workspace.TagLayout) string {
+	for _, l := range layouts {
+		if m, err := datamap.ReadJSON(l.DataMapping(dir)); err == nil && m.Benchmark != "" {
+			return m.Benchmark
+		}
+	}
+	return dir
+}
+
+func compareBenchmark(catalog *tooling.Catalog, base, head workspace.TagLayout, dir, where string) (BenchmarkDelta, error) {
+	bd := BenchmarkDelta{Name: benchmarkName(dir, head, base), Dir: dir, Presence: where}
+	if where != PresenceBoth {
+		return bd, nil
+	}
+	bd.Metrics = compareMeasurements(base.Measurement(dir), head.Measurement(dir))
+
+	baseKinds, err := base.ProfileKinds(dir)
+	if err != nil {
+		return bd, err
+	}
+	headKinds, err := head.ProfileKinds(dir)
+	if err != nil {
+		return bd, err
+	}
+	for _, profile := range unionSorted(baseKinds, headKinds) {
+		if catalog.Format(profile) == tooling.FormatTrace {
+			continue // not pprof; its trace views are compared instead
+		}
+		pd := ProfileDelta{Profile: profile, Presence: presence(baseKinds, headKinds, profile)}
+		if pd.Presence == PresenceBoth {
+			compareProfile(&pd, base.ProfileBinary(dir, profile), head.ProfileBinary(dir, profile))
+		}
+		bd.Profiles = append(bd.Profiles, pd)
+	}
+	return bd, nil
+}
+
+// WriteJSON encodes r to path with standard permissions.
+func WriteJSON(path string, r Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal comparison report: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+		return fmt.Errorf("mkdir comparison dir: %w", err)
+	}
+	if err = os.WriteFile(path, data, workspace.PermFile); err != nil {
+		return fmt.Errorf("write comparison report: %w", err)
+	}
+	return nil
+}
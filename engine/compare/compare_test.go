@@ -0,0 +1,300 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+const (
+	testBench = "BenchmarkFoo"
+	runTxtA   = "BenchmarkFoo-8   1000   1990 ns/op   100 B/op   4 allocs/op\n" +
+		"BenchmarkFoo-8   1000   2000 ns/op   100 B/op   4 allocs/op\n" +
+		"BenchmarkFoo-8   1000   2010 ns/op   100 B/op   4 allocs/op\n" +
+		"BenchmarkFoo-8   1000   2005 ns/op   100 B/op   4 allocs/op\n" +
+		"BenchmarkFoo-8   1000   1995 ns/op   100 B/op   4 allocs/op\nPASS\n"
+	runTxtB = "BenchmarkFoo-8   1000   1490 ns/op   100 B/op   2 allocs/op\n" +
+		"BenchmarkFoo-8   1000   1500 ns/op   100 B/op   2 allocs/op\n" +
+		"BenchmarkFoo-8   1000   1510 ns/op   100 B/op   2 allocs/op\n" +
+		"BenchmarkFoo-8   1000   1505 ns/op   100 B/op   2 allocs/op\n" +
+		"BenchmarkFoo-8   1000   1495 ns/op   100 B/op   2 allocs/op\nPASS\n"
+)
+
+func writeTagFixture(t *testing.T, l workspace.TagLayout, bench, runTxt, cpuFixture string) {
+	t.Helper()
+	data, err := os.ReadFile(cpuFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string][]byte{
+		l.Measurement(bench):          []byte(runTxt),
+		l.ProfileBinary(bench, "cpu"): data,
+	} {
+		if err = os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, content, workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuild_measurementAndFunctionDeltas(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	base := workspace.NewTagLayout(root, "base")
+	head := workspace.NewTagLayout(root, "head")
+	writeTagFixture(t, base, testBench, runTxtA, testpaths.MustAsset(t, "cpu.out"))
+	writeTagFixture(t, head, testBench, runTxtB, testpaths.MustAsset(t, "fixtures", "BenchmarkStringProcessor_cpu.out"))
+
+	r, err := Build(tooling.DefaultCatalog(), base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Benchmarks) != 1 || r.Benchmarks[0].Presence != PresenceBoth {
+		t.Fatalf("benchmarks=%+v", r.Benchmarks)
+	}
+	bd := r.Benchmarks[0]
+	if len(bd.Metrics) != 3 {
+		t.Fatalf("metrics=%+v", bd.Metrics)
+	}
+	ns := bd.Metrics[0]
+	if ns.Metric != MetricNsPerOp || ns.Base != 2000 || ns.Head != 1500 || ns.DeltaPct != -25 {
+		t.Fatalf("ns/op delta=%+v", ns)
+	}
+	if !ns.Significant || ns.BaseSamples != 5 || ns.HeadSamples != 5 || ns.PValue >= 0.05 {
+		t.Fatalf("ns/op significance=%+v", ns)
+	}
+	if bpo := bd.Metrics[1]; bpo.Significant || bpo.PValue != 1 {
+		t.Fatalf("unchanged B/op reported as significant: %+v", bpo)
+	}
+	if allocs := bd.Metrics[2]; allocs.DeltaPct != -50 || !allocs.Significant {
+		t.Fatalf("allocs/op delta=%+v", allocs)
+	}
+	if len(bd.Profiles) != 1 || bd.Profiles[0].Profile != "cpu" {
+		t.Fatalf("profiles=%+v", bd.Profiles)
+	}
+	fns := bd.Profiles[0].Functions
+	if len(fns) == 0 {
+		t.Fatal("expected function deltas between different fixtures")
+	}
+	for i := 1; i < len(fns); i++ {
+		if abs64(fns[i-1].FlatDelta) < abs64(fns[i].FlatDelta) {
+			t.Fatalf("functions not sorted by |flat delta| at %d", i)
+		}
+	}
+}
+
+func TestBuild_pairsMeasurementsByResultName(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	base := workspace.NewTagLayout(root, "base")
+	head := workspace.NewTagLayout(root, "head")
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	writeTagFixture(t, base, testBench, "BenchmarkFoo-1   1000   100 ns/op\nBenchmarkFoo-4   1000   1000 ns/op\nPASS\n", cpu)
+	writeTagFixture(t, head, testBench, "BenchmarkFoo-1   1000   110 ns/op\nBenchmarkFoo-4   1000   500 ns/op\nPASS\n", cpu)
+
+	r, err := Build(tooling.DefaultCatalog(), base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := r.Benchmarks[0].Metrics
+	if len(ms) != 2 {
+		t.Fatalf("metrics=%+v", ms)
+	}
+	if ms[0].Result != "BenchmarkFoo-1" || ms[0].DeltaPct != 10 || ms[1].Result != "BenchmarkFoo-4" || ms[1].DeltaPct != -50 {
+		t.Fatalf("per-result deltas=%+v", ms)
+	}
+}
+
+func TestBuild_namesBenchmarksAsCollected(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	base := workspace.NewTagLayout(root, "base")
+	head := workspace.NewTagLayout(root, "head")
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	for _, bench := range []string{"BenchmarkSub/big", "./inner.BenchmarkFoo"} {
+		for _, l := range []workspace.TagLayout{base, head} {
+			writeTagFixture(t, l, bench, runTxtA, cpu)
+		}
+		if err := datamap.WriteJSON(head.DataMapping(bench), datamap.BenchmarkMap{Benchmark: bench}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Build(tooling.DefaultCatalog(), base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, bd := range r.Benchmarks {
+		got[bd.Name] = bd.Dir
+	}
+	if got["BenchmarkSub/big"] != "BenchmarkSub__big" || got["./inner.BenchmarkFoo"] != "inner.BenchmarkFoo" || len(got) != 2 {
+		t.Fatalf("name → dir=%v", got)
+	}
+	var out bytes.Buffer
+	if err = WriteText(&out, r, 5); err != nil {
+		t.Fatal(err)
+	}
+	if text := out.String(); !strings.Contains(text, "\nBenchmarkSub/big\n") || strings.Contains(text, "BenchmarkSub__big") {
+		t.Fatalf("text report:\n%s", text)
+	}
+}
+
+func TestBuild_presenceOnlyInOneTag(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	base := workspace.NewTagLayout(root, "base")
+	head := workspace.NewTagLayout(root, "head")
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	writeTagFixture(t, base, testBench, runTxtA, cpu)
+	writeTagFixture(t, head, "BenchmarkBar", runTxtB, cpu)
+
+	r, err := Build(tooling.DefaultCatalog(), base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, bd := range r.Benchmarks {
+		got[bd.Name] = bd.Presence
+	}
+	if got[testBench] != PresenceBaseOnly || got["BenchmarkBar"] != PresenceHeadOnly {
+		t.Fatalf("presence=%v", got)
+	}
+}
+
+func TestBuild_skipsExecutionTraces(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	base := workspace.NewTagLayout(root, "base")
+	head := workspace.NewTagLayout(root, "head")
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	for _, l := range []workspace.TagLayout{base, head} {
+		writeTagFixture(t, l, testBench, runTxtA, cpu)
+		if err := os.WriteFile(l.ProfileBinary(testBench, "trace"), []byte("go 1.23 trace"), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Build(tooling.DefaultCatalog(), base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profiles := r.Benchmarks[0].Profiles; len(profiles) != 1 || profiles[0].Profile != "cpu" {
+		t.Fatalf("profiles=%+v, want the trace skipped", profiles)
+	}
+}
+
+func TestRun_writesReportAndText(t *testing.T) {
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module cmp\n\ngo 1.24.3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	writeTagFixture(t, workspace.NewTagLayout(root, "a"), testBench, runTxtA, cpu)
+	writeTagFixture(t, workspace.NewTagLayout(root, "b"), testBench, runTxtB, cpu)
+
+	withGraphviz(t, false)
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("diff top\n"), []byte("diff tree\n")}}
+	var out bytes.Buffer
+	if err := Run(t.Context(), runner, Options{Base: "a", Head: "b"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{testBench, MetricNsPerOp, "-25.00%", "no function-level changes"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("output missing %q:\n%s", want, text)
+		}
+	}
+
+	data, err := os.ReadFile(workspace.NewComparisonLayout(root, "a", "b").Report())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Report
+	if err = json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.SchemaVersion != ReportSchemaVersion || r.Base != "a" || r.Head != "b" {
+		t.Fatalf("report=%+v", r)
+	}
+	diff := r.Benchmarks[0].Profiles[0].Diff
+	if diff == nil || diff.Hotspots != "hotspots/BenchmarkFoo/cpu.txt" || diff.CallGraph != "" {
+		t.Fatalf("diff=%+v", diff)
+	}
+}
+
+// ctxRunner fails every run with the error of its context, like an exec'd process that was
+// killed on cancellation.
+type ctxRunner struct{}
+
+func (ctxRunner) Run(ctx context.Context, _ []string, _ tooling.RunOpts) ([]byte, error) {
+	return nil, ctx.Err()
+}
+
+func TestRun_canceledStopsDiffReports(t *testing.T) {
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module cmp\n\ngo 1.24.3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	writeTagFixture(t, workspace.NewTagLayout(root, "a"), testBench, runTxtA, cpu)
+	writeTagFixture(t, workspace.NewTagLayout(root, "b"), testBench, runTxtB, cpu)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	var out bytes.Buffer
+	if err := Run(ctx, ctxRunner{}, Options{Base: "a", Head: "b"}, &out); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestWriteText_noiseShownAsTilde(t *testing.T) {
+	t.Parallel()
+	r := Report{Base: "a", Head: "b", Benchmarks: []BenchmarkDelta{{
+		Name:     testBench,
+		Presence: PresenceBoth,
+		Metrics: []MetricDelta{
+			newMetricDelta(MetricNsPerOp, []float64{100}, []float64{140}),
+		},
+	}}}
+	var out bytes.Buffer
+	if err := WriteText(&out, r, DefaultTop); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "~ (p=1.000 n=1+1)") || strings.Contains(text, "+40.00%") {
+		t.Fatalf("single-sample delta should be reported as noise:\n%s", text)
+	}
+}
+
+func TestRun_validation(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module cmp\n\ngo 1.24.3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	var out bytes.Buffer
+	if err := Run(t.Context(), &tooling.FakeRunner{}, Options{Base: "a"}, &out); err == nil {
+		t.Fatal("expected error for missing head")
+	}
+	if err := Run(t.Context(), &tooling.FakeRunner{}, Options{Base: "a", Head: "a"}, &out); err == nil {
+		t.Fatal("expected error for identical tags")
+	}
+	if err := Run(t.Context(), &tooling.FakeRunner{}, Options{Base: "a", Head: "b"}, &out); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected missing tag error, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+const (
+	artifactDiffHotspots     = "diff_hotspots"
+	artifactDiffCallTreeText = "diff_call_tree_text"
+	artifactDiffCallGraphPNG = "diff_call_graph_png"
+)
+
+// DiffContext carries inputs for one diff artifact producer.
+type DiffContext struct {
+	Context  context.Context // cancels the go tool pprof subprocesses
+	Runner   tooling.Runner
+	Layout   workspace.ComparisonLayout
+	Bench    string
+	Profile  string
+	BasePath string
+	HeadPath string
+	Graphviz bool
+}
+
+// DiffArtifact describes one go tool pprof -diff_base output for a (benchmark, profile) pair.
+type DiffArtifact struct {
+	ID      string
+	Policy  tooling.FailurePolicy
+	Path    func(DiffContext) string
+	Argv    func(DiffContext) []string
+	Record  func(d *DiffArtifacts, rel string)
+	Enabled func(DiffContext) bool // nil means always
+}
+
+func diffArtifacts() []DiffArtifact {
+	return []DiffArtifact{
+		{
+			ID:     artifactDiffHotspots,
+			Policy: tooling.Required,
+			Path:   func(ctx DiffContext) string { return ctx.Layout.Hotspot(ctx.Bench, ctx.Profile) },
+			Argv: func(ctx DiffContext) []string {
+				return tooling.PprofDiffTextReportArgs("top", ctx.BasePath, ctx.HeadPath)
+			},
+			Record: func(d *DiffArtifacts, rel string) { d.Hotspots = rel },
+		},
+		{
+			ID:     artifactDiffCallTreeText,
+			Policy: tooling.Required,
+			Path:   func(ctx DiffContext) string { return ctx.Layout.CallTreeText(ctx.Bench, ctx.Profile) },
+			Argv: func(ctx DiffContext) []string {
+				return tooling.PprofDiffTextReportArgs("tree", ctx.BasePath, ctx.HeadPath)
+			},
+			Record: func(d *DiffArtifacts, rel string) { d.CallTree = rel },
+		},
+		{
+			ID:      artifactDiffCallGraphPNG,
+			Policy:  tooling.BestEffort,
+			Path:    func(ctx DiffContext) string { return ctx.Layout.CallGraph(ctx.Profile, ctx.Bench) },
+			Argv:    func(ctx DiffContext) []string { return tooling.PprofDiffPNGArgs(ctx.BasePath, ctx.HeadPath) },
+			Record:  func(d *DiffArtifacts, rel string) { d.CallGraph = rel },
+			Enabled: func(ctx DiffContext) bool { return ctx.Graphviz },
+		},
+	}
+}
+
+// emitDiffArtifacts writes every diff artifact for one profile pair and records their
+// comparison-relative paths on pd. Best-effort failures are logged and left out of pd.Diff.
+func emitDiffArtifacts(ctx DiffContext, pd *ProfileDelta) error {
+	if ctx.Runner == nil {
+		return errors.New("tooling runner is nil")
+	}
+	diff := &DiffArtifacts{}
+	for _, art := range diffArtifacts() {
+		if art.Enabled != nil && !art.Enabled(ctx) {
+			continue
+		}
+		path := art.Path(ctx)
+		if err := runDiffReport(ctx.Context, ctx.Runner, art.Argv(ctx), path); err != nil {
+			if art.Policy == tooling.BestEffort {
+				slog.Warn("Diff artifact skipped", "artifact", art.ID, "profile", ctx.Profile, "benchmark", ctx.Bench, "err", err)
+				continue
+			}
+			return fmt.Errorf("%s: %w", art.ID, err)
+		}
+		rel, err := ctx.Layout.RelFromLayout(path)
+		if err != nil {
+			return err
+		}
+		art.Record(diff, rel)
+	}
+	pd.Diff = diff
+	return nil
+}
+
+// emitAllDiffArtifacts produces diff artifacts for every profile present in both tags.
+func emitAllDiffArtifacts(ctx context.Context, runner tooling.Runner, r *Report, base, head workspace.TagLayout, out workspace.ComparisonLayout) error {
+	graphviz := tooling.GraphvizAvailable()
+	if !graphviz {
+		slog.Info(tooling.SkipPNGNotice)
+	}
+	for i := range r.Benchmarks {
+		bd := &r.Benchmarks[i]
+		for j := range bd.Profiles {
+			pd := &bd.Profiles[j]
+			if pd.Presence != PresenceBoth || pd.Error != "" {
+				continue
+			}
+			dctx := DiffContext{
+				Context:  ctx,
+				Runner:   runner,
+				Layout:   out,
+				Bench:    bd.dir(),
+				Profile:  pd.Profile,
+				BasePath: base.ProfileBinary(bd.dir(), pd.Profile),
+				HeadPath: head.ProfileBinary(bd.dir(), pd.Profile),
+				Graphviz: graphviz,
+			}
+			if err := emitDiffArtifacts(dctx, pd); err != nil {
+				return fmt.Errorf("benchmark %s profile %s: %w", bd.Name, pd.Profile, err)
+			}
+		}
+	}
+	return nil
+}
+
+func runDiffReport(ctx context.Context, runner tooling.Runner, argv []string, outputFile string) error {
+	out, err := runner.Run(ctx, argv, tooling.RunOpts{})
+	if err != nil {
+		return fmt.Errorf("pprof diff command failed: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(outputFile), workspace.PermDir); err != nil {
+		return fmt.Errorf("mkdir artifact parent: %w", err)
+	}
+	return os.WriteFile(outputFile, out, workspace.PermFile)
+}
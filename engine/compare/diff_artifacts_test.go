@@ -0,0 +1,85 @@
+package compare
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func withGraphviz(t *testing.T, available bool) {
+	t.Helper()
+	orig := tooling.LookPathForTests
+	t.Cleanup(func() { tooling.LookPathForTests = orig })
+	tooling.LookPathForTests = func(name string) (string, error) {
+		if available {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func diffTestContext(t *testing.T, runner tooling.Runner, graphviz bool) DiffContext {
+	t.Helper()
+	root := t.TempDir()
+	return DiffContext{
+		Runner:   runner,
+		Layout:   workspace.NewComparisonLayout(root, "a", "b"),
+		Bench:    testBench,
+		Profile:  "cpu",
+		BasePath: filepath.Join(root, "a.out"),
+		HeadPath: filepath.Join(root, "b.out"),
+		Graphviz: graphviz,
+	}
+}
+
+func TestEmitDiffArtifacts_writesAllArtifacts(t *testing.T) {
+	t.Parallel()
+	runner := &tooling.FakeRunner{Out: [][]byte{[]byte("top"), []byte("tree"), []byte("png")}}
+	ctx := diffTestContext(t, runner, true)
+	var pd ProfileDelta
+	if err := emitDiffArtifacts(ctx, &pd); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 3 {
+		t.Fatalf("runs=%d", len(runner.Runs))
+	}
+	if want := tooling.PprofDiffTextReportArgs("top", ctx.BasePath, ctx.HeadPath); !slices.Equal(runner.Runs[0].Argv, want) {
+		t.Fatalf("argv=%v want %v", runner.Runs[0].Argv, want)
+	}
+	if pd.Diff == nil || pd.Diff.CallGraph != "call_graphs/cpu/BenchmarkFoo/cpu.png" {
+		t.Fatalf("diff=%+v", pd.Diff)
+	}
+	data, err := os.ReadFile(ctx.Layout.CallTreeText(testBench, "cpu"))
+	if err != nil || string(data) != "tree" {
+		t.Fatalf("call tree=%q err=%v", data, err)
+	}
+}
+
+func TestEmitDiffArtifacts_pngIsBestEffort(t *testing.T) {
+	t.Parallel()
+	runner := &tooling.FakeRunner{
+		Out: [][]byte{[]byte("top"), []byte("tree"), nil},
+		Err: []error{nil, nil, errors.New("dot crashed")},
+	}
+	var pd ProfileDelta
+	if err := emitDiffArtifacts(diffTestContext(t, runner, true), &pd); err != nil {
+		t.Fatal(err)
+	}
+	if pd.Diff == nil || pd.Diff.CallTree == "" || pd.Diff.CallGraph != "" {
+		t.Fatalf("diff=%+v", pd.Diff)
+	}
+}
+
+func TestEmitDiffArtifacts_requiredFailure(t *testing.T) {
+	t.Parallel()
+	runner := &tooling.FakeRunner{Err: []error{errors.New("incompatible profiles")}}
+	var pd ProfileDelta
+	if err := emitDiffArtifacts(diffTestContext(t, runner, false), &pd); err == nil {
+		t.Fatal("expected error when diff hotspots fail")
+	}
+}
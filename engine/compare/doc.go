@@ -0,0 +1,5 @@
+// Package compare diffs two tags under .prof/ (prof compare): benchmark measurement deltas from
+// measurements/<bench>/run.txt and per-function flat/cum deltas aggregated in-process from
+// profiles/<bench>/<profile>.out. Results are written as terminal text and as compare.json under
+// internal/workspace.ComparisonLayout.
+package compare
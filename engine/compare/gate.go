@@ -0,0 +1,223 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/stats"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/AlexsanderHamir/prof/parser"
+)
+
+// ErrGateFailed is returned by Gate when at least one limit is exceeded.
+var ErrGateFailed = errors.New("regression gate failed")
+
+// Violation is one exceeded gate limit.
+type Violation struct {
+	Benchmark string
+	Result    string // go test result name of a metric check, when run.txt holds several
+	Check     string // metric unit (e.g. "ns/op") or "<profile> flat%"
+	Function  string // set for function flat% limits
+	Actual    float64
+	Limit     float64
+	Missing   bool // the value could not be read; Actual is meaningless
+	Detail    string
+}
+
+// Gate compares base and head like Run, checks the result against the gate limits in prof.json,
+// prints a short report to w, and returns ErrGateFailed when any limit is exceeded.
+func Gate(opts GateOptions, w io.Writer) error {
+	_, base, head, err := resolveTags(opts.Out, opts.Base, opts.Head)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("gate limits are read from %s: %w", config.Filename, err)
+	}
+	if config.GateLimitsEmpty(cfg.Gate.Defaults) && len(cfg.Gate.Benchmarks) == 0 {
+		return fmt.Errorf("no gate limits configured in %s", config.Filename)
+	}
+
+	catalog, err := tooling.ConfiguredCatalog(cfg)
+	if err != nil {
+		return err
+	}
+	report, err := Build(catalog, base, head)
+	if err != nil {
+		return err
+	}
+	if unmatched := config.UnmatchedGateBenchmarks(cfg, headBenchmarks(report)); len(unmatched) > 0 {
+		return fmt.Errorf("%s gate.benchmarks %s: no such benchmark in %s", config.Filename, strings.Join(unmatched, ", "), head.Tag)
+	}
+	violations, checks := EvaluateGate(cfg, report, head)
+	writeGateText(w, report, violations, checks)
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %d violation(s)", ErrGateFailed, len(violations))
+	}
+	return nil
+}
+
+// EvaluateGate checks every head benchmark in r against its resolved limits and returns the
+// violations with the number of checks performed. Metric limits apply only to benchmarks present
+// in both tags; a regression counts when it exceeds the limit and is statistically significant,
+// or when the sample counts are too small for any difference to reach significance.
+// Function limits read flat% from the head tag's profile and fail when the profile is missing.
+func EvaluateGate(cfg *config.Config, r Report, head workspace.TagLayout) ([]Violation, int) {
+	var violations []Violation
+	checks := 0
+	for _, bd := range r.Benchmarks {
+		if bd.Presence == PresenceBaseOnly {
+			continue
+		}
+		limits := config.ResolveGateLimits(cfg, bd.Name)
+		if bd.Presence == PresenceBoth {
+			for _, ml := range metricLimits(limits) {
+				failed, n := checkMetric(bd, ml.metric, *ml.max)
+				violations = append(violations, failed...)
+				checks += n
+			}
+		}
+		profiles := map[string]*parser.ProfileData{}
+		for _, fl := range limits.Functions {
+			checks++
+			if v, failed := checkFunction(head, bd.dir(), bd.Name, fl, profiles); failed {
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, checks
+}
+
+// headBenchmarks returns the names of the benchmarks of r collected in the head tag.
+func headBenchmarks(r Report) []string {
+	var names []string
+	for _, bd := range r.Benchmarks {
+		if bd.Presence != PresenceBaseOnly {
+			names = append(names, bd.Name)
+		}
+	}
+	return names
+}
+
+type metricLimit struct {
+	metric string
+	max    *float64
+}
+
+func metricLimits(l config.GateLimits) []metricLimit {
+	var out []metricLimit
+	for _, ml := range []metricLimit{
+		{MetricNsPerOp, l.MaxNsPerOpRegressionPct},
+		{MetricBytesPerOp, l.MaxBytesPerOpRegressionPct},
+		{MetricAllocsPerOp, l.MaxAllocsPerOpRegressionPct},
+	} {
+		if ml.max != nil {
+			out = append(out, ml)
+		}
+	}
+	return out
+}
+
+// checkMetric checks metric against limit in every result name of bd (one check each) and
+// returns the violations with the number of checks performed.
+func checkMetric(bd BenchmarkDelta, metric string, limit float64) ([]Violation, int) {
+	var violations []Violation
+	checks := 0
+	for _, m := range bd.Metrics {
+		if m.Metric != metric {
+			continue
+		}
+		checks++
+		if m.DeltaPct <= limit {
+			continue
+		}
+		tooFew := stats.MinPValue(m.BaseSamples, m.HeadSamples) >= stats.DefaultAlpha
+		if !m.Significant && !tooFew {
+			continue
+		}
+		v := Violation{Benchmark: bd.Name, Result: m.Result, Check: metric, Actual: m.DeltaPct, Limit: limit}
+		v.Detail = fmt.Sprintf("p=%.3f n=%d+%d", m.PValue, m.BaseSamples, m.HeadSamples)
+		if tooFew {
+			v.Detail += ", too few samples to test significance"
+		}
+		violations = append(violations, v)
+	}
+	if checks == 0 {
+		v := Violation{Benchmark: bd.Name, Check: metric, Limit: limit, Missing: true, Detail: "not measured in both tags"}
+		return []Violation{v}, 1
+	}
+	return violations, checks
+}
+
+func checkFunction(head workspace.TagLayout, dir, bench string, fl config.FunctionLimit, cache map[string]*parser.ProfileData) (Violation, bool) {
+	v := Violation{
+		Benchmark: bench,
+		Check:     fl.Profile + " flat%",
+		Function:  fl.Name,
+		Limit:     fl.MaxFlatPct,
+	}
+	pd, ok := cache[fl.Profile]
+	if !ok {
+		path := head.ProfileBinary(dir, fl.Profile)
+		if _, err := os.Stat(path); err != nil {
+			v.Missing = true
+			v.Detail = fmt.Sprintf("%s profile not collected in %s", fl.Profile, head.Tag)
+			return v, true
+		}
+		var err error
+		if pd, err = aggregate(path); err != nil {
+			v.Missing = true
+			v.Detail = err.Error()
+			return v, true
+		}
+		cache[fl.Profile] = pd
+	}
+	for symbol, pct := range pd.FlatPercentages {
+		if symbolMatches(symbol, fl.Name) {
+			v.Actual = max(v.Actual, pct)
+		}
+	}
+	return v, v.Actual > fl.MaxFlatPct
+}
+
+// symbolMatches reports whether the pprof symbol is name or ends with "."+name / "/"+name.
+func symbolMatches(symbol, name string) bool {
+	return symbol == name || strings.HasSuffix(symbol, "."+name) || strings.HasSuffix(symbol, "/"+name)
+}
+
+func writeGateText(w io.Writer, r Report, violations []Violation, checks int) {
+	if len(violations) == 0 {
+		fmt.Fprintf(w, "Gate %s (base) → %s (head): passed %d checks\n", r.Base, r.Head, checks)
+		return
+	}
+	fmt.Fprintf(w, "Gate %s (base) → %s (head): %d of %d checks failed\n", r.Base, r.Head, len(violations), checks)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, v := range violations {
+		check := v.Check
+		actual := formatPct(v.Actual)
+		if v.Function != "" {
+			check += " " + v.Function
+			actual = fmt.Sprintf("%.2f%%", v.Actual)
+		}
+		if v.Missing {
+			actual = "n/a"
+		}
+		detail := ""
+		if v.Detail != "" {
+			detail = "(" + v.Detail + ")"
+		}
+		bench := v.Benchmark
+		if v.Result != "" {
+			bench += " " + v.Result
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s > %.2f%%\t%s\n", bench, check, actual, v.Limit, detail)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,181 @@
+package compare
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func writeGateModule(t *testing.T, profJSON string) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{"go.mod": "module cmp\n\ngo 1.24.3\n", config.Filename: profJSON}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestGate_failsOnSignificantRegression(t *testing.T) {
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	root := writeGateModule(t, `{"version": 1, "gate": {"defaults": {"max_ns_per_op_regression_pct": 10}}}`)
+	t.Chdir(root)
+	writeTagFixture(t, workspace.NewTagLayout(root, "fast"), testBench, runTxtB, cpu)
+	writeTagFixture(t, workspace.NewTagLayout(root, "slow"), testBench, runTxtA, cpu)
+
+	var out bytes.Buffer
+	err := Gate(GateOptions{Base: "fast", Head: "slow"}, &out)
+	if !errors.Is(err, ErrGateFailed) {
+		t.Fatalf("err=%v", err)
+	}
+	if text := out.String(); !strings.Contains(text, "1 of 1 checks failed") || !strings.Contains(text, "+33.33%") {
+		t.Fatalf("output:\n%s", text)
+	}
+
+	out.Reset()
+	if err = Gate(GateOptions{Base: "slow", Head: "fast"}, &out); err != nil {
+		t.Fatalf("improvement should pass: %v\n%s", err, out.String())
+	}
+}
+
+func TestGate_benchmarkLimitsUseCollectedNames(t *testing.T) {
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	root := writeGateModule(t, `{"version": 1, "gate": {"benchmarks": {
+		"BenchmarkSub/big": {"max_ns_per_op_regression_pct": 10},
+		"./inner.BenchmarkFoo": {"max_ns_per_op_regression_pct": 10}
+	}}}`)
+	t.Chdir(root)
+	for _, bench := range []string{"BenchmarkSub/big", "./inner.BenchmarkFoo"} {
+		for tag, runTxt := range map[string]string{"fast": runTxtB, "slow": runTxtA} {
+			l := workspace.NewTagLayout(root, tag)
+			writeTagFixture(t, l, bench, runTxt, cpu)
+			if err := datamap.WriteJSON(l.DataMapping(bench), datamap.BenchmarkMap{Benchmark: bench}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	err := Gate(GateOptions{Base: "fast", Head: "slow"}, &out)
+	if !errors.Is(err, ErrGateFailed) {
+		t.Fatalf("err=%v", err)
+	}
+	if text := out.String(); !strings.Contains(text, "2 of 2 checks failed") || !strings.Contains(text, "BenchmarkSub/big") {
+		t.Fatalf("output:\n%s", text)
+	}
+}
+
+func TestGate_rejectsUnmatchedBenchmarkLimits(t *testing.T) {
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	root := writeGateModule(t, `{"version": 1, "gate": {"benchmarks": {"BenchmarkSub__big": {"max_ns_per_op_regression_pct": 10}}}}`)
+	t.Chdir(root)
+	for tag, runTxt := range map[string]string{"fast": runTxtB, "slow": runTxtA} {
+		l := workspace.NewTagLayout(root, tag)
+		writeTagFixture(t, l, "BenchmarkSub/big", runTxt, cpu)
+		if err := datamap.WriteJSON(l.DataMapping("BenchmarkSub/big"), datamap.BenchmarkMap{Benchmark: "BenchmarkSub/big"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Gate(GateOptions{Base: "fast", Head: "slow"}, &bytes.Buffer{})
+	if err == nil || errors.Is(err, ErrGateFailed) || !strings.Contains(err.Error(), "BenchmarkSub__big") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestGate_requiresLimits(t *testing.T) {
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	root := writeGateModule(t, `{"version": 1}`)
+	t.Chdir(root)
+	writeTagFixture(t, workspace.NewTagLayout(root, "a"), testBench, runTxtA, cpu)
+	writeTagFixture(t, workspace.NewTagLayout(root, "b"), testBench, runTxtB, cpu)
+
+	var out bytes.Buffer
+	if err := Gate(GateOptions{Base: "a", Head: "b"}, &out); err == nil || errors.Is(err, ErrGateFailed) {
+		t.Fatalf("expected configuration error, got %v", err)
+	}
+}
+
+func TestEvaluateGate_noiseAndFunctionLimits(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	head := workspace.NewTagLayout(root, "head")
+	cpu := testpaths.MustAsset(t, "cpu.out")
+	writeTagFixture(t, head, testBench, runTxtA, cpu)
+	pd, err := aggregate(cpu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hottest := pd.SortedEntries[0].Name
+
+	ten := 10.0
+	cfg := &config.Config{Gate: config.Gate{Defaults: config.GateLimits{
+		MaxNsPerOpRegressionPct: &ten,
+		Functions: []config.FunctionLimit{
+			{Name: hottest, MaxFlatPct: 1},
+			{Name: "does.NotExist", MaxFlatPct: 1},
+			{Name: "main.x", Profile: "memory", MaxFlatPct: 1},
+		},
+	}}}
+	config.Normalize(cfg)
+
+	// Overlapping samples are noise: an over-limit delta must not fail unless it is significant.
+	noisy := BenchmarkDelta{Name: testBench, Presence: PresenceBoth, Metrics: []MetricDelta{
+		newMetricDelta(MetricNsPerOp, []float64{100, 120, 90, 110, 95}, []float64{102, 88, 125, 99, 118}),
+	}}
+	noisy.Metrics[0].DeltaPct = 50
+	violations, checks := EvaluateGate(cfg, Report{Benchmarks: []BenchmarkDelta{noisy}}, head)
+	if checks != 4 {
+		t.Fatalf("checks=%d", checks)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("violations=%+v", violations)
+	}
+	if violations[0].Function != hottest || violations[0].Actual <= 1 {
+		t.Fatalf("hot function violation=%+v", violations[0])
+	}
+	if !violations[1].Missing || violations[1].Check != "memory flat%" {
+		t.Fatalf("missing profile violation=%+v", violations[1])
+	}
+}
+
+func TestEvaluateGate_tooFewSamplesUsesRawDelta(t *testing.T) {
+	t.Parallel()
+	ten := 10.0
+	cfg := &config.Config{Gate: config.Gate{Defaults: config.GateLimits{MaxAllocsPerOpRegressionPct: &ten}}}
+	bd := BenchmarkDelta{Name: testBench, Presence: PresenceBoth, Metrics: []MetricDelta{
+		newMetricDelta(MetricAllocsPerOp, []float64{10}, []float64{12}),
+	}}
+	violations, _ := EvaluateGate(cfg, Report{Benchmarks: []BenchmarkDelta{bd}}, workspace.TagLayout{})
+	if len(violations) != 1 || violations[0].Actual != 20 {
+		t.Fatalf("violations=%+v", violations)
+	}
+}
+
+func TestEvaluateGate_checksEveryResultName(t *testing.T) {
+	t.Parallel()
+	ten := 10.0
+	cfg := &config.Config{Gate: config.Gate{Defaults: config.GateLimits{MaxNsPerOpRegressionPct: &ten}}}
+	fast := newMetricDelta(MetricNsPerOp, []float64{100}, []float64{100})
+	fast.Result = "BenchmarkFoo-1"
+	slow := newMetricDelta(MetricNsPerOp, []float64{100}, []float64{150})
+	slow.Result = "BenchmarkFoo-4"
+	bd := BenchmarkDelta{Name: testBench, Presence: PresenceBoth, Metrics: []MetricDelta{fast, slow}}
+	violations, checks := EvaluateGate(cfg, Report{Benchmarks: []BenchmarkDelta{bd}}, workspace.TagLayout{})
+	if checks != 2 {
+		t.Fatalf("checks=%d", checks)
+	}
+	if len(violations) != 1 || violations[0].Result != "BenchmarkFoo-4" || violations[0].Actual != 50 {
+		t.Fatalf("violations=%+v", violations)
+	}
+}
@@ -0,0 +1,94 @@
+package compare
+
+import (
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/stats"
+)
+
+// headlineMetrics are compared first, in this order; other units present in both runs follow.
+var headlineMetrics = []string{MetricNsPerOp, MetricBytesPerOp, MetricAllocsPerOp}
+
+// compareMeasurements tests every metric recorded in both run.txt files; nil when either is missing or unparsable.
+// Result names (sub-benchmarks, -cpu values) are compared one to one and only when both runs
+// have them. A run.txt with a single name is compared with the other's single name even when
+// the GOMAXPROCS suffix differs, as between two machines.
+func compareMeasurements(basePath, headPath string) []MetricDelta {
+	b, err := datamap.ParseMeasurementSummary(basePath)
+	if err != nil {
+		return nil
+	}
+	h, err := datamap.ParseMeasurementSummary(headPath)
+	if err != nil {
+		return nil
+	}
+
+	baseGroups, headGroups := b.Groups(), h.Groups()
+	if len(baseGroups) == 1 && len(headGroups) == 1 {
+		return compareResult("", baseGroups[0], headGroups[0])
+	}
+	var out []MetricDelta
+	for _, bg := range baseGroups {
+		for _, hg := range headGroups {
+			if hg.Name == bg.Name {
+				out = append(out, compareResult(bg.Name, bg, hg)...)
+			}
+		}
+	}
+	return out
+}
+
+// compareResult tests every metric of one result name; name labels the deltas.
+func compareResult(name string, b, h datamap.ResultSummary) []MetricDelta {
+	units := append([]string(nil), headlineMetrics...)
+	for _, m := range b.Metrics {
+		if !contains(units, m.Unit) {
+			units = append(units, m.Unit)
+		}
+	}
+	var out []MetricDelta
+	for _, unit := range units {
+		bm, okBase := b.Metric(unit)
+		hm, okHead := h.Metric(unit)
+		if !okBase || !okHead {
+			continue
+		}
+		d := newMetricDelta(unit, bm.Samples, hm.Samples)
+		d.Result = name
+		out = append(out, d)
+	}
+	return out
+}
+
+func newMetricDelta(metric string, base, head []float64) MetricDelta {
+	c := stats.Compare(base, head, stats.DefaultAlpha)
+	return MetricDelta{
+		Metric:        metric,
+		Base:          c.Base.Median,
+		Head:          c.Head.Median,
+		Delta:         c.Head.Median - c.Base.Median,
+		DeltaPct:      c.DeltaPct,
+		BaseVariation: c.Base.SpreadPct(),
+		HeadVariation: c.Head.SpreadPct(),
+		BaseSamples:   c.Base.N,
+		HeadSamples:   c.Head.N,
+		PValue:        c.P,
+		Significant:   c.Significant,
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pctChange(base, head float64) float64 {
+	const pctScale = 100.0
+	if base == 0 {
+		return 0
+	}
+	return (head - base) / base * pctScale
+}
@@ -0,0 +1,19 @@
+package compare
+
+// DefaultTop is the number of function rows printed per profile when Options.Top is zero.
+const DefaultTop = 15
+
+// Options configures Run.
+type Options struct {
+	Base string
+	Head string
+	Top  int    // function rows per profile in terminal output; 0 uses DefaultTop
+	Out  string // prof --out; empty keeps the tags under $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
+}
+
+// GateOptions configures Gate.
+type GateOptions struct {
+	Base string
+	Head string
+	Out  string // prof --out; see Options.Out
+}
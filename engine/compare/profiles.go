@@ -0,0 +1,103 @@
+package compare
+
+import (
+	"sort"
+
+	"github.com/AlexsanderHamir/prof/parser"
+)
+
+// compareProfile aggregates both binaries in-process and fills per-function deltas on pd.
+// Decode failures are recorded on pd.Error so one bad binary does not abort the comparison.
+func compareProfile(pd *ProfileDelta, basePath, headPath string) {
+	b, err := aggregate(basePath)
+	if err != nil {
+		pd.Error = err.Error()
+		return
+	}
+	h, err := aggregate(headPath)
+	if err != nil {
+		pd.Error = err.Error()
+		return
+	}
+	pd.SampleUnit = h.SampleUnit
+	pd.BaseTotal = b.Total
+	pd.HeadTotal = h.Total
+	pd.Functions = functionDeltas(b, h)
+}
+
+func aggregate(path string) (*parser.ProfileData, error) {
+	p, err := parser.ParseProfileFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = parser.ValidateProfile(p); err != nil {
+		return nil, err
+	}
+	idx, err := parser.PrimarySampleValueIndex(p)
+	if err != nil {
+		return nil, err
+	}
+	if err = parser.ValidateSamplesHaveValueAt(p, idx); err != nil {
+		return nil, err
+	}
+	return parser.AggregateProfileData(p, idx), nil
+}
+
+// functionDeltas returns every symbol whose flat or cum changed, largest absolute flat change first.
+func functionDeltas(b, h *parser.ProfileData) []FunctionDelta {
+	names := make(map[string]struct{}, len(b.Cum)+len(h.Cum))
+	for name := range b.Cum {
+		names[name] = struct{}{}
+	}
+	for name := range h.Cum {
+		names[name] = struct{}{}
+	}
+
+	out := make([]FunctionDelta, 0, len(names))
+	for name := range names {
+		fd := FunctionDelta{
+			Name:        name,
+			BaseFlat:    b.Flat[name],
+			HeadFlat:    h.Flat[name],
+			BaseCum:     b.Cum[name],
+			HeadCum:     h.Cum[name],
+			BaseFlatPct: pctOf(b.Flat[name], b.Total),
+			HeadFlatPct: pctOf(h.Flat[name], h.Total),
+			BaseCumPct:  pctOf(b.Cum[name], b.Total),
+			HeadCumPct:  pctOf(h.Cum[name], h.Total),
+		}
+		fd.FlatDelta = fd.HeadFlat - fd.BaseFlat
+		fd.CumDelta = fd.HeadCum - fd.BaseCum
+		if fd.FlatDelta == 0 && fd.CumDelta == 0 {
+			continue
+		}
+		out = append(out, fd)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		fi, fj := abs64(out[i].FlatDelta), abs64(out[j].FlatDelta)
+		if fi != fj {
+			return fi > fj
+		}
+		ci, cj := abs64(out[i].CumDelta), abs64(out[j].CumDelta)
+		if ci != cj {
+			return ci > cj
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func pctOf(v, total int64) float64 {
+	const pctScale = 100.0
+	if total == 0 {
+		return 0
+	}
+	return float64(v) / float64(total) * pctScale
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
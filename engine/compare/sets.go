@@ -0,0 +1,35 @@
+package compare
+
+import (
+	"slices"
+	"sort"
+)
+
+func unionSorted(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for _, s := range a {
+		seen[s] = struct{}{}
+	}
+	for _, s := range b {
+		seen[s] = struct{}{}
+	}
+	out := make([]string, 0, len(seen))
+	for s := range seen {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func presence(base, head []string, name string) string {
+	inBase := slices.Contains(base, name)
+	inHead := slices.Contains(head, name)
+	switch {
+	case inBase && inHead:
+		return PresenceBoth
+	case inBase:
+		return PresenceBaseOnly
+	default:
+		return PresenceHeadOnly
+	}
+}
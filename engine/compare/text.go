@@ -0,0 +1,142 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlexsanderHamir/prof/internal/pprofscale"
+)
+
+// WriteText prints a human-readable summary of r to w, showing at most top function rows per profile.
+func WriteText(w io.Writer, r Report, top int) error {
+	fmt.Fprintf(w, "Comparing %s (base) → %s (head)\n", r.Base, r.Head)
+	if len(r.Benchmarks) == 0 {
+		fmt.Fprintln(w, "\nNo benchmarks found in either tag.")
+		return nil
+	}
+	for _, bd := range r.Benchmarks {
+		fmt.Fprintf(w, "\n%s\n", bd.Name)
+		switch bd.Presence {
+		case PresenceBaseOnly:
+			fmt.Fprintf(w, "  only in %s\n", r.Base)
+			continue
+		case PresenceHeadOnly:
+			fmt.Fprintf(w, "  only in %s\n", r.Head)
+			continue
+		}
+		if err := writeMetrics(w, bd.Metrics); err != nil {
+			return err
+		}
+		for _, pd := range bd.Profiles {
+			if err := writeProfile(w, r, pd, top); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeMetrics(w io.Writer, metrics []MetricDelta) error {
+	if len(metrics) == 0 {
+		fmt.Fprintln(w, "  measurements: not available")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  metric\tbase\thead\tdelta\t")
+	result := ""
+	for _, m := range metrics {
+		if m.Result != result {
+			result = m.Result
+			fmt.Fprintf(tw, "  %s\t\t\t\t\n", result)
+		}
+		indent := "  "
+		if m.Result != "" {
+			indent = "    "
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t\n", indent, m.Metric,
+			withVariation(m.Base, m.BaseVariation), withVariation(m.Head, m.HeadVariation), formatMetricDelta(m))
+	}
+	return tw.Flush()
+}
+
+// formatMetricDelta mirrors benchstat: "~" marks a delta that is not statistically significant.
+func formatMetricDelta(m MetricDelta) string {
+	delta := "~"
+	if m.Significant {
+		delta = formatPct(m.DeltaPct)
+	}
+	return fmt.Sprintf("%s (p=%.3f n=%d+%d)", delta, m.PValue, m.BaseSamples, m.HeadSamples)
+}
+
+func withVariation(v, variationPct float64) string {
+	return fmt.Sprintf("%s ± %.0f%%", formatNumber(v), variationPct)
+}
+
+func writeProfile(w io.Writer, r Report, pd ProfileDelta, top int) error {
+	switch {
+	case pd.Presence == PresenceBaseOnly:
+		fmt.Fprintf(w, "\n  %s: only in %s\n", pd.Profile, r.Base)
+		return nil
+	case pd.Presence == PresenceHeadOnly:
+		fmt.Fprintf(w, "\n  %s: only in %s\n", pd.Profile, r.Head)
+		return nil
+	case pd.Error != "":
+		fmt.Fprintf(w, "\n  %s: %s\n", pd.Profile, pd.Error)
+		return nil
+	}
+
+	unit := outputUnit(pd)
+	fmt.Fprintf(w, "\n  %s: total %s → %s (%s)\n", pd.Profile,
+		pprofscale.ScaledLabel(pd.BaseTotal, pd.SampleUnit, unit),
+		pprofscale.ScaledLabel(pd.HeadTotal, pd.SampleUnit, unit),
+		formatPct(pctChange(float64(pd.BaseTotal), float64(pd.HeadTotal))))
+	if len(pd.Functions) == 0 {
+		fmt.Fprintln(w, "    no function-level changes")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "    flat Δ\tflat% base→head\tcum Δ\tfunction")
+	for i, fd := range pd.Functions {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(tw, "    %s\t%.2f%% → %.2f%%\t%s\t%s\n",
+			signedLabel(fd.FlatDelta, pd.SampleUnit, unit),
+			fd.BaseFlatPct, fd.HeadFlatPct,
+			signedLabel(fd.CumDelta, pd.SampleUnit, unit),
+			fd.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if rest := len(pd.Functions) - top; rest > 0 {
+		fmt.Fprintf(w, "    … and %d more changed functions (see compare.json)\n", rest)
+	}
+	return nil
+}
+
+func outputUnit(pd ProfileDelta) string {
+	total := max(pd.BaseTotal, pd.HeadTotal)
+	return pprofscale.SelectOutputUnit(pd.SampleUnit, total, nil, nil)
+}
+
+func signedLabel(v int64, sampleUnit, outUnit string) string {
+	label := pprofscale.ScaledLabel(v, sampleUnit, outUnit)
+	if v > 0 {
+		return "+" + label
+	}
+	return label
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatPct(pct float64) string {
+	s := fmt.Sprintf("%+.2f%%", pct)
+	return strings.Replace(s, "-0.00%", "+0.00%", 1)
+}
@@ -0,0 +1,100 @@
+package compare
+
+import "github.com/AlexsanderHamir/prof/internal/workspace"
+
+// ReportSchemaVersion is the compare.json schema version written by prof.
+const ReportSchemaVersion = 1
+
+// Presence values for benchmarks and profiles that exist in only one tag.
+const (
+	PresenceBoth     = "both"
+	PresenceBaseOnly = "base_only"
+	PresenceHeadOnly = "head_only"
+)
+
+// Metric names as printed by go test -benchmem.
+const (
+	MetricNsPerOp     = "ns/op"
+	MetricBytesPerOp  = "B/op"
+	MetricAllocsPerOp = "allocs/op"
+)
+
+// Report is the root document written to compare.json.
+type Report struct {
+	SchemaVersion int              `json:"schema_version"`
+	Base          string           `json:"base"`
+	Head          string           `json:"head"`
+	Benchmarks    []BenchmarkDelta `json:"benchmarks"`
+}
+
+// BenchmarkDelta holds measurement and profile deltas for one benchmark. Name is the benchmark
+// as collected (map.json "benchmark", e.g. BenchmarkSub/big or ./inner.BenchmarkFoo); Dir is
+// its directory under profiles/ and measurements/ and is only used to find its files.
+type BenchmarkDelta struct {
+	Name     string         `json:"name"`
+	Dir      string         `json:"dir,omitempty"`
+	Presence string         `json:"presence"`
+	Metrics  []MetricDelta  `json:"metrics,omitempty"`
+	Profiles []ProfileDelta `json:"profiles,omitempty"`
+}
+
+// MetricDelta compares one go test -benchmem metric between tags.
+// Base and Head are medians across all samples; Significant is false when the Mann-Whitney U
+// p-value is at or above stats.DefaultAlpha, in which case the delta is noise.
+type MetricDelta struct {
+	Result        string  `json:"result,omitempty"` // go test result name, set when run.txt holds several
+	Metric        string  `json:"metric"`
+	Base          float64 `json:"base"`
+	Head          float64 `json:"head"`
+	Delta         float64 `json:"delta"`
+	DeltaPct      float64 `json:"delta_pct"`
+	BaseVariation float64 `json:"base_variation_pct"`
+	HeadVariation float64 `json:"head_variation_pct"`
+	BaseSamples   int     `json:"base_samples"`
+	HeadSamples   int     `json:"head_samples"`
+	PValue        float64 `json:"p_value"`
+	Significant   bool    `json:"significant"`
+}
+
+// ProfileDelta compares one profile kind between tags.
+type ProfileDelta struct {
+	Profile    string          `json:"profile"`
+	Presence   string          `json:"presence"`
+	SampleUnit string          `json:"sample_unit,omitempty"`
+	BaseTotal  int64           `json:"base_total"`
+	HeadTotal  int64           `json:"head_total"`
+	Functions  []FunctionDelta `json:"functions,omitempty"`
+	Diff       *DiffArtifacts  `json:"diff,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// DiffArtifacts holds go tool pprof -diff_base outputs, relative to the comparison root.
+// CallGraph is empty when Graphviz is unavailable or PNG rendering failed.
+type DiffArtifacts struct {
+	Hotspots  string `json:"hotspots"`
+	CallTree  string `json:"call_tree"`
+	CallGraph string `json:"call_graph,omitempty"`
+}
+
+// FunctionDelta compares flat and cum cost for one symbol; percentages are of each tag's own total.
+type FunctionDelta struct {
+	Name        string  `json:"name"`
+	BaseFlat    int64   `json:"base_flat"`
+	HeadFlat    int64   `json:"head_flat"`
+	FlatDelta   int64   `json:"flat_delta"`
+	BaseCum     int64   `json:"base_cum"`
+	HeadCum     int64   `json:"head_cum"`
+	CumDelta    int64   `json:"cum_delta"`
+	BaseFlatPct float64 `json:"base_flat_pct"`
+	HeadFlatPct float64 `json:"head_flat_pct"`
+	BaseCumPct  float64 `json:"base_cum_pct"`
+	HeadCumPct  float64 `json:"head_cum_pct"`
+}
+
+// dir returns the directory of bd under a tag; deltas built by hand may leave Dir empty.
+func (bd BenchmarkDelta) dir() string {
+	if bd.Dir != "" {
+		return bd.Dir
+	}
+	return workspace.BenchmarkDir(bd.Name)
+}
@@ -0,0 +1,5 @@
+// Package export converts a profile already collected under .prof/<tag>/ into another
+// format (prof export): speedscope JSON, folded stacks, or an SVG flame graph. It renders
+// from profiles/<bench>/<profile>.out in-process, so old tags can be converted without
+// re-running benchmarks.
+package export
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/pprofreport"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/AlexsanderHamir/prof/parser"
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// Export formats accepted by Options.Format.
+const (
+	FormatSpeedscope = "speedscope"
+	FormatFolded     = "folded"
+	FormatFlameGraph = "flamegraph"
+)
+
+// StdoutOutput as Options.Output writes the export to the Run writer instead of a file.
+const StdoutOutput = "-"
+
+// Options configures Run.
+type Options struct {
+	Format  string // one of Formats; empty uses FormatSpeedscope
+	Tag     string
+	Bench   string
+	Profile string // profile kind or variant such as memory.alloc_objects
+	Output  string // destination file; empty uses the tag layout path, StdoutOutput writes to w
+	Out     string // prof --out; empty reads the tag from $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
+}
+
+type format struct {
+	path   func(l workspace.TagLayout, bench, profile string) string
+	render func(w io.Writer, p *pprofprofile.Profile, sampleIndex, name string) error
+}
+
+var formats = map[string]format{
+	FormatSpeedscope: {
+		path:   workspace.TagLayout.Speedscope,
+		render: pprofreport.Speedscope,
+	},
+	FormatFolded: {
+		path: workspace.TagLayout.FoldedStacks,
+		render: func(w io.Writer, p *pprofprofile.Profile, sampleIndex, _ string) error {
+			return pprofreport.Folded(w, p, sampleIndex)
+		},
+	},
+	FormatFlameGraph: {
+		path: workspace.TagLayout.FlameGraph,
+		render: func(w io.Writer, p *pprofprofile.Profile, sampleIndex, _ string) error {
+			return pprofreport.FlameGraph(w, p, sampleIndex)
+		},
+	},
+}
+
+// Formats lists the accepted Options.Format values in sorted order.
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Run renders the profile binary of opts.Bench/opts.Profile in opts.Tag as opts.Format and
+// writes it to opts.Output. A variant profile selects its sample type. The destination path
+// is reported on w unless the export itself goes to w.
+func Run(opts Options, w io.Writer) error {
+	name := strings.ToLower(strings.TrimSpace(opts.Format))
+	if name == "" {
+		name = FormatSpeedscope
+	}
+	f, ok := formats[name]
+	if !ok {
+		return fmt.Errorf("unknown export format %q (want one of: %s)", opts.Format, strings.Join(Formats(), ", "))
+	}
+	if opts.Tag == "" || opts.Bench == "" || opts.Profile == "" {
+		return errors.New("tag, benchmark and profile are required")
+	}
+	layout, err := workspace.TagLayoutFromCWD(opts.Out, opts.Tag)
+	if err != nil {
+		return fmt.Errorf("failed to locate module root: %w", err)
+	}
+	if !layout.Exists() {
+		return fmt.Errorf("tag %q not found at %s", opts.Tag, layout.Root)
+	}
+	binPath, err := layout.ResolveProfileBinary(opts.Bench, opts.Profile)
+	if err != nil {
+		return fmt.Errorf("profile %s for %s in tag %q: %w", opts.Profile, opts.Bench, opts.Tag, err)
+	}
+	p, err := parser.ParseProfileFromPath(binPath)
+	if err != nil {
+		return err
+	}
+
+	_, sampleIndex := workspace.SplitProfileVariant(opts.Profile)
+	var buf bytes.Buffer
+	if err = f.render(&buf, p, sampleIndex, opts.Bench+" "+opts.Profile); err != nil {
+		return fmt.Errorf("render %s: %w", name, err)
+	}
+	if opts.Output == StdoutOutput {
+		_, err = w.Write(buf.Bytes())
+		return err
+	}
+
+	out := opts.Output
+	if out == "" {
+		out = f.path(layout, opts.Bench, opts.Profile)
+	}
+	if err = os.MkdirAll(filepath.Dir(out), workspace.PermDir); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	if err = os.WriteFile(out, buf.Bytes(), workspace.PermFile); err != nil {
+		return fmt.Errorf("write %s: %w", out, err)
+	}
+	fmt.Fprintf(w, "Wrote %s\n", out)
+	return nil
+}
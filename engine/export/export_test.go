@@ -0,0 +1,95 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+const testBench = "BenchmarkFoo"
+
+// writeModule creates a module with one tag holding the memory fixture and chdirs into it.
+func writeModule(t *testing.T) workspace.TagLayout {
+	t.Helper()
+	fixture := testpaths.MustAsset(t, "fixtures", "BenchmarkStringProcessor_memory.out")
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module exp\n\ngo 1.24.3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	layout := workspace.NewTagLayout(root, "old")
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := layout.ProfileBinary(testBench, "memory")
+	if err = os.MkdirAll(filepath.Dir(dst), workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(dst, data, workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	return layout
+}
+
+func TestRun_speedscopeToLayoutPath(t *testing.T) {
+	layout := writeModule(t)
+	var w bytes.Buffer
+	opts := Options{Tag: "old", Bench: testBench, Profile: "memory.alloc_objects"}
+	if err := Run(opts, &w); err != nil {
+		t.Fatal(err)
+	}
+	path := layout.Speedscope(testBench, "memory.alloc_objects")
+	if !strings.Contains(w.String(), path) {
+		t.Fatalf("output %q does not name %s", w.String(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		ActiveProfileIndex int `json:"activeProfileIndex"`
+		Profiles           []struct {
+			Name string `json:"name"`
+		} `json:"profiles"`
+	}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Profiles) != 4 || doc.Profiles[doc.ActiveProfileIndex].Name != "alloc_objects" {
+		t.Fatalf("profiles=%+v active=%d", doc.Profiles, doc.ActiveProfileIndex)
+	}
+}
+
+func TestRun_stdout(t *testing.T) {
+	writeModule(t)
+	var w bytes.Buffer
+	opts := Options{Format: FormatFolded, Tag: "old", Bench: testBench, Profile: "memory", Output: StdoutOutput}
+	if err := Run(opts, &w); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(w.String(), ";") || strings.Contains(w.String(), "Wrote") {
+		t.Fatalf("unexpected folded output:\n%s", w.String())
+	}
+}
+
+func TestRun_validation(t *testing.T) {
+	writeModule(t)
+	cases := map[string]Options{
+		"unknown format":  {Format: "pdf", Tag: "old", Bench: testBench, Profile: "memory"},
+		"missing tag":     {Format: FormatSpeedscope, Tag: "nope", Bench: testBench, Profile: "memory"},
+		"missing profile": {Format: FormatSpeedscope, Tag: "old", Bench: testBench, Profile: "cpu"},
+		"empty bench":     {Format: FormatSpeedscope, Tag: "old", Profile: "memory"},
+	}
+	for name, opts := range cases {
+		if err := Run(opts, &bytes.Buffer{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
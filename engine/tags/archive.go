@@ -0,0 +1,463 @@
+package tags
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Tag archive formats, chosen by the archive file's extension.
+const (
+	ZipExtension    = ".zip"
+	TarZstExtension = ".tar.zst"
+)
+
+// ArchiveManifestFile is the first entry of a tag archive. The tag's files follow it, with
+// slash-separated paths relative to the tag root.
+const ArchiveManifestFile = "prof-archive.json"
+
+// ArchiveSchemaVersion is the prof-archive.json schema version written by prof.
+const ArchiveSchemaVersion = 1
+
+// ArchiveManifest describes a tag archive and lets an import verify every file in it.
+type ArchiveManifest struct {
+	SchemaVersion int           `json:"schema_version"`
+	Tag           string        `json:"tag"`
+	ProfVersion   string        `json:"prof_version"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Files         []ArchiveFile `json:"files"`
+}
+
+// ArchiveFile is one file of the archived tag.
+type ArchiveFile struct {
+	Path   string `json:"path"` // relative to the tag root, slash-separated
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ExportOptions configures ExportTag.
+type ExportOptions struct {
+	Tag         string
+	Output      string // archive path ending in .zip or .tar.zst; empty writes <tag>.zip in the current directory
+	ProfVersion string // recorded in prof-archive.json
+	Out         string // prof --out; empty reads the tag from $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
+}
+
+// ImportOptions configures ImportTag.
+type ImportOptions struct {
+	Archive string // .zip or .tar.zst written by ExportTag
+	Tag     string // tag to import as; empty keeps the archived tag's name
+	Force   bool   // replace an existing tag of that name
+	Out     string // prof --out; empty imports into $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
+}
+
+// ExportTag packs .prof/<tag>/ into one archive with a manifest of every file's checksum.
+// Writing .tar.zst needs the zstd binary.
+func ExportTag(ctx context.Context, runner tooling.Runner, opts ExportOptions, w io.Writer) error {
+	if err := workspace.ValidateTagName(opts.Tag); err != nil {
+		return err
+	}
+	output := opts.Output
+	if output == "" {
+		output = opts.Tag + ZipExtension
+	}
+	format, err := archiveFormat(output)
+	if err != nil {
+		return err
+	}
+	outDir, err := outputDir(opts.Out)
+	if err != nil {
+		return err
+	}
+	l := workspace.TagLayoutIn(outDir, opts.Tag)
+	if !l.Exists() {
+		return fmt.Errorf("tag %q not found at %s", opts.Tag, l.Root)
+	}
+	unlock, err := lockTags(outDir, opts.Tag)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	manifest, err := archiveManifest(l, opts.ProfVersion)
+	if err != nil {
+		return err
+	}
+	if format == ZipExtension {
+		err = writeArchive(output, l, manifest, newZipWriter)
+	} else {
+		err = writeTarZst(ctx, runner, output, l, manifest)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported %s (%d files) to %s\n", opts.Tag, len(manifest.Files), output)
+	return nil
+}
+
+// ImportTag unpacks an archive written by ExportTag into .prof/ of the current module,
+// verifying every file against the archive's manifest. Nothing is written to the tag unless
+// the whole archive checks out. Reading .tar.zst needs the zstd binary.
+func ImportTag(ctx context.Context, runner tooling.Runner, opts ImportOptions, w io.Writer) error {
+	format, err := archiveFormat(opts.Archive)
+	if err != nil {
+		return err
+	}
+	if opts.Tag != "" {
+		if err = workspace.ValidateTagName(opts.Tag); err != nil {
+			return err
+		}
+	}
+	outDir, err := outputDir(opts.Out)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(outDir, workspace.PermDir); err != nil {
+		return fmt.Errorf("create %s: %w", outDir, err)
+	}
+	// Unpack beside the tags so the final move is a rename; TagNames skips _ directories.
+	staging, err := os.MkdirTemp(outDir, "_import-")
+	if err != nil {
+		return fmt.Errorf("create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	archive := opts.Archive
+	if format == TarZstExtension {
+		tarPath := filepath.Join(staging, "archive.tar")
+		if out, runErr := runner.Run(ctx, tooling.ZstdDecompressArgs(archive, tarPath), tooling.RunOpts{Combined: true}); runErr != nil {
+			return fmt.Errorf("zstd -d %s: %w: %s", archive, runErr, strings.TrimSpace(string(out)))
+		}
+		archive = tarPath
+	}
+	tagDir := filepath.Join(staging, "tag")
+	manifest, err := extractArchive(archive, format, tagDir)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", opts.Archive, err)
+	}
+
+	tag := opts.Tag
+	if tag == "" {
+		tag = manifest.Tag
+	}
+	if err = workspace.ValidateTagName(tag); err != nil {
+		return fmt.Errorf("import %s: %w", opts.Archive, err)
+	}
+	unlock, err := lockTags(outDir, tag)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	l := workspace.TagLayoutIn(outDir, tag)
+	if l.Exists() {
+		if !opts.Force {
+			return fmt.Errorf("tag %q already exists at %s (import with another tag, or replace it with force)", tag, l.Root)
+		}
+		if err = os.RemoveAll(l.Root); err != nil {
+			return fmt.Errorf("remove tag %s: %w", tag, err)
+		}
+	}
+	if err = os.Rename(tagDir, l.Root); err != nil {
+		return fmt.Errorf("move imported tag into place: %w", err)
+	}
+	if tag != manifest.Tag {
+		if err = retag(l, manifest.Tag); err != nil {
+			return fmt.Errorf("imported %s as %s, but: %w", manifest.Tag, tag, err)
+		}
+	}
+	fmt.Fprintf(w, "Imported %s (%d files) as %s\n", manifest.Tag, len(manifest.Files), tag)
+	return nil
+}
+
+func archiveFormat(name string) (string, error) {
+	for _, ext := range []string{ZipExtension, TarZstExtension} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return ext, nil
+		}
+	}
+	return "", fmt.Errorf("archive %q must end in %s or %s", name, ZipExtension, TarZstExtension)
+}
+
+// archiveManifest lists and checksums every regular file under the tag root.
+func archiveManifest(l workspace.TagLayout, profVersion string) (ArchiveManifest, error) {
+	m := ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Tag:           l.Tag,
+		ProfVersion:   profVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+	err := filepath.WalkDir(l.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, relErr := l.RelFromLayout(p)
+		if relErr != nil {
+			return relErr
+		}
+		sum, size, hashErr := fileSHA256(p)
+		if hashErr != nil {
+			return hashErr
+		}
+		m.Files = append(m.Files, ArchiveFile{Path: filepath.ToSlash(rel), Size: size, SHA256: sum})
+		return nil
+	})
+	return m, err
+}
+
+func fileSHA256(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("read %s: %w", p, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// entryWriter adds files to an archive being written.
+type entryWriter interface {
+	add(name string, size int64, r io.Reader) error
+	Close() error
+}
+
+type zipEntryWriter struct{ zw *zip.Writer }
+
+func newZipWriter(w io.Writer) entryWriter { return zipEntryWriter{zip.NewWriter(w)} }
+
+func (z zipEntryWriter) add(name string, _ int64, r io.Reader) error {
+	fw, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+func (z zipEntryWriter) Close() error { return z.zw.Close() }
+
+type tarEntryWriter struct{ tw *tar.Writer }
+
+func newTarWriter(w io.Writer) entryWriter { return tarEntryWriter{tar.NewWriter(w)} }
+
+func (t tarEntryWriter) add(name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{Name: name, Size: size, Mode: workspace.PermFile, ModTime: time.Now(), Typeflag: tar.TypeReg}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(t.tw, r)
+	return err
+}
+
+func (t tarEntryWriter) Close() error { return t.tw.Close() }
+
+// writeArchive writes the manifest, then every file it lists, to a new archive at dst.
+func writeArchive(dst string, l workspace.TagLayout, m ArchiveManifest, newWriter func(io.Writer) entryWriter) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	aw := newWriter(f)
+	if err = writeEntries(aw, l, m); err == nil {
+		err = aw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("write archive %s: %w", dst, err)
+	}
+	return nil
+}
+
+func writeEntries(aw entryWriter, l workspace.TagLayout, m ArchiveManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = aw.add(ArchiveManifestFile, int64(len(data)), strings.NewReader(string(data))); err != nil {
+		return err
+	}
+	for _, file := range m.Files {
+		if err = addFile(aw, filepath.Join(l.Root, filepath.FromSlash(file.Path)), file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFile(aw entryWriter, p string, file ArchiveFile) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return aw.add(file.Path, file.Size, io.LimitReader(f, file.Size))
+}
+
+// writeTarZst writes a tar archive to a temporary directory beside dst and compresses it
+// with zstd. The temporary name never matches dst or an existing .tar next to it.
+func writeTarZst(ctx context.Context, runner tooling.Runner, dst string, l workspace.TagLayout, m ArchiveManifest) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(dst), ".prof-export-")
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	tarPath := filepath.Join(tmp, "archive.tar")
+	if err = writeArchive(tarPath, l, m, newTarWriter); err != nil {
+		return err
+	}
+	if out, err := runner.Run(ctx, tooling.ZstdCompressArgs(tarPath, dst), tooling.RunOpts{Combined: true}); err != nil {
+		return fmt.Errorf("zstd %s: %w: %s", tarPath, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// extractArchive unpacks a .zip or tar archive into dir and returns its manifest once every
+// file listed there was written with a matching size and checksum.
+func extractArchive(archive, format, dir string) (ArchiveManifest, error) {
+	x := &extractor{dir: dir}
+	var err error
+	if format == ZipExtension {
+		err = walkZip(archive, x.entry)
+	} else {
+		err = walkTar(archive, x.entry)
+	}
+	if err != nil {
+		return ArchiveManifest{}, err
+	}
+	if x.manifest == nil {
+		return ArchiveManifest{}, fmt.Errorf("%s is missing; not a prof tag archive", ArchiveManifestFile)
+	}
+	for p := range x.pending {
+		return ArchiveManifest{}, fmt.Errorf("%s lists %s, which the archive does not hold", ArchiveManifestFile, p)
+	}
+	return *x.manifest, nil
+}
+
+type extractor struct {
+	dir      string
+	manifest *ArchiveManifest
+	pending  map[string]ArchiveFile
+}
+
+func (x *extractor) entry(name string, r io.Reader) error {
+	if x.manifest == nil {
+		if name != ArchiveManifestFile {
+			return fmt.Errorf("%s must be the first entry, found %s", ArchiveManifestFile, name)
+		}
+		var m ArchiveManifest
+		if err := json.NewDecoder(r).Decode(&m); err != nil {
+			return fmt.Errorf("decode %s: %w", ArchiveManifestFile, err)
+		}
+		if m.SchemaVersion != ArchiveSchemaVersion {
+			return fmt.Errorf("%s schema_version %d is not supported (want %d)", ArchiveManifestFile, m.SchemaVersion, ArchiveSchemaVersion)
+		}
+		x.pending = make(map[string]ArchiveFile, len(m.Files))
+		for _, f := range m.Files {
+			x.pending[f.Path] = f
+		}
+		x.manifest = &m
+		return nil
+	}
+	file, ok := x.pending[name]
+	if !ok {
+		return fmt.Errorf("%s is not listed in %s", name, ArchiveManifestFile)
+	}
+	delete(x.pending, name)
+	if !filepath.IsLocal(filepath.FromSlash(name)) || path.Clean(name) != name {
+		return fmt.Errorf("%s: path escapes the tag directory", name)
+	}
+	dst := filepath.Join(x.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), workspace.PermDir); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, workspace.PermFile)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(r, file.Size+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if n != file.Size || hex.EncodeToString(h.Sum(nil)) != file.SHA256 {
+		return fmt.Errorf("%s does not match the checksum in %s; the archive is corrupt", name, ArchiveManifestFile)
+	}
+	return nil
+}
+
+func walkZip(archive string, fn func(name string, r io.Reader) error) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err = walkZipFile(f, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkZipFile(f *zip.File, fn func(name string, r io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return fn(f.Name, rc)
+}
+
+func walkTar(archive string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+			if err = fn(hdr.Name, tr); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: only regular files are supported in a tag archive", hdr.Name)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package tags
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/engine/tooling"
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+func TestExportImportTag_zipRoundTrip(t *testing.T) {
+	src := writeModule(t)
+	writeTag(t, src, "base", time.Now())
+	archive := filepath.Join(t.TempDir(), "base.zip")
+	var w bytes.Buffer
+	if err := ExportTag(context.Background(), tooling.NewExecRunner(), ExportOptions{Tag: "base", Output: archive, ProfVersion: "v1.2.3"}, &w); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := emptyModule(t)
+	if err := ImportTag(context.Background(), tooling.NewExecRunner(), ImportOptions{Archive: archive, Tag: "ci-base"}, &w); err != nil {
+		t.Fatal(err)
+	}
+	l := workspace.NewTagLayout(dst, "ci-base")
+	got, err := os.ReadFile(l.ProfileBinary(testBench, "cpu"))
+	if err != nil || !bytes.Equal(got, src.cpu) {
+		t.Fatalf("imported profile differs: %v", err)
+	}
+	m, err := datamap.ReadJSON(l.DataMapping(testBench))
+	if err != nil || m.Tag != "ci-base" || m.Provenance.Tag != "ci-base" {
+		t.Fatalf("map.json tag not rewritten: %+v %v", m, err)
+	}
+	if names, _ := workspace.TagNames(workspace.OutputDir(dst)); strings.Join(names, ",") != "ci-base" {
+		t.Fatalf("tags=%v", names)
+	}
+
+	err = ImportTag(context.Background(), tooling.NewExecRunner(), ImportOptions{Archive: archive, Tag: "ci-base"}, &w)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("second import: %v", err)
+	}
+	if err = ImportTag(context.Background(), tooling.NewExecRunner(), ImportOptions{Archive: archive, Tag: "ci-base", Force: true}, &w); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportTag_tarZst(t *testing.T) {
+	if !tooling.ZstdAvailable() {
+		t.Skip("zstd not on PATH")
+	}
+	src := writeModule(t)
+	writeTag(t, src, "base", time.Now())
+	archive := filepath.Join(t.TempDir(), "base.tar.zst")
+	var w bytes.Buffer
+	if err := ExportTag(context.Background(), tooling.NewExecRunner(), ExportOptions{Tag: "base", Output: archive}, &w); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := emptyModule(t)
+	if err := ImportTag(context.Background(), tooling.NewExecRunner(), ImportOptions{Archive: archive}, &w); err != nil {
+		t.Fatal(err)
+	}
+	if !workspace.NewTagLayout(dst, "base").Exists() {
+		t.Fatal("tag base not imported")
+	}
+}
+
+func TestExportTag_tarZstUppercaseExtension(t *testing.T) {
+	src := writeModule(t)
+	writeTag(t, src, "base", time.Now())
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "OUT.TAR.ZST")
+	sibling := filepath.Join(dir, "OUT.TAR")
+	if err := os.WriteFile(sibling, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runner := &tooling.FakeRunner{Out: [][]byte{nil}}
+	if err := ExportTag(t.Context(), runner, ExportOptions{Tag: "base", Output: archive}, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Runs) != 1 {
+		t.Fatalf("runs=%v", runner.Runs)
+	}
+	argv := runner.Runs[0].Argv
+	if tarPath := argv[len(argv)-1]; tarPath == archive || tarPath == sibling {
+		t.Fatalf("zstd input %s collides with the output", tarPath)
+	}
+	if data, err := os.ReadFile(sibling); err != nil || string(data) != "keep" {
+		t.Fatalf("existing %s changed: %q %v", sibling, data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "OUT.TAR" {
+		t.Fatalf("temporary tar left behind: %v", entries)
+	}
+}
+
+func TestImportTag_rejectsCorruptArchive(t *testing.T) {
+	root := emptyModule(t)
+	archive := filepath.Join(t.TempDir(), "bad.zip")
+	writeZip(t, archive, ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Tag:           "base",
+		Files:         []ArchiveFile{{Path: "status.json", Size: 2, SHA256: strings.Repeat("0", 64)}},
+	}, map[string]string{"status.json": "{}"})
+
+	err := ImportTag(context.Background(), tooling.NewExecRunner(), ImportOptions{Archive: archive}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("err=%v", err)
+	}
+	if workspace.NewTagLayout(root, "base").Exists() {
+		t.Fatal("corrupt archive left a tag behind")
+	}
+	if names, _ := workspace.TagNames(workspace.OutputDir(root)); len(names) != 0 {
+		t.Fatalf("tags=%v", names)
+	}
+}
+
+func TestImportTag_rejectsEscapingPath(t *testing.T) {
+	emptyModule(t)
+	archive := filepath.Join(t.TempDir(), "escape.zip")
+	writeZip(t, archive, ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Tag:           "base",
+		Files:         []ArchiveFile{{Path: "../evil", Size: 2}},
+	}, map[string]string{"../evil": "{}"})
+
+	err := ImportTag(context.Background(), tooling.NewExecRunner(), ImportOptions{Archive: archive}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+// emptyModule creates a module without tags, chdirs into it and returns its root.
+func emptyModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module imported\n\ngo 1.24.3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	return root
+}
+
+func writeZip(t *testing.T, path string, m ArchiveManifest, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []struct{ name, body string }{{ArchiveManifestFile, string(data)}}
+	for _, f := range m.Files {
+		entries = append(entries, struct{ name, body string }{f.Path, files[f.Path]})
+	}
+	for _, e := range entries {
+		fw, createErr := zw.Create(e.name)
+		if createErr != nil {
+			t.Fatal(createErr)
+		}
+		if _, err = fw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,4 @@
+// Package tags lists and manages the tags collected under .prof/ (prof tags): list, show,
+// rm, rename and prune. It reads what collection left in each tag (manifest.json,
+// status.json, map.json and the profile binaries) and never runs go test or pprof.
+package tags
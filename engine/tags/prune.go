@@ -0,0 +1,95 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// PruneOptions configures Prune. At least one of Keep and OlderThan must be set; with both,
+// a tag is removed only when it is neither among the Keep newest nor newer than OlderThan.
+type PruneOptions struct {
+	Keep      int    // newest tags always kept; 0 keeps none by count
+	OlderThan string // age such as "30d" or "12h"; empty ignores age
+	DryRun    bool   // report the tags that would be removed without removing them
+	Out       string // prof --out; empty prunes the tags under $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
+}
+
+// Prune removes the tags opts does not retain, reporting each on w.
+func Prune(opts PruneOptions, w io.Writer) error {
+	dir, err := outputDir(opts.Out)
+	if err != nil {
+		return err
+	}
+	return prune(dir, opts, time.Now(), w)
+}
+
+func prune(outputDir string, opts PruneOptions, now time.Time, w io.Writer) error {
+	if opts.Keep < 0 {
+		return errors.New("keep cannot be negative")
+	}
+	var maxAge time.Duration
+	if opts.OlderThan != "" {
+		var err error
+		if maxAge, err = ParseAge(opts.OlderThan); err != nil {
+			return err
+		}
+	}
+	if opts.Keep == 0 && maxAge == 0 {
+		return errors.New("set keep, older-than, or both")
+	}
+	summaries, err := Summaries(outputDir)
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for i, s := range summaries {
+		if i < opts.Keep || (maxAge > 0 && now.Sub(s.Date) < maxAge) {
+			continue
+		}
+		removed++
+		if opts.DryRun {
+			fmt.Fprintf(w, "Would remove %s (%s, %s)\n", s.Tag, formatDate(s.Date), formatSize(s.Size))
+			continue
+		}
+		unlock, lockErr := lockTags(outputDir, s.Tag)
+		if lockErr != nil {
+			return lockErr
+		}
+		err = removeTag(workspace.TagLayoutIn(outputDir, s.Tag), w)
+		unlock()
+		if err != nil {
+			return err
+		}
+	}
+	if removed == 0 {
+		fmt.Fprintln(w, "No tags to prune.")
+	}
+	return nil
+}
+
+// ParseAge parses a positive age: a Go duration such as "12h", or a number of days such as "30d".
+func ParseAge(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q: want a number of days such as 30d or a duration such as 12h", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid age %q: want a number of days such as 30d or a duration such as 12h", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("age %q must be positive", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,170 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/pprofscale"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+	"github.com/AlexsanderHamir/prof/parser"
+)
+
+// DefaultShowTop is the number of hotspots prof tags show prints per profile.
+const DefaultShowTop = 5
+
+// ShowOptions configures Show.
+type ShowOptions struct {
+	Tag string
+	Top int    // hotspots per profile; 0 uses DefaultShowTop
+	Out string // prof --out; empty reads the tags from $PROF_HOME or .prof/ (see workspace.ResolveOutputDir)
+}
+
+// Show prints what produced opts.Tag, then each benchmark's measurements and the top
+// functions of each profile, read from its map.json.
+func Show(opts ShowOptions, w io.Writer) error {
+	if err := workspace.ValidateTagName(opts.Tag); err != nil {
+		return err
+	}
+	if opts.Top < 0 {
+		return errors.New("top cannot be negative")
+	}
+	if opts.Top == 0 {
+		opts.Top = DefaultShowTop
+	}
+	l, err := workspace.TagLayoutFromCWD(opts.Out, opts.Tag)
+	if err != nil {
+		return err
+	}
+	if !l.Exists() {
+		return fmt.Errorf("tag %q not found at %s", opts.Tag, l.Root)
+	}
+	s, err := Summarize(l)
+	if err != nil {
+		return err
+	}
+	writeHeader(w, l, s)
+	if len(s.Variants) == 0 {
+		return writeBenchmarks(w, l, "", opts.Top)
+	}
+	for _, v := range s.Variants {
+		variant := workspace.TagLayout{Tag: workspace.VariantTag(l.Tag, v), Root: filepath.Join(l.Root, v)}
+		if err = writeBenchmarks(w, variant, " · "+v, opts.Top); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeader(w io.Writer, l workspace.TagLayout, s Summary) {
+	fmt.Fprintf(w, "Tag %s\n", l.Tag)
+	fmt.Fprintf(w, "  collected  %s (%s)\n", formatDate(s.Date), orDash(s.State))
+	fmt.Fprintf(w, "  size       %s\n", formatSize(s.Size))
+	m, err := datamap.ReadManifest(l.Manifest())
+	if err != nil {
+		return
+	}
+	if len(m.Command) > 0 {
+		fmt.Fprintf(w, "  command    %s\n", strings.Join(m.Command, " "))
+	}
+	if m.ProfVersion != "" {
+		fmt.Fprintf(w, "  prof       %s\n", m.ProfVersion)
+	}
+	if m.Git != nil {
+		dirty := ""
+		if m.Git.Dirty {
+			dirty = " (uncommitted changes)"
+		}
+		fmt.Fprintf(w, "  git        %s%s\n", m.Git.Commit, dirty)
+	}
+	f := m.Fingerprint
+	fmt.Fprintf(w, "  machine    %s %s/%s, %s, %d CPUs, GOMAXPROCS %d\n",
+		orDash(f.GoVersion), orDash(f.GOOS), orDash(f.GOARCH), orDash(f.CPUModel), f.NumCPU, f.GOMAXPROCS)
+	if len(f.Env) > 0 {
+		env := make([]string, 0, len(f.Env))
+		for _, name := range slices.Sorted(maps.Keys(f.Env)) {
+			env = append(env, name+"="+f.Env[name])
+		}
+		fmt.Fprintf(w, "  env        %s\n", strings.Join(env, " "))
+	}
+}
+
+func writeBenchmarks(w io.Writer, l workspace.TagLayout, suffix string, top int) error {
+	names, err := l.BenchmarkNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(w, "\nNo benchmarks%s.\n", suffix)
+	}
+	for _, bench := range names {
+		m, readErr := datamap.ReadJSON(l.DataMapping(bench))
+		if errors.Is(readErr, os.ErrNotExist) {
+			fmt.Fprintf(w, "\n%s%s\n  map.json not available (the benchmark did not finish)\n", bench, suffix)
+			continue
+		}
+		if readErr != nil {
+			return fmt.Errorf("benchmark %s: %w", bench, readErr)
+		}
+		fmt.Fprintf(w, "\n%s%s\n", m.Benchmark, suffix)
+		writeMeasurements(w, m.Measurements)
+		for _, profile := range slices.Sorted(maps.Keys(m.Profiles)) {
+			writeHotspots(w, l, profile, m.Profiles[profile], top)
+		}
+	}
+	return nil
+}
+
+func writeMeasurements(w io.Writer, section *datamap.MeasurementsSection) {
+	if section == nil || section.Summary == nil {
+		fmt.Fprintln(w, "  measurements: not available")
+		return
+	}
+	sum := section.Summary
+	if len(sum.Results) == 0 {
+		fmt.Fprintf(w, "  measurements: %d ns/op, %d B/op, %d allocs/op (median of %d)\n",
+			sum.NsPerOpMedian, sum.BytesPerOp, sum.AllocsPerOp, sum.Count)
+		return
+	}
+	fmt.Fprintln(w, "  measurements:")
+	for _, r := range sum.Results {
+		fmt.Fprintf(w, "    %s: %s ns/op, %s B/op, %s allocs/op (median of %d)\n",
+			r.Name, resultMedian(r, "ns/op"), resultMedian(r, "B/op"), resultMedian(r, "allocs/op"), r.Count)
+	}
+}
+
+func resultMedian(r datamap.ResultSummary, unit string) string {
+	m, ok := r.Metric(unit)
+	if !ok {
+		return "-"
+	}
+	return strconv.FormatFloat(m.Median, 'f', -1, 64)
+}
+
+// writeHotspots prints the top functions by flat value of one profile. Variants repeat their
+// kind's binary and execution traces are not pprof profiles, so both are left out.
+func writeHotspots(w io.Writer, l workspace.TagLayout, profile string, ref datamap.ProfileRef, top int) {
+	if _, sampleType := workspace.SplitProfileVariant(profile); sampleType != "" || ref.Purpose != datamap.PurposeRawPprofBinary {
+		return
+	}
+	data, err := parser.SampleIndexPipeline(ref.SampleIndex).RunFromPath(filepath.Join(l.Root, ref.Path))
+	if err != nil {
+		fmt.Fprintf(w, "  %s: %v\n", profile, err)
+		return
+	}
+	unit := ref.OutputUnit
+	if unit == "" {
+		unit = pprofscale.SelectOutputUnit(data.SampleUnit, data.Total, data.Flat, data.Cum)
+	}
+	fmt.Fprintf(w, "  %s (total %s)\n", profile, pprofscale.ScaledLabel(data.Total, data.SampleUnit, unit))
+	for _, e := range data.SortedEntries[:min(top, len(data.SortedEntries))] {
+		fmt.Fprintf(w, "    %10s %s  %s\n", pprofscale.ScaledLabel(e.Flat, data.SampleUnit, unit), pprofscale.Percentage(e.Flat, data.Total), e.Name)
+	}
+}
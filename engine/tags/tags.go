@@ -0,0 +1,245 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Summary describes one tag as prof tags list prints it.
+type Summary struct {
+	Tag        string
+	Date       time.Time // start of the last collect (manifest.json), else status.json or directory time
+	State      string    // datamap.TagState*; empty for tags collected before status.json
+	Benchmarks []string  // benchmark directory names, across variants for an env matrix tag
+	Profiles   []string
+	Variants   []string // env matrix variants nested in the tag
+	Size       int64    // bytes on disk
+}
+
+// Summarize reads the summary of the tag at l.
+func Summarize(l workspace.TagLayout) (Summary, error) {
+	s := Summary{Tag: l.Tag}
+	info, err := os.Stat(l.Root)
+	if err != nil {
+		return s, fmt.Errorf("tag %q: %w", l.Tag, err)
+	}
+	s.Date = info.ModTime()
+	if status, statusErr := datamap.ReadTagStatus(l.Status()); statusErr == nil {
+		s.State = status.State
+		s.Date = status.UpdatedAt
+	}
+	if m, manifestErr := datamap.ReadManifest(l.Manifest()); manifestErr == nil {
+		s.Date = m.StartedAt
+	}
+	if s.Variants, err = l.VariantNames(); err != nil {
+		return s, err
+	}
+	layouts := []workspace.TagLayout{l}
+	for _, v := range s.Variants {
+		layouts = append(layouts, workspace.TagLayout{Tag: workspace.VariantTag(l.Tag, v), Root: filepath.Join(l.Root, v)})
+	}
+	benchmarks := map[string]bool{}
+	profiles := map[string]bool{}
+	for _, layout := range layouts {
+		names, benchErr := layout.BenchmarkNames()
+		if benchErr != nil {
+			return s, benchErr
+		}
+		for _, bench := range names {
+			benchmarks[bench] = true
+			kinds, kindErr := layout.ProfileKinds(bench)
+			if kindErr != nil {
+				return s, kindErr
+			}
+			for _, k := range kinds {
+				profiles[k] = true
+			}
+		}
+	}
+	s.Benchmarks = slices.Sorted(maps.Keys(benchmarks))
+	s.Profiles = slices.Sorted(maps.Keys(profiles))
+	s.Size, err = dirSize(l.Root)
+	return s, err
+}
+
+// Summaries reads the summary of every tag under outputDir (see workspace.ResolveOutputDir),
+// newest first.
+func Summaries(outputDir string) ([]Summary, error) {
+	names, err := workspace.TagNames(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Summary, 0, len(names))
+	for _, name := range names {
+		s, sumErr := Summarize(workspace.TagLayoutIn(outputDir, name))
+		if sumErr != nil {
+			return nil, sumErr
+		}
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date.After(out[j].Date) })
+	return out, nil
+}
+
+// outputDir returns the directory holding the tags of the module at the current directory
+// when prof --out is out (see workspace.ResolveOutputDir).
+func outputDir(out string) (string, error) {
+	moduleRoot, err := workspace.FindModuleRoot()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate module root: %w", err)
+	}
+	return workspace.ResolveOutputDir(moduleRoot, out), nil
+}
+
+// Remove deletes each tag, reporting it on w. Every tag must exist and no prof run may be
+// writing to it; none is removed otherwise. out is prof --out (see outputDir).
+func Remove(out string, tags []string, w io.Writer) error {
+	if len(tags) == 0 {
+		return errors.New("at least one tag is required")
+	}
+	dir, err := outputDir(out)
+	if err != nil {
+		return err
+	}
+	layouts := make([]workspace.TagLayout, len(tags))
+	for i, tag := range tags {
+		if err = workspace.ValidateTagName(tag); err != nil {
+			return err
+		}
+		layouts[i] = workspace.TagLayoutIn(dir, tag)
+		if !layouts[i].Exists() {
+			return fmt.Errorf("tag %q not found at %s", tag, layouts[i].Root)
+		}
+	}
+	unlock, err := lockTags(dir, tags...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, l := range layouts {
+		if err = removeTag(l, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockTags takes the locks of tags (see workspace.LockTag), failing when a prof run is
+// writing to one of them. The returned function releases them.
+func lockTags(outputDir string, tags ...string) (func(), error) {
+	var locks []*workspace.Lock
+	unlock := func() {
+		for _, l := range locks {
+			_ = l.Unlock()
+		}
+	}
+	for _, tag := range tags {
+		l, err := workspace.LockTag(outputDir, tag)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		locks = append(locks, l)
+	}
+	return unlock, nil
+}
+
+// removeTag deletes l; the caller holds its lock.
+func removeTag(l workspace.TagLayout, w io.Writer) error {
+	if err := os.RemoveAll(l.Root); err != nil {
+		return fmt.Errorf("remove tag %s: %w", l.Tag, err)
+	}
+	fmt.Fprintf(w, "Removed %s\n", l.Tag)
+	return nil
+}
+
+// Rename moves tag from to to and updates the tag names recorded in its manifest.json and
+// map.json files, including those of its env matrix variants. out is prof --out (see outputDir).
+func Rename(out, from, to string, w io.Writer) error {
+	for _, tag := range []string{from, to} {
+		if err := workspace.ValidateTagName(tag); err != nil {
+			return err
+		}
+	}
+	dir, err := outputDir(out)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockTags(dir, from, to)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err = workspace.RenameTag(dir, from, to); err != nil {
+		return err
+	}
+	if err = retag(workspace.TagLayoutIn(dir, to), from); err != nil {
+		return fmt.Errorf("renamed %s to %s, but: %w", from, to, err)
+	}
+	fmt.Fprintf(w, "Renamed %s to %s\n", from, to)
+	return nil
+}
+
+// retag rewrites the tag recorded in the JSON files of l, which was called from before.
+func retag(l workspace.TagLayout, from string) error {
+	rename := func(tag string) string {
+		if tag == from {
+			return l.Tag
+		}
+		if rest, ok := strings.CutPrefix(tag, workspace.VariantTag(from, "")); ok {
+			return workspace.VariantTag(l.Tag, rest)
+		}
+		return tag
+	}
+	return filepath.WalkDir(l.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch d.Name() {
+		case workspace.TagManifestFile:
+			m, readErr := datamap.ReadManifest(path)
+			if readErr != nil {
+				return readErr
+			}
+			m.Tag = rename(m.Tag)
+			return datamap.WriteManifest(path, m)
+		case workspace.DataMappingFile:
+			m, readErr := datamap.ReadJSON(path)
+			if readErr != nil {
+				return readErr
+			}
+			m.Tag = rename(m.Tag)
+			m.Provenance.Tag = rename(m.Provenance.Tag)
+			return datamap.WriteJSON(path, m)
+		}
+		return nil
+	})
+}
+
+func dirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
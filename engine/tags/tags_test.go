@@ -0,0 +1,236 @@
+package tags
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/datamap"
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+const testBench = "BenchmarkFoo"
+
+// testModule is a module holding fake tags, with the cpu fixture they copy.
+type testModule struct {
+	root string
+	cpu  []byte
+}
+
+// writeModule creates a module and chdirs into it.
+func writeModule(t *testing.T) testModule {
+	t.Helper()
+	cpu, err := os.ReadFile(testpaths.MustAsset(t, "fixtures", "BenchmarkStringProcessor_cpu.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err = os.WriteFile(filepath.Join(root, "go.mod"), []byte("module tags\n\ngo 1.24.3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	return testModule{root: root, cpu: cpu}
+}
+
+// writeTag collects a fake run of testBench with the cpu fixture into tag, started at started.
+func writeTag(t *testing.T, mod testModule, tag string, started time.Time) workspace.TagLayout {
+	t.Helper()
+	l := workspace.NewTagLayout(mod.root, tag)
+	bin := l.ProfileBinary(testBench, "cpu")
+	if err := os.MkdirAll(filepath.Dir(bin), workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bin, mod.cpu, workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	rel, err := l.RelFromLayout(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := datamap.BenchmarkMap{
+		Tag:       tag,
+		Benchmark: testBench,
+		Measurements: &datamap.MeasurementsSection{Summary: &datamap.MeasurementSummary{
+			Count: 5, NsPerOpMedian: 1234, BytesPerOp: 64, AllocsPerOp: 2,
+		}},
+		Profiles: map[string]datamap.ProfileRef{
+			"cpu": {Path: rel, Purpose: datamap.PurposeRawPprofBinary, Kind: "cpu"},
+		},
+		Provenance: datamap.Provenance{Tag: tag},
+	}
+	if err = datamap.WriteJSON(l.DataMapping(testBench), m); err != nil {
+		t.Fatal(err)
+	}
+	manifest := datamap.TagManifest{
+		Tag:         tag,
+		Command:     []string{"prof", "auto", "--tag", tag},
+		Git:         &datamap.GitState{Commit: "0123abcd", Dirty: true},
+		Fingerprint: datamap.Fingerprint{GoVersion: "go1.24.3", GOOS: "linux", GOARCH: "amd64", NumCPU: 4, GOMAXPROCS: 4},
+		StartedAt:   started,
+		State:       datamap.TagStateComplete,
+	}
+	if err = datamap.WriteManifest(l.Manifest(), manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err = datamap.WriteTagStatus(l.Status(), datamap.TagStatus{State: datamap.TagStateComplete, UpdatedAt: started}); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestSummaries_newestFirst(t *testing.T) {
+	mod := writeModule(t)
+	now := time.Now().UTC()
+	writeTag(t, mod, "old", now.Add(-48*time.Hour))
+	writeTag(t, mod, "new", now)
+	if err := os.MkdirAll(workspace.NewComparisonLayout(mod.root, "old", "new").Root, workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+
+	summaries, err := Summaries(workspace.OutputDir(mod.root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].Tag != "new" || summaries[1].Tag != "old" {
+		t.Fatalf("summaries=%+v", summaries)
+	}
+	s := summaries[0]
+	if s.State != datamap.TagStateComplete || strings.Join(s.Benchmarks, ",") != testBench || strings.Join(s.Profiles, ",") != "cpu" || s.Size == 0 {
+		t.Fatalf("summary=%+v", s)
+	}
+
+	var w bytes.Buffer
+	if err = List("", &w); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(w.String(), "2 tags") || strings.Index(w.String(), "new") > strings.Index(w.String(), "old") {
+		t.Fatalf("list:\n%s", w.String())
+	}
+}
+
+func TestShow(t *testing.T) {
+	writeTag(t, writeModule(t), "base", time.Now())
+
+	var w bytes.Buffer
+	if err := Show(ShowOptions{Tag: "base", Top: 2}, &w); err != nil {
+		t.Fatal(err)
+	}
+	out := w.String()
+	for _, want := range []string{"Tag base", "0123abcd (uncommitted changes)", "go1.24.3 linux/amd64", testBench, "1234 ns/op, 64 B/op, 2 allocs/op (median of 5)", "cpu (total "} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("show output lacks %q:\n%s", want, out)
+		}
+	}
+	if err := Show(ShowOptions{Tag: "missing"}, &w); err == nil {
+		t.Fatal("expected missing tag error")
+	}
+}
+
+func TestRename_retagsRecordedNames(t *testing.T) {
+	mod := writeModule(t)
+	writeTag(t, mod, "a", time.Now())
+	writeTag(t, mod, workspace.VariantTag("a", "GOGC=50"), time.Now())
+
+	var w bytes.Buffer
+	if err := Rename("", "a", "b", &w); err != nil {
+		t.Fatal(err)
+	}
+	renamed := workspace.NewTagLayout(mod.root, "b")
+	m, err := datamap.ReadManifest(renamed.Manifest())
+	if err != nil || m.Tag != "b" {
+		t.Fatalf("manifest tag=%q err=%v", m.Tag, err)
+	}
+	variant := workspace.NewTagLayout(mod.root, workspace.VariantTag("b", "GOGC=50"))
+	bm, err := datamap.ReadJSON(variant.DataMapping(testBench))
+	if err != nil || bm.Tag != "b/GOGC=50" || bm.Provenance.Tag != "b/GOGC=50" {
+		t.Fatalf("map tag=%q provenance=%q err=%v", bm.Tag, bm.Provenance.Tag, err)
+	}
+	if err = Rename("", "b", "../c", &w); err == nil {
+		t.Fatal("expected invalid tag error")
+	}
+}
+
+func TestRemove_checksEveryTagFirst(t *testing.T) {
+	l := writeTag(t, writeModule(t), "a", time.Now())
+
+	var w bytes.Buffer
+	if err := Remove("", []string{"a", "missing"}, &w); err == nil || !l.Exists() {
+		t.Fatalf("err=%v, tag a exists=%v", err, l.Exists())
+	}
+	if err := Remove("", []string{"a"}, &w); err != nil || l.Exists() {
+		t.Fatalf("err=%v, tag a exists=%v", err, l.Exists())
+	}
+}
+
+func TestRemove_refusesTagInUse(t *testing.T) {
+	mod := writeModule(t)
+	l := writeTag(t, mod, "nightly", time.Now())
+	lock, err := workspace.LockTag(workspace.OutputDir(mod.root), "nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	var w bytes.Buffer
+	if err = Remove("", []string{"nightly"}, &w); err == nil || !strings.Contains(err.Error(), "in use") || !l.Exists() {
+		t.Fatalf("err=%v, tag exists=%v", err, l.Exists())
+	}
+	if err = Rename("", "nightly", "other", &w); err == nil || !l.Exists() {
+		t.Fatalf("rename: err=%v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		opts PruneOptions
+		kept string
+	}{
+		{"keep", PruneOptions{Keep: 2}, "d0,d10"},
+		{"older than", PruneOptions{OlderThan: "7d"}, "d0"},
+		{"both", PruneOptions{Keep: 1, OlderThan: "15d"}, "d0,d10"},
+		{"dry run", PruneOptions{Keep: 1, DryRun: true}, "d0,d10,d40"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mod := writeModule(t)
+			for _, days := range []int{0, 10, 40} {
+				writeTag(t, mod, fmt.Sprintf("d%d", days), now.AddDate(0, 0, -days))
+			}
+			var w bytes.Buffer
+			if err := prune(workspace.OutputDir(mod.root), tc.opts, now, &w); err != nil {
+				t.Fatal(err)
+			}
+			tags, err := workspace.TagNames(workspace.OutputDir(mod.root))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(tags, ","); got != tc.kept {
+				t.Fatalf("kept %s, want %s\n%s", got, tc.kept, w.String())
+			}
+		})
+	}
+	if err := prune(t.TempDir(), PruneOptions{}, now, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error without keep or older-than")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "12h": 12 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-3d", "0h", "soon"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) = nil error", in)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// dateLayout is how dates print in prof tags output, in local time.
+const dateLayout = "2006-01-02 15:04"
+
+// maxListedNames caps the benchmarks or variants one prof tags list row names.
+const maxListedNames = 3
+
+// List prints every tag under .prof/, newest first. out is prof --out (see outputDir).
+func List(out string, w io.Writer) error {
+	dir, err := outputDir(out)
+	if err != nil {
+		return err
+	}
+	summaries, err := Summaries(dir)
+	if err != nil {
+		return err
+	}
+	return WriteList(w, summaries)
+}
+
+// WriteList prints summaries as a table, one tag per row.
+func WriteList(w io.Writer, summaries []Summary) error {
+	if len(summaries) == 0 {
+		fmt.Fprintf(w, "No tags under %s/.\n", workspace.MainDirOutput)
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tDATE\tSTATE\tBENCHMARKS\tPROFILES\tSIZE\t")
+	var total int64
+	for _, s := range summaries {
+		benchmarks := listNames(s.Benchmarks)
+		if len(s.Variants) > 0 {
+			benchmarks += fmt.Sprintf(" × %d variants", len(s.Variants))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", s.Tag, formatDate(s.Date), orDash(s.State),
+			benchmarks, orDash(strings.Join(s.Profiles, ",")), formatSize(s.Size))
+		total += s.Size
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d tags, %s\n", len(summaries), formatSize(total))
+	return nil
+}
+
+// listNames joins names, naming at most maxListedNames and counting the rest.
+func listNames(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	if len(names) <= maxListedNames {
+		return strings.Join(names, ",")
+	}
+	return fmt.Sprintf("%s +%d more", strings.Join(names[:maxListedNames], ","), len(names)-maxListedNames)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(dateLayout)
+}
+
+// formatSize prints bytes in the largest binary unit that keeps the value at least 1.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
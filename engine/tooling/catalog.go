@@ -5,13 +5,34 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/config"
+)
+
+// ProfileFormat is the file format go test writes for a profile kind.
+type ProfileFormat int
+
+const (
+	// FormatPprof is a pprof protobuf, read by the profile artifact producers.
+	FormatPprof ProfileFormat = iota
+	// FormatTrace is a runtime/trace execution trace, read with go tool trace.
+	FormatTrace
 )
 
-// ProfileKind describes one go test profiling output (pprof binary) the benchmark pipeline supports.
+// ProfileKind describes one go test profiling output the benchmark pipeline supports.
 type ProfileKind struct {
 	ID          string
 	GoTestFlag  string // e.g. -cpuprofile=cpu.out
-	OutFileName string // basename written in the package directory before moves (e.g. cpu.out)
+	OutFileName string // basename written under go test -outputdir before moves (e.g. cpu.out)
+	Format      ProfileFormat
+	// SampleTypes lists sample types that each get their own artifacts (profile variants) besides
+	// the default ranking; empty for kinds with a single meaningful sample type.
+	SampleTypes []string
+	// TraceViews lists the go tool trace -pprof profile types extracted from a FormatTrace kind.
+	TraceViews []string
+	// TestMain marks a kind the benchmark package's TestMain writes, relative to the package
+	// directory, instead of go test under its -outputdir.
+	TestMain bool
 }
 
 // Catalog holds supported profile kinds and helpers to build go test / path logic from them.
@@ -20,13 +41,20 @@ type Catalog struct {
 	byID     map[string]ProfileKind
 }
 
-// DefaultCatalog returns the stock profile kinds (cpu, memory, mutex, block).
+// DefaultCatalog returns the stock profile kinds (cpu, memory, mutex, block, trace).
 func DefaultCatalog() *Catalog {
 	profiles := []ProfileKind{
 		{ID: "cpu", GoTestFlag: "-cpuprofile=cpu.out", OutFileName: "cpu.out"},
-		{ID: "memory", GoTestFlag: "-memprofile=memory.out", OutFileName: "memory.out"},
+		{
+			ID: "memory", GoTestFlag: "-memprofile=memory.out", OutFileName: "memory.out",
+			SampleTypes: []string{"alloc_space", "alloc_objects", "inuse_space", "inuse_objects"},
+		},
 		{ID: "mutex", GoTestFlag: "-mutexprofile=mutex.out", OutFileName: "mutex.out"},
 		{ID: "block", GoTestFlag: "-blockprofile=block.out", OutFileName: "block.out"},
+		{
+			ID: "trace", GoTestFlag: "-trace=trace.out", OutFileName: "trace.out", Format: FormatTrace,
+			TraceViews: []string{"net", "sync", "syscall", "sched"},
+		},
 	}
 	byID := make(map[string]ProfileKind, len(profiles))
 	for _, p := range profiles {
@@ -35,6 +63,45 @@ func DefaultCatalog() *Catalog {
 	return &Catalog{profiles: profiles, byID: byID}
 }
 
+// ConfiguredCatalog returns the stock profile kinds plus those collection.profile_kinds in cfg
+// declares. A nil cfg returns the stock kinds.
+func ConfiguredCatalog(cfg *config.Config) (*Catalog, error) {
+	if cfg == nil {
+		return DefaultCatalog(), nil
+	}
+	kinds := make([]ProfileKind, len(cfg.Collection.ProfileKinds))
+	for i, k := range cfg.Collection.ProfileKinds {
+		kinds[i] = ProfileKind{ID: k.ID, GoTestFlag: k.GoTestFlag, OutFileName: k.OutputFile, TestMain: true}
+	}
+	c, err := DefaultCatalog().With(kinds...)
+	if err != nil {
+		return nil, fmt.Errorf("collection.profile_kinds: %w", err)
+	}
+	return c, nil
+}
+
+// With returns a copy of c that also holds kinds. Ids and output file names must not repeat
+// those of c or each other, so go test output files map back to one kind.
+func (c *Catalog) With(kinds ...ProfileKind) (*Catalog, error) {
+	if c == nil {
+		return nil, errors.New("tooling: nil catalog")
+	}
+	profiles := append(c.ProfileKinds(), kinds...)
+	byID := make(map[string]ProfileKind, len(profiles))
+	files := make(map[string]string, len(profiles))
+	for _, p := range profiles {
+		if _, dup := byID[p.ID]; dup {
+			return nil, fmt.Errorf("profile %s is already defined", p.ID)
+		}
+		if other, dup := files[p.OutFileName]; dup {
+			return nil, fmt.Errorf("profile %s writes %s, like profile %s", p.ID, p.OutFileName, other)
+		}
+		byID[p.ID] = p
+		files[p.OutFileName] = p.ID
+	}
+	return &Catalog{profiles: profiles, byID: byID}, nil
+}
+
 // ProfileIDs returns supported profile identifiers in stable sorted order.
 func (c *Catalog) ProfileIDs() []string {
 	if c == nil {
@@ -99,6 +166,47 @@ func (c *Catalog) OutFileName(profileID string) (string, bool) {
 	return p.OutFileName, true
 }
 
+// WrittenByTestMain reports whether the benchmark package's TestMain writes profileID (see
+// ProfileKind.TestMain).
+func (c *Catalog) WrittenByTestMain(profileID string) bool {
+	if c == nil {
+		return false
+	}
+	return c.byID[profileID].TestMain
+}
+
+// SampleTypes returns the variant sample types registered for profileID (nil when it has none).
+func (c *Catalog) SampleTypes(profileID string) []string {
+	if c == nil {
+		return nil
+	}
+	p, ok := c.byID[profileID]
+	if !ok || len(p.SampleTypes) == 0 {
+		return nil
+	}
+	return append([]string(nil), p.SampleTypes...)
+}
+
+// Format returns the file format of profileID; unknown ids report FormatPprof.
+func (c *Catalog) Format(profileID string) ProfileFormat {
+	if c == nil {
+		return FormatPprof
+	}
+	return c.byID[profileID].Format
+}
+
+// TraceViews returns the go tool trace -pprof types extracted from profileID (nil when it is not a trace).
+func (c *Catalog) TraceViews(profileID string) []string {
+	if c == nil {
+		return nil
+	}
+	p, ok := c.byID[profileID]
+	if !ok || len(p.TraceViews) == 0 {
+		return nil
+	}
+	return append([]string(nil), p.TraceViews...)
+}
+
 // ProfileKinds returns a copy of registered profile kinds in declaration order.
 func (c *Catalog) ProfileKinds() []ProfileKind {
 	if c == nil {
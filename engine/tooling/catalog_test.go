@@ -1,10 +1,14 @@
 package tooling
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/internal/config"
+)
 
 func TestGoTestProfileArgs_unknown(t *testing.T) {
 	c := DefaultCatalog()
-	_, err := c.GoTestProfileArgs([]string{"cpu", "trace"})
+	_, err := c.GoTestProfileArgs([]string{"cpu", "goroutine"})
 	if err == nil {
 		t.Fatal("expected error for unknown profile")
 	}
@@ -19,3 +23,75 @@ func TestNormalizeProfileCSV(t *testing.T) {
 		t.Fatal("expected nil")
 	}
 }
+
+func TestCatalog_SampleTypes(t *testing.T) {
+	c := DefaultCatalog()
+	got := c.SampleTypes("memory")
+	if len(got) != 4 || got[0] != "alloc_space" || got[3] != "inuse_objects" {
+		t.Fatalf("memory sample types: %v", got)
+	}
+	got[0] = "mutated"
+	if c.SampleTypes("memory")[0] != "alloc_space" {
+		t.Fatal("SampleTypes must return a copy")
+	}
+	if c.SampleTypes("cpu") != nil || c.SampleTypes("nope") != nil {
+		t.Fatal("expected nil for kinds without variants")
+	}
+}
+
+func TestCatalog_trace(t *testing.T) {
+	c := DefaultCatalog()
+	args, err := c.GoTestProfileArgs([]string{"trace"})
+	if err != nil || len(args) != 1 || args[0] != "-trace=trace.out" {
+		t.Fatalf("trace args=%v err=%v", args, err)
+	}
+	if c.Format("trace") != FormatTrace || c.Format("cpu") != FormatPprof {
+		t.Fatal("only trace is an execution trace")
+	}
+	if got := c.TraceViews("trace"); len(got) != 4 || got[3] != "sched" {
+		t.Fatalf("trace views: %v", got)
+	}
+	if c.TraceViews("cpu") != nil {
+		t.Fatal("expected nil for pprof kinds")
+	}
+}
+
+func TestCatalog_With(t *testing.T) {
+	base := DefaultCatalog()
+	c, err := base.With(ProfileKind{ID: "goroutine", GoTestFlag: "-goroutineprofile=goroutine.out", OutFileName: "goroutine.out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args, argsErr := c.GoTestProfileArgs([]string{"cpu", "goroutine"}); argsErr != nil || args[1] != "-goroutineprofile=goroutine.out" {
+		t.Fatalf("args=%v err=%v", args, argsErr)
+	}
+	if base.ValidateProfile("goroutine") == nil {
+		t.Fatal("With must not change the receiver")
+	}
+	if _, err = base.With(ProfileKind{ID: "cpu", GoTestFlag: "-x=x.out", OutFileName: "x.out"}); err == nil {
+		t.Fatal("expected error for a built-in id")
+	}
+	if _, err = base.With(ProfileKind{ID: "heap", GoTestFlag: "-heap=cpu.out", OutFileName: "cpu.out"}); err == nil {
+		t.Fatal("expected error for a built-in output file")
+	}
+}
+
+func TestConfiguredCatalog(t *testing.T) {
+	c, err := ConfiguredCatalog(nil)
+	if err != nil || c.ValidateProfile("goroutine") == nil {
+		t.Fatalf("nil config must hold the stock kinds only: err=%v", err)
+	}
+	cfg := &config.Config{Collection: config.Collection{
+		ProfileKinds: []config.ProfileKind{{ID: "goroutine", GoTestFlag: "-goroutineprofile=goroutine.out", OutputFile: "goroutine.out"}},
+	}}
+	if c, err = ConfiguredCatalog(cfg); err != nil || c.ValidateProfile("goroutine") != nil || c.ValidateProfile("cpu") != nil {
+		t.Fatalf("configured kinds missing: err=%v", err)
+	}
+	if !c.WrittenByTestMain("goroutine") || c.WrittenByTestMain("cpu") {
+		t.Fatal("only collection.profile_kinds entries are written by TestMain")
+	}
+	cfg.Collection.ProfileKinds[0].OutputFile = "cpu.out"
+	if _, err = ConfiguredCatalog(cfg); err == nil {
+		t.Fatal("expected error for a kind writing the cpu output file")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tooling
+
+import "os/exec"
+
+// killProcessGroupOnCancel keeps the default [exec.Cmd.Cancel], which kills only the direct child.
+func killProcessGroupOnCancel(*exec.Cmd) {}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package tooling
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroupOnCancel starts cmd in its own process group and makes context
+// cancellation kill the whole group. go test runs the benchmark in a child test binary,
+// which would otherwise keep running after the go command is killed.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -40,6 +40,8 @@ func (ExecRunner) Run(ctx context.Context, argv []string, opts RunOpts) ([]byte,
 	if opts.Dir != "" {
 		cmd.Dir = opts.Dir
 	}
+	cmd.Env = opts.Env
+	killProcessGroupOnCancel(cmd)
 	if opts.Stdout != nil {
 		cmd.Stdout = opts.Stdout
 		if opts.Stderr != nil {
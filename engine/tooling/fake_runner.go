@@ -21,7 +21,8 @@ type FakeRunner struct {
 	n    int
 }
 
-// Run appends argv and opts to Runs, then returns the next configured Out/Err pair.
+// Run appends argv and opts to Runs, then returns the next configured Out/Err pair. Like
+// [ExecRunner], a run with opts.Stdout set writes Out there and returns nil output.
 func (f *FakeRunner) Run(ctx context.Context, argv []string, opts RunOpts) ([]byte, error) {
 	_ = ctx
 	if f == nil {
@@ -42,5 +43,11 @@ func (f *FakeRunner) Run(ctx context.Context, argv []string, opts RunOpts) ([]by
 	} else if i >= len(f.Out) && len(f.Err) == 0 {
 		err = errors.New("tooling: FakeRunner missing Out/Err entry")
 	}
+	if opts.Stdout != nil {
+		if _, werr := opts.Stdout.Write(out); werr != nil && err == nil {
+			err = werr
+		}
+		return nil, err
+	}
 	return out, err
 }
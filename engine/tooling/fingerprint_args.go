@@ -0,0 +1,30 @@
+package tooling
+
+import "path/filepath"
+
+// GoEnvArgs returns argv for: go env <vars...>
+// go env prints one value per line, in the order requested.
+func GoEnvArgs(vars ...string) []string {
+	return append([]string{"go", "env"}, vars...)
+}
+
+// GitHeadArgs returns argv for: git rev-parse HEAD
+func GitHeadArgs() []string {
+	return []string{"git", "rev-parse", "HEAD"}
+}
+
+// GitStatusArgs returns argv for: git status --porcelain -- :/ :(exclude)<path>...
+// Empty output means the work tree has no uncommitted changes outside the excluded paths,
+// which are relative to the directory git runs in.
+func GitStatusArgs(exclude ...string) []string {
+	args := []string{"git", "status", "--porcelain", "--", ":/"}
+	for _, path := range exclude {
+		args = append(args, ":(exclude)"+filepath.ToSlash(path))
+	}
+	return args
+}
+
+// SysctlArgs returns argv for: sysctl -n <name>
+func SysctlArgs(name string) []string {
+	return []string{"sysctl", "-n", name}
+}
@@ -0,0 +1,26 @@
+package tooling
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGoEnvArgs(t *testing.T) {
+	got := GoEnvArgs("GOVERSION", "GOOS")
+	want := []string{"go", "env", "GOVERSION", "GOOS"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestGitArgs(t *testing.T) {
+	if got := GitHeadArgs(); !slices.Equal(got, []string{"git", "rev-parse", "HEAD"}) {
+		t.Fatalf("head: %v", got)
+	}
+	if got := GitStatusArgs(); !slices.Equal(got, []string{"git", "status", "--porcelain", "--", ":/"}) {
+		t.Fatalf("status: %v", got)
+	}
+	if got := GitStatusArgs(".prof"); !slices.Equal(got, []string{"git", "status", "--porcelain", "--", ":/", ":(exclude).prof"}) {
+		t.Fatalf("status excluding .prof: %v", got)
+	}
+}
@@ -0,0 +1,12 @@
+package tooling
+
+// FailurePolicy controls whether a failed tool-backed artifact (a pprof report, a PNG, a
+// diff) fails the collect or compare run that produces it.
+type FailurePolicy int
+
+const (
+	// Required artifacts fail the run on error.
+	Required FailurePolicy = iota
+	// BestEffort artifacts log a warning and continue.
+	BestEffort
+)
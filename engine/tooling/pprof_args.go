@@ -5,24 +5,29 @@ func goToolPprofPrefix() []string {
 	return []string{"go", "tool", "pprof"}
 }
 
-// PprofTextReportArgs returns argv for: go tool pprof -cum -edgefraction=0 -nodefraction=0 -<format> <binaryPath>
+// PprofTextReportArgs returns argv for: go tool pprof -cum -edgefraction=0 -nodefraction=0 -nodecount=0 -<format> <binaryPath>
 func PprofTextReportArgs(format, binaryPath string) []string {
 	return append(goToolPprofPrefix(),
-		"-cum", "-edgefraction=0", "-nodefraction=0", "-"+format,
+		"-cum", "-edgefraction=0", "-nodefraction=0", "-nodecount=0", "-"+format,
 		binaryPath,
 	)
 }
 
-// PprofTextTopArgs returns argv for: go tool pprof -cum -edgefraction=0 -nodefraction=0 -top <binaryPath>
+// PprofTextTopArgs returns argv for: go tool pprof -cum -edgefraction=0 -nodefraction=0 -nodecount=0 -top <binaryPath>
 func PprofTextTopArgs(binaryPath string) []string {
 	return PprofTextReportArgs("top", binaryPath)
 }
 
-// PprofTextTreeArgs returns argv for: go tool pprof -cum -edgefraction=0 -nodefraction=0 -tree <binaryPath>
+// PprofTextTreeArgs returns argv for: go tool pprof -cum -edgefraction=0 -nodefraction=0 -nodecount=0 -tree <binaryPath>
 func PprofTextTreeArgs(binaryPath string) []string {
 	return PprofTextReportArgs("tree", binaryPath)
 }
 
+// PprofReportArgs returns argv for: go tool pprof <flags...> <binaryPath>
+func PprofReportArgs(flags []string, binaryPath string) []string {
+	return append(append(goToolPprofPrefix(), flags...), binaryPath)
+}
+
 // PprofPNGArgs returns argv for: go tool pprof -png <binaryPath>
 func PprofPNGArgs(binaryPath string) []string {
 	return append(append(goToolPprofPrefix(), "-png"), binaryPath)
@@ -32,3 +37,31 @@ func PprofPNGArgs(binaryPath string) []string {
 func PprofListArgs(binaryPath, pattern string) []string {
 	return append(goToolPprofPrefix(), "-list="+pattern, binaryPath)
 }
+
+// PprofDiffTextReportArgs returns argv for:
+// go tool pprof -cum -edgefraction=0 -nodefraction=0 -nodecount=0 -<format> -diff_base=<basePath> <headPath>
+func PprofDiffTextReportArgs(format, basePath, headPath string) []string {
+	return append(goToolPprofPrefix(),
+		"-cum", "-edgefraction=0", "-nodefraction=0", "-nodecount=0", "-"+format,
+		"-diff_base="+basePath,
+		headPath,
+	)
+}
+
+// PprofDiffPNGArgs returns argv for: go tool pprof -png -diff_base=<basePath> <headPath>
+func PprofDiffPNGArgs(basePath, headPath string) []string {
+	return append(goToolPprofPrefix(), "-png", "-diff_base="+basePath, headPath)
+}
+
+// WithSampleIndex inserts -sample_index=<name> after the go tool pprof prefix of argv.
+// Empty name returns argv unchanged so pprof keeps its default sample type.
+func WithSampleIndex(argv []string, name string) []string {
+	prefix := goToolPprofPrefix()
+	if name == "" || len(argv) < len(prefix) {
+		return argv
+	}
+	out := make([]string, 0, len(argv)+1)
+	out = append(out, argv[:len(prefix)]...)
+	out = append(out, "-sample_index="+name)
+	return append(out, argv[len(prefix):]...)
+}
@@ -7,7 +7,7 @@ import (
 
 func TestPprofTextReportArgs(t *testing.T) {
 	got := PprofTextReportArgs("top", "/tmp/cpu.out")
-	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-top", "/tmp/cpu.out"}
+	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-nodecount=0", "-top", "/tmp/cpu.out"}
 	if !slices.Equal(got, want) {
 		t.Fatalf("got %v", got)
 	}
@@ -15,7 +15,7 @@ func TestPprofTextReportArgs(t *testing.T) {
 
 func TestPprofTextTopArgs(t *testing.T) {
 	got := PprofTextTopArgs("/tmp/cpu.out")
-	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-top", "/tmp/cpu.out"}
+	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-nodecount=0", "-top", "/tmp/cpu.out"}
 	if !slices.Equal(got, want) {
 		t.Fatalf("got %v", got)
 	}
@@ -23,7 +23,15 @@ func TestPprofTextTopArgs(t *testing.T) {
 
 func TestPprofTextTreeArgs(t *testing.T) {
 	got := PprofTextTreeArgs("/tmp/cpu.out")
-	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-tree", "/tmp/cpu.out"}
+	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-nodecount=0", "-tree", "/tmp/cpu.out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestPprofReportArgs(t *testing.T) {
+	got := PprofReportArgs([]string{"-peek=Encode$", "-nodecount=20"}, "/tmp/cpu.out")
+	want := []string{"go", "tool", "pprof", "-peek=Encode$", "-nodecount=20", "/tmp/cpu.out"}
 	if !slices.Equal(got, want) {
 		t.Fatalf("got %v", got)
 	}
@@ -42,3 +50,30 @@ func TestPprofListArgs(t *testing.T) {
 		t.Fatalf("got %v", got)
 	}
 }
+
+func TestPprofDiffTextReportArgs(t *testing.T) {
+	got := PprofDiffTextReportArgs("tree", "/a/cpu.out", "/b/cpu.out")
+	want := []string{"go", "tool", "pprof", "-cum", "-edgefraction=0", "-nodefraction=0", "-nodecount=0", "-tree", "-diff_base=/a/cpu.out", "/b/cpu.out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestPprofDiffPNGArgs(t *testing.T) {
+	got := PprofDiffPNGArgs("/a/cpu.out", "/b/cpu.out")
+	want := []string{"go", "tool", "pprof", "-png", "-diff_base=/a/cpu.out", "/b/cpu.out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestWithSampleIndex(t *testing.T) {
+	got := WithSampleIndex(PprofListArgs("m.out", "foo"), "alloc_space")
+	want := []string{"go", "tool", "pprof", "-sample_index=alloc_space", "-list=foo", "m.out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+	if plain := WithSampleIndex(PprofPNGArgs("m.out"), ""); !slices.Equal(plain, PprofPNGArgs("m.out")) {
+		t.Fatalf("empty name should not change argv: %v", plain)
+	}
+}
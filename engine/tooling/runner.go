@@ -21,6 +21,8 @@ type StreamRunOpts struct {
 type RunOpts struct {
 	// Dir is the working directory for the child process. Empty means the current process directory.
 	Dir string
+	// Env is the environment for the child. When nil, the child inherits the current process environment ([os.Environ]).
+	Env []string
 	// Combined, when Stdout is nil, selects CombinedOutput instead of stdout-only Output.
 	Combined bool
 	// Stdout, when non-nil, receives the child stdout; the returned byte slice is nil on success.
@@ -2,10 +2,14 @@ package tooling
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestExecRunner_emptyArgv(t *testing.T) {
@@ -63,6 +67,22 @@ func TestExecRunner_withDir(t *testing.T) {
 	}
 }
 
+func TestExecRunner_withEnv(t *testing.T) {
+	r := NewExecRunner()
+	exe, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not on PATH")
+	}
+	env := append(os.Environ(), "GOFLAGS=-mod=mod")
+	out, err := r.Run(t.Context(), []string{exe, "env", "GOFLAGS"}, RunOpts{Env: env})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "-mod=mod" {
+		t.Fatalf("GOFLAGS = %q, want -mod=mod", got)
+	}
+}
+
 func TestExecRunner_stdoutWriter(t *testing.T) {
 	r := NewExecRunner()
 	exe, err := exec.LookPath("go")
@@ -79,6 +99,27 @@ func TestExecRunner_stdoutWriter(t *testing.T) {
 	}
 }
 
+func TestExecRunner_cancelKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are unix-only")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not on PATH")
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	// The backgrounded sleep holds stdout open; only killing the group lets Run return.
+	start := time.Now()
+	_, err = NewExecRunner().Run(ctx, []string{sh, "-c", "sleep 30 & wait"}, RunOpts{})
+	if err == nil {
+		t.Fatal("expected error from canceled command")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Run returned after %s; grandchild outlived cancellation", elapsed)
+	}
+}
+
 func TestFakeRunner_recordsAndReturns(t *testing.T) {
 	f := &FakeRunner{
 		Out: [][]byte{[]byte("a"), []byte("b")},
@@ -108,10 +149,10 @@ func TestFakeRunner_nilReceiver(t *testing.T) {
 func TestDefaultCatalog(t *testing.T) {
 	c := DefaultCatalog()
 	ids := c.ProfileIDsSorted()
-	if len(ids) != 4 {
+	if len(ids) != 5 {
 		t.Fatalf("ids: %v", ids)
 	}
-	for _, id := range []string{"cpu", "memory", "mutex", "block"} {
+	for _, id := range []string{"cpu", "memory", "mutex", "block", "trace"} {
 		if err := c.ValidateProfile(id); err != nil {
 			t.Fatal(err)
 		}
@@ -133,7 +174,7 @@ func TestDefaultCatalog(t *testing.T) {
 		t.Fatalf("got %q %v", name, ok)
 	}
 	m := c.KnownProfileSet()
-	if len(m) != 4 {
+	if len(m) != 5 {
 		t.Fatalf("set size %d", len(m))
 	}
 }
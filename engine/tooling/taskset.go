@@ -0,0 +1,12 @@
+package tooling
+
+// TasksetAvailable reports whether the util-linux `taskset` binary is on PATH.
+func TasksetAvailable() bool {
+	_, err := pathLook("taskset")
+	return err == nil
+}
+
+// TasksetArgs returns the argv prefix that pins a command to cpuList: taskset -c <cpuList>.
+func TasksetArgs(cpuList string) []string {
+	return []string{"taskset", "-c", cpuList}
+}
@@ -0,0 +1,18 @@
+package tooling
+
+// goToolTracePrefix returns argv prefix {"go","tool","trace"}.
+func goToolTracePrefix() []string {
+	return []string{"go", "tool", "trace"}
+}
+
+// TracePprofArgs returns argv for: go tool trace -pprof=<view> <tracePath>
+// The pprof profile is written to stdout.
+func TracePprofArgs(view, tracePath string) []string {
+	return append(goToolTracePrefix(), "-pprof="+view, tracePath)
+}
+
+// TraceEventsArgs returns argv for: go tool trace -d=parsed <tracePath>
+// It prints one line per trace event (with indented stacks) to stdout.
+func TraceEventsArgs(tracePath string) []string {
+	return append(goToolTracePrefix(), "-d=parsed", tracePath)
+}
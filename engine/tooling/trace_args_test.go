@@ -0,0 +1,22 @@
+package tooling
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTracePprofArgs(t *testing.T) {
+	got := TracePprofArgs("sched", "/tmp/trace.out")
+	want := []string{"go", "tool", "trace", "-pprof=sched", "/tmp/trace.out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestTraceEventsArgs(t *testing.T) {
+	got := TraceEventsArgs("/tmp/trace.out")
+	want := []string{"go", "tool", "trace", "-d=parsed", "/tmp/trace.out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -0,0 +1,17 @@
+package tooling
+
+// ZstdAvailable reports whether the `zstd` binary is on PATH.
+func ZstdAvailable() bool {
+	_, err := pathLook("zstd")
+	return err == nil
+}
+
+// ZstdCompressArgs returns argv for: zstd -q -f -o <dst> <src>
+func ZstdCompressArgs(src, dst string) []string {
+	return []string{"zstd", "-q", "-f", "-o", dst, src}
+}
+
+// ZstdDecompressArgs returns argv for: zstd -d -q -f -o <dst> <src>
+func ZstdDecompressArgs(src, dst string) []string {
+	return []string{"zstd", "-d", "-q", "-f", "-o", dst, src}
+}
@@ -0,0 +1,15 @@
+package tooling
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestZstdArgs(t *testing.T) {
+	if got := ZstdCompressArgs("t.tar", "t.tar.zst"); !slices.Equal(got, []string{"zstd", "-q", "-f", "-o", "t.tar.zst", "t.tar"}) {
+		t.Fatalf("compress: %v", got)
+	}
+	if got := ZstdDecompressArgs("t.tar.zst", "t.tar"); !slices.Equal(got, []string{"zstd", "-d", "-q", "-f", "-o", "t.tar", "t.tar.zst"}) {
+		t.Fatalf("decompress: %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -10,10 +11,11 @@ import (
 
 type stubCollect struct{}
 
-func (stubCollect) RunAuto(_ CollectAutoOptions) error            { return nil }
-func (stubCollect) RunManual(_ CollectManualOptions) error        { return nil }
-func (stubCollect) DiscoverBenchmarks(_ string) ([]string, error) { return nil, nil }
-func (stubCollect) SupportedProfiles() []string                   { return nil }
+func (stubCollect) RunAuto(context.Context, CollectAutoOptions) error           { return nil }
+func (stubCollect) RunManual(context.Context, CollectManualOptions) error       { return nil }
+func (stubCollect) DiscoverBenchmarks(_ string) ([]string, error)               { return nil, nil }
+func (stubCollect) ListSubBenchmarks(context.Context, string) ([]string, error) { return nil, nil }
+func (stubCollect) SupportedProfiles() []string                                 { return nil }
 
 func TestWithDefaultsFillsAgentWhenNil(t *testing.T) {
 	s := &Services{}
@@ -74,10 +76,10 @@ func TestDefaultNonNil(t *testing.T) {
 
 func TestDefaultCollectDelegates(t *testing.T) {
 	d := Default()
-	if d.Collect.RunAuto(CollectAutoOptions{}) == nil {
+	if d.Collect.RunAuto(t.Context(), CollectAutoOptions{}) == nil {
 		t.Fatal("expected error for empty benchmarks")
 	}
-	if d.Collect.RunAuto(CollectAutoOptions{Benchmarks: []string{"B"}}) == nil {
+	if d.Collect.RunAuto(t.Context(), CollectAutoOptions{Benchmarks: []string{"B"}}) == nil {
 		t.Fatal("expected error for empty profiles")
 	}
 	if p := d.Collect.SupportedProfiles(); p == nil {
@@ -95,7 +97,7 @@ func TestDefaultCollectRunManualEmptyFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 	t.Chdir(root)
-	if err := Default().Collect.RunManual(CollectManualOptions{Tag: "tag"}); err != nil {
+	if err := Default().Collect.RunManual(t.Context(), CollectManualOptions{Tag: "tag"}); err != nil {
 		t.Fatal(err)
 	}
 }
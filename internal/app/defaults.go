@@ -2,9 +2,13 @@ package app
 
 import (
 	"context"
+	"os"
 
 	"github.com/AlexsanderHamir/prof/engine/collect"
+	"github.com/AlexsanderHamir/prof/engine/compare"
 	"github.com/AlexsanderHamir/prof/engine/cursoragent"
+	"github.com/AlexsanderHamir/prof/engine/export"
+	"github.com/AlexsanderHamir/prof/engine/tags"
 	"github.com/AlexsanderHamir/prof/engine/tooling"
 	"github.com/AlexsanderHamir/prof/internal/config"
 )
@@ -15,6 +19,9 @@ func Default() *Services {
 	return &Services{
 		Runner:  r,
 		Collect: defaultCollect{runner: r},
+		Compare: defaultCompare{runner: r},
+		Export:  defaultExport{},
+		Tags:    defaultTags{runner: r},
 		Agent:   defaultAgent{},
 		Config:  defaultConfig{},
 	}
@@ -24,20 +31,78 @@ type defaultCollect struct {
 	runner tooling.Runner
 }
 
-func (d defaultCollect) RunAuto(opts CollectAutoOptions) error {
-	return collect.RunAuto(d.runner, collect.AutoOptions(opts))
+func (d defaultCollect) RunAuto(ctx context.Context, opts CollectAutoOptions) error {
+	return collect.RunAuto(ctx, d.runner, collect.AutoOptions(opts))
 }
 
-func (d defaultCollect) RunManual(opts CollectManualOptions) error {
-	return collect.RunManual(d.runner, collect.ManualOptions(opts))
+func (d defaultCollect) RunManual(ctx context.Context, opts CollectManualOptions) error {
+	return collect.RunManual(ctx, d.runner, collect.ManualOptions(opts))
 }
 
 func (d defaultCollect) DiscoverBenchmarks(scope string) ([]string, error) {
 	return collect.DiscoverBenchmarks(scope)
 }
 
+func (d defaultCollect) ListSubBenchmarks(ctx context.Context, benchmark string) ([]string, error) {
+	return collect.ListSubBenchmarks(ctx, d.runner, benchmark)
+}
+
 func (d defaultCollect) SupportedProfiles() []string {
-	return collect.SupportedProfiles
+	return collect.AvailableProfiles()
+}
+
+type defaultCompare struct {
+	runner tooling.Runner
+}
+
+func (d defaultCompare) Run(ctx context.Context, opts CompareOptions) error {
+	return compare.Run(ctx, d.runner, compare.Options(opts), os.Stdout)
+}
+
+func (defaultCompare) Gate(opts GateOptions) error {
+	return compare.Gate(compare.GateOptions(opts), os.Stdout)
+}
+
+type defaultExport struct{}
+
+func (defaultExport) Run(opts ExportOptions) error {
+	return export.Run(export.Options(opts), os.Stdout)
+}
+
+func (defaultExport) Formats() []string {
+	return export.Formats()
+}
+
+type defaultTags struct {
+	runner tooling.Runner
+}
+
+func (defaultTags) List(out string) error {
+	return tags.List(out, os.Stdout)
+}
+
+func (defaultTags) Show(opts TagShowOptions) error {
+	return tags.Show(tags.ShowOptions(opts), os.Stdout)
+}
+
+func (defaultTags) Remove(out string, names []string) error {
+	return tags.Remove(out, names, os.Stdout)
+}
+
+func (defaultTags) Rename(out, from, to string) error {
+	return tags.Rename(out, from, to, os.Stdout)
+}
+
+func (defaultTags) Prune(opts TagPruneOptions) error {
+	return tags.Prune(tags.PruneOptions(opts), os.Stdout)
+}
+
+func (d defaultTags) Export(ctx context.Context, opts TagExportOptions) error {
+	return tags.ExportTag(ctx, d.runner, tags.ExportOptions(opts), os.Stdout)
+}
+
+func (d defaultTags) Import(ctx context.Context, opts TagImportOptions) error {
+	return tags.ImportTag(ctx, d.runner, tags.ImportOptions(opts), os.Stdout)
 }
 
 type defaultAgent struct{}
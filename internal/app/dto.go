@@ -1,16 +1,85 @@
 package app
 
+import "github.com/AlexsanderHamir/prof/internal/config"
+
 // CollectAutoOptions describes a prof auto run.
 type CollectAutoOptions struct {
 	Benchmarks             []string
 	Profiles               []string
 	Tag                    string
 	Count                  int
-	MissingConfigWarnShown bool // survey already printed MissingConfigUserWarning
+	SampleIndex            map[string]string  // profile kind → pprof sample type (e.g. memory → alloc_space)
+	GoTest                 config.GoTestFlags // go test pass-through flags; override collection.go_test
+	Env                    []string           // env matrix, one NAME=v1,v2,... per variable; each combination runs as a tag variant
+	Append                 bool               // keep the tag; replace only the requested benchmarks
+	Resume                 bool               // like Append, but skip benchmarks whose map.json is complete
+	Parallel               int                // packages benchmarked at once on disjoint CPU sets; 0 or 1 is sequential
+	PerIteration           bool               // run each -count iteration as its own go test and keep profiles/<bench>/<kind>@<n>.out
+	MissingConfigWarnShown bool               // survey already printed MissingConfigUserWarning
+	ProfVersion            string             // recorded in manifest.json
+	Out                    string             // prof --out; empty uses $PROF_HOME, else .prof/
 }
 
 // CollectManualOptions describes a prof manual ingest run.
 type CollectManualOptions struct {
-	Files []string
-	Tag   string
+	Files       []string
+	Tag         string
+	ProfVersion string // recorded in manifest.json
+	Out         string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// CompareOptions describes a prof compare run.
+type CompareOptions struct {
+	Base string
+	Head string
+	Top  int
+	Out  string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// GateOptions describes a prof gate run.
+type GateOptions struct {
+	Base string
+	Head string
+	Out  string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// ExportOptions describes a prof export run.
+type ExportOptions struct {
+	Format  string // empty uses the speedscope format
+	Tag     string
+	Bench   string
+	Profile string
+	Output  string
+	Out     string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// TagShowOptions describes a prof tags show run.
+type TagShowOptions struct {
+	Tag string
+	Top int    // hotspots per profile; 0 uses the default
+	Out string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// TagPruneOptions describes a prof tags prune run.
+type TagPruneOptions struct {
+	Keep      int    // newest tags always kept
+	OlderThan string // age such as "30d"; only older tags are removed
+	DryRun    bool
+	Out       string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// TagExportOptions describes a prof export-tag run.
+type TagExportOptions struct {
+	Tag         string
+	Output      string // .zip or .tar.zst; empty writes <tag>.zip
+	ProfVersion string
+	Out         string // prof --out; empty uses $PROF_HOME, else .prof/
+}
+
+// TagImportOptions describes a prof import-tag run.
+type TagImportOptions struct {
+	Archive string
+	Tag     string // import under this name instead of the archived one
+	Force   bool   // replace an existing tag
+	Out     string // prof --out; empty uses $PROF_HOME, else .prof/
 }
@@ -8,12 +8,39 @@ import (
 	"github.com/AlexsanderHamir/prof/internal/config"
 )
 
-// Collect runs auto and manual profile collection pipelines.
+// Collect runs auto and manual profile collection pipelines. Canceling ctx stops the
+// subprocess in flight and marks the tag incomplete.
 type Collect interface {
-	RunAuto(opts CollectAutoOptions) error
-	RunManual(opts CollectManualOptions) error
+	RunAuto(ctx context.Context, opts CollectAutoOptions) error
+	RunManual(ctx context.Context, opts CollectManualOptions) error
 	DiscoverBenchmarks(scope string) ([]string, error)
-	SupportedProfiles() []string
+	ListSubBenchmarks(ctx context.Context, benchmark string) ([]string, error)
+	SupportedProfiles() []string // built-in profile kinds, then those prof.json declares
+}
+
+// Compare diffs two tags under .prof/ (prof compare) and enforces prof.json gate limits (prof gate).
+// Canceling ctx stops the go tool pprof subprocess in flight.
+type Compare interface {
+	Run(ctx context.Context, opts CompareOptions) error
+	Gate(opts GateOptions) error
+}
+
+// Export converts a collected profile to another format (prof export).
+type Export interface {
+	Run(opts ExportOptions) error
+	Formats() []string
+}
+
+// Tags lists and manages the tags under .prof/ (prof tags, prof export-tag, prof import-tag).
+// out is prof --out; empty uses $PROF_HOME, else .prof/.
+type Tags interface {
+	List(out string) error
+	Show(opts TagShowOptions) error
+	Remove(out string, tags []string) error
+	Rename(out, from, to string) error
+	Prune(opts TagPruneOptions) error
+	Export(ctx context.Context, opts TagExportOptions) error
+	Import(ctx context.Context, opts TagImportOptions) error
 }
 
 // Agent runs the cursor-agent integration when configured.
@@ -33,8 +60,13 @@ type Config interface {
 type Services struct {
 	Runner  tooling.Runner
 	Collect Collect
+	Compare Compare
+	Export  Export
+	Tags    Tags
 	Agent   Agent
 	Config  Config
+	Version string // prof version recorded in tag manifests; set by the CLI
+	Out     string // prof --out, the directory holding each project's tags; set by the CLI
 }
 
 // WithDefaults returns a copy of s with any nil fields replaced by default engine implementations.
@@ -49,6 +81,15 @@ func (s *Services) WithDefaults() *Services {
 	if out.Collect == nil {
 		out.Collect = defaultCollect{runner: out.Runner}
 	}
+	if out.Compare == nil {
+		out.Compare = defaultCompare{runner: out.Runner}
+	}
+	if out.Export == nil {
+		out.Export = defaultExport{}
+	}
+	if out.Tags == nil {
+		out.Tags = defaultTags{runner: out.Runner}
+	}
 	if out.Agent == nil {
 		out.Agent = defaultAgent{}
 	}
@@ -3,7 +3,9 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/AlexsanderHamir/prof/internal/config"
 )
@@ -49,6 +51,30 @@ func TestResolveCollectionFilter_perBenchOverrides(t *testing.T) {
 	}
 }
 
+func TestResolveCollectionFilter_subBenchmarkFallsBackToParent(t *testing.T) {
+	cfg := &config.Config{
+		Collection: config.Collection{
+			Benchmarks: map[string]config.FunctionFilter{
+				"BenchmarkCodec":            {IncludePrefixes: []string{"codec"}},
+				"BenchmarkCodec/json":       {IncludePrefixes: []string{"json"}},
+				"./xml.BenchmarkCodec/json": {IncludePrefixes: []string{"xmljson"}},
+			},
+		},
+	}
+	for name, want := range map[string]string{
+		"BenchmarkCodec/json/small":       "json",
+		"BenchmarkCodec/xml":              "codec",
+		"./xml.BenchmarkCodec/json/small": "xmljson",
+		"./bin.BenchmarkCodec/json/small": "json",
+		"./bin.BenchmarkCodec":            "codec",
+	} {
+		got := config.ResolveCollectionFilter(cfg, config.CollectionTargetAuto(name))
+		if len(got.IncludePrefixes) != 1 || got.IncludePrefixes[0] != want {
+			t.Fatalf("%s: got %+v want prefix %q", name, got, want)
+		}
+	}
+}
+
 func TestResolveCollectionFilter_manualProfile(t *testing.T) {
 	cfg := &config.Config{
 		Collection: config.Collection{
@@ -96,3 +122,198 @@ func TestSaveRoundTrip(t *testing.T) {
 		t.Fatalf("got %+v", loaded.Collection.Benchmarks)
 	}
 }
+
+func TestResolveGateLimits_perBenchOverrides(t *testing.T) {
+	ten, five := 10.0, 5.0
+	cfg := &config.Config{
+		Gate: config.Gate{
+			Defaults: config.GateLimits{
+				MaxNsPerOpRegressionPct:     &ten,
+				MaxAllocsPerOpRegressionPct: &ten,
+			},
+			Benchmarks: map[string]config.GateLimits{
+				"BenchmarkX": {
+					MaxNsPerOpRegressionPct: &five,
+					Functions:               []config.FunctionLimit{{Name: "pkg.Hot", MaxFlatPct: 30}},
+				},
+			},
+		},
+	}
+	config.Normalize(cfg)
+	got := config.ResolveGateLimits(cfg, "BenchmarkX")
+	if *got.MaxNsPerOpRegressionPct != 5 || *got.MaxAllocsPerOpRegressionPct != 10 || got.MaxBytesPerOpRegressionPct != nil {
+		t.Fatalf("got %+v", got)
+	}
+	if len(got.Functions) != 1 || got.Functions[0].Profile != config.DefaultGateProfile {
+		t.Fatalf("functions=%+v", got.Functions)
+	}
+	if other := config.ResolveGateLimits(cfg, "BenchmarkY"); *other.MaxNsPerOpRegressionPct != 10 || other.Functions != nil {
+		t.Fatalf("defaults=%+v", other)
+	}
+}
+
+func TestResolveGateLimits_looksUpLikeCollection(t *testing.T) {
+	five, seven := 5.0, 7.0
+	cfg := &config.Config{Gate: config.Gate{Benchmarks: map[string]config.GateLimits{
+		"BenchmarkSub/big":     {MaxNsPerOpRegressionPct: &five},
+		"./inner.BenchmarkFoo": {MaxNsPerOpRegressionPct: &seven},
+		"BenchmarkSub__big":    {MaxNsPerOpRegressionPct: &seven},
+	}}}
+	if got := config.ResolveGateLimits(cfg, "BenchmarkSub/big/1"); got.MaxNsPerOpRegressionPct == nil || *got.MaxNsPerOpRegressionPct != 5 {
+		t.Fatalf("sub-benchmark=%+v", got)
+	}
+	if got := config.ResolveGateLimits(cfg, "./inner.BenchmarkFoo"); got.MaxNsPerOpRegressionPct == nil || *got.MaxNsPerOpRegressionPct != 7 {
+		t.Fatalf("package-qualified=%+v", got)
+	}
+	unmatched := config.UnmatchedGateBenchmarks(cfg, []string{"BenchmarkSub/big/1", "./inner.BenchmarkFoo"})
+	if !slices.Equal(unmatched, []string{"BenchmarkSub__big"}) {
+		t.Fatalf("unmatched=%v", unmatched)
+	}
+}
+
+func TestValidate_gateLimits(t *testing.T) {
+	neg := -1.0
+	for name, g := range map[string]config.Gate{
+		"negative pct":   {Defaults: config.GateLimits{MaxBytesPerOpRegressionPct: &neg}},
+		"missing name":   {Defaults: config.GateLimits{Functions: []config.FunctionLimit{{MaxFlatPct: 10}}}},
+		"flat over 100%": {Benchmarks: map[string]config.GateLimits{"BenchmarkX": {Functions: []config.FunctionLimit{{Name: "f", MaxFlatPct: 101}}}}},
+	} {
+		cfg := &config.Config{Gate: g}
+		config.Normalize(cfg)
+		if err := config.Validate(cfg); err == nil {
+			t.Fatalf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestResolveSampleIndex_overridesWin(t *testing.T) {
+	cfg := &config.Config{Collection: config.Collection{SampleIndex: map[string]string{
+		"memory": " inuse_space ",
+		"block":  "delay",
+		"mutex":  " ",
+	}}}
+	config.Normalize(cfg)
+	got := config.ResolveSampleIndex(cfg, map[string]string{"memory": "alloc_space"})
+	if len(got) != 2 || got["memory"] != "alloc_space" || got["block"] != "delay" {
+		t.Fatalf("got %v", got)
+	}
+	if none := config.ResolveSampleIndex(nil, nil); none != nil {
+		t.Fatalf("expected nil, got %v", none)
+	}
+}
+
+func TestValidate_renderer(t *testing.T) {
+	for renderer, ok := range map[string]bool{"": true, " Builtin ": true, "pprof": true, "graphviz": false} {
+		cfg := &config.Config{Collection: config.Collection{Renderer: renderer}}
+		config.Normalize(cfg)
+		if err := config.Validate(cfg); (err == nil) != ok {
+			t.Fatalf("renderer %q: err = %v", renderer, err)
+		}
+	}
+}
+
+func TestResolveGoTestFlags_precedence(t *testing.T) {
+	yes, no := true, false
+	cfg := &config.Config{Collection: config.Collection{GoTest: config.GoTestConfig{
+		Defaults: config.GoTestFlags{Benchtime: " 1s ", Timeout: "30m", Race: &yes},
+		Benchmarks: map[string]config.GoTestFlags{
+			"BenchmarkCodec": {Benchtime: "5s", Tags: "integration", ExtraArgs: []string{"-shuffle=on", " "}},
+		},
+	}}}
+	config.Normalize(cfg)
+
+	got := config.ResolveGoTestFlags(cfg, "./codec.BenchmarkCodec/json", config.GoTestFlags{CPU: "1,4", Race: &no})
+	if got.Benchtime != "5s" || got.Timeout != "30m" || got.Tags != "integration" || got.CPU != "1,4" {
+		t.Fatalf("got %+v", got)
+	}
+	if got.Race == nil || *got.Race {
+		t.Fatalf("command-line race=false should win, got %v", got.Race)
+	}
+	if len(got.ExtraArgs) != 1 || got.ExtraArgs[0] != "-shuffle=on" {
+		t.Fatalf("extra args=%v", got.ExtraArgs)
+	}
+	if other := config.ResolveGoTestFlags(cfg, "BenchmarkOther", config.GoTestFlags{}); other.Benchtime != "1s" || other.Tags != "" {
+		t.Fatalf("defaults only: %+v", other)
+	}
+	if !config.GoTestFlagsEmpty(config.ResolveGoTestFlags(nil, "BenchmarkX", config.GoTestFlags{})) {
+		t.Fatal("nil config without overrides should pass nothing")
+	}
+}
+
+func TestValidate_goTestExtraArgs(t *testing.T) {
+	for args, ok := range map[string]bool{
+		"-shuffle=on":       true,
+		"-memprofilerate=1": true,
+		"-count=3":          false,
+		"--cpuprofile=x":    false,
+		"-test.bench=.":     false,
+		"-benchtime=2s":     false,
+		"-race":             false,
+	} {
+		cfg := &config.Config{Collection: config.Collection{GoTest: config.GoTestConfig{
+			Benchmarks: map[string]config.GoTestFlags{"BenchmarkX": {ExtraArgs: []string{args}}},
+		}}}
+		config.Normalize(cfg)
+		if err := config.Validate(cfg); (err == nil) != ok {
+			t.Fatalf("extra arg %q: err = %v", args, err)
+		}
+	}
+}
+
+func TestValidate_phaseTimeouts(t *testing.T) {
+	for value, ok := range map[string]bool{"": true, " 90s ": true, "1h30m": true, "0s": false, "-1m": false, "10": false} {
+		cfg := &config.Config{Collection: config.Collection{Timeouts: config.PhaseTimeouts{SourceLines: value}}}
+		config.Normalize(cfg)
+		if err := config.Validate(cfg); (err == nil) != ok {
+			t.Fatalf("timeout %q: err = %v", value, err)
+		}
+	}
+	if got := config.PhaseTimeout("90s"); got != 90*time.Second {
+		t.Fatalf("PhaseTimeout(90s) = %s", got)
+	}
+	if got := config.PhaseTimeout(""); got != 0 {
+		t.Fatalf("empty timeout should mean no limit, got %s", got)
+	}
+}
+
+func TestValidate_profileKindsAndProducers(t *testing.T) {
+	goroutine := config.ProfileKind{ID: "goroutine", GoTestFlag: "-goroutineprofile=goroutine.out", OutputFile: "goroutine.out"}
+	peek := config.ArtifactProducer{ID: "peek", Args: []string{`-peek=\.{benchmark}$`}}
+	for name, tc := range map[string]struct {
+		col config.Collection
+		ok  bool
+	}{
+		"valid":                {config.Collection{ProfileKinds: []config.ProfileKind{goroutine}, Producers: []config.ArtifactProducer{peek, {ID: "dot", Args: []string{"-dot"}, Extension: ".dot", Policy: " Best_Effort "}}}, true},
+		"kind id separator":    {config.Collection{ProfileKinds: []config.ProfileKind{{ID: "go_routine", GoTestFlag: "-g=g.out", OutputFile: "g.out"}}}, false},
+		"duplicate kind":       {config.Collection{ProfileKinds: []config.ProfileKind{goroutine, goroutine}}, false},
+		"managed go test flag": {config.Collection{ProfileKinds: []config.ProfileKind{{ID: "heap", GoTestFlag: "-memprofile=heap.out", OutputFile: "heap.out"}}}, false},
+		"output file dir":      {config.Collection{ProfileKinds: []config.ProfileKind{{ID: "heap", GoTestFlag: "-heap=x/heap.out", OutputFile: "x/heap.out"}}}, false},
+		"no producer args":     {config.Collection{Producers: []config.ArtifactProducer{{ID: "empty"}}}, false},
+		"positional arg":       {config.Collection{Producers: []config.ArtifactProducer{{ID: "bin", Args: []string{"-raw", "cpu.out"}}}}, false},
+		"unmanaged flag":       {config.Collection{Producers: []config.ArtifactProducer{{ID: "web", Args: []string{"-http=:8080"}}}}, false},
+		"unknown placeholder":  {config.Collection{Producers: []config.ArtifactProducer{{ID: "list", Args: []string{"-list={function}"}}}}, false},
+		"unknown policy":       {config.Collection{Producers: []config.ArtifactProducer{{ID: "raw", Args: []string{"-raw"}, Policy: "sometimes"}}}, false},
+	} {
+		cfg := &config.Config{Collection: tc.col}
+		config.Normalize(cfg)
+		if err := config.Validate(cfg); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestArtifactProducer_appliesAndExpands(t *testing.T) {
+	p := config.ArtifactProducer{ID: "peek", Args: []string{`-peek=\.{benchmark}$`, "-tagfocus={profile}"}, Profiles: []string{"memory", "trace_sched"}}
+	for profile, want := range map[string]bool{"memory": true, "memory.alloc_space": true, "trace_sched": true, "cpu": false, "trace_net": false} {
+		if got := p.AppliesTo(profile); got != want {
+			t.Errorf("AppliesTo(%q) = %v", profile, got)
+		}
+	}
+	got := p.ExpandArgs("BenchmarkEncode", "memory")
+	if got[0] != `-peek=\.BenchmarkEncode$` || got[1] != "-tagfocus=memory" || p.Args[0] != `-peek=\.{benchmark}$` {
+		t.Fatalf("expanded %v from %v", got, p.Args)
+	}
+	if p.FileExtension() != config.DefaultProducerExtension || p.BestEffort() {
+		t.Fatal("defaults: txt, required")
+	}
+}
@@ -7,6 +7,18 @@ const (
 	ExampleFilename = "prof.json.example"
 	// CurrentVersion is the supported prof.json schema version.
 	CurrentVersion = 1
+	// DefaultGateProfile is the profile a gate function limit reads when none is set.
+	DefaultGateProfile = "cpu"
+	// RendererBuiltin renders hotspots, call trees and source lines in-process (collection.renderer default).
+	RendererBuiltin = "builtin"
+	// RendererPprof renders them with go tool pprof -top/-tree/-list subprocesses.
+	RendererPprof = "pprof"
+	// ProducerRequired fails profile processing when an artifact producer fails (policy default).
+	ProducerRequired = "required"
+	// ProducerBestEffort warns and goes on when an artifact producer fails.
+	ProducerBestEffort = "best_effort"
+	// DefaultProducerExtension is the file extension of a producer's output when none is set.
+	DefaultProducerExtension = "txt"
 	// MissingConfigUserWarning is shown when prof.json is absent during collect.
 	MissingConfigUserWarning = "No prof.json found; proceeding without function filters (run prof config init to add one)."
 )
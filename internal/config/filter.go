@@ -1,5 +1,12 @@
 package config
 
+import (
+	"slices"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
 // ResolveCollectionFilter returns the merged function filter for a collection target.
 // Precedence: named benchmark/manual entry field overrides defaults; empty fields inherit.
 func ResolveCollectionFilter(cfg *Config, target CollectionTarget) FunctionFilter {
@@ -10,9 +17,7 @@ func ResolveCollectionFilter(cfg *Config, target CollectionTarget) FunctionFilte
 	var named FunctionFilter
 	switch {
 	case target.auto != "":
-		if cfg.Collection.Benchmarks != nil {
-			named = cfg.Collection.Benchmarks[target.auto]
-		}
+		named = benchmarkFilter(cfg.Collection.Benchmarks, target.auto)
 	case target.manual != "":
 		if cfg.Collection.ManualProfiles != nil {
 			named = cfg.Collection.ManualProfiles[target.manual]
@@ -22,6 +27,46 @@ func ResolveCollectionFilter(cfg *Config, target CollectionTarget) FunctionFilte
 	return mergeFunctionFilter(cfg.Collection.Defaults, named)
 }
 
+// benchmarkFilter returns the filter configured for name; see lookupBenchmark.
+func benchmarkFilter(benchmarks map[string]FunctionFilter, name string) FunctionFilter {
+	f, _ := lookupBenchmark(benchmarks, name)
+	return f
+}
+
+// lookupBenchmark returns the entry configured for name. A sub-benchmark path without its
+// own entry (BenchmarkCodec/json/small) falls back to its closest configured parent. At each
+// level a package-qualified name (./internal/codec.BenchmarkCodec) is looked up as written,
+// then without its package.
+func lookupBenchmark[T any](benchmarks map[string]T, name string) (T, bool) {
+	key, ok := benchmarkKey(benchmarks, name)
+	return benchmarks[key], ok
+}
+
+// benchmarkKey returns the key of benchmarks that lookupBenchmark resolves name to.
+func benchmarkKey[T any](benchmarks map[string]T, name string) (string, bool) {
+	pkg, name := workspace.SplitQualifiedBenchmark(name)
+	for {
+		if pkg != "" {
+			if key := workspace.QualifyBenchmark(pkg, name); hasKey(benchmarks, key) {
+				return key, true
+			}
+		}
+		if hasKey(benchmarks, name) {
+			return name, true
+		}
+		i := strings.LastIndex(name, workspace.SubBenchmarkSeparator)
+		if i < 0 {
+			return "", false
+		}
+		name = name[:i]
+	}
+}
+
+func hasKey[T any](m map[string]T, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
 func mergeFunctionFilter(defaults, named FunctionFilter) FunctionFilter {
 	out := defaults
 	if len(named.IncludePrefixes) > 0 {
@@ -32,3 +77,127 @@ func mergeFunctionFilter(defaults, named FunctionFilter) FunctionFilter {
 	}
 	return out
 }
+
+// ResolveGateLimits returns the merged gate limits for benchmark, the name it was collected
+// under. Precedence matches ResolveCollectionFilter: set benchmark fields override defaults,
+// and the gate.benchmarks entry is looked up like collection.benchmarks.
+func ResolveGateLimits(cfg *Config, benchmark string) GateLimits {
+	if cfg == nil {
+		return GateLimits{}
+	}
+	out := cfg.Gate.Defaults
+	named, ok := lookupBenchmark(cfg.Gate.Benchmarks, benchmark)
+	if !ok {
+		return out
+	}
+	if named.MaxNsPerOpRegressionPct != nil {
+		out.MaxNsPerOpRegressionPct = named.MaxNsPerOpRegressionPct
+	}
+	if named.MaxBytesPerOpRegressionPct != nil {
+		out.MaxBytesPerOpRegressionPct = named.MaxBytesPerOpRegressionPct
+	}
+	if named.MaxAllocsPerOpRegressionPct != nil {
+		out.MaxAllocsPerOpRegressionPct = named.MaxAllocsPerOpRegressionPct
+	}
+	if len(named.Functions) > 0 {
+		out.Functions = named.Functions
+	}
+	return out
+}
+
+// UnmatchedGateBenchmarks returns the gate.benchmarks keys, sorted, that none of benchmarks
+// resolves to in ResolveGateLimits; their limits would never be checked.
+func UnmatchedGateBenchmarks(cfg *Config, benchmarks []string) []string {
+	if cfg == nil {
+		return nil
+	}
+	used := make(map[string]bool, len(cfg.Gate.Benchmarks))
+	for _, name := range benchmarks {
+		if key, ok := benchmarkKey(cfg.Gate.Benchmarks, name); ok {
+			used[key] = true
+		}
+	}
+	var out []string
+	for key := range cfg.Gate.Benchmarks {
+		if !used[key] {
+			out = append(out, key)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ResolveGoTestFlags returns the go test flags for benchmark: collection.go_test.defaults,
+// then the benchmark's entry (looked up like collection.benchmarks), then overrides from the
+// command line. Set fields win field by field; a set ExtraArgs replaces the earlier list.
+func ResolveGoTestFlags(cfg *Config, benchmark string, overrides GoTestFlags) GoTestFlags {
+	var out GoTestFlags
+	if cfg != nil {
+		out = cfg.Collection.GoTest.Defaults
+		if named, ok := lookupBenchmark(cfg.Collection.GoTest.Benchmarks, benchmark); ok {
+			out = mergeGoTestFlags(out, named)
+		}
+	}
+	return mergeGoTestFlags(out, overrides)
+}
+
+func mergeGoTestFlags(base, over GoTestFlags) GoTestFlags {
+	out := base
+	if over.Benchtime != "" {
+		out.Benchtime = over.Benchtime
+	}
+	if over.CPU != "" {
+		out.CPU = over.CPU
+	}
+	if over.Timeout != "" {
+		out.Timeout = over.Timeout
+	}
+	if over.Tags != "" {
+		out.Tags = over.Tags
+	}
+	if over.Gcflags != "" {
+		out.Gcflags = over.Gcflags
+	}
+	if over.Ldflags != "" {
+		out.Ldflags = over.Ldflags
+	}
+	if over.Race != nil {
+		out.Race = over.Race
+	}
+	if len(over.ExtraArgs) > 0 {
+		out.ExtraArgs = over.ExtraArgs
+	}
+	return out
+}
+
+// GoTestFlagsEmpty reports whether f passes nothing to go test.
+func GoTestFlagsEmpty(f GoTestFlags) bool {
+	return f.Benchtime == "" && f.CPU == "" && f.Timeout == "" && f.Tags == "" &&
+		f.Gcflags == "" && f.Ldflags == "" && f.Race == nil && len(f.ExtraArgs) == 0
+}
+
+// GateLimitsEmpty reports whether l checks nothing.
+func GateLimitsEmpty(l GateLimits) bool {
+	return l.MaxNsPerOpRegressionPct == nil &&
+		l.MaxBytesPerOpRegressionPct == nil &&
+		l.MaxAllocsPerOpRegressionPct == nil &&
+		len(l.Functions) == 0
+}
+
+// ResolveSampleIndex merges collection.sample_index with per-run overrides (e.g. --sample-index);
+// overrides win per profile kind. Returns nil when nothing is selected.
+func ResolveSampleIndex(cfg *Config, overrides map[string]string) map[string]string {
+	out := make(map[string]string)
+	if cfg != nil {
+		for profile, sampleType := range cfg.Collection.SampleIndex {
+			out[profile] = sampleType
+		}
+	}
+	for profile, sampleType := range NormalizeSampleIndex(overrides) {
+		out[profile] = sampleType
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
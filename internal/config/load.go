@@ -92,17 +92,22 @@ func Default() *Config {
 	return cfg
 }
 
-// configForJSON omits empty collection sections so minimal prof.json stays version-only.
+// configForJSON omits empty collection and gate sections so minimal prof.json stays version-only.
 func configForJSON(cfg Config) any {
 	type fileConfig struct {
 		Version    int         `json:"version"`
 		Collection *Collection `json:"collection,omitempty"`
+		Gate       *Gate       `json:"gate,omitempty"`
 	}
 	out := fileConfig{Version: cfg.Version}
 	if !collectionEmpty(cfg.Collection) {
 		col := cfg.Collection
 		out.Collection = &col
 	}
+	if !gateEmpty(cfg.Gate) {
+		gate := cfg.Gate
+		out.Gate = &gate
+	}
 	return out
 }
 
@@ -184,6 +189,8 @@ func PrintAutoConfiguration(args *AutoArgs, cfg *Config) {
 		"Profiles", args.Profiles,
 		"Tag", args.Tag,
 		"Count", args.Count,
+		"SampleIndex", args.SampleIndex,
+		"Renderer", args.Renderer,
 	)
 
 	if cfg == nil {
@@ -14,10 +14,126 @@ func Normalize(cfg *Config) {
 	cfg.Collection.Defaults = normalizeFunctionFilter(cfg.Collection.Defaults)
 	cfg.Collection.Benchmarks = normalizeFunctionFilterMap(cfg.Collection.Benchmarks)
 	cfg.Collection.ManualProfiles = normalizeFunctionFilterMap(cfg.Collection.ManualProfiles)
+	cfg.Collection.SampleIndex = NormalizeSampleIndex(cfg.Collection.SampleIndex)
+	cfg.Collection.Renderer = strings.ToLower(strings.TrimSpace(cfg.Collection.Renderer))
+	cfg.Collection.GoTest.Defaults = NormalizeGoTestFlags(cfg.Collection.GoTest.Defaults)
+	cfg.Collection.GoTest.Benchmarks = normalizeGoTestFlagsMap(cfg.Collection.GoTest.Benchmarks)
+	cfg.Collection.Timeouts = PhaseTimeouts{
+		Benchmark:   strings.TrimSpace(cfg.Collection.Timeouts.Benchmark),
+		Profiles:    strings.TrimSpace(cfg.Collection.Timeouts.Profiles),
+		SourceLines: strings.TrimSpace(cfg.Collection.Timeouts.SourceLines),
+	}
+	cfg.Collection.ProfileKinds = normalizeProfileKinds(cfg.Collection.ProfileKinds)
+	cfg.Collection.Producers = normalizeProducers(cfg.Collection.Producers)
+	cfg.Gate.Defaults = normalizeGateLimits(cfg.Gate.Defaults)
+	cfg.Gate.Benchmarks = normalizeGateLimitsMap(cfg.Gate.Benchmarks)
 }
 
 func collectionEmpty(c Collection) bool {
-	return functionFilterEmpty(c.Defaults) && c.Benchmarks == nil && c.ManualProfiles == nil
+	return functionFilterEmpty(c.Defaults) && c.Benchmarks == nil && c.ManualProfiles == nil && c.SampleIndex == nil && c.Renderer == "" &&
+		GoTestFlagsEmpty(c.GoTest.Defaults) && c.GoTest.Benchmarks == nil && c.Timeouts == PhaseTimeouts{} &&
+		c.EnvMatrix == nil && c.ProfileKinds == nil && c.Producers == nil
+}
+
+// NormalizeSampleIndex trims profile kinds and sample type names and drops incomplete entries.
+func NormalizeSampleIndex(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for profile, sampleType := range m {
+		profile = strings.TrimSpace(profile)
+		sampleType = strings.TrimSpace(sampleType)
+		if profile == "" || sampleType == "" {
+			continue
+		}
+		out[profile] = sampleType
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func gateEmpty(g Gate) bool {
+	return GateLimitsEmpty(g.Defaults) && g.Benchmarks == nil
+}
+
+func normalizeGateLimitsMap(m map[string]GateLimits) map[string]GateLimits {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]GateLimits, len(m))
+	for k, v := range m {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		v = normalizeGateLimits(v)
+		if GateLimitsEmpty(v) {
+			continue
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func normalizeGateLimits(l GateLimits) GateLimits {
+	if len(l.Functions) == 0 {
+		l.Functions = nil
+		return l
+	}
+	fns := make([]FunctionLimit, 0, len(l.Functions))
+	for _, f := range l.Functions {
+		f.Name = strings.TrimSpace(f.Name)
+		f.Profile = strings.TrimSpace(f.Profile)
+		if f.Profile == "" {
+			f.Profile = DefaultGateProfile
+		}
+		fns = append(fns, f)
+	}
+	l.Functions = fns
+	return l
+}
+
+// NormalizeGoTestFlags trims every field and drops empty extra args.
+func NormalizeGoTestFlags(f GoTestFlags) GoTestFlags {
+	f.Benchtime = strings.TrimSpace(f.Benchtime)
+	f.CPU = strings.TrimSpace(f.CPU)
+	f.Timeout = strings.TrimSpace(f.Timeout)
+	f.Tags = strings.TrimSpace(f.Tags)
+	f.Gcflags = strings.TrimSpace(f.Gcflags)
+	f.Ldflags = strings.TrimSpace(f.Ldflags)
+	f.ExtraArgs = trimStrings(f.ExtraArgs)
+	if len(f.ExtraArgs) == 0 {
+		f.ExtraArgs = nil
+	}
+	return f
+}
+
+func normalizeGoTestFlagsMap(m map[string]GoTestFlags) map[string]GoTestFlags {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]GoTestFlags, len(m))
+	for k, v := range m {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		v = NormalizeGoTestFlags(v)
+		if GoTestFlagsEmpty(v) {
+			continue
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
 }
 
 func normalizeFunctionFilterMap(m map[string]FunctionFilter) map[string]FunctionFilter {
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Placeholders substituted in ArtifactProducer.Args.
+const (
+	producerBenchmarkVar = "{benchmark}"
+	producerProfileVar   = "{profile}"
+)
+
+var (
+	// Profile kind ids may not contain the variant (.) or trace view (_) separators.
+	profileKindIDRe = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+	producerIDRe    = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	extensionRe     = regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9]+)*$`)
+	placeholderRe   = regexp.MustCompile(`\{[a-z_]+\}`)
+)
+
+// pprofUnmanagedFlags are go tool pprof flags a producer may not pass: prof sets the sample
+// index and writes stdout to the report path, and the others start a server or a browser.
+var pprofUnmanagedFlags = []string{"sample_index", "output", "o", "http", "web", "weblist", "no_browser"}
+
+// AppliesTo reports whether the producer runs for profile, a profile kind, variant
+// (memory.alloc_space) or trace view (trace_sched). A variant also matches its kind.
+func (p ArtifactProducer) AppliesTo(profile string) bool {
+	if len(p.Profiles) == 0 {
+		return true
+	}
+	kind, _ := workspace.SplitProfileVariant(profile)
+	return slices.Contains(p.Profiles, profile) || slices.Contains(p.Profiles, kind)
+}
+
+// ExpandArgs returns Args with {benchmark} set to benchmarkFunc and {profile} to profile.
+func (p ArtifactProducer) ExpandArgs(benchmarkFunc, profile string) []string {
+	r := strings.NewReplacer(producerBenchmarkVar, benchmarkFunc, producerProfileVar, profile)
+	out := make([]string, len(p.Args))
+	for i, arg := range p.Args {
+		out[i] = r.Replace(arg)
+	}
+	return out
+}
+
+// FileExtension returns Extension, or DefaultProducerExtension when it is empty.
+func (p ArtifactProducer) FileExtension() string {
+	if p.Extension == "" {
+		return DefaultProducerExtension
+	}
+	return p.Extension
+}
+
+// BestEffort reports whether a failure of the producer only warns.
+func (p ArtifactProducer) BestEffort() bool {
+	return p.Policy == ProducerBestEffort
+}
+
+func normalizeProfileKinds(kinds []ProfileKind) []ProfileKind {
+	if len(kinds) == 0 {
+		return nil
+	}
+	out := make([]ProfileKind, len(kinds))
+	for i, k := range kinds {
+		out[i] = ProfileKind{
+			ID:         strings.TrimSpace(k.ID),
+			GoTestFlag: strings.TrimSpace(k.GoTestFlag),
+			OutputFile: strings.TrimSpace(k.OutputFile),
+		}
+	}
+	return out
+}
+
+func normalizeProducers(producers []ArtifactProducer) []ArtifactProducer {
+	if len(producers) == 0 {
+		return nil
+	}
+	out := make([]ArtifactProducer, len(producers))
+	for i, p := range producers {
+		out[i] = ArtifactProducer{
+			ID:        strings.TrimSpace(p.ID),
+			Args:      trimNonEmpty(p.Args),
+			Profiles:  trimNonEmpty(p.Profiles),
+			Extension: strings.TrimPrefix(strings.TrimSpace(p.Extension), "."),
+			Policy:    strings.ToLower(strings.TrimSpace(p.Policy)),
+		}
+	}
+	return out
+}
+
+func trimNonEmpty(in []string) []string {
+	var out []string
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func validateProfileKinds(kinds []ProfileKind) error {
+	seen := make(map[string]bool, len(kinds))
+	for i, k := range kinds {
+		where := fmt.Sprintf("config: collection.profile_kinds[%d]", i)
+		if !profileKindIDRe.MatchString(k.ID) {
+			return fmt.Errorf("%s.id must be lowercase letters and digits, got %q", where, k.ID)
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("%s.id %q is declared twice", where, k.ID)
+		}
+		seen[k.ID] = true
+		if !strings.HasPrefix(k.GoTestFlag, "-") {
+			return fmt.Errorf("%s.go_test_flag must be a flag such as \"-%sprofile=%s.out\", got %q", where, k.ID, k.ID, k.GoTestFlag)
+		}
+		if err := ValidateGoTestFlags(GoTestFlags{ExtraArgs: []string{k.GoTestFlag}}); err != nil {
+			return fmt.Errorf("%s.go_test_flag: %w", where, err)
+		}
+		if k.OutputFile == "" || filepath.Base(k.OutputFile) != k.OutputFile || k.OutputFile == "." || k.OutputFile == ".." {
+			return fmt.Errorf("%s.output_file must be a file name without directories, got %q", where, k.OutputFile)
+		}
+	}
+	return nil
+}
+
+func validateProducers(producers []ArtifactProducer) error {
+	seen := make(map[string]bool, len(producers))
+	for i, p := range producers {
+		where := fmt.Sprintf("config: collection.producers[%d]", i)
+		if !producerIDRe.MatchString(p.ID) {
+			return fmt.Errorf("%s.id must be lowercase letters, digits, '-' and '_', got %q", where, p.ID)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("%s.id %q is declared twice", where, p.ID)
+		}
+		seen[p.ID] = true
+		if len(p.Args) == 0 {
+			return fmt.Errorf("%s.args must hold at least one go tool pprof flag such as \"-traces\"", where)
+		}
+		for _, arg := range p.Args {
+			if !strings.HasPrefix(arg, "-") {
+				return fmt.Errorf("%s.args: %q is not a flag; prof appends the profile binary itself", where, arg)
+			}
+			name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if slices.Contains(pprofUnmanagedFlags, name) {
+				return fmt.Errorf("%s.args: -%s is not supported in a producer", where, name)
+			}
+			for _, v := range placeholderRe.FindAllString(arg, -1) {
+				if v != producerBenchmarkVar && v != producerProfileVar {
+					return fmt.Errorf("%s.args: unknown placeholder %s in %q (use %s or %s)", where, v, arg, producerBenchmarkVar, producerProfileVar)
+				}
+			}
+		}
+		if p.Extension != "" && !extensionRe.MatchString(p.Extension) {
+			return fmt.Errorf("%s.extension must be lowercase letters and digits such as \"dot\", got %q", where, p.Extension)
+		}
+		switch p.Policy {
+		case "", ProducerRequired, ProducerBestEffort:
+		default:
+			return fmt.Errorf("%s.policy must be %q or %q, got %q", where, ProducerRequired, ProducerBestEffort, p.Policy)
+		}
+	}
+	return nil
+}
@@ -60,6 +60,74 @@ func ExampleTemplate(modulePath string) string {
             "BenchmarkFoo_cpu": {
                 "include_prefixes": ["`+includeExample+`/pkg/foo"]
             }
+        },
+
+        // Optional — pprof sample type each profile kind is ranked by (go tool pprof -sample_index).
+        // Omitted kinds use the profile's default (alloc_space for go test memory). --sample-index on prof auto wins.
+        // Docs: `+docSiteBase+`/configure/#collection-sample-index
+        "sample_index": {
+            "memory": "alloc_objects"
+        },
+
+        // Optional — how hotspots, call trees and source lines are rendered: "builtin" (default, in-process)
+        // or "pprof" (go tool pprof -top/-tree/-list subprocesses; output is the same, use it to verify parity).
+        // Docs: `+docSiteBase+`/configure/#collection-renderer
+        "renderer": "builtin",
+
+        // Optional — go test flags prof auto passes through (benchtime, cpu, timeout, tags, gcflags, ldflags, race),
+        // plus extra_args appended verbatim. Benchmark entries override defaults field by field; prof auto flags win.
+        // Docs: `+docSiteBase+`/configure/#collection-go-test
+        "go_test": {
+            "defaults": {
+                "benchtime": "2s",
+                "timeout": "30m"
+            },
+            "benchmarks": {
+                "BenchmarkMyHotPath": {
+                    "cpu": "1,4,8",
+                    "tags": "integration",
+                    "extra_args": ["-shuffle=on"]
+                }
+            }
+        },
+
+        // Optional — time limit per collect phase of each benchmark (Go durations); omit a phase for no limit.
+        // A phase that runs out stops prof auto / prof manual and marks the tag incomplete in status.json.
+        // Docs: `+docSiteBase+`/configure/#collection-timeouts
+        "timeouts": {
+            "benchmark": "30m",
+            "profiles": "5m",
+            "source_lines": "10m"
+        }
+
+        // Optional — "env_matrix" runs prof auto under every combination of env values, like --env;
+        // a value may hold commas: "env_matrix": {"GOEXPERIMENT": ["arenas,loopvar", "loopvar"]}.
+        // Docs: `+docSiteBase+`/configure/#collection-env-matrix
+
+        // Optional — "profile_kinds" adds profile kinds your benchmark package writes from its own go test flag,
+        // and "producers" adds go tool pprof reports (-peek, -traces, -disasm, -raw, -dot...) for every profile.
+        // Docs: `+docSiteBase+`/configure/#collection-profile-kinds
+    },
+
+    // gate — regression limits checked by prof gate --base <tag> --head <tag> (non-zero exit on violation).
+    // Docs: `+docSiteBase+`/configure/#gate
+    "gate": {
+        "defaults": {
+            // Largest allowed increase in percent of the base median; omit a field to skip the check.
+            // Only statistically significant regressions fail (collect with --count 5 or more).
+            "max_ns_per_op_regression_pct": 10,
+            "max_bytes_per_op_regression_pct": 10,
+            "max_allocs_per_op_regression_pct": 0
+        },
+
+        // Optional — override defaults for one benchmark, and cap a function's flat% in the head profile.
+        "benchmarks": {
+            "BenchmarkMyHotPath": {
+                "max_ns_per_op_regression_pct": 5,
+                "functions": [
+                    { "name": "hot.Process", "profile": "cpu", "max_flat_pct": 30 }
+                ]
+            }
         }
     }
 }
@@ -4,13 +4,81 @@ package config
 type Config struct {
 	Version    int        `json:"version"`
 	Collection Collection `json:"collection,omitempty"`
+	Gate       Gate       `json:"gate,omitempty"`
 }
 
-// Collection holds function-extract filters for collect pipelines.
+// Collection holds function-extract filters and ranking options for collect pipelines.
 type Collection struct {
 	Defaults       FunctionFilter            `json:"defaults,omitempty"`
 	Benchmarks     map[string]FunctionFilter `json:"benchmarks,omitempty"`
 	ManualProfiles map[string]FunctionFilter `json:"manual_profiles,omitempty"`
+	// SampleIndex maps a profile kind to the pprof sample type it is ranked by
+	// (e.g. "memory": "alloc_space"); kinds not listed use pprof's default.
+	SampleIndex map[string]string `json:"sample_index,omitempty"`
+	// Renderer selects how hotspot, call-tree and source_lines text is produced: RendererBuiltin
+	// (default, in-process) or RendererPprof (go tool pprof subprocesses, for parity checks).
+	Renderer string `json:"renderer,omitempty"`
+	// GoTest holds go test flags prof auto passes through, for every benchmark and per benchmark.
+	GoTest GoTestConfig `json:"go_test,omitempty"`
+	// Timeouts bounds each collect phase of one benchmark.
+	Timeouts PhaseTimeouts `json:"timeouts,omitempty"`
+	// EnvMatrix runs prof auto under every combination of these environment variable values,
+	// like --env. Each value is used as written, so it may hold commas (GOEXPERIMENT=arenas,loopvar).
+	EnvMatrix map[string][]string `json:"env_matrix,omitempty"`
+	// ProfileKinds declares profile kinds prof auto collects besides the built-in ones.
+	ProfileKinds []ProfileKind `json:"profile_kinds,omitempty"`
+	// Producers declares extra go tool pprof reports written for every processed profile.
+	Producers []ArtifactProducer `json:"producers,omitempty"`
+}
+
+// ProfileKind declares a profile kind written by a go test flag the benchmark package handles
+// itself, typically one its TestMain defines to write a pprof.Lookup profile. The ID is passed
+// to --profiles like cpu or memory.
+type ProfileKind struct {
+	ID         string `json:"id"`           // e.g. "goroutine"
+	GoTestFlag string `json:"go_test_flag"` // e.g. "-goroutineprofile=goroutine.out"
+	OutputFile string `json:"output_file"`  // file the flag writes in the package directory, e.g. "goroutine.out"
+}
+
+// ArtifactProducer declares a go tool pprof report, written for each processed profile to
+// reports/<benchmark>/<profile>.<id>.<extension>. Args are pprof flags placed before the
+// profile binary; {benchmark} (the BenchmarkXxx function) and {profile} are substituted.
+type ArtifactProducer struct {
+	ID        string   `json:"id"`
+	Args      []string `json:"args"`                // e.g. ["-peek=\\.{benchmark}$"] or ["-traces"]
+	Profiles  []string `json:"profiles,omitempty"`  // profile kinds, variants or trace views it runs for; empty runs it for all
+	Extension string   `json:"extension,omitempty"` // default "txt"
+	Policy    string   `json:"policy,omitempty"`    // ProducerRequired (default) or ProducerBestEffort
+}
+
+// PhaseTimeouts bounds each collect phase of one benchmark with a Go duration such as "30m".
+// An empty field does not limit its phase. A phase that runs out fails the collection and
+// marks the tag incomplete.
+type PhaseTimeouts struct {
+	Benchmark   string `json:"benchmark,omitempty"`    // the go test run
+	Profiles    string `json:"profiles,omitempty"`     // hotspots, call trees and other per-profile artifacts
+	SourceLines string `json:"source_lines,omitempty"` // per-function source_lines extracts
+}
+
+// GoTestConfig holds go test flags for prof auto. Benchmarks keys follow collection.benchmarks.
+type GoTestConfig struct {
+	Defaults   GoTestFlags            `json:"defaults,omitempty"`
+	Benchmarks map[string]GoTestFlags `json:"benchmarks,omitempty"`
+}
+
+// GoTestFlags are go test flags passed through to the benchmark run. Empty fields are not
+// passed, so go test's own defaults apply. ExtraArgs is appended verbatim after every other
+// flag; it may not set flags prof manages (-run, -bench, -count, profile outputs) or the
+// structured fields.
+type GoTestFlags struct {
+	Benchtime string   `json:"benchtime,omitempty"` // e.g. "2s" or "1000x"
+	CPU       string   `json:"cpu,omitempty"`       // GOMAXPROCS list, e.g. "1,4,8"
+	Timeout   string   `json:"timeout,omitempty"`   // e.g. "30m"
+	Tags      string   `json:"tags,omitempty"`      // build tags, e.g. "integration"
+	Gcflags   string   `json:"gcflags,omitempty"`
+	Ldflags   string   `json:"ldflags,omitempty"`
+	Race      *bool    `json:"race,omitempty"`
+	ExtraArgs []string `json:"extra_args,omitempty"`
 }
 
 // FunctionFilter defines filters for collection (per-function extracts).
@@ -19,18 +87,55 @@ type FunctionFilter struct {
 	IgnoreFunctions []string `json:"ignore_functions,omitempty"`
 }
 
+// Gate holds regression limits enforced by prof gate.
+type Gate struct {
+	Defaults   GateLimits            `json:"defaults,omitempty"`
+	Benchmarks map[string]GateLimits `json:"benchmarks,omitempty"`
+}
+
+// GateLimits bounds how much a benchmark may regress between two tags.
+// Nil percentages are unchecked; per-benchmark fields override defaults.
+type GateLimits struct {
+	MaxNsPerOpRegressionPct     *float64        `json:"max_ns_per_op_regression_pct,omitempty"`
+	MaxBytesPerOpRegressionPct  *float64        `json:"max_bytes_per_op_regression_pct,omitempty"`
+	MaxAllocsPerOpRegressionPct *float64        `json:"max_allocs_per_op_regression_pct,omitempty"`
+	Functions                   []FunctionLimit `json:"functions,omitempty"`
+}
+
+// FunctionLimit caps the flat% a named function may reach in the head tag's profile.
+// Name matches the full pprof symbol or its trailing package-qualified form (e.g. "pkg.Func").
+type FunctionLimit struct {
+	Name       string  `json:"name"`
+	Profile    string  `json:"profile,omitempty"`
+	MaxFlatPct float64 `json:"max_flat_pct"`
+}
+
 // CollectionArgs describes one benchmark collection run.
 type CollectionArgs struct {
 	Tag             string
+	Out             string // prof --out; see workspace.ResolveOutputDir
 	Profiles        []string
 	BenchmarkName   string
 	BenchmarkConfig FunctionFilter
+	SampleIndex     map[string]string
+	Renderer        string
+	GoTest          GoTestFlags // resolved go test flags of the benchmark run
 }
 
 // AutoArgs holds arguments for the auto-benchmark command.
 type AutoArgs struct {
-	Benchmarks []string
-	Profiles   []string
-	Count      int
-	Tag        string
+	Benchmarks   []string
+	Profiles     []string
+	Count        int
+	Tag          string
+	Out          string // prof --out; see workspace.ResolveOutputDir
+	SampleIndex  map[string]string
+	Renderer     string
+	GoTest       GoTestFlags   // command-line go test flags; override collection.go_test
+	Timeouts     PhaseTimeouts // collection.timeouts
+	Append       bool          // keep the other benchmarks of the tag
+	Resume       bool          // skip benchmarks whose map.json is complete
+	Parallel     int           // packages benchmarked at once on disjoint CPU sets; 0 or 1 is sequential
+	PerIteration bool          // one go test -count=1 invocation per iteration, keeping each run's profiles
+	Env          []string      // NAME=value overrides for the go test run (one env matrix variant)
 }
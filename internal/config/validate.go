@@ -3,6 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 )
 
 var (
@@ -21,5 +25,111 @@ func Validate(cfg *Config) error {
 	if cfg.Version > CurrentVersion {
 		return fmt.Errorf("config: unsupported version %d (max supported %d)", cfg.Version, CurrentVersion)
 	}
+	switch cfg.Collection.Renderer {
+	case "", RendererBuiltin, RendererPprof:
+	default:
+		return fmt.Errorf("config: collection.renderer must be %q or %q, got %q", RendererBuiltin, RendererPprof, cfg.Collection.Renderer)
+	}
+	if err := ValidateGoTestFlags(cfg.Collection.GoTest.Defaults); err != nil {
+		return fmt.Errorf("config: collection.go_test.defaults: %w", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Collection.GoTest.Benchmarks)) {
+		if err := ValidateGoTestFlags(cfg.Collection.GoTest.Benchmarks[name]); err != nil {
+			return fmt.Errorf("config: collection.go_test.benchmarks.%s: %w", name, err)
+		}
+	}
+	if err := validatePhaseTimeouts(cfg.Collection.Timeouts); err != nil {
+		return err
+	}
+	if err := validateProfileKinds(cfg.Collection.ProfileKinds); err != nil {
+		return err
+	}
+	if err := validateProducers(cfg.Collection.Producers); err != nil {
+		return err
+	}
+	if err := validateGateLimits("gate.defaults", cfg.Gate.Defaults); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Gate.Benchmarks)) {
+		if err := validateGateLimits("gate.benchmarks."+name, cfg.Gate.Benchmarks[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateGateLimits(where string, l GateLimits) error {
+	pcts := []struct {
+		field string
+		v     *float64
+	}{
+		{"max_ns_per_op_regression_pct", l.MaxNsPerOpRegressionPct},
+		{"max_bytes_per_op_regression_pct", l.MaxBytesPerOpRegressionPct},
+		{"max_allocs_per_op_regression_pct", l.MaxAllocsPerOpRegressionPct},
+	}
+	for _, p := range pcts {
+		if p.v != nil && *p.v < 0 {
+			return fmt.Errorf("config: %s.%s must not be negative", where, p.field)
+		}
+	}
+	const maxPct = 100.0
+	for i, f := range l.Functions {
+		if f.Name == "" {
+			return fmt.Errorf("config: %s.functions[%d].name is required", where, i)
+		}
+		if f.MaxFlatPct < 0 || f.MaxFlatPct > maxPct {
+			return fmt.Errorf("config: %s.functions[%d].max_flat_pct must be between 0 and 100", where, i)
+		}
+	}
+	return nil
+}
+
+// goTestManagedFlags are go test flags prof sets itself on every benchmark run.
+var goTestManagedFlags = []string{
+	"run", "bench", "count", "c", "o", "json", "outputdir",
+	"cpuprofile", "memprofile", "blockprofile", "mutexprofile", "trace", "coverprofile",
+}
+
+// goTestFieldFlags are go test flags that have their own GoTestFlags field (and prof auto flag).
+var goTestFieldFlags = []string{"benchtime", "cpu", "timeout", "tags", "gcflags", "ldflags", "race"}
+
+func validatePhaseTimeouts(t PhaseTimeouts) error {
+	for _, phase := range []struct{ name, value string }{
+		{"benchmark", t.Benchmark},
+		{"profiles", t.Profiles},
+		{"source_lines", t.SourceLines},
+	} {
+		if phase.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(phase.value); err != nil || d <= 0 {
+			return fmt.Errorf("config: collection.timeouts.%s must be a positive duration such as \"30m\", got %q", phase.name, phase.value)
+		}
+	}
+	return nil
+}
+
+// PhaseTimeout returns the duration of a validated collection.timeouts entry; empty is 0 (no limit).
+func PhaseTimeout(value string) time.Duration {
+	d, _ := time.ParseDuration(value)
+	return d
+}
+
+// ValidateGoTestFlags rejects extra args that set a flag prof manages or one that has its
+// own field.
+func ValidateGoTestFlags(f GoTestFlags) error {
+	for _, arg := range f.ExtraArgs {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		name = strings.TrimPrefix(name, "test.")
+		if slices.Contains(goTestManagedFlags, name) {
+			return fmt.Errorf("extra arg %s: -%s is set by prof", arg, name)
+		}
+		if slices.Contains(goTestFieldFlags, name) {
+			return fmt.Errorf("extra arg %s: use the %s setting instead", arg, name)
+		}
+	}
 	return nil
 }
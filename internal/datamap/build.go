@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
@@ -23,11 +24,16 @@ const (
 var (
 	defaultRecommendedFlow = []string{"measurements", "hotspots", "call_trees", "source_lines", "profiles"}
 	defaultReadingGuide    = map[string]string{
-		"measurements": "Go benchmark output (ns/op, B/op, allocs/op). Start here to confirm the run succeeded.",
-		"hotspots":     "pprof -top text at path; flat/cum metrics live there, not in map.json. See profile_cost_columns.",
-		"call_trees":   "pprof -tree: caller/callee context for top nodes.",
-		"source_lines": "pprof -list extract paths per function; open the linked .txt for line-level detail.",
-		"profiles":     "Raw .out binaries; re-query with go tool pprof when text is insufficient.",
+		"measurements":  "Go benchmark output (ns/op, B/op, allocs/op). Start here to confirm the run succeeded.",
+		"hotspots":      "pprof -top text at path; flat/cum metrics live there, not in map.json. See profile_cost_columns. stability, when set, shows each top function's flat% in every go test run.",
+		"call_trees":    "pprof -tree: caller/callee context for top nodes.",
+		"source_lines":  "pprof -list extract paths per function; open the linked .txt for line-level detail.",
+		"folded_stacks": "One line per distinct call stack (root;...;leaf value); grep-friendly and accepted by flamegraph.pl, speedscope and inferno.",
+		"flame_graphs":  "Standalone SVG flame graph; open in a browser, hover for values, click a frame to zoom.",
+		"speedscope":    "speedscope JSON with one profile per sample type; drop it on https://www.speedscope.app.",
+		"reports":       "Outputs of the go tool pprof reports prof.json declares in collection.producers, by profile then producer id; producer is the command that wrote path.",
+		"traces":        "go test -trace execution traces; open path with go tool trace. summary has GC stop-the-world pauses and scheduler latency; views are the profiles keys extracted with go tool trace -pprof.",
+		"profiles":      "Raw .out binaries; re-query with go tool pprof when text is insufficient. Keys like memory.alloc_space are sample-type variants of one binary. iterations, when set, lists the per-run binaries the .out merges.",
 	}
 	defaultProfileCostColumns = map[string]string{
 		"flat":     "Cost in this function's own code only (excludes callees). CPU: seconds in the function body; memory: bytes allocated there.",
@@ -56,10 +62,18 @@ type BuildInput struct {
 	Tag              string
 	Benchmark        string
 	Package          string
+	Module           string
 	CollectionMode   string
 	Profiles         []string
+	Traces           []string                  // execution trace kinds; their views are in Profiles
+	Producers        []config.ArtifactProducer // collection.producers run for each of Profiles
 	Filter           config.FunctionFilter
 	BenchCount       int
+	SampleIndex      map[string]string // requested pprof -sample_index per profile kind
+	GoTest           config.GoTestFlags
+	GoTestCommand    []string // go test argv of an auto run; empty for manual
+	Env              []string // NAME=value overrides of an env matrix variant
+	PerIteration     bool     // go test ran once per BenchCount iteration
 	PerProfile       []ProfileSnapshot
 	IncludeMeasuring bool
 }
@@ -77,7 +91,9 @@ func Build(in BuildInput) (BenchmarkMap, error) {
 		SchemaVersion:      SchemaVersion,
 		Tag:                in.Tag,
 		Benchmark:          in.Benchmark,
+		BenchmarkDir:       benchmarkDir(in.Benchmark),
 		Package:            in.Package,
+		Module:             in.Module,
 		RecommendedFlow:    append([]string(nil), defaultRecommendedFlow...),
 		ReadingGuide:       copyReadingGuide(),
 		ProfileCostColumns: copyProfileCostColumns(),
@@ -87,18 +103,26 @@ func Build(in BuildInput) (BenchmarkMap, error) {
 		CallTrees:          make(map[string]CallTreeSection, len(in.Profiles)),
 		SourceLines:        make(map[string]SourceLinesSection, len(in.Profiles)),
 		CallGraphs:         make(map[string]CallGraphRef, len(in.Profiles)),
+		FoldedStacks:       make(map[string]FoldedStacksSection, len(in.Profiles)),
+		FlameGraphs:        make(map[string]FlameGraphSection, len(in.Profiles)),
+		Speedscope:         make(map[string]SpeedscopeSection, len(in.Profiles)),
 		Status: Status{
-			Profiles:    make(map[string]string, len(in.Profiles)),
-			Hotspots:    make(map[string]string, len(in.Profiles)),
-			CallTrees:   make(map[string]string, len(in.Profiles)),
-			CallGraphs:  make(map[string]CallGraphStatus, len(in.Profiles)),
-			SourceLines: make(map[string]SourceLinesStatus, len(in.Profiles)),
+			Profiles:     make(map[string]string, len(in.Profiles)),
+			Hotspots:     make(map[string]string, len(in.Profiles)),
+			CallTrees:    make(map[string]string, len(in.Profiles)),
+			FoldedStacks: make(map[string]string, len(in.Profiles)),
+			FlameGraphs:  make(map[string]string, len(in.Profiles)),
+			Speedscope:   make(map[string]string, len(in.Profiles)),
+			CallGraphs:   make(map[string]CallGraphStatus, len(in.Profiles)),
+			SourceLines:  make(map[string]SourceLinesStatus, len(in.Profiles)),
 		},
 		Provenance: Provenance{
 			Tag:               in.Tag,
 			CollectionMode:    in.CollectionMode,
 			BenchCount:        in.BenchCount,
-			ProfilesRequested: append([]string(nil), in.Profiles...),
+			ProfilesRequested: requestedProfiles(in),
+			SampleIndex:       requestedSampleIndex(in),
+			GoTest:            goTestSnapshot(in),
 			Filter: FilterSnapshot{
 				IncludePrefixes: append([]string(nil), in.Filter.IncludePrefixes...),
 				IgnoreFunctions: append([]string(nil), in.Filter.IgnoreFunctions...),
@@ -124,6 +148,17 @@ func Build(in BuildInput) (BenchmarkMap, error) {
 			return BenchmarkMap{}, err
 		}
 	}
+	m.linkProfileVariants()
+	for _, profile := range in.Profiles {
+		if err := m.addReports(in, profile); err != nil {
+			return BenchmarkMap{}, err
+		}
+	}
+	for _, kind := range in.Traces {
+		if err := m.addTrace(in, kind); err != nil {
+			return BenchmarkMap{}, err
+		}
+	}
 
 	return m, nil
 }
@@ -155,7 +190,7 @@ func (m *BenchmarkMap) addMeasurements(in BuildInput) error {
 		Purpose:     PurposeBenchmemResults,
 		Description: "Combined stdout from go test -bench with -benchmem.",
 	}
-	if summary, sumErr := parseMeasurementSummary(abs); sumErr == nil {
+	if summary, sumErr := ParseMeasurementSummary(abs); sumErr == nil {
 		section.Summary = summary
 	}
 	m.Measurements = section
@@ -167,13 +202,24 @@ func (m *BenchmarkMap) addProfileArtifacts(in BuildInput, profile string, snap P
 	if err != nil {
 		return err
 	}
+	kind, variantType := workspace.SplitProfileVariant(profile)
+	if variantType == "" {
+		kind = ""
+	}
 	m.Profiles[profile] = ProfileRef{
 		Path:         profRel,
 		Purpose:      PurposeRawPprofBinary,
 		Description:  "Raw pprof profile binary; source of truth for go tool pprof.",
+		Kind:         kind,
 		TotalSamples: snapTotal(snap.ProfileData),
+		SampleIndex:  sampleType(snap.ProfileData),
 		SampleUnit:   sampleUnit(snap.ProfileData),
 	}
+	if traceKind, view := traceView(in, profile); traceKind != "" {
+		ref := m.Profiles[profile]
+		ref.Description = fmt.Sprintf("pprof profile extracted from the %s.out execution trace with go tool trace -pprof=%s.", traceKind, view)
+		m.Profiles[profile] = ref
+	}
 	if snap.ProfileData != nil {
 		display, sec, outUnit := profileTotalDisplay(snap.ProfileData)
 		ref := m.Profiles[profile]
@@ -182,19 +228,30 @@ func (m *BenchmarkMap) addProfileArtifacts(in BuildInput, profile string, snap P
 		ref.OutputUnit = outUnit
 		m.Profiles[profile] = ref
 	}
+	if variantType == "" {
+		if err = m.addProfileIterations(in, profile); err != nil {
+			return err
+		}
+	}
 	m.Status.Profiles[profile] = statusOK
 
 	hotRel, err := in.Layout.RelFromLayout(in.Layout.Hotspot(in.Benchmark, profile))
 	if err != nil {
 		return err
 	}
-	m.Hotspots[profile] = HotspotSection{
+	hotspots := HotspotSection{
 		Path:                hotRel,
 		Purpose:             PurposeFlatCumulativeRanking,
 		Description:         "go tool pprof -top output: flat time in function body, cum time including callees.",
-		Producer:            "go tool pprof -top",
+		Producer:            pprofProducer("-top", requestedIndex(in, profile)),
 		HotspotsMetricsNote: hotspotsMetricsNote,
 	}
+	if stability := in.Layout.HotspotStability(in.Benchmark, profile); fileExists(stability) {
+		if hotspots.Stability, err = in.Layout.RelFromLayout(stability); err != nil {
+			return err
+		}
+	}
+	m.Hotspots[profile] = hotspots
 	m.Status.Hotspots[profile] = statusOK
 
 	treeRel, err := in.Layout.RelFromLayout(in.Layout.CallTreeText(in.Benchmark, profile))
@@ -205,10 +262,46 @@ func (m *BenchmarkMap) addProfileArtifacts(in BuildInput, profile string, snap P
 		Path:        treeRel,
 		Purpose:     PurposeCallerCalleeContext,
 		Description: "go tool pprof -tree output: caller/callee context for ranked nodes.",
-		Producer:    "go tool pprof -tree",
+		Producer:    pprofProducer("-tree", requestedIndex(in, profile)),
 	}
 	m.Status.CallTrees[profile] = statusOK
 
+	foldedRel, err := in.Layout.RelFromLayout(in.Layout.FoldedStacks(in.Benchmark, profile))
+	if err != nil {
+		return err
+	}
+	m.FoldedStacks[profile] = FoldedStacksSection{
+		Path:        foldedRel,
+		Purpose:     PurposeFoldedStacks,
+		Description: "Brendan Gregg folded stacks: frames root first joined by ';', then the summed sample value.",
+		Producer:    profProducer("folded", requestedIndex(in, profile)),
+	}
+	m.Status.FoldedStacks[profile] = statusOK
+
+	flameRel, err := in.Layout.RelFromLayout(in.Layout.FlameGraph(in.Benchmark, profile))
+	if err != nil {
+		return err
+	}
+	m.FlameGraphs[profile] = FlameGraphSection{
+		Path:        flameRel,
+		Purpose:     PurposeVisualFlameGraph,
+		Description: "Interactive SVG flame graph rendered by prof; needs no Graphviz.",
+		Producer:    profProducer("flamegraph", requestedIndex(in, profile)),
+	}
+	m.Status.FlameGraphs[profile] = statusOK
+
+	speedscopeRel, err := in.Layout.RelFromLayout(in.Layout.Speedscope(in.Benchmark, profile))
+	if err != nil {
+		return err
+	}
+	m.Speedscope[profile] = SpeedscopeSection{
+		Path:        speedscopeRel,
+		Purpose:     PurposeSpeedscopeProfile,
+		Description: "speedscope file format: one sampled profile per sample type; the requested one opens first.",
+		Producer:    profProducer("speedscope", requestedIndex(in, profile)),
+	}
+	m.Status.Speedscope[profile] = statusOK
+
 	srcDir := in.Layout.SourceLinesDir(profile, in.Benchmark)
 	srcRel, err := in.Layout.RelFromLayout(srcDir)
 	if err != nil {
@@ -219,7 +312,7 @@ func (m *BenchmarkMap) addProfileArtifacts(in BuildInput, profile string, snap P
 		PathPattern: "source_lines/{profile}/{benchmark}/{output_stem}.txt",
 		Purpose:     PurposeLineLevelSource,
 		Description: "Per-function go tool pprof -list output with annotated source lines.",
-		Producer:    "go tool pprof -list",
+		Producer:    pprofProducer("-list", requestedIndex(in, profile)),
 		Functions:   functionRefs(in, profile, snap),
 	}
 	m.Status.SourceLines[profile] = SourceLinesStatus{
@@ -251,6 +344,211 @@ func (m *BenchmarkMap) addProfileArtifacts(in BuildInput, profile string, snap P
 	return nil
 }
 
+// addTrace indexes the execution trace binary of kind, its summary and the views extracted from it.
+func (m *BenchmarkMap) addTrace(in BuildInput, kind string) error {
+	pathRel, err := in.Layout.RelFromLayout(in.Layout.ProfileBinary(in.Benchmark, kind))
+	if err != nil {
+		return err
+	}
+	summaryRel, err := in.Layout.RelFromLayout(in.Layout.TraceSummary(in.Benchmark, kind))
+	if err != nil {
+		return err
+	}
+	section := TraceSection{
+		Path:        pathRel,
+		Purpose:     PurposeExecutionTrace,
+		Description: "go test -trace execution trace; not a pprof profile. Open with go tool trace.",
+		Summary:     summaryRel,
+	}
+	for _, profile := range in.Profiles {
+		if traceKind, _ := traceView(in, profile); traceKind == kind {
+			section.Views = append(section.Views, profile)
+		}
+	}
+	if m.Traces == nil {
+		m.Traces = make(map[string]TraceSection, len(in.Traces))
+	}
+	m.Traces[kind] = section
+	m.Status.Profiles[kind] = statusOK
+	return nil
+}
+
+// addReports indexes the outputs of the prof.json producers that ran for profile.
+func (m *BenchmarkMap) addReports(in BuildInput, profile string) error {
+	_, name := workspace.SplitQualifiedBenchmark(in.Benchmark)
+	benchFunc, _, _ := strings.Cut(name, workspace.SubBenchmarkSeparator)
+	for _, p := range in.Producers {
+		if !p.AppliesTo(profile) {
+			continue
+		}
+		path := in.Layout.Report(in.Benchmark, profile, p.ID, p.FileExtension())
+		rel, err := in.Layout.RelFromLayout(path)
+		if err != nil {
+			return err
+		}
+		ref := ReportRef{
+			Path:        rel,
+			Purpose:     PurposePprofReport,
+			Description: fmt.Sprintf("go tool pprof report declared as collection.producers %q in prof.json.", p.ID),
+			Producer:    pprofProducer(strings.Join(p.ExpandArgs(benchFunc, profile), " "), requestedIndex(in, profile)),
+			Status:      statusOK,
+		}
+		if !fileExists(path) {
+			ref.Status = statusSkipped
+		}
+		if m.Reports == nil {
+			m.Reports = make(map[string]map[string]ReportRef, len(in.Profiles))
+		}
+		if m.Reports[profile] == nil {
+			m.Reports[profile] = make(map[string]ReportRef, len(in.Producers))
+		}
+		m.Reports[profile][p.ID] = ref
+	}
+	return nil
+}
+
+// traceView returns the trace kind and go tool trace -pprof type of a trace view profile name;
+// both are empty for other profiles.
+func traceView(in BuildInput, profile string) (kind, view string) {
+	for _, k := range in.Traces {
+		if v, ok := strings.CutPrefix(profile, k+workspace.TraceViewSeparator); ok {
+			return k, v
+		}
+	}
+	return "", ""
+}
+
+// addProfileIterations lists the per-iteration binaries merged into profile's binary.
+func (m *BenchmarkMap) addProfileIterations(in BuildInput, profile string) error {
+	paths, err := in.Layout.ProfileIterations(in.Benchmark, profile)
+	if err != nil {
+		return err
+	}
+	ref := m.Profiles[profile]
+	for _, p := range paths {
+		rel, relErr := in.Layout.RelFromLayout(p)
+		if relErr != nil {
+			return relErr
+		}
+		ref.Iterations = append(ref.Iterations, rel)
+	}
+	m.Profiles[profile] = ref
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// benchmarkDir returns the layout directory name of bench when it differs from the name.
+func benchmarkDir(bench string) string {
+	if dir := workspace.BenchmarkDir(bench); dir != bench {
+		return dir
+	}
+	return ""
+}
+
+// requestedSampleIndex keeps only the selections for profiles in this map.
+func requestedSampleIndex(in BuildInput) map[string]string {
+	out := make(map[string]string)
+	for _, profile := range in.Profiles {
+		if st, ok := in.SampleIndex[profile]; ok {
+			out[profile] = st
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// goTestSnapshot returns the go test provenance of an auto run, or nil when no command ran.
+func goTestSnapshot(in BuildInput) *GoTestSnapshot {
+	if len(in.GoTestCommand) == 0 {
+		return nil
+	}
+	g := in.GoTest
+	return &GoTestSnapshot{
+		Benchtime:    g.Benchtime,
+		CPU:          g.CPU,
+		Timeout:      g.Timeout,
+		Tags:         g.Tags,
+		Gcflags:      g.Gcflags,
+		Ldflags:      g.Ldflags,
+		Race:         g.Race != nil && *g.Race,
+		ExtraArgs:    append([]string(nil), g.ExtraArgs...),
+		Command:      append([]string(nil), in.GoTestCommand...),
+		Env:          append([]string(nil), in.Env...),
+		PerIteration: in.PerIteration,
+	}
+}
+
+// requestedIndex returns the -sample_index a profile or variant was rendered with.
+func requestedIndex(in BuildInput, profile string) string {
+	if _, st := workspace.SplitProfileVariant(profile); st != "" {
+		return st
+	}
+	return in.SampleIndex[profile]
+}
+
+// requestedProfiles returns the profile kinds that were collected: profiles without variants
+// and trace views, then the trace kinds.
+func requestedProfiles(in BuildInput) []string {
+	out := make([]string, 0, len(in.Profiles)+len(in.Traces))
+	for _, p := range in.Profiles {
+		if _, st := workspace.SplitProfileVariant(p); st != "" {
+			continue
+		}
+		if kind, _ := traceView(in, p); kind != "" {
+			continue
+		}
+		out = append(out, p)
+	}
+	return append(out, in.Traces...)
+}
+
+// linkProfileVariants lists each variant on the entry of its profile kind.
+func (m *BenchmarkMap) linkProfileVariants() {
+	names := make([]string, 0, len(m.Profiles))
+	for name := range m.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kind := m.Profiles[name].Kind
+		if kind == "" {
+			continue
+		}
+		if ref, ok := m.Profiles[kind]; ok {
+			ref.Variants = append(ref.Variants, name)
+			m.Profiles[kind] = ref
+		}
+	}
+}
+
+func pprofProducer(report, sampleIndex string) string {
+	if sampleIndex == "" {
+		return "go tool pprof " + report
+	}
+	return "go tool pprof -sample_index=" + sampleIndex + " " + report
+}
+
+// profProducer names an artifact prof renders itself from the profile binary.
+func profProducer(report, sampleIndex string) string {
+	if sampleIndex == "" {
+		return "prof " + report
+	}
+	return "prof " + report + " -sample_index=" + sampleIndex
+}
+
+func sampleType(d *parser.ProfileData) string {
+	if d == nil {
+		return ""
+	}
+	return d.SampleType
+}
+
 func snapTotal(d *parser.ProfileData) int64 {
 	if d == nil {
 		return 0
@@ -308,6 +606,33 @@ func WriteJSON(path string, m BenchmarkMap) error {
 	return nil
 }
 
+// ReadJSON decodes the map.json at path.
+func ReadJSON(path string) (BenchmarkMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return BenchmarkMap{}, err
+	}
+	var m BenchmarkMap
+	if err = json.Unmarshal(data, &m); err != nil {
+		return BenchmarkMap{}, fmt.Errorf("decode benchmark map: %w", err)
+	}
+	return m, nil
+}
+
+// Complete reports whether m records a successful go test run and artifacts for every profile
+// in profiles, i.e. whether a prof auto run of the benchmark finished.
+func (m BenchmarkMap) Complete(profiles []string) bool {
+	if m.Status.BenchmarkRun != statusOK {
+		return false
+	}
+	for _, profile := range profiles {
+		if m.Status.Profiles[profile] != statusOK {
+			return false
+		}
+	}
+	return true
+}
+
 // SortedProfileNames returns profile IDs in stable sorted order for tests.
 func SortedProfileNames(m BenchmarkMap) []string {
 	names := make([]string, 0, len(m.Profiles))
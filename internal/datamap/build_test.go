@@ -1,11 +1,16 @@
 package datamap
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/AlexsanderHamir/prof/internal/config"
 	"github.com/AlexsanderHamir/prof/internal/workspace"
 	"github.com/AlexsanderHamir/prof/parser"
 )
@@ -60,6 +65,18 @@ func TestBuild_minimalCPUProfile(t *testing.T) {
 	if fn.FullSymbol != "main.work" || fn.Status != statusOK {
 		t.Fatalf("function ref=%+v", fn)
 	}
+	if got := m.FoldedStacks["cpu"]; got.Path != "folded_stacks/BenchmarkFoo/cpu.folded" || got.Purpose != PurposeFoldedStacks {
+		t.Fatalf("folded stacks=%+v", got)
+	}
+	if got := m.FlameGraphs["cpu"]; got.Path != "flame_graphs/BenchmarkFoo/cpu.svg" || got.Purpose != PurposeVisualFlameGraph {
+		t.Fatalf("flame graph=%+v", got)
+	}
+	if got := m.Speedscope["cpu"]; got.Path != "speedscope/BenchmarkFoo/cpu.speedscope.json" || got.Purpose != PurposeSpeedscopeProfile {
+		t.Fatalf("speedscope=%+v", got)
+	}
+	if m.Status.FoldedStacks["cpu"] != statusOK || m.Status.FlameGraphs["cpu"] != statusOK {
+		t.Fatalf("status=%+v", m.Status)
+	}
 	if m.Hotspots["cpu"].HotspotsMetricsNote == "" {
 		t.Fatal("expected hotspots_metrics_note on hotspot section")
 	}
@@ -71,6 +88,224 @@ func TestBuild_minimalCPUProfile(t *testing.T) {
 	}
 }
 
+func TestBuild_sampleIndexRecorded(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	snap := ProfileSnapshot{
+		Profile: "memory",
+		ProfileData: &parser.ProfileData{
+			Total:      10,
+			SampleType: "alloc_space",
+			Flat:       map[string]int64{},
+			Cum:        map[string]int64{},
+		},
+	}
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      "BenchmarkFoo",
+		CollectionMode: collectionManual,
+		Profiles:       []string{"memory"},
+		PerProfile:     []ProfileSnapshot{snap},
+		SampleIndex:    map[string]string{"memory": "alloc_space", "cpu": "samples"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Profiles["memory"].SampleIndex; got != "alloc_space" {
+		t.Fatalf("profile sample_index=%q", got)
+	}
+	if len(m.Provenance.SampleIndex) != 1 || m.Provenance.SampleIndex["memory"] != "alloc_space" {
+		t.Fatalf("provenance sample_index=%v", m.Provenance.SampleIndex)
+	}
+	if got := m.Hotspots["memory"].Producer; got != "go tool pprof -sample_index=alloc_space -top" {
+		t.Fatalf("producer=%q", got)
+	}
+	if got := m.FlameGraphs["memory"].Producer; got != "prof flamegraph -sample_index=alloc_space" {
+		t.Fatalf("flame graph producer=%q", got)
+	}
+}
+
+func TestBuild_goTestProvenance(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	race := true
+	cmd := []string{"go", "test", "-run=^$", "-bench=^BenchmarkFoo$", "-benchtime=2s", "-race", "-shuffle=on"}
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      "BenchmarkFoo",
+		CollectionMode: collectionManual,
+		GoTest:         config.GoTestFlags{Benchtime: "2s", Race: &race, ExtraArgs: []string{"-shuffle=on"}},
+		GoTestCommand:  cmd,
+		Env:            []string{"GOGC=50"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := m.Provenance.GoTest
+	if g == nil || g.Benchtime != "2s" || !g.Race || len(g.ExtraArgs) != 1 || !slices.Equal(g.Command, cmd) ||
+		!slices.Equal(g.Env, []string{"GOGC=50"}) {
+		t.Fatalf("go_test provenance=%+v", g)
+	}
+
+	manual, err := Build(BuildInput{Layout: layout, Tag: "baseline", Benchmark: "BenchmarkFoo", CollectionMode: collectionManual})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manual.Provenance.GoTest != nil {
+		t.Fatalf("manual collection should not record go test flags: %+v", manual.Provenance.GoTest)
+	}
+}
+
+func TestBuild_profileVariants(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      "BenchmarkFoo",
+		CollectionMode: collectionManual,
+		Profiles:       []string{"memory", "memory.alloc_objects", "memory.alloc_space"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := m.Profiles["memory"]
+	if base.Kind != "" || len(base.Variants) != 2 || base.Variants[0] != "memory.alloc_objects" {
+		t.Fatalf("memory ref=%+v", base)
+	}
+	v := m.Profiles["memory.alloc_objects"]
+	if v.Kind != "memory" || v.Path != "profiles/BenchmarkFoo/memory.out" {
+		t.Fatalf("variant ref=%+v", v)
+	}
+	if got := m.Hotspots["memory.alloc_objects"].Path; got != "hotspots/BenchmarkFoo/memory.alloc_objects.txt" {
+		t.Fatalf("variant hotspot path=%q", got)
+	}
+	if got := m.SourceLines["memory.alloc_objects"].Producer; got != "go tool pprof -sample_index=alloc_objects -list" {
+		t.Fatalf("variant producer=%q", got)
+	}
+	if len(m.Provenance.ProfilesRequested) != 1 || m.Provenance.ProfilesRequested[0] != "memory" {
+		t.Fatalf("profiles_requested=%v", m.Provenance.ProfilesRequested)
+	}
+}
+
+func TestBuild_perIteration(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	for _, path := range []string{
+		layout.ProfileIteration("BenchmarkFoo", "cpu", 1),
+		layout.ProfileIteration("BenchmarkFoo", "cpu", 2),
+		layout.HotspotStability("BenchmarkFoo", "cpu"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      "BenchmarkFoo",
+		CollectionMode: collectionManual,
+		Profiles:       []string{"cpu", "memory"},
+		GoTestCommand:  []string{"go", "test", "-count=1"},
+		PerIteration:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Profiles["cpu"].Iterations; !slices.Equal(got, []string{"profiles/BenchmarkFoo/cpu@1.out", "profiles/BenchmarkFoo/cpu@2.out"}) {
+		t.Fatalf("cpu iterations=%v", got)
+	}
+	if got := m.Hotspots["cpu"].Stability; got != "hotspots/BenchmarkFoo/cpu_stability.txt" {
+		t.Fatalf("cpu stability=%q", got)
+	}
+	if m.Profiles["memory"].Iterations != nil || m.Hotspots["memory"].Stability != "" {
+		t.Fatalf("memory has no iterations: %+v %+v", m.Profiles["memory"], m.Hotspots["memory"])
+	}
+	if !m.Provenance.GoTest.PerIteration {
+		t.Fatal("provenance should record the per-iteration run")
+	}
+}
+
+func TestBuild_trace(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      "BenchmarkFoo",
+		CollectionMode: collectionManual,
+		Profiles:       []string{"cpu", "trace_sync", "trace_sched"},
+		Traces:         []string{"trace"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, ok := m.Traces["trace"]
+	if !ok {
+		t.Fatalf("traces=%v", m.Traces)
+	}
+	if tr.Path != "profiles/BenchmarkFoo/trace.out" || tr.Summary != "traces/BenchmarkFoo/trace.txt" || tr.Purpose != PurposeExecutionTrace {
+		t.Fatalf("trace section=%+v", tr)
+	}
+	if !slices.Equal(tr.Views, []string{"trace_sync", "trace_sched"}) {
+		t.Fatalf("views=%v", tr.Views)
+	}
+	if got := m.Profiles["trace_sched"]; got.Path != "profiles/BenchmarkFoo/trace_sched.out" || got.Kind != "" || !strings.Contains(got.Description, "-pprof=sched") {
+		t.Fatalf("trace_sched profile=%+v", got)
+	}
+	if !slices.Equal(m.Provenance.ProfilesRequested, []string{"cpu", "trace"}) {
+		t.Fatalf("requested=%v", m.Provenance.ProfilesRequested)
+	}
+	if m.Status.Profiles["trace"] != statusOK {
+		t.Fatalf("status=%v", m.Status.Profiles)
+	}
+}
+
+func TestBuild_reports(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	const bench = "./codec.BenchmarkFoo/small"
+	peek := layout.Report(bench, "memory.alloc_space", "peek", "txt")
+	if err := os.MkdirAll(filepath.Dir(peek), workspace.PermDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(peek, []byte("peek"), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      bench,
+		CollectionMode: collectionManual,
+		Profiles:       []string{"cpu", "memory.alloc_space"},
+		Producers: []config.ArtifactProducer{
+			{ID: "peek", Args: []string{`-peek=\.{benchmark}$`}, Profiles: []string{"memory"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Reports["cpu"]; ok {
+		t.Fatalf("peek only runs for memory: %v", m.Reports)
+	}
+	got := m.Reports["memory.alloc_space"]["peek"]
+	want := ReportRef{
+		Path:     "reports/codec.BenchmarkFoo__small/memory.alloc_space.peek.txt",
+		Purpose:  PurposePprofReport,
+		Producer: `go tool pprof -sample_index=alloc_space -peek=\.BenchmarkFoo$`,
+		Status:   statusOK,
+	}
+	got.Description = ""
+	if got != want {
+		t.Fatalf("report=%+v\nwant %+v", got, want)
+	}
+}
+
 func TestWriteJSON_roundTrip(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
@@ -104,6 +339,53 @@ func TestWriteJSON_roundTrip(t *testing.T) {
 	}
 }
 
+func TestReadJSON_complete(t *testing.T) {
+	t.Parallel()
+	path := workspace.NewTagLayout(t.TempDir(), "t1").DataMapping("BenchmarkBar")
+	m := BenchmarkMap{Status: Status{BenchmarkRun: statusOK, Profiles: map[string]string{"cpu": statusOK}}}
+	if err := WriteJSON(path, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Complete([]string{"cpu"}) {
+		t.Fatal("map with the run and every profile should be complete")
+	}
+	if got.Complete([]string{"cpu", "memory"}) {
+		t.Fatal("map missing a requested profile should not be complete")
+	}
+	if (BenchmarkMap{}).Complete(nil) {
+		t.Fatal("map without a benchmark run should not be complete")
+	}
+}
+
+func TestTagStatus_roundTrip(t *testing.T) {
+	t.Parallel()
+	path := workspace.NewTagLayout(t.TempDir(), "t1").Status()
+	if _, err := ReadTagStatus(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing status.json: err = %v", err)
+	}
+	want := TagStatus{
+		State:     TagStateIncomplete,
+		Reason:    TagStopCanceled,
+		Completed: []string{"BenchmarkA"},
+		Pending:   []string{"BenchmarkB"},
+		UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := WriteTagStatus(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTagStatus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v want %+v", got, want)
+	}
+}
+
 func TestParseMeasurementSummary(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
@@ -116,7 +398,7 @@ ok  	example.com/bench	1.234s
 	if err := os.WriteFile(path, []byte(content), workspace.PermFile); err != nil {
 		t.Fatal(err)
 	}
-	sum, err := parseMeasurementSummary(path)
+	sum, err := ParseMeasurementSummary(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -127,3 +409,90 @@ ok  	example.com/bench	1.234s
 		t.Fatalf("result=%q", sum.Result)
 	}
 }
+
+func TestParseMeasurementSummary_keepsAllSamples(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.txt")
+	content := `BenchmarkFoo-8    1000    100 ns/op    64 B/op    2 allocs/op    3.5 widgets/op
+BenchmarkFoo-8    1000    130 ns/op    96 B/op    3 allocs/op    3.5 widgets/op
+BenchmarkFoo-8    1000    110.5 ns/op    64 B/op    2 allocs/op    3.5 widgets/op
+PASS
+`
+	if err := os.WriteFile(path, []byte(content), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := ParseMeasurementSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Count != 3 || sum.NsPerOpMedian != 111 || sum.BytesPerOp != 64 || sum.AllocsPerOp != 2 {
+		t.Fatalf("summary=%+v", sum)
+	}
+	ns, ok := sum.Metric(unitNsPerOp)
+	if !ok || len(ns.Samples) != 3 || ns.Median != 110.5 || ns.CILow != 100 || ns.CIHigh != 130 {
+		t.Fatalf("ns/op stats=%+v", ns)
+	}
+	if custom, ok := sum.Metric("widgets/op"); !ok || custom.Median != 3.5 || custom.VariationPct != 0 {
+		t.Fatalf("custom metric=%+v ok=%v", custom, ok)
+	}
+}
+
+func TestParseMeasurementSummary_groupsByResultName(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.txt")
+	// go test -bench BenchmarkFoo -cpu 1,4 -count 3 interleaves one result name per -cpu value.
+	content := `BenchmarkFoo      1000    26079 ns/op
+BenchmarkFoo-4    1000    126131 ns/op
+BenchmarkFoo      1000    17318 ns/op
+BenchmarkFoo-4    1000    144963 ns/op
+BenchmarkFoo      1000    13272 ns/op
+BenchmarkFoo-4    1000    143514 ns/op
+PASS
+`
+	if err := os.WriteFile(path, []byte(content), workspace.PermFile); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := ParseMeasurementSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Count != 6 || sum.NsPerOpMedian != 0 || len(sum.Metrics) != 0 {
+		t.Fatalf("several result names must leave the headline empty: %+v", sum)
+	}
+	if _, ok := sum.Metric(unitNsPerOp); ok {
+		t.Fatal("Metric must not pool samples across result names")
+	}
+	groups := sum.Groups()
+	if len(groups) != 2 || groups[0].Name != "BenchmarkFoo" || groups[1].Name != "BenchmarkFoo-4" {
+		t.Fatalf("groups=%+v", groups)
+	}
+	for i, want := range []float64{17318, 143514} {
+		ns, ok := groups[i].Metric(unitNsPerOp)
+		if !ok || groups[i].Count != 3 || len(ns.Samples) != 3 || ns.Median != want {
+			t.Fatalf("%s ns/op=%+v", groups[i].Name, ns)
+		}
+	}
+}
+
+func TestBuild_subBenchmarkKeepsOriginalName(t *testing.T) {
+	t.Parallel()
+	layout := workspace.NewTagLayout(t.TempDir(), "baseline")
+	m, err := Build(BuildInput{
+		Layout:         layout,
+		Tag:            "baseline",
+		Benchmark:      "BenchmarkCodec/json/small",
+		CollectionMode: collectionAuto,
+		Profiles:       []string{"cpu"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Benchmark != "BenchmarkCodec/json/small" || m.BenchmarkDir != "BenchmarkCodec__json__small" {
+		t.Fatalf("benchmark=%q dir=%q", m.Benchmark, m.BenchmarkDir)
+	}
+	if got := m.Hotspots["cpu"].Path; got != "hotspots/BenchmarkCodec__json__small/cpu.txt" {
+		t.Fatalf("hotspot path=%q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package datamap
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// ManifestSchemaVersion is the manifest.json schema version written by prof.
+const ManifestSchemaVersion = 1
+
+// TagEntryPending marks a manifest benchmark that has not finished (TagStateComplete when it has).
+const TagEntryPending = "pending"
+
+// TagManifest is .prof/<tag>/manifest.json: what produced the tag and the environment it ran
+// in. It describes the last prof auto or prof manual run into the tag, except that Benchmarks,
+// like status.json, keeps the entries of earlier --append or --resume runs.
+type TagManifest struct {
+	SchemaVersion  int               `json:"schema_version"`
+	Tag            string            `json:"tag"`
+	CollectionMode string            `json:"collection_mode"` // auto or manual
+	ProfVersion    string            `json:"prof_version"`
+	Command        []string          `json:"command"` // prof command line
+	Git            *GitState         `json:"git,omitempty"`
+	Fingerprint    Fingerprint       `json:"fingerprint"`
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+	State          string            `json:"state"`      // TagState*, as in status.json
+	Benchmarks     map[string]string `json:"benchmarks"` // TagStateComplete or TagEntryPending (prof manual: input files; env matrix tag: variants)
+}
+
+// GitState is the commit of the module's repository and whether its work tree had changes.
+type GitState struct {
+	Commit string `json:"commit"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// Fingerprint describes the machine and toolchain a tag was collected with. Benchmarks from
+// tags with different fingerprints may differ for reasons other than the code.
+type Fingerprint struct {
+	GoVersion  string            `json:"go_version,omitempty"` // go env GOVERSION of the toolchain that ran go test
+	GOOS       string            `json:"goos,omitempty"`
+	GOARCH     string            `json:"goarch,omitempty"`
+	CPUModel   string            `json:"cpu_model,omitempty"`
+	NumCPU     int               `json:"num_cpu"`
+	GOMAXPROCS int               `json:"gomaxprocs"`    // before go test -cpu or --parallel lower it
+	Env        map[string]string `json:"env,omitempty"` // runtime and toolchain variables that were set, e.g. GOGC
+}
+
+// Diff lists the fields of f and other that differ, as "name: f value → other value".
+func (f Fingerprint) Diff(other Fingerprint) []string {
+	var diffs []string
+	add := func(name string, a, b any) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s: %v → %v", name, orUnset(a), orUnset(b)))
+		}
+	}
+	add("go_version", f.GoVersion, other.GoVersion)
+	add("goos", f.GOOS, other.GOOS)
+	add("goarch", f.GOARCH, other.GOARCH)
+	add("cpu_model", f.CPUModel, other.CPUModel)
+	add("num_cpu", f.NumCPU, other.NumCPU)
+	add("gomaxprocs", f.GOMAXPROCS, other.GOMAXPROCS)
+	names := slices.Sorted(maps.Keys(f.Env))
+	for name := range other.Env {
+		if _, ok := f.Env[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		add("env "+name, f.Env[name], other.Env[name])
+	}
+	return diffs
+}
+
+func orUnset(v any) any {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
+
+// WriteManifest encodes m to path with standard permissions.
+func WriteManifest(path string, m TagManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tag manifest: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+		return fmt.Errorf("mkdir manifest parent: %w", err)
+	}
+	if err = os.WriteFile(path, data, workspace.PermFile); err != nil {
+		return fmt.Errorf("write tag manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest decodes the manifest.json at path. Tags collected before manifest.json existed
+// return an error wrapping [os.ErrNotExist].
+func ReadManifest(path string) (TagManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TagManifest{}, err
+	}
+	var m TagManifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		return TagManifest{}, fmt.Errorf("decode tag manifest: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,56 @@
+package datamap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFingerprint_Diff(t *testing.T) {
+	t.Parallel()
+	base := Fingerprint{GoVersion: "go1.24.3", GOOS: "linux", GOARCH: "amd64", NumCPU: 8, GOMAXPROCS: 8, Env: map[string]string{"GOGC": "100"}}
+	if diffs := base.Diff(base); len(diffs) != 0 {
+		t.Fatalf("identical fingerprints differ: %v", diffs)
+	}
+	head := base
+	head.GoVersion = "go1.25.0"
+	head.GOMAXPROCS = 4
+	head.Env = map[string]string{"GODEBUG": "gctrace=1"}
+	want := []string{
+		"go_version: go1.24.3 → go1.25.0",
+		"gomaxprocs: 8 → 4",
+		"env GODEBUG: (unset) → gctrace=1",
+		"env GOGC: 100 → (unset)",
+	}
+	if got := base.Diff(head); !slices.Equal(got, want) {
+		t.Fatalf("diff=%q want %q", got, want)
+	}
+}
+
+func TestManifest_roundTrip(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "t1", "manifest.json")
+	if _, err := ReadManifest(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing manifest: err = %v", err)
+	}
+	m := TagManifest{
+		SchemaVersion: ManifestSchemaVersion,
+		Tag:           "t1",
+		Git:           &GitState{Commit: "0123abcd", Dirty: true},
+		Fingerprint:   Fingerprint{GoVersion: "go1.24.3", NumCPU: 4},
+		State:         TagStateComplete,
+		Benchmarks:    map[string]string{"BenchmarkA": TagStateComplete},
+	}
+	if err := WriteManifest(path, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tag != "t1" || *got.Git != *m.Git || got.Fingerprint.GoVersion != "go1.24.3" || got.Benchmarks["BenchmarkA"] != TagStateComplete {
+		t.Fatalf("manifest=%+v", got)
+	}
+}
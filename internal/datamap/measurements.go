@@ -3,26 +3,43 @@ package datamap
 import (
 	"bufio"
 	"fmt"
+	"math"
 	"os"
-	"regexp"
-	"sort"
 	"strconv"
 	"strings"
-)
 
-var benchLineRE = regexp.MustCompile(`^\S+\s+\d+\s+(\d+)\s+ns/op\s+(\d+)\s+B/op\s+(\d+)\s+allocs/op`)
+	"github.com/AlexsanderHamir/prof/internal/stats"
+)
 
 const benchResultPass = "PASS"
 
-func parseMeasurementSummary(path string) (*MeasurementSummary, error) {
+// Units printed by go test -benchmem that feed the headline summary fields.
+const (
+	unitNsPerOp     = "ns/op"
+	unitBytesPerOp  = "B/op"
+	unitAllocsPerOp = "allocs/op"
+)
+
+// ParseMeasurementSummary reads every benchmark sample from a go test -benchmem transcript.
+// Each "value unit" pair on a benchmark line (including custom b.ReportMetric units) is kept per
+// result name and metric, and summarized with its median and a confidence interval. Lines of
+// different result names (sub-benchmarks, -cpu values) are never pooled; see
+// MeasurementSummary.
+func ParseMeasurementSummary(path string) (*MeasurementSummary, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	var nsValues []int64
-	var bytesPerOp, allocsPerOp int64
+	type group struct {
+		units   []string
+		samples map[string][]float64
+		lines   int
+	}
+	var names []string
+	groups := make(map[string]*group)
+	lines := 0
 	result := ""
 	var elapsed float64
 
@@ -33,36 +50,133 @@ func parseMeasurementSummary(path string) (*MeasurementSummary, error) {
 			fields := strings.Fields(line)
 			if len(fields) >= 2 {
 				if d, parseErr := strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "s"), 64); parseErr == nil {
-					elapsed = d
+					elapsed += d // a per-iteration run.txt holds one ok line per go test run
 				}
 			}
 		}
 		if line == benchResultPass {
 			result = benchResultPass
 		}
-		m := benchLineRE.FindStringSubmatch(line)
-		if m == nil {
+		name, pairs, ok := parseBenchLine(line)
+		if !ok {
 			continue
 		}
-		ns, _ := strconv.ParseInt(m[1], 10, 64)
-		nsValues = append(nsValues, ns)
-		bytesPerOp, _ = strconv.ParseInt(m[2], 10, 64)
-		allocsPerOp, _ = strconv.ParseInt(m[3], 10, 64)
+		lines++
+		g := groups[name]
+		if g == nil {
+			g = &group{samples: make(map[string][]float64)}
+			groups[name] = g
+			names = append(names, name)
+		}
+		g.lines++
+		for _, p := range pairs {
+			if _, seen := g.samples[p.unit]; !seen {
+				g.units = append(g.units, p.unit)
+			}
+			g.samples[p.unit] = append(g.samples[p.unit], p.value)
+		}
 	}
 	if err = scanner.Err(); err != nil {
 		return nil, err
 	}
-	if len(nsValues) == 0 {
+	if lines == 0 {
 		return nil, fmt.Errorf("no benchmark lines in %s", path)
 	}
-	sort.Slice(nsValues, func(i, j int) bool { return nsValues[i] < nsValues[j] })
-	median := nsValues[len(nsValues)/2]
-	return &MeasurementSummary{
-		Count:          len(nsValues),
-		NsPerOpMedian:  median,
-		BytesPerOp:     bytesPerOp,
-		AllocsPerOp:    allocsPerOp,
+
+	sum := &MeasurementSummary{
+		Count:          lines,
 		ElapsedSeconds: elapsed,
 		Result:         result,
-	}, nil
+	}
+	for _, name := range names {
+		g := groups[name]
+		r := ResultSummary{Name: name, Count: g.lines}
+		for _, unit := range g.units {
+			r.Metrics = append(r.Metrics, newMetricStats(unit, g.samples[unit]))
+		}
+		sum.Results = append(sum.Results, r)
+	}
+	if len(sum.Results) > 1 {
+		return sum, nil
+	}
+	sum.Name, sum.Metrics, sum.Results = sum.Results[0].Name, sum.Results[0].Metrics, nil
+	if m, ok := sum.Metric(unitNsPerOp); ok {
+		sum.NsPerOpMedian = int64(math.Round(m.Median))
+	}
+	if m, ok := sum.Metric(unitBytesPerOp); ok {
+		sum.BytesPerOp = int64(math.Round(m.Median))
+	}
+	if m, ok := sum.Metric(unitAllocsPerOp); ok {
+		sum.AllocsPerOp = int64(math.Round(m.Median))
+	}
+	return sum, nil
+}
+
+// Metric returns the statistics recorded for unit, if any. It is empty when run.txt holds
+// several result names; use Groups for those.
+func (s *MeasurementSummary) Metric(unit string) (MetricStats, bool) {
+	return metricByUnit(s.Metrics, unit)
+}
+
+// Groups returns one summary per result name, in run.txt order: Results, or the single name
+// the summary itself describes.
+func (s *MeasurementSummary) Groups() []ResultSummary {
+	if len(s.Results) > 0 {
+		return s.Results
+	}
+	return []ResultSummary{{Name: s.Name, Count: s.Count, Metrics: s.Metrics}}
+}
+
+// Metric returns the statistics recorded for unit, if any.
+func (r ResultSummary) Metric(unit string) (MetricStats, bool) {
+	return metricByUnit(r.Metrics, unit)
+}
+
+func metricByUnit(metrics []MetricStats, unit string) (MetricStats, bool) {
+	for _, m := range metrics {
+		if m.Unit == unit {
+			return m, true
+		}
+	}
+	return MetricStats{}, false
+}
+
+type benchValue struct {
+	value float64
+	unit  string
+}
+
+// parseBenchLine splits "BenchmarkName/sub-8  N  v1 unit1  v2 unit2 ..." into its result name
+// (kept whole, GOMAXPROCS suffix included) and its value/unit pairs.
+func parseBenchLine(line string) (string, []benchValue, bool) {
+	fields := strings.Fields(line)
+	const minFields = 4 // name, iterations, one value/unit pair
+	if len(fields) < minFields || !strings.HasPrefix(fields[0], "Benchmark") {
+		return "", nil, false
+	}
+	if _, err := strconv.ParseInt(fields[1], 10, 64); err != nil {
+		return "", nil, false
+	}
+	var pairs []benchValue
+	for i := 2; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return "", nil, false
+		}
+		pairs = append(pairs, benchValue{value: v, unit: fields[i+1]})
+	}
+	return fields[0], pairs, len(pairs) > 0
+}
+
+func newMetricStats(unit string, values []float64) MetricStats {
+	s := stats.Summarize(values, stats.DefaultConfidence)
+	return MetricStats{
+		Unit:         unit,
+		Samples:      values,
+		Median:       s.Median,
+		CILow:        s.Lo,
+		CIHigh:       s.Hi,
+		Confidence:   s.Confidence,
+		VariationPct: s.SpreadPct(),
+	}
 }
@@ -0,0 +1,66 @@
+package datamap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/workspace"
+)
+
+// Tag collect states recorded in status.json.
+const (
+	TagStateRunning    = "running"
+	TagStateComplete   = "complete"
+	TagStateIncomplete = "incomplete"
+)
+
+// Reasons an incomplete collect stopped.
+const (
+	TagStopCanceled = "canceled"
+	TagStopTimeout  = "timeout"
+	TagStopFailed   = "failed"
+)
+
+// TagStatus is .prof/<tag>/status.json: how far the prof auto or prof manual runs into the tag
+// got. An --append or --resume run updates the entries it repeats and keeps the others. A tag
+// left "running" belongs to a run that was killed before it could record the outcome.
+type TagStatus struct {
+	State     string    `json:"state"`
+	Reason    string    `json:"reason,omitempty"` // set when State is incomplete
+	Error     string    `json:"error,omitempty"`
+	Completed []string  `json:"completed"`         // finished benchmarks (prof manual: input files; env matrix tag: variants)
+	Pending   []string  `json:"pending,omitempty"` // requested entries that did not finish
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WriteTagStatus encodes s to path with standard permissions.
+func WriteTagStatus(path string, s TagStatus) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tag status: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), workspace.PermDir); err != nil {
+		return fmt.Errorf("mkdir status parent: %w", err)
+	}
+	if err = os.WriteFile(path, data, workspace.PermFile); err != nil {
+		return fmt.Errorf("write tag status: %w", err)
+	}
+	return nil
+}
+
+// ReadTagStatus decodes the status.json at path. Tags collected before status.json existed
+// return an error wrapping [os.ErrNotExist].
+func ReadTagStatus(path string) (TagStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TagStatus{}, err
+	}
+	var s TagStatus
+	if err = json.Unmarshal(data, &s); err != nil {
+		return TagStatus{}, fmt.Errorf("decode tag status: %w", err)
+	}
+	return s, nil
+}
@@ -11,26 +11,38 @@ const (
 	PurposeCallerCalleeContext   = "caller_callee_context"
 	PurposeLineLevelSource       = "line_level_source_extract"
 	PurposeVisualCallGraph       = "visual_call_graph"
+	PurposeFoldedStacks          = "folded_stacks"
+	PurposeVisualFlameGraph      = "visual_flame_graph"
+	PurposeSpeedscopeProfile     = "speedscope_profile"
+	PurposeExecutionTrace        = "execution_trace"
+	PurposePprofReport           = "pprof_report"
 )
 
 // BenchmarkMap is the root document written to data_mapping/<Benchmark>/map.json.
 type BenchmarkMap struct {
-	SchemaVersion      int                           `json:"schema_version"`
-	Tag                string                        `json:"tag"`
-	Benchmark          string                        `json:"benchmark"`
-	Package            string                        `json:"package,omitempty"`
-	RecommendedFlow    []string                      `json:"recommended_flow"`
-	ReadingGuide       map[string]string             `json:"reading_guide"`
-	ProfileCostColumns map[string]string             `json:"profile_cost_columns"`
-	ProfileCostTriage  string                        `json:"profile_cost_triage"`
-	Measurements       *MeasurementsSection          `json:"measurements,omitempty"`
-	Profiles           map[string]ProfileRef         `json:"profiles"`
-	Hotspots           map[string]HotspotSection     `json:"hotspots"`
-	CallTrees          map[string]CallTreeSection    `json:"call_trees"`
-	SourceLines        map[string]SourceLinesSection `json:"source_lines"`
-	CallGraphs         map[string]CallGraphRef       `json:"call_graphs,omitempty"`
-	Provenance         Provenance                    `json:"provenance"`
-	Status             Status                        `json:"status"`
+	SchemaVersion      int                             `json:"schema_version"`
+	Tag                string                          `json:"tag"`
+	Benchmark          string                          `json:"benchmark"`
+	BenchmarkDir       string                          `json:"benchmark_dir,omitempty"` // set when Benchmark is a sub-benchmark path
+	Package            string                          `json:"package,omitempty"`
+	Module             string                          `json:"module,omitempty"` // module path of Package; one of the go.work modules in a workspace
+	RecommendedFlow    []string                        `json:"recommended_flow"`
+	ReadingGuide       map[string]string               `json:"reading_guide"`
+	ProfileCostColumns map[string]string               `json:"profile_cost_columns"`
+	ProfileCostTriage  string                          `json:"profile_cost_triage"`
+	Measurements       *MeasurementsSection            `json:"measurements,omitempty"`
+	Profiles           map[string]ProfileRef           `json:"profiles"`
+	Hotspots           map[string]HotspotSection       `json:"hotspots"`
+	CallTrees          map[string]CallTreeSection      `json:"call_trees"`
+	SourceLines        map[string]SourceLinesSection   `json:"source_lines"`
+	CallGraphs         map[string]CallGraphRef         `json:"call_graphs,omitempty"`
+	FoldedStacks       map[string]FoldedStacksSection  `json:"folded_stacks"`
+	FlameGraphs        map[string]FlameGraphSection    `json:"flame_graphs"`
+	Speedscope         map[string]SpeedscopeSection    `json:"speedscope"`
+	Traces             map[string]TraceSection         `json:"traces,omitempty"`
+	Reports            map[string]map[string]ReportRef `json:"reports,omitempty"` // profile → producer id
+	Provenance         Provenance                      `json:"provenance"`
+	Status             Status                          `json:"status"`
 }
 
 // MeasurementsSection points at go test bench output.
@@ -42,25 +54,58 @@ type MeasurementsSection struct {
 }
 
 // MeasurementSummary holds parsed headline numbers from run.txt.
+// go test prints one result name per sub-benchmark and -cpu value (BenchmarkCodec/json-8), and
+// samples are only pooled under one name. When run.txt holds a single name, Name, the headline
+// fields and Metrics describe it; BytesPerOp and AllocsPerOp are medians across all -count
+// samples, like NsPerOpMedian. With several names, Results holds each one and those fields
+// stay empty; Count still counts every benchmark line.
 type MeasurementSummary struct {
-	Count          int     `json:"count,omitempty"`
-	NsPerOpMedian  int64   `json:"ns_per_op_median,omitempty"`
-	BytesPerOp     int64   `json:"bytes_per_op,omitempty"`
-	AllocsPerOp    int64   `json:"allocs_per_op,omitempty"`
-	ElapsedSeconds float64 `json:"elapsed_seconds,omitempty"`
-	Result         string  `json:"result,omitempty"`
+	Name           string          `json:"name,omitempty"`
+	Count          int             `json:"count,omitempty"`
+	NsPerOpMedian  int64           `json:"ns_per_op_median,omitempty"`
+	BytesPerOp     int64           `json:"bytes_per_op,omitempty"`
+	AllocsPerOp    int64           `json:"allocs_per_op,omitempty"`
+	ElapsedSeconds float64         `json:"elapsed_seconds,omitempty"`
+	Result         string          `json:"result,omitempty"`
+	Metrics        []MetricStats   `json:"metrics,omitempty"`
+	Results        []ResultSummary `json:"results,omitempty"`
+}
+
+// ResultSummary holds the samples of one go test result name, in a run.txt with several.
+type ResultSummary struct {
+	Name    string        `json:"name"` // as go test printed it, with the GOMAXPROCS suffix
+	Count   int           `json:"count"`
+	Metrics []MetricStats `json:"metrics"`
+}
+
+// MetricStats keeps every sample of one benchmark metric with its median and confidence interval.
+// VariationPct is the benchstat-style "± x%" spread; deltas within it are likely noise.
+type MetricStats struct {
+	Unit         string    `json:"unit"`
+	Samples      []float64 `json:"samples"`
+	Median       float64   `json:"median"`
+	CILow        float64   `json:"ci_low"`
+	CIHigh       float64   `json:"ci_high"`
+	Confidence   float64   `json:"confidence"`
+	VariationPct float64   `json:"variation_pct"`
 }
 
 // ProfileRef describes a raw pprof binary.
+// A variant entry (key such as memory.alloc_space) reuses the binary of its profile Kind
+// ranked by another sample type; the profile kind entry lists its Variants.
 type ProfileRef struct {
-	Path         string  `json:"path"`
-	Purpose      string  `json:"purpose"`
-	Description  string  `json:"description"`
-	TotalSamples int64   `json:"total_samples,omitempty"`
-	SampleUnit   string  `json:"sample_unit,omitempty"`
-	OutputUnit   string  `json:"output_unit,omitempty"`
-	TotalDisplay string  `json:"total_display,omitempty"`
-	TotalSeconds float64 `json:"total_seconds,omitempty"`
+	Path         string   `json:"path"`
+	Purpose      string   `json:"purpose"`
+	Description  string   `json:"description"`
+	Kind         string   `json:"kind,omitempty"`
+	Variants     []string `json:"variants,omitempty"`
+	Iterations   []string `json:"iterations,omitempty"` // per-iteration binaries Path merges, in run order
+	TotalSamples int64    `json:"total_samples,omitempty"`
+	SampleIndex  string   `json:"sample_index,omitempty"`
+	SampleUnit   string   `json:"sample_unit,omitempty"`
+	OutputUnit   string   `json:"output_unit,omitempty"`
+	TotalDisplay string   `json:"total_display,omitempty"`
+	TotalSeconds float64  `json:"total_seconds,omitempty"`
 }
 
 // HotspotSection describes a pprof -top text artifact.
@@ -70,6 +115,7 @@ type HotspotSection struct {
 	Description         string `json:"description"`
 	Producer            string `json:"producer"`
 	HotspotsMetricsNote string `json:"hotspots_metrics_note,omitempty"`
+	Stability           string `json:"stability,omitempty"` // per-iteration flat share report of a per-iteration run
 }
 
 // CallTreeSection describes a pprof -tree text artifact.
@@ -80,6 +126,50 @@ type CallTreeSection struct {
 	Producer    string `json:"producer"`
 }
 
+// FoldedStacksSection describes a Brendan Gregg folded stacks artifact.
+type FoldedStacksSection struct {
+	Path        string `json:"path"`
+	Purpose     string `json:"purpose"`
+	Description string `json:"description"`
+	Producer    string `json:"producer"`
+}
+
+// FlameGraphSection describes a standalone SVG flame graph.
+type FlameGraphSection struct {
+	Path        string `json:"path"`
+	Purpose     string `json:"purpose"`
+	Description string `json:"description"`
+	Producer    string `json:"producer"`
+}
+
+// SpeedscopeSection describes a speedscope JSON export.
+type SpeedscopeSection struct {
+	Path        string `json:"path"`
+	Purpose     string `json:"purpose"`
+	Description string `json:"description"`
+	Producer    string `json:"producer"`
+}
+
+// TraceSection describes a go test -trace execution trace. The pprof profiles extracted from it
+// with go tool trace -pprof are regular Profiles entries, listed in Views.
+type TraceSection struct {
+	Path        string   `json:"path"`
+	Purpose     string   `json:"purpose"`
+	Description string   `json:"description"`
+	Summary     string   `json:"summary"`         // GC pause and scheduler latency summary
+	Views       []string `json:"views,omitempty"` // Profiles keys, e.g. trace_sched
+}
+
+// ReportRef describes the output of a collection.producers entry of prof.json for one profile.
+// A best-effort producer that failed has status skipped and no file at Path.
+type ReportRef struct {
+	Path        string `json:"path"`
+	Purpose     string `json:"purpose"`
+	Description string `json:"description"`
+	Producer    string `json:"producer"`
+	Status      string `json:"status"`
+}
+
 // SourceLinesSection indexes per-function -list extracts for one profile kind.
 type SourceLinesSection struct {
 	Dir         string                 `json:"dir"`
@@ -108,11 +198,29 @@ type CallGraphRef struct {
 
 // Provenance records how the map was produced.
 type Provenance struct {
-	Tag               string         `json:"tag"`
-	CollectionMode    string         `json:"collection_mode"`
-	BenchCount        int            `json:"bench_count,omitempty"`
-	ProfilesRequested []string       `json:"profiles_requested"`
-	Filter            FilterSnapshot `json:"filter"`
+	Tag               string            `json:"tag"`
+	CollectionMode    string            `json:"collection_mode"`
+	BenchCount        int               `json:"bench_count,omitempty"`
+	ProfilesRequested []string          `json:"profiles_requested"`
+	SampleIndex       map[string]string `json:"sample_index,omitempty"`
+	GoTest            *GoTestSnapshot   `json:"go_test,omitempty"`
+	Filter            FilterSnapshot    `json:"filter"`
+}
+
+// GoTestSnapshot records the go test flags of an auto collection, the full command line, and
+// the environment overrides it ran under.
+type GoTestSnapshot struct {
+	Benchtime    string   `json:"benchtime,omitempty"`
+	CPU          string   `json:"cpu,omitempty"`
+	Timeout      string   `json:"timeout,omitempty"`
+	Tags         string   `json:"tags,omitempty"`
+	Gcflags      string   `json:"gcflags,omitempty"`
+	Ldflags      string   `json:"ldflags,omitempty"`
+	Race         bool     `json:"race,omitempty"`
+	ExtraArgs    []string `json:"extra_args,omitempty"`
+	Command      []string `json:"command"`
+	Env          []string `json:"env,omitempty"`           // env matrix overrides, NAME=value
+	PerIteration bool     `json:"per_iteration,omitempty"` // Command ran once per bench_count iteration
 }
 
 // FilterSnapshot mirrors prof.json function filter at collect time.
@@ -127,6 +235,9 @@ type Status struct {
 	Profiles     map[string]string            `json:"profiles"`
 	Hotspots     map[string]string            `json:"hotspots"`
 	CallTrees    map[string]string            `json:"call_trees"`
+	FoldedStacks map[string]string            `json:"folded_stacks"`
+	FlameGraphs  map[string]string            `json:"flame_graphs"`
+	Speedscope   map[string]string            `json:"speedscope"`
 	CallGraphs   map[string]CallGraphStatus   `json:"call_graphs,omitempty"`
 	SourceLines  map[string]SourceLinesStatus `json:"source_lines"`
 }
@@ -1,6 +1,7 @@
 package intent
 
 import (
+	"context"
 	"errors"
 	"strings"
 
@@ -44,13 +45,15 @@ func (i *CollectIntent) Validate() error {
 }
 
 // Run implements [Executable].
-func (i *CollectIntent) Run(svc *app.Services) error {
-	return svc.Collect.RunAuto(app.CollectAutoOptions{
+func (i *CollectIntent) Run(ctx context.Context, svc *app.Services) error {
+	return svc.Collect.RunAuto(ctx, app.CollectAutoOptions{
 		Benchmarks:             i.Benchmarks,
 		Profiles:               i.Profiles,
 		Tag:                    i.Tag,
 		Count:                  i.Count,
 		MissingConfigWarnShown: i.MissingConfigWarnShown,
+		ProfVersion:            svc.Version,
+		Out:                    svc.Out,
 	})
 }
 
@@ -1,6 +1,7 @@
 package intent
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -13,18 +14,19 @@ type fakeCollect struct {
 	err        error
 }
 
-func (f *fakeCollect) RunAuto(opts app.CollectAutoOptions) error {
+func (f *fakeCollect) RunAuto(_ context.Context, opts app.CollectAutoOptions) error {
 	f.lastAuto = opts
 	return f.err
 }
 
-func (f *fakeCollect) RunManual(opts app.CollectManualOptions) error {
+func (f *fakeCollect) RunManual(_ context.Context, opts app.CollectManualOptions) error {
 	f.lastManual = opts
 	return f.err
 }
 
-func (f *fakeCollect) DiscoverBenchmarks(string) ([]string, error) { return nil, nil }
-func (f *fakeCollect) SupportedProfiles() []string                 { return nil }
+func (f *fakeCollect) DiscoverBenchmarks(string) ([]string, error)                 { return nil, nil }
+func (f *fakeCollect) ListSubBenchmarks(context.Context, string) ([]string, error) { return nil, nil }
+func (f *fakeCollect) SupportedProfiles() []string                                 { return nil }
 
 func TestCollectIntent_Validate(t *testing.T) {
 	t.Parallel()
@@ -83,7 +85,7 @@ func TestCollectIntent_Run(t *testing.T) {
 		Tag:        "v1",
 		Count:      3,
 	}
-	if err := RunValidated(intent, svc); err != nil {
+	if err := RunValidated(t.Context(), intent, svc); err != nil {
 		t.Fatal(err)
 	}
 	if fc.lastAuto.Tag != "v1" || fc.lastAuto.Count != 3 {
@@ -108,7 +110,7 @@ func TestCollectIntent_Run_propagatesError(t *testing.T) {
 		Tag:        "t",
 		Count:      1,
 	}
-	err := RunValidated(intent, svc)
+	err := RunValidated(t.Context(), intent, svc)
 	if !errors.Is(err, want) {
 		t.Fatalf("err: %v", err)
 	}
@@ -1,6 +1,8 @@
 package intent
 
 import (
+	"context"
+
 	"github.com/AlexsanderHamir/prof/internal/app"
 )
 
@@ -14,6 +16,6 @@ func (i *ConfigCreateIntent) Kind() Kind { return KindConfigCreate }
 func (i *ConfigCreateIntent) Validate() error { return nil }
 
 // Run implements [Executable].
-func (i *ConfigCreateIntent) Run(svc *app.Services) error {
+func (i *ConfigCreateIntent) Run(_ context.Context, svc *app.Services) error {
 	return svc.Config.CreateDefaultFile()
 }
@@ -37,7 +37,7 @@ func TestConfigCreateIntent_Run(t *testing.T) {
 	t.Parallel()
 	fc := &fakeConfig{}
 	svc := &app.Services{Config: fc}
-	if err := RunValidated(&ConfigCreateIntent{}, svc); err != nil {
+	if err := RunValidated(t.Context(), &ConfigCreateIntent{}, svc); err != nil {
 		t.Fatal(err)
 	}
 	if fc.createCalls != 1 {
@@ -1,6 +1,8 @@
 package intent
 
 import (
+	"context"
+
 	"github.com/AlexsanderHamir/prof/internal/app"
 )
 
@@ -32,13 +34,13 @@ func AllKinds() []KindDescriptor {
 type Executable interface {
 	Kind() Kind
 	Validate() error
-	Run(svc *app.Services) error
+	Run(ctx context.Context, svc *app.Services) error
 }
 
 // RunValidated runs e after Validate; use from CLI glue when you already built an intent.
-func RunValidated(e Executable, svc *app.Services) error {
+func RunValidated(ctx context.Context, e Executable, svc *app.Services) error {
 	if err := e.Validate(); err != nil {
 		return err
 	}
-	return e.Run(svc)
+	return e.Run(ctx, svc)
 }
@@ -0,0 +1,10 @@
+// Package pprofreport renders go tool pprof -top, -tree and -list text reports in-process.
+//
+// The graph building and ordering rules are ported from
+// github.com/google/pprof/internal/{graph,report} (function granularity, -cum order, no
+// node or edge trimming) so the output matches the go tool pprof command prof runs
+// without spawning it.
+// [Folded], [FlameGraph] and [Speedscope] build folded stacks, a standalone SVG flame graph
+// and speedscope JSON from the same prepared profile; pprof has no text equivalent for them.
+// Units and percentages come from [github.com/AlexsanderHamir/prof/internal/pprofscale].
+package pprofreport
@@ -0,0 +1,255 @@
+package pprofreport
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/AlexsanderHamir/prof/internal/pprofscale"
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// Flame graph geometry, in SVG user units.
+const (
+	flameWidth       = 1200
+	flameFrameHeight = 16
+	flamePadTop      = 36
+	flamePadBottom   = 30
+	flamePadSide     = 10
+	flameMinWidth    = 0.1 // frames narrower than this are not drawn
+	flameCharWidth   = 7   // approximate width of one 12px Verdana glyph
+	flameRootName    = "all"
+)
+
+// stack is one distinct call stack, frames ordered root first.
+type stack struct {
+	frames []string
+	value  int64
+}
+
+// Folded writes p as Brendan Gregg folded stacks: one line per distinct stack with frames
+// root first separated by ';', a space, and the summed sample value at sampleIndex
+// (empty uses the profile's default sample type). Lines are sorted. p is not modified.
+func Folded(w io.Writer, p *pprofprofile.Profile, sampleIndex string) error {
+	rpt, err := newReport(p, sampleIndex, functions)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, s := range rpt.stacks() {
+		fmt.Fprintf(&b, "%s %d\n", strings.Join(s.frames, ";"), s.value)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// FlameGraph writes a self-contained SVG flame graph of p at sampleIndex. Hovering a frame
+// shows its value and share of the total; clicking zooms to it. It needs no external tools.
+// p is not modified.
+func FlameGraph(w io.Writer, p *pprofprofile.Profile, sampleIndex string) error {
+	rpt, err := newReport(p, sampleIndex, functions)
+	if err != nil {
+		return err
+	}
+	root := &flameNode{name: flameRootName}
+	for _, s := range rpt.stacks() {
+		root.add(s.frames, s.value)
+	}
+
+	height := flamePadTop + flamePadBottom + root.depth()*flameFrameHeight
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" standalone="no"?>
+<svg version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">
+<style>
+text { font-family: Verdana, sans-serif; font-size: 12px; fill: #000; }
+.frame { cursor: pointer; }
+.frame:hover rect { stroke: #000; stroke-width: 0.5; }
+#title { font-size: 17px; text-anchor: middle; }
+#unzoom { cursor: pointer; }
+</style>
+<script><![CDATA[%s]]></script>
+<rect width="100%%" height="100%%" fill="#eeeeee"/>
+<text id="title" x="%d" y="24">%s</text>
+<text id="unzoom" x="%d" y="24" visibility="hidden" onclick="unzoom()">Reset Zoom</text>
+<text id="details" x="%d" y="%d"> </text>
+<g id="frames">
+`, flameWidth, height, flameWidth, height, fmt.Sprintf(flameScript, flameWidth, flamePadSide, flameCharWidth, flameMinWidth),
+		flameWidth/2, html.EscapeString("Flame Graph — "+rpt.sampleType+" (total "+rpt.format(rpt.total)+")"),
+		flamePadSide, flamePadSide, height-flamePadBottom/2+4)
+
+	if root.value > 0 {
+		rpt.writeFrames(&b, root, 0, 0, root.value, height)
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// stacks returns every sampled stack at function granularity, frames root first,
+// merged by identical frame sequence and sorted by their folded representation.
+func (r *report) stacks() []stack {
+	byKey := make(map[string]*stack)
+	for _, s := range r.prof.Sample {
+		v := r.value(s.Value)
+		if v == 0 {
+			continue
+		}
+		var frames []string
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			l := s.Location[i]
+			lines := l.Line
+			if len(lines) == 0 {
+				lines = []pprofprofile.Line{{}}
+			}
+			for j := len(lines) - 1; j >= 0; j-- {
+				frames = append(frames, nodeName(l, lines[j]))
+			}
+		}
+		key := strings.Join(frames, ";")
+		if st := byKey[key]; st != nil {
+			st.value += v
+			continue
+		}
+		byKey[key] = &stack{frames: frames, value: v}
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]stack, len(keys))
+	for i, k := range keys {
+		out[i] = *byKey[k]
+	}
+	return out
+}
+
+// flameNode is one frame of the merged flame graph tree.
+type flameNode struct {
+	name     string
+	value    int64
+	children []*flameNode
+	index    map[string]*flameNode
+}
+
+func (n *flameNode) add(frames []string, v int64) {
+	n.value += v
+	if len(frames) == 0 {
+		return
+	}
+	child := n.index[frames[0]]
+	if child == nil {
+		if n.index == nil {
+			n.index = make(map[string]*flameNode)
+		}
+		child = &flameNode{name: frames[0]}
+		n.index[frames[0]] = child
+		n.children = append(n.children, child)
+	}
+	child.add(frames[1:], v)
+}
+
+// depth returns the number of frame rows, counting the root.
+func (n *flameNode) depth() int {
+	d := 0
+	for _, c := range n.children {
+		d = max(d, c.depth())
+	}
+	return d + 1
+}
+
+// writeFrames emits n and its children (alphabetically, left to right) as SVG groups.
+// x is the frame's left edge in value units out of total; frames also carry data-x/data-w
+// as fractions of total so the zoom script can rescale them.
+func (r *report) writeFrames(b *strings.Builder, n *flameNode, x int64, depth int, total int64, height int) {
+	scale := float64(flameWidth-2*flamePadSide) / float64(total)
+	width := float64(n.value) * scale
+	if width < flameMinWidth {
+		return
+	}
+	px := float64(flamePadSide) + float64(x)*scale
+	py := height - flamePadBottom - (depth+1)*flameFrameHeight
+	name := html.EscapeString(n.name)
+	info := html.EscapeString(fmt.Sprintf("%s (%s, %s)", n.name, r.format(n.value),
+		strings.TrimSpace(pprofscale.Percentage(n.value, r.total))))
+	fmt.Fprintf(b, `<g class="frame" data-n="%s" data-x="%.6f" data-w="%.6f" data-d="%d" onclick="zoom(this)" onmouseover="details(this)" onmouseout="details(null)">`+
+		`<title>%s</title><rect x="%.2f" y="%d" width="%.2f" height="%d" rx="2" fill="%s"/>`+
+		`<text x="%.2f" y="%d">%s</text></g>`+"\n",
+		name, float64(x)/float64(total), float64(n.value)/float64(total), depth,
+		info, px, py, width, flameFrameHeight-1, flameColor(n.name),
+		px+3, py+flameFrameHeight-4, html.EscapeString(fitLabel(n.name, width)))
+
+	sort.Slice(n.children, func(i, j int) bool { return n.children[i].name < n.children[j].name })
+	for _, c := range n.children {
+		r.writeFrames(b, c, x, depth+1, total, height)
+		x += c.value
+	}
+}
+
+// fitLabel truncates name to what fits in width, or returns "" when nothing useful fits.
+func fitLabel(name string, width float64) string {
+	chars := int((width - 6) / flameCharWidth)
+	if chars < 3 {
+		return ""
+	}
+	if len(name) <= chars {
+		return name
+	}
+	return name[:chars-2] + ".."
+}
+
+// flameColor returns a stable warm color for name, in the classic flame graph palette.
+func flameColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	v := h.Sum32()
+	r := 205 + v%50
+	g := (v >> 8) % 230
+	bl := (v >> 16) % 55
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, bl)
+}
+
+// flameScript implements hover details and click-to-zoom on the frames group. It is a
+// format string taking the width, side padding, glyph width and minimum frame width.
+const flameScript = `
+var W = %d, PAD = %d, CH = %d, MIN = %g;
+function fit(name, w) {
+  var n = Math.floor((w - 6) / CH);
+  if (n < 3) return "";
+  return name.length <= n ? name : name.substring(0, n - 2) + "..";
+}
+function place(g, x, w) {
+  var rect = g.querySelector("rect"), text = g.querySelector("text");
+  if (w < MIN) { g.setAttribute("display", "none"); return; }
+  g.removeAttribute("display");
+  rect.setAttribute("x", PAD + x);
+  rect.setAttribute("width", w);
+  text.setAttribute("x", PAD + x + 3);
+  text.textContent = fit(g.getAttribute("data-n"), w);
+}
+function zoom(target) {
+  var fx = parseFloat(target.getAttribute("data-x")), fw = parseFloat(target.getAttribute("data-w"));
+  var fd = parseInt(target.getAttribute("data-d")), span = W - 2 * PAD;
+  document.querySelectorAll("#frames .frame").forEach(function (g) {
+    var x = parseFloat(g.getAttribute("data-x")), w = parseFloat(g.getAttribute("data-w"));
+    var d = parseInt(g.getAttribute("data-d"));
+    if (d < fd && x <= fx + 1e-9 && x + w >= fx + fw - 1e-9) { place(g, 0, span); return; }
+    if (d < fd || x < fx - 1e-9 || x + w > fx + fw + 1e-9) { g.setAttribute("display", "none"); return; }
+    place(g, (x - fx) / fw * span, w / fw * span);
+  });
+  document.getElementById("unzoom").setAttribute("visibility", "visible");
+}
+function unzoom() {
+  var span = W - 2 * PAD;
+  document.querySelectorAll("#frames .frame").forEach(function (g) {
+    place(g, parseFloat(g.getAttribute("data-x")) * span, parseFloat(g.getAttribute("data-w")) * span);
+  });
+  document.getElementById("unzoom").setAttribute("visibility", "hidden");
+}
+function details(g) {
+  document.getElementById("details").textContent = g ? g.querySelector("title").textContent : " ";
+}
+`
@@ -0,0 +1,79 @@
+package pprofreport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// stackProfile has main → a → b (b inlined into a) and main → c.
+func stackProfile() *pprofprofile.Profile {
+	fn := func(id uint64, name string) *pprofprofile.Function {
+		return &pprofprofile.Function{ID: id, Name: name}
+	}
+	mainFn, a, b, c := fn(1, "main.main"), fn(2, "main.a"), fn(3, "main.b"), fn(4, "main.c")
+	locMain := &pprofprofile.Location{ID: 1, Line: []pprofprofile.Line{{Function: mainFn, Line: 10}}}
+	locAB := &pprofprofile.Location{ID: 2, Line: []pprofprofile.Line{{Function: b, Line: 30}, {Function: a, Line: 20}}}
+	locC := &pprofprofile.Location{ID: 3, Line: []pprofprofile.Line{{Function: c, Line: 40}}}
+	return &pprofprofile.Profile{
+		SampleType: []*pprofprofile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		Function:   []*pprofprofile.Function{mainFn, a, b, c},
+		Location:   []*pprofprofile.Location{locMain, locAB, locC},
+		Sample: []*pprofprofile.Sample{
+			{Location: []*pprofprofile.Location{locAB, locMain}, Value: []int64{3, 30}},
+			{Location: []*pprofprofile.Location{locC, locMain}, Value: []int64{1, 10}},
+			{Location: []*pprofprofile.Location{locAB, locMain}, Value: []int64{2, 20}},
+			{Location: []*pprofprofile.Location{locMain}, Value: []int64{0, 0}},
+		},
+	}
+}
+
+func TestFolded_mergesAndSortsStacks(t *testing.T) {
+	t.Parallel()
+	var got bytes.Buffer
+	if err := Folded(&got, stackProfile(), "samples"); err != nil {
+		t.Fatal(err)
+	}
+	want := "main.main;main.a;main.b 5\nmain.main;main.c 1\n"
+	if got.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
+
+func TestFlameGraph_wellFormedSVG(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	if err := FlameGraph(&out, stackProfile(), ""); err != nil {
+		t.Fatal(err)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(out.Bytes()))
+	frames := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "g" {
+			for _, attr := range se.Attr {
+				if attr.Name.Local == "class" && attr.Value == "frame" {
+					frames++
+				}
+			}
+		}
+	}
+	// all, main.main, main.a, main.b, main.c
+	if frames != 5 {
+		t.Fatalf("frames=%d want 5", frames)
+	}
+	if svg := out.String(); !strings.Contains(svg, "Flame Graph — cpu") || !strings.Contains(svg, "main.b (50ns, 83.33%)") {
+		t.Fatalf("missing title or frame details:\n%s", svg)
+	}
+}
@@ -0,0 +1,163 @@
+package pprofreport
+
+import (
+	"path/filepath"
+	"sort"
+
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// node is one function in the report graph. Nodes are keyed by their printable name,
+// which is unique at function granularity.
+type node struct {
+	name      string
+	flat, cum int64
+	in, out   map[*node]*edge
+}
+
+// edge is a caller → callee link weighted by the samples flowing through it.
+type edge struct {
+	src, dest *node
+	weight    int64
+	// inline edges are calls inlined into the caller.
+	inline bool
+}
+
+type graph struct {
+	nodes []*node
+}
+
+// newGraph builds the function graph for value with every node and edge: prof runs pprof
+// with -nodefraction=0 -edgefraction=0 -nodecount=0, so nothing is trimmed.
+func newGraph(p *pprofprofile.Profile, value func([]int64) int64) *graph {
+	byName := make(map[string]*node)
+	locNodes := make(map[uint64][]*node, len(p.Location))
+	for _, l := range p.Location {
+		lines := l.Line
+		if len(lines) == 0 {
+			lines = []pprofprofile.Line{{}}
+		}
+		ns := make([]*node, len(lines))
+		for i, line := range lines {
+			name := nodeName(l, line)
+			n := byName[name]
+			if n == nil {
+				n = &node{name: name, in: make(map[*node]*edge), out: make(map[*node]*edge)}
+				byName[name] = n
+			}
+			ns[i] = n
+		}
+		locNodes[l.ID] = ns
+	}
+
+	type nodePair struct{ src, dest *node }
+	seenNode := make(map[*node]bool)
+	seenEdge := make(map[nodePair]bool)
+	for _, s := range p.Sample {
+		w := value(s.Value)
+		if w == 0 {
+			continue
+		}
+		clear(seenNode)
+		clear(seenEdge)
+		var parent *node
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			ns := locNodes[s.Location[i].ID]
+			for ni := len(ns) - 1; ni >= 0; ni-- {
+				n := ns[ni]
+				if !seenNode[n] {
+					seenNode[n] = true
+					n.cum += w
+				}
+				if pair := (nodePair{n, parent}); parent != nil && n != parent && !seenEdge[pair] {
+					seenEdge[pair] = true
+					parent.addToEdge(n, w, ni != len(ns)-1)
+				}
+				parent = n
+			}
+		}
+		if parent != nil {
+			parent.flat += w
+		}
+	}
+
+	g := &graph{nodes: make([]*node, 0, len(byName))}
+	for _, n := range byName {
+		if n.cum == 0 && n.flat == 0 {
+			continue
+		}
+		g.nodes = append(g.nodes, n)
+	}
+	return g
+}
+
+// nodeName mirrors pprof NodeInfo.PrintableName for an aggregated (function-level) location.
+func nodeName(l *pprofprofile.Location, line pprofprofile.Line) string {
+	if line.Function != nil && line.Function.Name != "" {
+		return line.Function.Name
+	}
+	if l.Mapping != nil && l.Mapping.File != "" {
+		return "[" + filepath.Base(l.Mapping.File) + "]"
+	}
+	return "<unknown>"
+}
+
+func (n *node) addToEdge(to *node, w int64, inline bool) {
+	if e := n.out[to]; e != nil {
+		e.weight += w
+		if !inline {
+			e.inline = false
+		}
+		return
+	}
+	e := &edge{src: n, dest: to, weight: w, inline: inline}
+	n.out[to] = e
+	to.in[n] = e
+}
+
+func (g *graph) flatSum() int64 {
+	var total int64
+	for _, n := range g.nodes {
+		total += n.flat
+	}
+	return total
+}
+
+// sortCum orders nodes by |cum| descending, then name, then |flat| (pprof CumNameOrder, -cum).
+func (g *graph) sortCum() {
+	sort.Slice(g.nodes, func(i, j int) bool {
+		l, r := g.nodes[i], g.nodes[j]
+		if lv, rv := abs64(l.cum), abs64(r.cum); lv != rv {
+			return lv > rv
+		}
+		if l.name != r.name {
+			return l.name < r.name
+		}
+		return abs64(l.flat) > abs64(r.flat)
+	})
+}
+
+// sortedEdges orders edges by |weight| descending, then source and destination names.
+func sortedEdges(m map[*node]*edge) []*edge {
+	el := make([]*edge, 0, len(m))
+	for _, e := range m {
+		el = append(el, e)
+	}
+	sort.Slice(el, func(i, j int) bool {
+		if el[i].weight != el[j].weight {
+			return abs64(el[i].weight) > abs64(el[j].weight)
+		}
+		if el[i].src.name != el[j].src.name {
+			return el[i].src.name < el[j].src.name
+		}
+		return el[i].dest.name < el[j].dest.name
+	})
+	return el
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,240 @@
+package pprofreport
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AlexsanderHamir/prof/internal/pprofscale"
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+const (
+	treeSeparator = "----------------------------------------------------------+-------------"
+	treeLegend    = "      flat  flat%   sum%        cum   cum%   calls calls% + context \t \t "
+)
+
+// report holds one profile prepared for rendering at a single sample index.
+type report struct {
+	prof       *pprofprofile.Profile
+	index      int // position of sampleType in prof.SampleType
+	value      func([]int64) int64
+	sampleType string
+	sampleUnit string
+	outputUnit string
+	total      int64
+}
+
+// Top writes the equivalent of go tool pprof -cum -edgefraction=0 -nodefraction=0
+// -nodecount=0 -top [-sample_index=sampleIndex] for p, the report prof collects.
+// An empty sampleIndex uses the profile's default sample type. p is not modified.
+func Top(w io.Writer, p *pprofprofile.Profile, sampleIndex string) error {
+	rpt, err := newReport(p, sampleIndex, functions)
+	if err != nil {
+		return err
+	}
+	g := rpt.graph()
+	rpt.selectOutputUnit(g)
+
+	var b strings.Builder
+	b.WriteString(strings.Join(rpt.labels(g), "\n"))
+	b.WriteByte('\n')
+	fmt.Fprintf(&b, "%10s %5s%% %5s%% %10s %5s%%\n", "flat", "flat", "sum", "cum", "cum")
+	var flatSum int64
+	for _, n := range g.nodes {
+		flatSum += n.flat
+		fmt.Fprintf(&b, "%10s %s %s %10s %s  %s%s\n",
+			rpt.format(n.flat), pprofscale.Percentage(n.flat, rpt.total),
+			pprofscale.Percentage(flatSum, rpt.total),
+			rpt.format(n.cum), pprofscale.Percentage(n.cum, rpt.total),
+			n.name, inlineLabel(n))
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// Tree writes the equivalent of go tool pprof -cum -edgefraction=0 -nodefraction=0
+// -nodecount=0 -tree [-sample_index=sampleIndex] for p, the report prof collects.
+// An empty sampleIndex uses the profile's default sample type. p is not modified.
+func Tree(w io.Writer, p *pprofprofile.Profile, sampleIndex string) error {
+	rpt, err := newReport(p, sampleIndex, functions)
+	if err != nil {
+		return err
+	}
+	g := rpt.graph()
+	rpt.selectOutputUnit(g)
+
+	var b strings.Builder
+	b.WriteString(strings.Join(rpt.labels(g), "\n"))
+	b.WriteByte('\n')
+	b.WriteString(treeSeparator + "\n")
+	b.WriteString(treeLegend + "\n")
+	var flatSum int64
+	for _, n := range g.nodes {
+		b.WriteString(treeSeparator + "\n")
+		for _, in := range sortedEdges(n.in) {
+			fmt.Fprintf(&b, "%50s %s |   %s%s\n", rpt.format(in.weight),
+				pprofscale.Percentage(in.weight, n.cum), in.src.name, edgeInline(in))
+		}
+		flatSum += n.flat
+		fmt.Fprintf(&b, "%10s %s %s %10s %s                | %s\n",
+			rpt.format(n.flat),
+			pprofscale.Percentage(n.flat, rpt.total),
+			pprofscale.Percentage(flatSum, rpt.total),
+			rpt.format(n.cum),
+			pprofscale.Percentage(n.cum, rpt.total),
+			n.name)
+		for _, out := range sortedEdges(n.out) {
+			fmt.Fprintf(&b, "%50s %s |   %s%s\n", rpt.format(out.weight),
+				pprofscale.Percentage(out.weight, n.cum), out.dest.name, edgeInline(out))
+		}
+	}
+	if len(g.nodes) > 0 {
+		b.WriteString(treeSeparator + "\n")
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// granularity is the level newReport aggregates locations to.
+type granularity int
+
+const (
+	functions     granularity = iota // function names only (pprof -functions, the default)
+	functionFiles                    // functions, keeping their source file for frame tables
+	sourceLines                      // file and line of every location (pprof -lines, -list)
+)
+
+// newReport copies p, selects the sample index, and aggregates locations to g the way the
+// pprof driver does before generating a text report.
+func newReport(p *pprofprofile.Profile, sampleIndex string, g granularity) (*report, error) {
+	if p == nil {
+		return nil, fmt.Errorf("pprofreport: nil profile")
+	}
+	if len(p.SampleType) == 0 {
+		return nil, fmt.Errorf("profile has no samples")
+	}
+	p = p.Copy()
+	if err := p.RemoveUninteresting(); err != nil {
+		return nil, err
+	}
+	index, err := p.SampleIndexByName(sampleIndex)
+	if err != nil {
+		return nil, err
+	}
+	value := func(v []int64) int64 { return v[index] }
+	rpt := &report{
+		prof:       p,
+		index:      index,
+		value:      value,
+		sampleType: p.SampleType[index].Type,
+		sampleUnit: p.SampleType[index].Unit,
+		outputUnit: "minimum",
+	}
+	for _, s := range p.Sample {
+		rpt.total += abs64(value(s.Value))
+	}
+	if err = p.Aggregate(true, true, g != functions, g == sourceLines, false, false); err != nil {
+		return nil, err
+	}
+	return rpt, nil
+}
+
+// graph returns every node of the report, ordered by cumulative value (-cum).
+func (r *report) graph() *graph {
+	g := newGraph(r.prof, r.value)
+	g.sortCum()
+	return g
+}
+
+func (r *report) selectOutputUnit(g *graph) {
+	if len(g.nodes) == 0 {
+		return
+	}
+	flat := make(map[string]int64, len(g.nodes))
+	cum := make(map[string]int64, len(g.nodes))
+	for _, n := range g.nodes {
+		flat[n.name] = n.flat
+		cum[n.name] = n.cum
+	}
+	r.outputUnit = pprofscale.SelectOutputUnit(r.sampleUnit, r.total, flat, cum)
+}
+
+func (r *report) format(v int64) string {
+	return pprofscale.ScaledLabel(v, r.sampleUnit, r.outputUnit)
+}
+
+// labels returns the report header: profile labels, then the node accounting line. No
+// node is dropped, so pprof's "Dropped" and "Showing top" lines never apply.
+func (r *report) labels(g *graph) []string {
+	label := r.profileLabels()
+	shown := g.flatSum()
+	return append(label, fmt.Sprintf("Showing nodes accounting for %s, %s of %s total",
+		r.format(shown), strings.TrimSpace(pprofscale.Percentage(shown, r.total)), r.format(r.total)))
+}
+
+// profileLabels mirrors pprof report.ProfileLabels (File, Build ID, comments, Type, Time, Duration).
+func (r *report) profileLabels() []string {
+	var label []string
+	p := r.prof
+	if len(p.Mapping) > 0 {
+		if p.Mapping[0].File != "" {
+			label = append(label, "File: "+filepath.Base(p.Mapping[0].File))
+		}
+		if p.Mapping[0].BuildID != "" {
+			label = append(label, "Build ID: "+p.Mapping[0].BuildID)
+		}
+	}
+	for _, c := range p.Comments {
+		if !strings.HasPrefix(c, "#") {
+			label = append(label, c)
+		}
+	}
+	label = append(label, "Type: "+r.sampleType)
+	if p.DocURL != "" {
+		label = append(label, "Doc: "+p.DocURL)
+	}
+	if p.TimeNanos != 0 {
+		const layout = "2006-01-02 15:04:05 MST"
+		label = append(label, "Time: "+time.Unix(0, p.TimeNanos).Format(layout))
+	}
+	if p.DurationNanos != 0 {
+		duration := pprofscale.Label(p.DurationNanos, "nanoseconds")
+		totalNanos, totalUnit := pprofscale.Scale(r.total, r.sampleUnit, "nanoseconds")
+		var ratio string
+		if totalUnit == "ns" && totalNanos != 0 {
+			ratio = "(" + pprofscale.Percentage(int64(totalNanos), p.DurationNanos) + ")"
+		}
+		label = append(label, fmt.Sprintf("Duration: %s, Total samples = %s %s", duration, r.format(r.total), ratio))
+	}
+	return label
+}
+
+// inlineLabel marks -top rows whose incoming calls were all or partly inlined.
+func inlineLabel(n *node) string {
+	var inline, noinline bool
+	for _, e := range n.in {
+		if e.inline {
+			inline = true
+		} else {
+			noinline = true
+		}
+	}
+	switch {
+	case inline && noinline:
+		return " (partial-inline)"
+	case inline:
+		return " (inline)"
+	default:
+		return ""
+	}
+}
+
+func edgeInline(e *edge) string {
+	if e.inline {
+		return " (inline)"
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package pprofreport
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// Golden files under tests/assets/pprof_text were produced by the command prof runs:
+// go tool pprof [-sample_index=<type>] -cum -edgefraction=0 -nodefraction=0 -nodecount=0 -top|-tree <profile>.
+func TestTopTree_matchPprofCLI(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		profile, sampleIndex, golden string
+	}{
+		{"cpu.out", "", "cpu"},
+		{"memory.out", "", "memory"},
+		{"memory.out", "alloc_objects", "memory_alloc_objects"},
+		{"block.out", "", "block"},
+		{"mutex.out", "", "mutex"},
+	}
+	for _, tc := range cases {
+		p := loadProfile(t, tc.profile)
+		for mode, render := range map[string]func(*bytes.Buffer) error{
+			"top":  func(b *bytes.Buffer) error { return Top(b, p, tc.sampleIndex) },
+			"tree": func(b *bytes.Buffer) error { return Tree(b, p, tc.sampleIndex) },
+		} {
+			var got bytes.Buffer
+			if err := render(&got); err != nil {
+				t.Fatalf("%s %s: %v", tc.golden, mode, err)
+			}
+			want, err := os.ReadFile(testpaths.MustAsset(t, "pprof_text", tc.golden+"_"+mode+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, w := withoutTime(got.String()), withoutTime(string(want)); g != w {
+				t.Errorf("%s %s mismatch\n--- got\n%s\n--- want\n%s", tc.golden, mode, g, w)
+			}
+		}
+	}
+}
+
+func TestTop_doesNotModifyProfile(t *testing.T) {
+	t.Parallel()
+	p := loadProfile(t, "memory.out")
+	before := p.String()
+	if err := Top(&bytes.Buffer{}, p, "alloc_objects"); err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != before {
+		t.Fatal("Top modified the input profile")
+	}
+}
+
+func TestTop_unknownSampleIndex(t *testing.T) {
+	t.Parallel()
+	p := loadProfile(t, "cpu.out")
+	if err := Top(&bytes.Buffer{}, p, "alloc_space"); err == nil {
+		t.Fatal("expected error for sample index not in profile")
+	}
+}
+
+func loadProfile(t *testing.T, name string) *pprofprofile.Profile {
+	t.Helper()
+	f, err := os.Open(testpaths.MustAsset(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p, err := pprofprofile.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// withoutTime drops the Time: header, which pprof formats in the local time zone.
+func withoutTime(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "Time: ") {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
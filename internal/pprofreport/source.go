@@ -0,0 +1,342 @@
+package pprofreport
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AlexsanderHamir/prof/internal/pprofscale"
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// sourceMargin is the number of lines pprof -list prints around sampled lines.
+const sourceMargin = 5
+
+var (
+	// ErrNoMatch is returned by [Source.List] when no sampled function matches the symbol.
+	ErrNoMatch = errors.New("no matches found")
+	// ErrSourceMissing is returned by [Source.List] when none of the matched functions'
+	// source files could be opened.
+	ErrSourceMissing = errors.New("source file not found")
+)
+
+// lineNode is one source line of one function, carrying the samples attributed to it.
+type lineNode struct {
+	name      string
+	file      string
+	line      int
+	startLine int
+	flat, cum int64
+}
+
+// Source annotates source lines with flat/cum values, like go tool pprof -list.
+// The line graph is built once in [NewSource]; [Source.List] can then be called for
+// any number of functions, concurrently, and reads each source file at most once.
+type Source struct {
+	rpt    *report
+	byName map[string][]*lineNode
+	names  []string
+	reader *sourceReader
+}
+
+// NewSource prepares line-level annotation of p at sampleIndex (empty uses the profile's
+// default sample type). Relative source paths are resolved against searchPath and its
+// parents; an empty searchPath uses the working directory, as pprof does. p is not modified.
+func NewSource(p *pprofprofile.Profile, sampleIndex, searchPath string) (*Source, error) {
+	rpt, err := newReport(p, sampleIndex, sourceLines)
+	if err != nil {
+		return nil, err
+	}
+	if searchPath == "" {
+		if searchPath, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("could not stat current dir: %w", err)
+		}
+	}
+	for _, f := range rpt.prof.Function {
+		f.Filename = trimPath(f.Filename, "")
+	}
+
+	s := &Source{
+		rpt:    rpt,
+		byName: make(map[string][]*lineNode),
+		reader: &sourceReader{searchPath: searchPath, files: make(map[string]*sourceFile)},
+	}
+	for _, n := range newLineGraph(rpt.prof, rpt.value) {
+		if s.byName[n.name] == nil {
+			s.names = append(s.names, n.name)
+		}
+		s.byName[n.name] = append(s.byName[n.name], n)
+	}
+	sort.Strings(s.names)
+	return s, nil
+}
+
+// List writes the annotated source of every sampled function whose name matches symbol.
+// It returns [ErrNoMatch] when nothing matches and [ErrSourceMissing] when no source
+// file of the matched functions exists; output is only written on success.
+func (s *Source) List(w io.Writer, symbol *regexp.Regexp) error {
+	var matched []string
+	for _, name := range s.names {
+		if symbol.MatchString(name) {
+			matched = append(matched, name)
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("%w for regexp: %s", ErrNoMatch, symbol)
+	}
+
+	var b strings.Builder
+	var found bool
+	var missing error
+	fmt.Fprintf(&b, "Total: %s\n", s.rpt.format(s.rpt.total))
+	for _, name := range matched {
+		files, byFile := groupByFile(s.byName[name])
+		if len(files) == 0 {
+			fmt.Fprintf(&b, "No source information for %s\n", name)
+			continue
+		}
+		for _, file := range files {
+			fns := byFile[file]
+			var flatSum, cumSum int64
+			for _, n := range fns {
+				flatSum += n.flat
+				cumSum += n.cum
+			}
+			fmt.Fprintf(&b, "ROUTINE ======================== %s in %s\n", name, file)
+			fmt.Fprintf(&b, "%10s %10s (flat, cum) %s of Total\n",
+				s.rpt.format(flatSum), s.rpt.format(cumSum), pprofscale.Percentage(cumSum, s.rpt.total))
+
+			src, err := s.reader.file(file)
+			if err != nil {
+				fmt.Fprintf(&b, " Error: %v\n", err)
+				missing = err
+				continue
+			}
+			found = true
+			s.writeLines(&b, src, fns)
+		}
+	}
+	if !found && missing != nil {
+		return fmt.Errorf("%w: %w", ErrSourceMissing, missing)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeLines prints the function's span of src: from its start line (or margin lines
+// before the first sample) to margin lines after the last sample.
+func (s *Source) writeLines(b *strings.Builder, src []string, fns []*lineNode) {
+	byLine := make(map[int][]*lineNode, len(fns))
+	start := fns[0].startLine
+	if start == 0 {
+		start = fns[0].line - sourceMargin
+	}
+	end := fns[0].line + sourceMargin
+	for _, n := range fns {
+		nodeStart := n.startLine
+		if nodeStart == 0 {
+			nodeStart = n.line - sourceMargin
+		}
+		if nodeEnd := n.line + sourceMargin; nodeStart < start {
+			start = nodeStart
+		} else if nodeEnd > end {
+			end = nodeEnd
+		}
+		byLine[n.line] = append(byLine[n.line], n)
+	}
+	start = max(start, 1)
+	end = min(end, len(src))
+	for lineno := start; lineno <= end; lineno++ {
+		var flat, cum int64
+		for _, n := range byLine[lineno] {
+			flat += n.flat
+			cum += n.cum
+		}
+		fmt.Fprintf(b, "%10s %10s %6d:%s\n", s.valueOrDot(flat), s.valueOrDot(cum), lineno, src[lineno-1])
+	}
+}
+
+func (s *Source) valueOrDot(v int64) string {
+	if v == 0 {
+		return "."
+	}
+	return s.rpt.format(v)
+}
+
+// groupByFile returns the distinct source files of fns in pprof FileOrder, with the
+// nodes of each file sorted by line.
+func groupByFile(fns []*lineNode) ([]string, map[string][]*lineNode) {
+	byFile := make(map[string][]*lineNode)
+	startLine := make(map[string]int)
+	var files []string
+	for _, n := range fns {
+		if n.file == "" {
+			continue
+		}
+		if byFile[n.file] == nil {
+			files = append(files, n.file)
+			startLine[n.file] = n.startLine
+		}
+		byFile[n.file] = append(byFile[n.file], n)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i] != files[j] {
+			return files[i] < files[j]
+		}
+		return startLine[files[i]] < startLine[files[j]]
+	})
+	for _, nodes := range byFile {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].line < nodes[j].line })
+	}
+	return files, byFile
+}
+
+// newLineGraph attributes samples to (function, file, line) nodes: cum once per sample
+// for every frame on the stack, flat to the leaf frame.
+func newLineGraph(p *pprofprofile.Profile, value func([]int64) int64) []*lineNode {
+	type lineKey struct {
+		name, file      string
+		line, startLine int
+	}
+	byKey := make(map[lineKey]*lineNode)
+	locNodes := make(map[uint64][]*lineNode, len(p.Location))
+	for _, l := range p.Location {
+		ns := make([]*lineNode, len(l.Line))
+		for i, line := range l.Line {
+			if line.Function == nil {
+				continue
+			}
+			key := lineKey{
+				name:      line.Function.Name,
+				line:      int(line.Line),
+				startLine: int(line.Function.StartLine),
+			}
+			if line.Function.Filename != "" {
+				key.file = filepath.Clean(line.Function.Filename)
+			}
+			n := byKey[key]
+			if n == nil {
+				n = &lineNode{name: key.name, file: key.file, line: key.line, startLine: key.startLine}
+				byKey[key] = n
+			}
+			ns[i] = n
+		}
+		locNodes[l.ID] = ns
+	}
+
+	seen := make(map[*lineNode]bool)
+	for _, s := range p.Sample {
+		w := value(s.Value)
+		if w == 0 {
+			continue
+		}
+		clear(seen)
+		for _, l := range s.Location {
+			for _, n := range locNodes[l.ID] {
+				if n != nil && !seen[n] {
+					seen[n] = true
+					n.cum += w
+				}
+			}
+		}
+		if len(s.Location) > 0 {
+			if ns := locNodes[s.Location[0].ID]; len(ns) > 0 && ns[0] != nil {
+				ns[0].flat += w
+			}
+		}
+	}
+
+	nodes := make([]*lineNode, 0, len(byKey))
+	for _, n := range byKey {
+		if n.flat != 0 || n.cum != 0 {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// sourceFile caches one file's lines or the error opening it.
+type sourceFile struct {
+	once  sync.Once
+	lines []string
+	err   error
+}
+
+// sourceReader resolves and caches source files; it is safe for concurrent use.
+type sourceReader struct {
+	searchPath string
+	mu         sync.Mutex
+	files      map[string]*sourceFile
+}
+
+func (r *sourceReader) file(path string) ([]string, error) {
+	r.mu.Lock()
+	f := r.files[path]
+	if f == nil {
+		f = &sourceFile{}
+		r.files[path] = f
+	}
+	r.mu.Unlock()
+	f.once.Do(func() { f.lines, f.err = readSourceFile(path, r.searchPath) })
+	return f.lines, f.err
+}
+
+// readSourceFile opens path, searching relative paths in searchPath and its parents
+// (pprof openSourceFile), and returns its lines.
+func readSourceFile(path, searchPath string) ([]string, error) {
+	f, err := openSourceFile(trimPath(path, searchPath), searchPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, sc.Err()
+}
+
+func openSourceFile(path, searchPath string) (*os.File, error) {
+	if filepath.IsAbs(path) {
+		return os.Open(path)
+	}
+	for _, dir := range filepath.SplitList(searchPath) {
+		for {
+			if f, err := os.Open(filepath.Join(dir, path)); err == nil {
+				return f, nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return nil, fmt.Errorf("could not find file %s on path %s", path, searchPath)
+}
+
+// trimPath mirrors pprof's path heuristic: when the base name of a search directory
+// appears in path, everything up to it is dropped so the file resolves locally.
+func trimPath(path, searchPath string) string {
+	sPath := filepath.ToSlash(path)
+	for _, dir := range filepath.SplitList(filepath.ToSlash(searchPath)) {
+		want := "/" + filepath.Base(dir) + "/"
+		if found := strings.Index(sPath, want); found != -1 {
+			return path[found+len(want):]
+		}
+	}
+	for _, prefix := range []string{"/proc/self/cwd/./", "/proc/self/cwd/"} {
+		if strings.HasPrefix(sPath, prefix) {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
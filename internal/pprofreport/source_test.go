@@ -0,0 +1,76 @@
+package pprofreport
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+const sourceFixture = "package pkg\n\nfunc F() int {\n\tx := 1\n\treturn x\n}\n"
+
+// lineProfile samples pkg.F at lines 4 (10) and 5 (20) of file.
+func lineProfile(file string) *pprofprofile.Profile {
+	fn := &pprofprofile.Function{ID: 1, Name: "pkg.F", Filename: file, StartLine: 3}
+	loc4 := &pprofprofile.Location{ID: 1, Line: []pprofprofile.Line{{Function: fn, Line: 4}}}
+	loc5 := &pprofprofile.Location{ID: 2, Line: []pprofprofile.Line{{Function: fn, Line: 5}}}
+	return &pprofprofile.Profile{
+		SampleType: []*pprofprofile.ValueType{{Type: "samples", Unit: "count"}},
+		Function:   []*pprofprofile.Function{fn},
+		Location:   []*pprofprofile.Location{loc4, loc5},
+		Sample: []*pprofprofile.Sample{
+			{Location: []*pprofprofile.Location{loc4}, Value: []int64{10}},
+			{Location: []*pprofprofile.Location{loc5}, Value: []int64{20}},
+		},
+	}
+}
+
+func TestSourceList_annotatesLines(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "f.go")
+	if err := os.WriteFile(file, []byte(sourceFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := NewSource(lineProfile(file), "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err = src.List(&got, regexp.MustCompile(regexp.QuoteMeta("pkg.F"))); err != nil {
+		t.Fatal(err)
+	}
+	want := "Total: 30\n" +
+		"ROUTINE ======================== pkg.F in " + file + "\n" +
+		"        30         30 (flat, cum)   100% of Total\n" +
+		"         .          .      3:func F() int {\n" +
+		"        10         10      4:\tx := 1\n" +
+		"        20         20      5:\treturn x\n" +
+		"         .          .      6:}\n"
+	if got.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
+
+func TestSourceList_errors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src, err := NewSource(lineProfile(filepath.Join(dir, "gone.go")), "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err = src.List(&out, regexp.MustCompile("pkg.F")); !errors.Is(err, ErrSourceMissing) {
+		t.Fatalf("missing source: err = %v", err)
+	}
+	if err = src.List(&out, regexp.MustCompile("pkg.G")); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("no match: err = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("nothing should be written on error, got %q", out.String())
+	}
+}
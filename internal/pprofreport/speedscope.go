@@ -0,0 +1,127 @@
+package pprofreport
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+// speedscopeSchema identifies the speedscope file format version prof writes.
+const speedscopeSchema = "https://www.speedscope.app/file-format-schema.json"
+
+type speedscopeFile struct {
+	Schema             string              `json:"$schema"`
+	Shared             speedscopeShared    `json:"shared"`
+	Profiles           []speedscopeProfile `json:"profiles"`
+	Name               string              `json:"name,omitempty"`
+	ActiveProfileIndex int                 `json:"activeProfileIndex"`
+	Exporter           string              `json:"exporter"`
+}
+
+type speedscopeShared struct {
+	Frames []speedscopeFrame `json:"frames"`
+}
+
+type speedscopeFrame struct {
+	Name string `json:"name"`
+	File string `json:"file,omitempty"`
+	Line int64  `json:"line,omitempty"`
+}
+
+// speedscopeProfile is a "sampled" profile: samples[i] lists frame indices root first
+// and weights[i] is its value.
+type speedscopeProfile struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Unit       string  `json:"unit"`
+	StartValue int64   `json:"startValue"`
+	EndValue   int64   `json:"endValue"`
+	Samples    [][]int `json:"samples"`
+	Weights    []int64 `json:"weights"`
+}
+
+// Speedscope writes p in the speedscope file format: one sampled profile per sample type,
+// sharing a frame table at function granularity. The profile selected by sampleIndex (empty
+// uses the profile's default sample type) opens first. name titles the file in the viewer.
+// Samples with non-positive values are left out, since speedscope cannot draw them.
+// p is not modified.
+func Speedscope(w io.Writer, p *pprofprofile.Profile, sampleIndex, name string) error {
+	rpt, err := newReport(p, sampleIndex, functionFiles)
+	if err != nil {
+		return err
+	}
+	type frameKey struct{ name, file string }
+	frameIndex := make(map[frameKey]int)
+	out := speedscopeFile{
+		Schema:             speedscopeSchema,
+		Name:               name,
+		ActiveProfileIndex: rpt.index,
+		Exporter:           "prof",
+	}
+	stackOf := func(s *pprofprofile.Sample) []int {
+		var stack []int
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			l := s.Location[i]
+			lines := l.Line
+			if len(lines) == 0 {
+				lines = []pprofprofile.Line{{}}
+			}
+			for j := len(lines) - 1; j >= 0; j-- {
+				key := frameKey{name: nodeName(l, lines[j])}
+				var start int64
+				if fn := lines[j].Function; fn != nil {
+					key.file, start = fn.Filename, fn.StartLine
+				}
+				idx, ok := frameIndex[key]
+				if !ok {
+					idx = len(out.Shared.Frames)
+					frameIndex[key] = idx
+					out.Shared.Frames = append(out.Shared.Frames, speedscopeFrame{Name: key.name, File: key.file, Line: start})
+				}
+				stack = append(stack, idx)
+			}
+		}
+		return stack
+	}
+
+	stacks := make([][]int, len(rpt.prof.Sample))
+	for i, s := range rpt.prof.Sample {
+		stacks[i] = stackOf(s)
+	}
+	for ti, st := range rpt.prof.SampleType {
+		prof := speedscopeProfile{
+			Type:    "sampled",
+			Name:    st.Type,
+			Unit:    speedscopeUnit(st.Unit),
+			Samples: [][]int{},
+			Weights: []int64{},
+		}
+		for i, s := range rpt.prof.Sample {
+			if v := s.Value[ti]; v > 0 {
+				prof.Samples = append(prof.Samples, stacks[i])
+				prof.Weights = append(prof.Weights, v)
+				prof.EndValue += v
+			}
+		}
+		out.Profiles = append(out.Profiles, prof)
+	}
+	if out.Shared.Frames == nil {
+		out.Shared.Frames = []speedscopeFrame{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
+
+// speedscopeUnit maps a pprof sample unit to a speedscope value unit.
+func speedscopeUnit(unit string) string {
+	switch u := strings.ToLower(unit); u {
+	case "nanoseconds", "microseconds", "milliseconds", "seconds", "bytes":
+		return u
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,74 @@
+package pprofreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	pprofprofile "github.com/google/pprof/profile"
+)
+
+func TestSpeedscope_profilePerSampleType(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	if err := Speedscope(&out, stackProfile(), "cpu", "BenchmarkFoo cpu"); err != nil {
+		t.Fatal(err)
+	}
+	var got speedscopeFile
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Schema != speedscopeSchema || got.Name != "BenchmarkFoo cpu" || got.ActiveProfileIndex != 1 {
+		t.Fatalf("header=%+v", got)
+	}
+	if len(got.Profiles) != 2 {
+		t.Fatalf("profiles=%d want 2", len(got.Profiles))
+	}
+	names := make([]string, len(got.Shared.Frames))
+	for i, f := range got.Shared.Frames {
+		names[i] = f.Name
+	}
+	samples, cpu := got.Profiles[0], got.Profiles[1]
+	if samples.Unit != "none" || cpu.Unit != "nanoseconds" || cpu.Type != "sampled" {
+		t.Fatalf("units=%q,%q type=%q", samples.Unit, cpu.Unit, cpu.Type)
+	}
+	if cpu.EndValue != 60 || len(cpu.Samples) != 3 || len(cpu.Weights) != 3 {
+		t.Fatalf("cpu profile=%+v", cpu)
+	}
+	var stack []string
+	for _, idx := range cpu.Samples[0] {
+		stack = append(stack, names[idx])
+	}
+	if want := []string{"main.main", "main.a", "main.b"}; len(stack) != 3 || stack[0] != want[0] || stack[1] != want[1] || stack[2] != want[2] {
+		t.Fatalf("first stack=%v want %v", stack, want)
+	}
+}
+
+func TestSpeedscope_functionGranularity(t *testing.T) {
+	t.Parallel()
+	fn := &pprofprofile.Function{ID: 1, Name: "main.work", Filename: "/src/main.go", StartLine: 7}
+	p := &pprofprofile.Profile{
+		SampleType: []*pprofprofile.ValueType{{Type: "cpu", Unit: "nanoseconds"}},
+		Function:   []*pprofprofile.Function{fn},
+		Location: []*pprofprofile.Location{
+			{ID: 1, Line: []pprofprofile.Line{{Function: fn, Line: 9}}},
+			{ID: 2, Line: []pprofprofile.Line{{Function: fn, Line: 12}}},
+		},
+	}
+	p.Sample = []*pprofprofile.Sample{
+		{Location: p.Location[:1], Value: []int64{10}},
+		{Location: p.Location[1:], Value: []int64{20}},
+	}
+	var out bytes.Buffer
+	if err := Speedscope(&out, p, "", "work"); err != nil {
+		t.Fatal(err)
+	}
+	var got speedscopeFile
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := speedscopeFrame{Name: "main.work", File: "/src/main.go", Line: 7}
+	if len(got.Shared.Frames) != 1 || got.Shared.Frames[0] != want {
+		t.Fatalf("frames=%+v want one %+v", got.Shared.Frames, want)
+	}
+}
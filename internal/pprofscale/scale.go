@@ -47,6 +47,28 @@ func ScaledLabel(value int64, fromUnit, toUnit string) string {
 	return sv + u
 }
 
+// Label formats value in the most readable unit of its category, like pprof measurement.Label.
+func Label(value int64, unit string) string {
+	return ScaledLabel(value, unit, unitAuto)
+}
+
+// Percentage formats value as a share of total with at least two significant digits,
+// right-aligned to six columns like the flat%/cum% columns of pprof -top.
+func Percentage(value, total int64) string {
+	var ratio float64
+	if total != 0 {
+		ratio = math.Abs(float64(value)/float64(total)) * 100
+	}
+	switch {
+	case ratio >= 99.95 && ratio <= 100.05:
+		return "  100%"
+	case ratio >= 1.0:
+		return fmt.Sprintf("%5.2f%%", ratio)
+	default:
+		return fmt.Sprintf("%5.2g%%", ratio)
+	}
+}
+
 // Seconds converts a sample value to seconds when fromUnit is a time unit; ok is false otherwise.
 func Seconds(value int64, fromUnit string) (sec float64, ok bool) {
 	v, u := Scale(value, fromUnit, unitSeconds)
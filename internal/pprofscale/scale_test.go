@@ -54,3 +54,29 @@ func TestScale_totalCPUProfile(t *testing.T) {
 		t.Fatalf("seconds=%v ok=%v", sec, ok)
 	}
 }
+
+func TestPercentage(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		value, total int64
+		want         string
+	}{
+		{603, 10000, " 6.03%"},
+		{10000, 10000, "  100%"},
+		{5, 10000, " 0.05%"},
+		{0, 0, "    0%"},
+		{-2500, 10000, "25.00%"},
+	}
+	for _, tc := range cases {
+		if got := Percentage(tc.value, tc.total); got != tc.want {
+			t.Fatalf("Percentage(%d, %d)=%q want %q", tc.value, tc.total, got, tc.want)
+		}
+	}
+}
+
+func TestLabel_duration(t *testing.T) {
+	t.Parallel()
+	if got := Label(2_580_000_000, "nanoseconds"); got != "2.58s" {
+		t.Fatalf("got %q want 2.58s", got)
+	}
+}
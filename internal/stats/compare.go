@@ -0,0 +1,35 @@
+package stats
+
+// DefaultAlpha is the significance level below which a difference is reported as real.
+const DefaultAlpha = 0.05
+
+// Comparison describes the change of one metric between a base and a head sample set.
+type Comparison struct {
+	Base Summary
+	Head Summary
+	// DeltaPct is the change of the head median relative to the base median.
+	DeltaPct float64
+	// P is the two-sided Mann-Whitney U p-value; 1 when either side has no samples.
+	P float64
+	// Significant is true when P < alpha. Non-significant deltas are noise and must not be
+	// reported as regressions or improvements (benchstat prints "~").
+	Significant bool
+}
+
+// Compare summarizes both sample sets and tests whether the head differs from the base at alpha.
+func Compare(base, head []float64, alpha float64) Comparison {
+	c := Comparison{
+		Base: Summarize(base, DefaultConfidence),
+		Head: Summarize(head, DefaultConfidence),
+		P:    1,
+	}
+	if c.Base.Median != 0 {
+		const pctScale = 100.0
+		c.DeltaPct = (c.Head.Median - c.Base.Median) / c.Base.Median * pctScale
+	}
+	if res, err := MannWhitneyUTest(base, head); err == nil {
+		c.P = res.P
+	}
+	c.Significant = c.P < alpha
+	return c
+}
@@ -0,0 +1,4 @@
+// Package stats summarizes repeated benchmark samples (median with a distribution-free
+// confidence interval) and tests differences between two sample sets with a two-sided
+// Mann-Whitney U test, following the conventions benchstat uses to separate real changes from noise.
+package stats
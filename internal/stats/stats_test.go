@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-4 }
+
+func TestSummarize_medianAndInterval(t *testing.T) {
+	t.Parallel()
+	values := []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}
+	s := Summarize(values, DefaultConfidence)
+	if s.N != 10 || s.Median != 5.5 || s.Min != 1 || s.Max != 10 {
+		t.Fatalf("summary=%+v", s)
+	}
+	// n=10 at 95%: [x_(2), x_(9)] with coverage 1 - 2*11/1024.
+	if s.Lo != 2 || s.Hi != 9 || !approx(s.Confidence, 1-2*11.0/1024) {
+		t.Fatalf("interval=[%v, %v] confidence=%v", s.Lo, s.Hi, s.Confidence)
+	}
+}
+
+func TestSummarize_fewSamplesWidensToRange(t *testing.T) {
+	t.Parallel()
+	s := Summarize([]float64{3, 1, 2}, DefaultConfidence)
+	if s.Lo != 1 || s.Hi != 3 || s.Confidence >= DefaultConfidence {
+		t.Fatalf("summary=%+v", s)
+	}
+	if one := Summarize([]float64{4}, DefaultConfidence); one.Median != 4 || one.Confidence != 0 {
+		t.Fatalf("single sample=%+v", one)
+	}
+	if empty := Summarize(nil, DefaultConfidence); empty.N != 0 {
+		t.Fatalf("empty=%+v", empty)
+	}
+}
+
+func TestSummary_SpreadPct(t *testing.T) {
+	t.Parallel()
+	s := Summary{Median: 100, Lo: 95, Hi: 103}
+	if got := s.SpreadPct(); got != 5 {
+		t.Fatalf("spread=%v want 5", got)
+	}
+}
+
+func TestMannWhitneyUTest_exactSeparated(t *testing.T) {
+	t.Parallel()
+	res, err := MannWhitneyUTest([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Exact two-sided p for complete separation of 5 vs 5 is 2/252.
+	if res.U != 0 || !approx(res.P, 2.0/252) {
+		t.Fatalf("result=%+v", res)
+	}
+}
+
+func TestMannWhitneyUTest_identicalAndEmpty(t *testing.T) {
+	t.Parallel()
+	res, err := MannWhitneyUTest([]float64{5, 5, 5}, []float64{5, 5})
+	if err != nil || res.P != 1 {
+		t.Fatalf("identical: res=%+v err=%v", res, err)
+	}
+	if _, err = MannWhitneyUTest(nil, []float64{1}); err == nil {
+		t.Fatal("expected error for empty sample")
+	}
+}
+
+func TestMannWhitneyUTest_tiesUseNormalApproximation(t *testing.T) {
+	t.Parallel()
+	res, err := MannWhitneyUTest([]float64{1, 1, 2, 2, 3, 3}, []float64{4, 4, 5, 5, 6, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.P <= 0 || res.P >= DefaultAlpha {
+		t.Fatalf("expected significant p with ties, got %v", res.P)
+	}
+}
+
+func TestCompare_noiseIsNotSignificant(t *testing.T) {
+	t.Parallel()
+	noisy := Compare([]float64{100, 104, 98, 101, 99}, []float64{102, 97, 103, 100, 99}, DefaultAlpha)
+	if noisy.Significant {
+		t.Fatalf("noise reported as significant: %+v", noisy)
+	}
+	single := Compare([]float64{100}, []float64{150}, DefaultAlpha)
+	if single.Significant || single.DeltaPct != 50 {
+		t.Fatalf("single sample comparison=%+v", single)
+	}
+	real := Compare([]float64{100, 101, 99, 100, 102}, []float64{80, 81, 79, 80, 82}, DefaultAlpha)
+	if !real.Significant || real.DeltaPct != -20 {
+		t.Fatalf("real change=%+v", real)
+	}
+}
+
+func TestMinPValue(t *testing.T) {
+	t.Parallel()
+	if got := MinPValue(1, 1); got != 1 {
+		t.Fatalf("1+1: %v", got)
+	}
+	if got := MinPValue(3, 3); !approx(got, 0.1) {
+		t.Fatalf("3+3: %v", got)
+	}
+	if got := MinPValue(4, 4); got >= DefaultAlpha {
+		t.Fatalf("4+4 should be able to reach significance, got %v", got)
+	}
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// DefaultConfidence is the confidence level used for median intervals.
+const DefaultConfidence = 0.95
+
+// Summary describes one set of samples for a single metric.
+type Summary struct {
+	N      int
+	Median float64
+	Min    float64
+	Max    float64
+	// Lo and Hi bound the median at Confidence. When there are too few samples to reach the
+	// requested level, the interval widens to [Min, Max] and Confidence reports the coverage achieved.
+	Lo         float64
+	Hi         float64
+	Confidence float64
+}
+
+// Summarize returns the median of values and a distribution-free confidence interval around it
+// built from binomial order statistics. An empty input yields the zero Summary.
+func Summarize(values []float64, confidence float64) Summary {
+	n := len(values)
+	if n == 0 {
+		return Summary{}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	s := Summary{
+		N:      n,
+		Median: median(sorted),
+		Min:    sorted[0],
+		Max:    sorted[n-1],
+		Lo:     sorted[0],
+		Hi:     sorted[n-1],
+	}
+	// [x_(k), x_(n-k+1)] (1-based) covers the median with probability 1 - 2*P(B <= k-1), B ~ Binom(n, 1/2).
+	s.Confidence = 1 - 2*binomHalfCDF(n, 0)
+	for k := 2; 2*k <= n+1; k++ {
+		coverage := 1 - 2*binomHalfCDF(n, k-1)
+		if coverage < confidence {
+			break
+		}
+		s.Lo = sorted[k-1]
+		s.Hi = sorted[n-k]
+		s.Confidence = coverage
+	}
+	if n == 1 {
+		s.Confidence = 0
+	}
+	return s
+}
+
+// SpreadPct is the larger distance from the median to an interval bound, as a percentage of the
+// median (the "± x%" column in benchstat). It is zero when the median is zero.
+func (s Summary) SpreadPct() float64 {
+	const pctScale = 100.0
+	if s.Median == 0 {
+		return 0
+	}
+	return math.Max(s.Hi-s.Median, s.Median-s.Lo) / math.Abs(s.Median) * pctScale
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// binomHalfCDF returns P(B <= k) for B ~ Binom(n, 1/2).
+func binomHalfCDF(n, k int) float64 {
+	if k < 0 {
+		return 0
+	}
+	if k >= n {
+		return 1
+	}
+	var sum float64
+	for i := 0; i <= k; i++ {
+		sum += math.Exp(logChoose(n, i) - float64(n)*math.Ln2)
+	}
+	return sum
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,182 @@
+package termui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Lanes shows several benchmarks running at once (prof auto --parallel). Each lane owns one
+// live line at the bottom of the terminal; finished steps, benchmark headers, warnings and
+// errors are printed above the live lines and scroll up like the sequential log:
+//
+//	Benchmark 1/3 · ./codec/json.BenchmarkEncode
+//	✓ ./codec/json.BenchmarkEncode · 0) Run benchmark (count=5)
+//	⠋ Benchmark 1/3 · ./codec/json.BenchmarkEncode · 1) Collect profiles (cpu)…
+//	⠋ Benchmark 2/3 · ./codec/xml.BenchmarkEncode · 0) Run benchmark (count=5)…
+//
+// A nil *Lanes hands out nil sessions, which are non-interactive.
+type Lanes struct {
+	s *Session // parent session; s.mu guards the fields below
+
+	slots []laneSlot
+	drawn int // live lines currently on screen
+	frame int
+	stop  chan struct{}
+	done  sync.WaitGroup
+}
+
+type laneSlot struct {
+	name      string // benchmark name, prefixed to the lane's permanent lines
+	title     string // "Benchmark i/n · name"
+	label     string // running step label; empty when the lane is idle
+	warnCount int
+}
+
+// StartLanes draws n live lines and keeps their spinners moving until Stop. A non-interactive
+// session returns nil.
+func (s *Session) StartLanes(n int) *Lanes {
+	if s == nil || !s.interactive || n < 1 {
+		return nil
+	}
+	l := &Lanes{s: s, slots: make([]laneSlot, n), stop: make(chan struct{})}
+
+	s.mu.Lock()
+	fmt.Fprintln(s.w)
+	l.redrawLocked()
+	s.mu.Unlock()
+
+	l.done.Add(1)
+	go func() {
+		defer l.done.Done()
+		ticker := time.NewTicker(dotSpinner.FPS)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				l.frame++
+				l.redrawLocked()
+				s.mu.Unlock()
+			}
+		}
+	}()
+	return l
+}
+
+// Lane returns the session that reports through lane i. Its BeginBenchmark, RunWhile, Warn
+// and Error update that lane; ErrorDisplayed reports on the parent session.
+func (l *Lanes) Lane(i int) *Session {
+	if l == nil {
+		return nil
+	}
+	return &Session{
+		w:                 l.s.w,
+		fd:                l.s.fd,
+		interactive:       true,
+		termWidthOverride: l.s.termWidthOverride,
+		lanes:             l,
+		lane:              i,
+	}
+}
+
+// Stop halts the spinners and erases the live lines, leaving the permanent log.
+func (l *Lanes) Stop() {
+	if l == nil {
+		return
+	}
+	close(l.stop)
+	l.done.Wait()
+
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	if l.drawn > 0 {
+		fmt.Fprint(l.s.w, ansi.CursorUp(l.drawn)+"\r"+ansi.EraseScreenBelow)
+		l.drawn = 0
+	}
+}
+
+// redrawLocked prints above as permanent lines, then repaints every lane below them.
+func (l *Lanes) redrawLocked(above ...string) {
+	if l.drawn > 0 {
+		fmt.Fprint(l.s.w, ansi.CursorUp(l.drawn)+"\r")
+	}
+	for _, line := range above {
+		l.s.overwriteLineLocked(line, true)
+	}
+	width := l.s.termWidth()
+	for i := range l.slots {
+		// Truncate so a long label never wraps and throws off the CursorUp count.
+		l.s.overwriteLineLocked(ansi.Truncate(l.laneLineLocked(i), width-1, "…"), true)
+	}
+	l.drawn = len(l.slots)
+}
+
+func (l *Lanes) laneLineLocked(i int) string {
+	slot := l.slots[i]
+	if slot.label == "" {
+		return FaintStyle.Render("  idle")
+	}
+	frames := dotSpinner.Frames
+	frame := frames[l.frame%len(frames)]
+	return spinnerFrameStyle.Render(frame) + " " + LabelStyle.Render(slot.title+" · "+slot.label)
+}
+
+func (l *Lanes) beginBenchmark(i int, title, name string) {
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	l.slots[i].name = name
+	l.slots[i].title = title
+	l.redrawLocked(BenchmarkTitleStyle.Render(title))
+}
+
+func (l *Lanes) runWhile(i int, p Progress, fn func() error) error {
+	l.s.mu.Lock()
+	l.slots[i].label = strings.TrimSpace(formatProgressLabel(p, true))
+	l.slots[i].warnCount = 0
+	l.redrawLocked()
+	l.s.mu.Unlock()
+
+	fnErr := fn()
+
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	slot := &l.slots[i]
+	mark := DoneStyle.Render("✓")
+	if fnErr != nil {
+		mark = FailStyle.Render("✗")
+	}
+	line := mark + " " + slot.name + " · " + strings.TrimSpace(formatProgressLabel(p, false))
+	above := []string{line}
+	if fnErr != nil {
+		above = append(above, formatStageDetailLine(StageError, "    ", shortUserMessage(fnErr)))
+		l.s.errorDisplayed = true
+	} else if slot.warnCount > 0 {
+		above[0] += warnCountSuffix(slot.warnCount)
+	}
+	slot.label = ""
+	l.redrawLocked(above...)
+	return fnErr
+}
+
+func (l *Lanes) detail(i int, kind StageDetailKind, msg string) {
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	slot := &l.slots[i]
+	var above []string
+	for _, line := range splitDetailMessage(msg) {
+		above = append(above, formatStageDetailLine(kind, "    ", slot.name+": "+line))
+	}
+	switch kind {
+	case StageWarn:
+		slot.warnCount++
+	case StageError:
+		l.s.errorDisplayed = true
+	}
+	l.redrawLocked(above...)
+}
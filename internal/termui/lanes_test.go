@@ -0,0 +1,63 @@
+package termui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestLanes_concurrentBenchmarks(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	session := newSessionForTest(&buf)
+	lanes := session.StartLanes(2)
+	json, xml := lanes.Lane(0), lanes.Lane(1)
+
+	json.BeginBenchmark(1, 2, "BenchmarkJSON")
+	xml.BeginBenchmark(2, 2, "BenchmarkXML")
+	if err := json.RunWhile(Progress{Phase: PhaseRunBenchmark, Detail: "count=5"}, func() error {
+		json.Warn("slow machine")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	runErr := errors.New("exit status 1")
+	if err := xml.RunWhile(Progress{Phase: PhaseCollectProfiles}, func() error { return runErr }); !errors.Is(err, runErr) {
+		t.Fatalf("RunWhile err = %v", err)
+	}
+	lanes.Stop()
+
+	out := ansi.Strip(buf.String())
+	for _, want := range []string{
+		"Benchmark 1/2 · BenchmarkJSON",
+		"warning: BenchmarkJSON: slow machine",
+		"✓ BenchmarkJSON · 0) Run benchmark (count=5) (1 warning)",
+		"✗ BenchmarkXML · 1) Collect profiles",
+		"error: exit status 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if !session.ErrorDisplayed() || !xml.ErrorDisplayed() {
+		t.Fatal("a failed lane should mark the session's error as displayed")
+	}
+}
+
+func TestLanes_nonInteractive(t *testing.T) {
+	t.Parallel()
+
+	var session *Session
+	lanes := session.StartLanes(2)
+	if lanes != nil {
+		t.Fatal("non-interactive session should not start lanes")
+	}
+	if lane := lanes.Lane(1); lane.Interactive() {
+		t.Fatal("lane of nil Lanes should be non-interactive")
+	}
+	lanes.Stop()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -66,6 +67,7 @@ func (p Progress) WithDetail(detail string) Progress {
 //	  ✓ 2) Collect per-function text profiles
 //
 // The step line updates in place while running (spinner), then becomes ✓ when done.
+// Benchmarks that run concurrently report through [Session.StartLanes] instead.
 type Session struct {
 	w                 io.Writer
 	fd                int
@@ -85,6 +87,9 @@ type Session struct {
 	spinnerStop        chan struct{}
 	spinnerDone        sync.WaitGroup
 	benchmarksStarted  int
+
+	lanes *Lanes // set on the sessions handed out by Lanes.Lane
+	lane  int
 }
 
 // NewSession reports whether w/fd is an interactive terminal.
@@ -152,18 +157,22 @@ func (s *Session) BeginBenchmark(index, total int, name string) {
 		return
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	fmt.Fprintln(s.w)
-	s.benchmarksStarted++
-
 	var title string
 	if total > 1 {
 		title = fmt.Sprintf("Benchmark %d/%d · %s", index, total, name)
 	} else {
 		title = name
 	}
+	if s.lanes != nil {
+		s.lanes.beginBenchmark(s.lane, title, name)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintln(s.w)
+	s.benchmarksStarted++
 	fmt.Fprintln(s.w, BenchmarkTitleStyle.Render(title))
 }
 
@@ -193,6 +202,9 @@ func (s *Session) RunWhile(p Progress, fn func() error) error {
 	if s == nil || !s.interactive {
 		return fn()
 	}
+	if s.lanes != nil {
+		return s.lanes.runWhile(s.lane, p, fn)
+	}
 
 	s.mu.Lock()
 	s.stageActive = true
@@ -431,6 +443,10 @@ func (s *Session) stageDetail(kind StageDetailKind, msg string) {
 		}
 		return
 	}
+	if s.lanes != nil {
+		s.lanes.detail(s.lane, kind, msg)
+		return
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -465,6 +481,9 @@ func (s *Session) ErrorDisplayed() bool {
 	if s == nil {
 		return false
 	}
+	if s.lanes != nil {
+		return s.lanes.s.ErrorDisplayed()
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return s.errorDisplayed
@@ -486,6 +505,15 @@ func (s *Session) Success(msg string) {
 	fmt.Fprintln(s.w)
 }
 
+// Output is where a command prints its results once the stages are done: the session's
+// terminal when interactive, so the output lands below the stage lines, and stdout otherwise.
+func (s *Session) Output() io.Writer {
+	if s == nil || !s.interactive {
+		return os.Stdout
+	}
+	return s.w
+}
+
 // newSessionForTest builds a session with a forced interactive flag (tests only).
 func newSessionForTest(w io.Writer) *Session {
 	return &Session{w: w, interactive: true, termWidthOverride: defaultTermWidth}
@@ -480,3 +480,15 @@ func TestSession_WarningsStayBelowHeaderWithMultipleWarns(t *testing.T) {
 		t.Fatalf("expected two warning lines in order: %q", out)
 	}
 }
+
+func TestSession_Output(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if got := newSessionForTest(&buf).Output(); got != &buf {
+		t.Fatalf("interactive Output() = %v, want the session writer", got)
+	}
+	if got := NewSession(&buf, -1).Output(); got != os.Stdout {
+		t.Fatalf("non-interactive Output() = %v, want os.Stdout", got)
+	}
+}
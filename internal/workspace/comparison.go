@@ -0,0 +1,54 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ComparisonLayout is the .prof/_compare/<base>_vs_<head>/ artifact path contract for tag comparisons.
+// Diff artifacts mirror the TagLayout domains (hotspots/, call_trees/, call_graphs/) and hold
+// go tool pprof -diff_base output with base as the baseline and head as the profile shown.
+type ComparisonLayout struct {
+	Base string
+	Head string
+	Root string // absolute .prof/_compare/<base>_vs_<head>/
+}
+
+// NewComparisonLayout builds comparison paths under OutputDir(moduleRoot)/_compare.
+func NewComparisonLayout(moduleRoot, base, head string) ComparisonLayout {
+	return ComparisonLayoutIn(OutputDir(moduleRoot), base, head)
+}
+
+// ComparisonLayoutIn builds comparison paths under outputDir/_compare (see ResolveOutputDir).
+func ComparisonLayoutIn(outputDir, base, head string) ComparisonLayout {
+	return ComparisonLayout{
+		Base: base,
+		Head: head,
+		Root: filepath.Join(outputDir, ComparisonsDir, base+"_vs_"+head),
+	}
+}
+
+// Report returns the machine-readable comparison JSON path.
+func (l ComparisonLayout) Report() string {
+	return filepath.Join(l.Root, CompareReportFile)
+}
+
+// Hotspot returns the diff -top report path for a benchmark and profile kind.
+func (l ComparisonLayout) Hotspot(bench, profile string) string {
+	return filepath.Join(l.Root, HotspotsDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, TextExtension))
+}
+
+// CallTreeText returns the diff -tree report path for a benchmark and profile kind.
+func (l ComparisonLayout) CallTreeText(bench, profile string) string {
+	return filepath.Join(l.Root, CallTreesDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, TextExtension))
+}
+
+// CallGraph returns the diff Graphviz call-graph PNG path for a profile.
+func (l ComparisonLayout) CallGraph(profile, bench string) string {
+	return filepath.Join(l.Root, CallGraphsDir, profile, BenchmarkDir(bench), fmt.Sprintf("%s.png", profile))
+}
+
+// RelFromLayout returns path relative to the comparison root for one layout-resolved absolute path.
+func (l ComparisonLayout) RelFromLayout(absPath string) (string, error) {
+	return RelFromTagRoot(l.Root, absPath)
+}
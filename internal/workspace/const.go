@@ -10,19 +10,48 @@ const (
 	CallTreesDir             = "call_trees"
 	SourceLinesDir           = "source_lines"
 	CallGraphsDir            = "call_graphs"
+	FoldedStacksDir          = "folded_stacks"
+	FlameGraphsDir           = "flame_graphs"
+	SpeedscopeDir            = "speedscope"
+	TracesDir                = "traces"
+	ReportsDir               = "reports"
 	DataMappingDir           = "data_mapping"
 	DataMappingFile          = "map.json"
+	ComparisonsDir           = "_compare"
+	LocksDir                 = "_locks"     // tag lock files, beside the tags
+	PackageLocksDir          = "prof-locks" // package directory lock files, in the system temp directory
+	LockExtension            = ".lock"
+	CompareReportFile        = "compare.json"
 	MeasurementRunFile       = "run.txt"
 	TagNotesFileName         = "notes.txt"
+	VariantSummaryFile       = "variants.txt"
+	TagStatusFile            = "status.json"
+	TagManifestFile          = "manifest.json"
 	TagNotesPlaceholder      = "The explanation for this profiling session goes here"
 	PermDir                  = 0o755
 	PermFile                 = 0o644
 	TextExtension            = "txt"
+	FoldedExtension          = "folded"
+	FlameGraphExtension      = "svg"
+	SpeedscopeExtension      = "speedscope.json"
 	ExpectedTestSuffix       = ".test"
 	ProfileArtifactExtension = "out"
+	ProfileVariantSeparator  = "."
+	IterationSeparator       = "@"  // joins a profile kind to its go test invocation (cpu@3.out)
+	TraceViewSeparator       = "_"  // joins a trace kind to a go tool trace -pprof type (trace_sched)
+	SubBenchmarkSeparator    = "/"  // levels of a sub-benchmark name (BenchmarkCodec/json/small)
+	BenchmarkDirSeparator    = "__" // replaces SubBenchmarkSeparator in benchmark directory names
+	BenchmarkPrefix          = "Benchmark"
+	BenchmarkPackageSep      = "." // joins a package to its benchmark (./internal/codec.BenchmarkEncode)
+	RootPackage              = "." // module-relative path of the module root package
+	EnvVariantSeparator      = "+" // joins the NAME=value pairs of an env matrix variant (GOGC=50+GOMAXPROCS=2)
 	GoBinaryName             = "go"
 	GoTestSubcommand         = "test"
 )
 
+// OutputDirEnv moves the tags out of the source tree: with PROF_HOME=/scratch/prof, tag
+// artifacts go to /scratch/prof/<ProjectDir>/<tag>/ instead of .prof/<tag>/.
+const OutputDirEnv = "PROF_HOME"
+
 // InfoCollectionSuccess is logged when auto collection completes.
 const InfoCollectionSuccess = "All benchmarks and profile processing completed successfully!"
@@ -8,4 +8,6 @@
 //   - source_lines/  — line-level pprof -list extracts per profile kind
 //   - call_graphs/   — optional Graphviz PNG call graphs per profile kind
 //   - notes.txt      — tag-level note at the tag root
+//
+// Tag comparisons live beside tags under .prof/_compare/<base>_vs_<head>/ ([ComparisonLayout]).
 package workspace
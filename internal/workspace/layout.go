@@ -1,9 +1,13 @@
 package workspace
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // TagLayout is the canonical .prof/<tag>/ artifact path contract.
@@ -12,65 +16,304 @@ type TagLayout struct {
 	Root string // absolute .prof/<tag>/
 }
 
-// NewTagLayout builds layout paths under moduleRoot/.prof/tag.
+// NewTagLayout builds layout paths under OutputDir(moduleRoot)/tag.
 func NewTagLayout(moduleRoot, tag string) TagLayout {
+	return TagLayoutIn(OutputDir(moduleRoot), tag)
+}
+
+// TagLayoutIn builds layout paths under outputDir/tag, where outputDir holds the tags of a
+// project (see ResolveOutputDir).
+func TagLayoutIn(outputDir, tag string) TagLayout {
 	return TagLayout{
 		Tag:  tag,
-		Root: filepath.Join(moduleRoot, MainDirOutput, tag),
+		Root: filepath.Join(outputDir, tag),
+	}
+}
+
+// OutputDir returns the directory holding the tags of the project at moduleRoot:
+// $PROF_HOME/<ProjectDir> when PROF_HOME is set, else moduleRoot/.prof.
+func OutputDir(moduleRoot string) string {
+	return ResolveOutputDir(moduleRoot, "")
+}
+
+// ResolveOutputDir returns the directory holding the tags of the project at moduleRoot when
+// prof --out is out: out/<ProjectDir>, or OutputDir(moduleRoot) when out is empty.
+func ResolveOutputDir(moduleRoot, out string) string {
+	if out == "" {
+		out = os.Getenv(OutputDirEnv)
+	}
+	if out == "" {
+		return filepath.Join(moduleRoot, MainDirOutput)
+	}
+	if abs, err := filepath.Abs(out); err == nil {
+		out = abs
 	}
+	return filepath.Join(out, ProjectDir(moduleRoot))
 }
 
-// TagLayoutFromCWD resolves module root from cwd and returns the tag layout.
-func TagLayoutFromCWD(tag string) (TagLayout, error) {
+// ProjectDir names the directory of the project at moduleRoot under PROF_HOME: the base name
+// of the root and a short hash of its absolute path (myrepo-1a2b3c4d), so projects and
+// checkouts sharing PROF_HOME keep their tags apart.
+func ProjectDir(moduleRoot string) string {
+	if abs, err := filepath.Abs(moduleRoot); err == nil {
+		moduleRoot = abs
+	}
+	moduleRoot = filepath.Clean(moduleRoot)
+	sum := sha256.Sum256([]byte(moduleRoot))
+	return filepath.Base(moduleRoot) + "-" + hex.EncodeToString(sum[:4])
+}
+
+// TagLayoutFromCWD resolves module root from cwd and returns the tag layout under
+// ResolveOutputDir(root, out). tag must be a valid tag name or the VariantTag of one.
+func TagLayoutFromCWD(out, tag string) (TagLayout, error) {
+	if err := validateLayoutTag(tag); err != nil {
+		return TagLayout{}, err
+	}
 	root, err := FindModuleRoot()
 	if err != nil {
 		return TagLayout{}, err
 	}
-	return NewTagLayout(root, tag), nil
+	return TagLayoutIn(ResolveOutputDir(root, out), tag), nil
 }
 
-// TagDirFromCWD returns .prof/<tag>/ under the current module root.
-func TagDirFromCWD(tag string) (string, error) {
-	l, err := TagLayoutFromCWD(tag)
+// TagDirFromCWD returns .prof/<tag>/ under the current module root, as TagLayoutFromCWD
+// resolves it.
+func TagDirFromCWD(out, tag string) (string, error) {
+	l, err := TagLayoutFromCWD(out, tag)
 	if err != nil {
 		return "", err
 	}
 	return l.Root, nil
 }
 
+// validateLayoutTag accepts a tag name, or tag/variant as built by VariantTag.
+func validateLayoutTag(tag string) error {
+	base, variant, nested := strings.Cut(tag, "/")
+	if err := ValidateTagName(base); err != nil {
+		return err
+	}
+	if nested && (variant == "" || variant == "." || variant == ".." || strings.ContainsAny(variant, `/\`)) {
+		return fmt.Errorf("tag %q: variant %q must be a single directory name", tag, variant)
+	}
+	return nil
+}
+
+// VariantTag returns the tag of one env matrix variant, stored as a nested tag at
+// .prof/<tag>/<variant>/ with the full TagLayout below it.
+func VariantTag(tag, variant string) string {
+	return tag + "/" + variant
+}
+
+// EnvVariant returns the directory name of an env matrix variant from its NAME=value
+// overrides: GOGC=50+GOMAXPROCS=2. Characters outside [A-Za-z0-9_.=+,-] become '_'.
+func EnvVariant(env []string) string {
+	var b strings.Builder
+	for i, kv := range env {
+		if i > 0 {
+			b.WriteString(EnvVariantSeparator)
+		}
+		for _, r := range kv {
+			if isBenchmarkDirRune(r) {
+				b.WriteRune(r)
+			} else {
+				b.WriteByte('_')
+			}
+		}
+	}
+	return b.String()
+}
+
+// ProfileVariant names the artifacts of one sample type of a profile kind (e.g. memory.alloc_space).
+// Every TagLayout path helper accepts a variant wherever it accepts a profile kind.
+func ProfileVariant(profile, sampleType string) string {
+	return profile + ProfileVariantSeparator + sampleType
+}
+
+// SplitProfileVariant returns the profile kind and sample type of a variant name.
+// A plain profile kind is returned unchanged with an empty sample type.
+func SplitProfileVariant(name string) (profile, sampleType string) {
+	profile, sampleType, _ = strings.Cut(name, ProfileVariantSeparator)
+	return profile, sampleType
+}
+
+// TraceView names the pprof profile extracted from an execution trace kind with
+// go tool trace -pprof=<view> (e.g. trace_sched). Unlike a variant it has its own binary, so
+// every TagLayout path helper treats it as a profile kind.
+func TraceView(kind, view string) string {
+	return kind + TraceViewSeparator + view
+}
+
+// QualifyBenchmark returns the package-qualified identity of bench defined in pkg, a
+// module-relative package path ("." or "./internal/codec"): ./internal/codec.BenchmarkEncode.
+// The root package is written as its path alone, without a separator: .BenchmarkEncode.
+func QualifyBenchmark(pkg, bench string) string {
+	if pkg == RootPackage {
+		return RootPackage + bench
+	}
+	return pkg + BenchmarkPackageSep + bench
+}
+
+// SplitQualifiedBenchmark splits a package-qualified benchmark identity into its package path
+// and benchmark name (which may carry a sub-benchmark path). Names that do not start with a
+// relative package path are returned unchanged with an empty package.
+func SplitQualifiedBenchmark(name string) (pkg, bench string) {
+	rest, ok := strings.CutPrefix(name, RootPackage)
+	if !ok {
+		return "", name
+	}
+	if strings.HasPrefix(rest, BenchmarkPrefix) {
+		return RootPackage, rest
+	}
+	i := strings.Index(rest, BenchmarkPackageSep+BenchmarkPrefix)
+	if i < 0 {
+		return "", name
+	}
+	return name[:i+1], name[i+2:]
+}
+
+// BenchmarkDir returns the filesystem-safe directory name for a benchmark. Top-level names are
+// returned unchanged; a sub-benchmark's levels are joined with "__" (BenchmarkCodec__json__small)
+// and characters outside [A-Za-z0-9_.=+,-] become '_'. A package-qualified name keeps its
+// package without the leading "./" (internal__codec.BenchmarkEncode); one qualified with the
+// root package maps to the bare name. It is idempotent, so directory names read back from
+// disk map to themselves. Every TagLayout path helper applies it.
+func BenchmarkDir(bench string) string {
+	if pkg, name := SplitQualifiedBenchmark(bench); pkg == RootPackage {
+		bench = name
+	} else if pkg != "" {
+		bench = strings.TrimPrefix(bench, RootPackage+SubBenchmarkSeparator)
+	}
+	var b strings.Builder
+	for i, level := range strings.Split(bench, SubBenchmarkSeparator) {
+		if i > 0 {
+			b.WriteString(BenchmarkDirSeparator)
+		}
+		for _, r := range level {
+			if isBenchmarkDirRune(r) {
+				b.WriteRune(r)
+			} else {
+				b.WriteByte('_')
+			}
+		}
+	}
+	return b.String()
+}
+
+func isBenchmarkDirRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune("_.=+,-", r)
+	}
+}
+
 // ProfileBinary returns the raw pprof profile path for a benchmark and profile kind.
+// Variants share the binary of their profile kind.
 func (l TagLayout) ProfileBinary(bench, profile string) string {
-	return filepath.Join(l.Root, ProfilesDir, bench, fmt.Sprintf("%s.%s", profile, ProfileArtifactExtension))
+	kind, _ := SplitProfileVariant(profile)
+	return filepath.Join(l.Root, ProfilesDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", kind, ProfileArtifactExtension))
+}
+
+// ProfileIteration returns the raw pprof profile of go test invocation n (1-based) of a
+// per-iteration run: profiles/<bench>/<kind>@<n>.out. ProfileBinary then holds their merge.
+func (l TagLayout) ProfileIteration(bench, profile string, n int) string {
+	kind, _ := SplitProfileVariant(profile)
+	return filepath.Join(l.Root, ProfilesDir, BenchmarkDir(bench), fmt.Sprintf("%s%s%d.%s", kind, IterationSeparator, n, ProfileArtifactExtension))
+}
+
+// splitProfileIteration parses the <kind>@<n> stem of a per-iteration profile binary. Tags
+// collected before the @ separator named them <kind>.<n>; a sample type is never a number,
+// so that form cannot be a variant.
+func splitProfileIteration(stem string) (kind string, n int, ok bool) {
+	kind, iter, found := strings.Cut(stem, IterationSeparator)
+	if !found {
+		kind, iter, found = strings.Cut(stem, ProfileVariantSeparator)
+	}
+	if !found {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(iter)
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+	return kind, n, true
 }
 
 // Hotspot returns the function-ranked stack summary path for a benchmark and profile kind.
 func (l TagLayout) Hotspot(bench, profile string) string {
-	return filepath.Join(l.Root, HotspotsDir, bench, fmt.Sprintf("%s.%s", profile, TextExtension))
+	return filepath.Join(l.Root, HotspotsDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, TextExtension))
+}
+
+// HotspotStability returns the path of the per-iteration flat share report of a profile.
+func (l TagLayout) HotspotStability(bench, profile string) string {
+	return filepath.Join(l.Root, HotspotsDir, BenchmarkDir(bench), fmt.Sprintf("%s_stability.%s", profile, TextExtension))
 }
 
 // CallTreeText returns the pprof -tree report path for a benchmark and profile kind.
 func (l TagLayout) CallTreeText(bench, profile string) string {
-	return filepath.Join(l.Root, CallTreesDir, bench, fmt.Sprintf("%s.%s", profile, TextExtension))
+	return filepath.Join(l.Root, CallTreesDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, TextExtension))
+}
+
+// FoldedStacks returns the Brendan Gregg folded stacks path for a benchmark and profile kind.
+func (l TagLayout) FoldedStacks(bench, profile string) string {
+	return filepath.Join(l.Root, FoldedStacksDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, FoldedExtension))
+}
+
+// FlameGraph returns the standalone SVG flame graph path for a benchmark and profile kind.
+func (l TagLayout) FlameGraph(bench, profile string) string {
+	return filepath.Join(l.Root, FlameGraphsDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, FlameGraphExtension))
+}
+
+// Speedscope returns the speedscope JSON path for a benchmark and profile kind.
+func (l TagLayout) Speedscope(bench, profile string) string {
+	return filepath.Join(l.Root, SpeedscopeDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", profile, SpeedscopeExtension))
+}
+
+// TraceSummary returns the GC pause and scheduler latency summary path of an execution trace kind.
+func (l TagLayout) TraceSummary(bench, kind string) string {
+	return filepath.Join(l.Root, TracesDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s", kind, TextExtension))
+}
+
+// Report returns the path of a prof.json artifact producer's output for a benchmark and
+// profile kind: reports/<bench>/<profile>.<producer>.<extension>.
+func (l TagLayout) Report(bench, profile, producer, extension string) string {
+	return filepath.Join(l.Root, ReportsDir, BenchmarkDir(bench), fmt.Sprintf("%s.%s.%s", profile, producer, extension))
 }
 
 // Measurement returns the go test benchmark run transcript path.
 func (l TagLayout) Measurement(bench string) string {
-	return filepath.Join(l.Root, MeasurementsDir, bench, MeasurementRunFile)
+	return filepath.Join(l.Root, MeasurementsDir, BenchmarkDir(bench), MeasurementRunFile)
 }
 
 // SourceLinesDir returns the per-function pprof -list output directory.
 func (l TagLayout) SourceLinesDir(profile, bench string) string {
-	return filepath.Join(l.Root, SourceLinesDir, profile, bench)
+	return filepath.Join(l.Root, SourceLinesDir, profile, BenchmarkDir(bench))
 }
 
 // CallGraph returns the Graphviz call-graph PNG path for a profile.
 func (l TagLayout) CallGraph(profile, bench string) string {
-	return filepath.Join(l.Root, CallGraphsDir, profile, bench, fmt.Sprintf("%s.png", profile))
+	return filepath.Join(l.Root, CallGraphsDir, profile, BenchmarkDir(bench), fmt.Sprintf("%s.png", profile))
 }
 
 // DataMapping returns the per-benchmark navigation map JSON path.
 func (l TagLayout) DataMapping(bench string) string {
-	return filepath.Join(l.Root, DataMappingDir, bench, DataMappingFile)
+	return filepath.Join(l.Root, DataMappingDir, BenchmarkDir(bench), DataMappingFile)
+}
+
+// Status returns the path of the tag's collect status (running, complete or incomplete).
+func (l TagLayout) Status() string {
+	return filepath.Join(l.Root, TagStatusFile)
+}
+
+// Manifest returns the path of the tag's manifest: what produced it, and on which machine.
+func (l TagLayout) Manifest() string {
+	return filepath.Join(l.Root, TagManifestFile)
+}
+
+// VariantSummary returns the env matrix comparison table path of a tag whose runs are variants.
+func (l TagLayout) VariantSummary() string {
+	return filepath.Join(l.Root, VariantSummaryFile)
 }
 
 // RelFromTagRoot returns absPath relative to tagRoot using forward slashes for portable JSON.
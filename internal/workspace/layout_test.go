@@ -50,6 +50,51 @@ func TestTagLayout_paths(t *testing.T) {
 			l.CallGraph("cpu", "BenchmarkFoo"),
 			filepath.Join(root, workspace.MainDirOutput, "v1", "call_graphs", "cpu", "BenchmarkFoo", "cpu.png"),
 		},
+		{
+			"folded stacks",
+			l.FoldedStacks("BenchmarkFoo", "cpu"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "folded_stacks", "BenchmarkFoo", "cpu.folded"),
+		},
+		{
+			"flame graph",
+			l.FlameGraph("BenchmarkFoo", "memory.alloc_space"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "flame_graphs", "BenchmarkFoo", "memory.alloc_space.svg"),
+		},
+		{
+			"speedscope",
+			l.Speedscope("BenchmarkFoo", "cpu"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "speedscope", "BenchmarkFoo", "cpu.speedscope.json"),
+		},
+		{
+			"variant binary",
+			l.ProfileBinary("BenchmarkFoo", workspace.ProfileVariant("memory", "alloc_space")),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "profiles", "BenchmarkFoo", "memory.out"),
+		},
+		{
+			"variant hotspot",
+			l.Hotspot("BenchmarkFoo", "memory.alloc_space"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "hotspots", "BenchmarkFoo", "memory.alloc_space.txt"),
+		},
+		{
+			"variant source lines",
+			l.SourceLinesDir("memory.alloc_space", "BenchmarkFoo"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "source_lines", "memory.alloc_space", "BenchmarkFoo"),
+		},
+		{
+			"trace view binary",
+			l.ProfileBinary("BenchmarkFoo", workspace.TraceView("trace", "sched")),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "profiles", "BenchmarkFoo", "trace_sched.out"),
+		},
+		{
+			"trace summary",
+			l.TraceSummary("BenchmarkFoo", "trace"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "traces", "BenchmarkFoo", "trace.txt"),
+		},
+		{
+			"producer report",
+			l.Report("BenchmarkFoo", "memory.alloc_space", "peek", "txt"),
+			filepath.Join(root, workspace.MainDirOutput, "v1", "reports", "BenchmarkFoo", "memory.alloc_space.peek.txt"),
+		},
 		{
 			"data mapping",
 			l.DataMapping("BenchmarkFoo"),
@@ -66,6 +111,16 @@ func TestTagLayout_paths(t *testing.T) {
 	}
 }
 
+func TestSplitProfileVariant(t *testing.T) {
+	t.Parallel()
+	if p, st := workspace.SplitProfileVariant("memory.inuse_objects"); p != "memory" || st != "inuse_objects" {
+		t.Fatalf("got %q %q", p, st)
+	}
+	if p, st := workspace.SplitProfileVariant("cpu"); p != "cpu" || st != "" {
+		t.Fatalf("got %q %q", p, st)
+	}
+}
+
 func TestRelFromTagRoot(t *testing.T) {
 	t.Parallel()
 	tagRoot := filepath.Join(t.TempDir(), ".prof", "v1")
@@ -129,3 +184,284 @@ func TestStemNormalization_windowsPath(t *testing.T) {
 		t.Fatalf("base=%q", base)
 	}
 }
+
+func TestBenchmarkDir(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"BenchmarkFoo":                          "BenchmarkFoo",
+		"BenchmarkCodec/json/small":             "BenchmarkCodec__json__small",
+		"BenchmarkPool/size=1024":               "BenchmarkPool__size=1024",
+		`BenchmarkOdd/a:b*c?"<>|\\d`:            "BenchmarkOdd__a_b_c_______d",
+		"BenchmarkCodec__json__small":           "BenchmarkCodec__json__small",
+		"./internal/codec.BenchmarkEncode/json": "internal__codec.BenchmarkEncode__json",
+		".BenchmarkEncode":                      "BenchmarkEncode",
+	}
+	for in, want := range cases {
+		got := workspace.BenchmarkDir(in)
+		if got != want {
+			t.Errorf("BenchmarkDir(%q)=%q want %q", in, got, want)
+		}
+		if again := workspace.BenchmarkDir(got); again != got {
+			t.Errorf("BenchmarkDir not idempotent: %q → %q", got, again)
+		}
+	}
+	l := workspace.NewTagLayout(t.TempDir(), "t")
+	rel, err := l.RelFromLayout(l.Hotspot("BenchmarkCodec/json/small", "cpu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel != "hotspots/BenchmarkCodec__json__small/cpu.txt" {
+		t.Fatalf("sub-benchmark hotspot path=%q", rel)
+	}
+}
+
+func TestSplitQualifiedBenchmark(t *testing.T) {
+	t.Parallel()
+	cases := map[string][2]string{
+		"./internal/codec.BenchmarkEncode": {"./internal/codec", "BenchmarkEncode"},
+		"./codec.BenchmarkEncode/size=1.5": {"./codec", "BenchmarkEncode/size=1.5"},
+		".BenchmarkEncode/json":            {".", "BenchmarkEncode/json"},
+		"BenchmarkEncode/json":             {"", "BenchmarkEncode/json"},
+		"./codec":                          {"", "./codec"},
+	}
+	for in, want := range cases {
+		pkg, bench := workspace.SplitQualifiedBenchmark(in)
+		if pkg != want[0] || bench != want[1] {
+			t.Errorf("SplitQualifiedBenchmark(%q)=(%q, %q) want (%q, %q)", in, pkg, bench, want[0], want[1])
+		}
+		if pkg != "" && workspace.QualifyBenchmark(pkg, bench) != in {
+			t.Errorf("QualifyBenchmark(%q, %q) does not round-trip %q", pkg, bench, in)
+		}
+	}
+}
+
+func TestEnvVariant(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		env  []string
+		want string
+	}{
+		{[]string{"GOGC=50"}, "GOGC=50"},
+		{[]string{"GOGC=50", "GOMAXPROCS=2"}, "GOGC=50+GOMAXPROCS=2"},
+		{[]string{"GOEXPERIMENT=arenas", "GODEBUG=gctrace=1 madvdontneed=1"}, "GOEXPERIMENT=arenas+GODEBUG=gctrace=1_madvdontneed=1"},
+	}
+	for _, tc := range cases {
+		if got := workspace.EnvVariant(tc.env); got != tc.want {
+			t.Errorf("EnvVariant(%q)=%q want %q", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestTagLayout_variant(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(t.TempDir(), "mod")
+	l := workspace.NewTagLayout(root, workspace.VariantTag("gc", "GOGC=50"))
+	want := filepath.Join(root, workspace.MainDirOutput, "gc", "GOGC=50", "measurements", "BenchmarkFoo", "run.txt")
+	if got := l.Measurement("BenchmarkFoo"); got != want {
+		t.Fatalf("variant measurement=%q want %q", got, want)
+	}
+	parent := workspace.NewTagLayout(root, "gc")
+	if got, want := parent.VariantSummary(), filepath.Join(root, workspace.MainDirOutput, "gc", "variants.txt"); got != want {
+		t.Fatalf("variant summary=%q want %q", got, want)
+	}
+}
+
+func TestTagLayout_status(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(t.TempDir(), "mod")
+	l := workspace.NewTagLayout(root, "run1")
+	if got, want := l.Status(), filepath.Join(root, workspace.MainDirOutput, "run1", "status.json"); got != want {
+		t.Fatalf("status=%q want %q", got, want)
+	}
+	if got, want := l.Manifest(), filepath.Join(root, workspace.MainDirOutput, "run1", "manifest.json"); got != want {
+		t.Fatalf("manifest=%q want %q", got, want)
+	}
+}
+
+func TestComparisonLayout_report(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(t.TempDir(), "mod")
+	l := workspace.NewComparisonLayout(root, "base", "head")
+	want := filepath.Join(root, workspace.MainDirOutput, "_compare", "base_vs_head", "compare.json")
+	if got := l.Report(); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestComparisonLayout_diffArtifacts(t *testing.T) {
+	t.Parallel()
+	l := workspace.NewComparisonLayout(filepath.Join(t.TempDir(), "mod"), "base", "head")
+	cases := map[string]string{
+		l.Hotspot("BenchmarkX", "cpu"):      "hotspots/BenchmarkX/cpu.txt",
+		l.CallTreeText("BenchmarkX", "cpu"): "call_trees/BenchmarkX/cpu.txt",
+		l.CallGraph("cpu", "BenchmarkX"):    "call_graphs/cpu/BenchmarkX/cpu.png",
+	}
+	for abs, want := range cases {
+		rel, err := l.RelFromLayout(abs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rel != want {
+			t.Fatalf("got %q want %q", rel, want)
+		}
+	}
+}
+
+func TestTagLayout_BenchmarkNamesAndProfileKinds(t *testing.T) {
+	t.Parallel()
+	l := workspace.NewTagLayout(t.TempDir(), "t")
+	if l.Exists() {
+		t.Fatal("tag should not exist yet")
+	}
+	for _, p := range []string{
+		l.ProfileBinary("BenchmarkB", "cpu"),
+		l.ProfileBinary("BenchmarkB", "memory"),
+		l.ProfileIteration("BenchmarkB", "cpu", 10),
+		l.ProfileIteration("BenchmarkB", "cpu", 2),
+		filepath.Join(filepath.Dir(l.ProfileBinary("BenchmarkB", "memory")), "memory.3.out"), // before the @ separator
+		l.Measurement("BenchmarkA"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), workspace.PermDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := l.BenchmarkNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "BenchmarkA,BenchmarkB" {
+		t.Fatalf("names=%v", names)
+	}
+	kinds, err := l.ProfileKinds("BenchmarkB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(kinds, ",") != "cpu,memory" {
+		t.Fatalf("kinds=%v", kinds)
+	}
+	iterations, err := l.ProfileIterations("BenchmarkB", "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{l.ProfileIteration("BenchmarkB", "cpu", 2), l.ProfileIteration("BenchmarkB", "cpu", 10)}
+	if strings.Join(iterations, ",") != strings.Join(want, ",") {
+		t.Fatalf("iterations=%v want %v", iterations, want)
+	}
+	if filepath.Base(want[0]) != "cpu@2.out" {
+		t.Fatalf("iteration file=%s", want[0])
+	}
+	if iterations, err = l.ProfileIterations("BenchmarkB", "memory"); err != nil || len(iterations) != 1 {
+		t.Fatalf("legacy memory iterations=%v err=%v", iterations, err)
+	}
+	if iterations, err = l.ProfileIterations("BenchmarkB", "block"); err != nil || iterations != nil {
+		t.Fatalf("block iterations=%v err=%v", iterations, err)
+	}
+}
+
+func TestRemoveBenchmark(t *testing.T) {
+	t.Parallel()
+	l := workspace.NewTagLayout(t.TempDir(), "t")
+	keep := []string{l.Hotspot("BenchmarkA", "cpu"), l.CallGraph("cpu", "BenchmarkA")}
+	drop := []string{
+		l.Hotspot("BenchmarkB", "cpu"),
+		l.DataMapping("BenchmarkB"),
+		l.CallGraph("memory.alloc_space", "BenchmarkB"),
+		filepath.Join(l.SourceLinesDir("cpu", "BenchmarkB"), "pkg.Func.txt"),
+	}
+	for _, p := range append(keep, drop...) {
+		if err := os.MkdirAll(filepath.Dir(p), workspace.PermDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), workspace.PermFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := workspace.RemoveBenchmark(l, "BenchmarkB"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range keep {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("kept %s: %v", p, err)
+		}
+	}
+	for _, p := range drop {
+		if _, err := os.Stat(filepath.Dir(p)); !os.IsNotExist(err) {
+			t.Fatalf("%s should be removed: %v", filepath.Dir(p), err)
+		}
+	}
+}
+
+func TestTagNamesAndRenameTag(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	out := workspace.OutputDir(root)
+	if tags, err := workspace.TagNames(out); err != nil || tags != nil {
+		t.Fatalf("no .prof: tags=%v err=%v", tags, err)
+	}
+	for _, dir := range []string{
+		workspace.NewTagLayout(root, "b").Root,
+		workspace.NewTagLayout(root, "a").Root,
+		filepath.Join(workspace.NewTagLayout(root, "a").Root, "GOGC=50"),
+		workspace.NewComparisonLayout(root, "a", "b").Root,
+	} {
+		if err := os.MkdirAll(dir, workspace.PermDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tags, err := workspace.TagNames(out)
+	if err != nil || strings.Join(tags, ",") != "a,b" {
+		t.Fatalf("tags=%v err=%v", tags, err)
+	}
+	if variants, _ := workspace.NewTagLayout(root, "a").VariantNames(); strings.Join(variants, ",") != "GOGC=50" {
+		t.Fatalf("variants=%v", variants)
+	}
+
+	if err = workspace.RenameTag(out, "a", "b"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("rename onto a tag: err = %v", err)
+	}
+	if err = workspace.RenameTag(out, "missing", "c"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("rename missing: err = %v", err)
+	}
+	if err = workspace.RenameTag(out, "a", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, _ = workspace.TagNames(out); strings.Join(tags, ",") != "b,c" {
+		t.Fatalf("after rename tags=%v", tags)
+	}
+}
+
+func TestValidateTagName(t *testing.T) {
+	t.Parallel()
+	for _, tag := range []string{"", ".", "..", "a/b", `a\b`, workspace.ComparisonsDir} {
+		if err := workspace.ValidateTagName(tag); err == nil {
+			t.Errorf("ValidateTagName(%q) = nil", tag)
+		}
+	}
+	if err := workspace.ValidateTagName("baseline-2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagLayoutFromCWD_validatesTag(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+	for _, tag := range []string{"", "..", workspace.ComparisonsDir, "_compare/x", "a/..", "a/", `a\b`, "a/b/c"} {
+		if _, err := workspace.TagLayoutFromCWD("", tag); err == nil {
+			t.Errorf("TagLayoutFromCWD(%q) = nil", tag)
+		}
+		if _, err := workspace.TagDirFromCWD("", tag); err == nil {
+			t.Errorf("TagDirFromCWD(%q) = nil", tag)
+		}
+	}
+	for _, tag := range []string{"base", workspace.VariantTag("base", workspace.EnvVariant([]string{"GOGC=50"}))} {
+		if _, err := workspace.TagLayoutFromCWD("", tag); err != nil {
+			t.Errorf("TagLayoutFromCWD(%q): %v", tag, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package workspace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lockPollInterval is how often WaitLock retries a lock another process holds.
+const lockPollInterval = 100 * time.Millisecond
+
+// ErrLocked is wrapped by the error TryLock returns when another process holds the lock.
+var ErrLocked = errors.New("locked by another prof run")
+
+// errWouldBlock is returned by tryLockFile when the file is locked elsewhere.
+var errWouldBlock = errors.New("lock held elsewhere")
+
+// LockHolder is the process holding a lock, as recorded in its lock file.
+type LockHolder struct {
+	PID     int       `json:"pid"`
+	Command []string  `json:"command"`
+	Since   time.Time `json:"since"`
+}
+
+// String describes the holder for error messages, e.g. "pid 4242 since 10:04:05".
+func (h LockHolder) String() string {
+	if h.PID == 0 {
+		return "unknown process"
+	}
+	return fmt.Sprintf("pid %d since %s", h.PID, h.Since.Local().Format(time.TimeOnly))
+}
+
+// LockedError reports a lock file held by another process.
+type LockedError struct {
+	Path   string
+	Holder LockHolder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is %s (%s)", e.Path, ErrLocked, e.Holder)
+}
+
+func (e *LockedError) Unwrap() error { return ErrLocked }
+
+// Lock is an advisory lock on a lock file, held until Unlock or until the process exits.
+// It only excludes other prof runs that take the same lock.
+type Lock struct {
+	f *os.File
+}
+
+// TryLock takes the lock file at path, creating it, or fails with a *LockedError at once
+// when another process holds it.
+func TryLock(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), PermDir); err != nil {
+		return nil, fmt.Errorf("create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, PermFile)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	if err = tryLockFile(f); err != nil {
+		holder := readLockHolder(f)
+		f.Close()
+		if errors.Is(err, errWouldBlock) {
+			return nil, &LockedError{Path: path, Holder: holder}
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	writeLockHolder(f)
+	return &Lock{f: f}, nil
+}
+
+// WaitLock takes the lock file at path, waiting while another process holds it until ctx
+// is done. onWait, when not nil, is called once if the lock is busy.
+func WaitLock(ctx context.Context, path string, onWait func(LockHolder)) (*Lock, error) {
+	for {
+		l, err := TryLock(path)
+		var locked *LockedError
+		if !errors.As(err, &locked) {
+			return l, err
+		}
+		if onWait != nil {
+			onWait(locked.Holder)
+			onWait = nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for %s: %w", path, context.Cause(ctx))
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Unlock releases the lock. The lock file stays, so the next run locks the same file.
+func (l *Lock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	l.f = nil
+	return err
+}
+
+func writeLockHolder(f *os.File) {
+	data, err := json.Marshal(LockHolder{PID: os.Getpid(), Command: os.Args, Since: time.Now().UTC()})
+	if err != nil {
+		return
+	}
+	// The holder is only informational; a lock without it is still held.
+	if err = f.Truncate(0); err == nil {
+		_, _ = f.WriteAt(append(data, '\n'), 0)
+	}
+}
+
+func readLockHolder(f *os.File) LockHolder {
+	var h LockHolder
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<16))
+	if err == nil {
+		_ = json.Unmarshal(data, &h)
+	}
+	return h
+}
+
+// TagLock returns the lock file of tag under outputDir, the directory holding the tags of a
+// project (see ResolveOutputDir). An env matrix variant (base/GOGC=50) shares the lock of its tag.
+func TagLock(outputDir, tag string) string {
+	top, _, _ := strings.Cut(tag, SubBenchmarkSeparator)
+	return filepath.Join(outputDir, LocksDir, top+LockExtension)
+}
+
+// PackageLock returns the lock file of the package directory pkgDir. It lives in the
+// system temp directory, so runs writing to different output directories share it.
+func PackageLock(pkgDir string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(pkgDir)))
+	return filepath.Join(os.TempDir(), PackageLocksDir, hex.EncodeToString(sum[:8])+LockExtension)
+}
+
+// LockTag takes the lock of tag for a run that writes to it, failing when another prof run
+// holds it.
+func LockTag(outputDir, tag string) (*Lock, error) {
+	l, err := TryLock(TagLock(outputDir, tag))
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		return nil, fmt.Errorf("tag %q is in use by another prof run (%s); wait for it to finish or use another tag", tag, locked.Holder)
+	}
+	return l, err
+}
@@ -0,0 +1,79 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryLock_exclusive(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "locks", "nightly.lock")
+	first, err := TryLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = TryLock(path)
+	var locked *LockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrLocked) {
+		t.Fatalf("err=%v, want a LockedError", err)
+	}
+	if locked.Holder.PID != os.Getpid() {
+		t.Fatalf("holder=%+v", locked.Holder)
+	}
+	if err = first.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("after Unlock: %v", err)
+	}
+	_ = second.Unlock()
+}
+
+func TestWaitLock(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "pkg.lock")
+	held, err := TryLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 3*lockPollInterval)
+	defer cancel()
+	waited := 0
+	if _, err = WaitLock(ctx, path, func(LockHolder) { waited++ }); !errors.Is(err, context.DeadlineExceeded) || waited != 1 {
+		t.Fatalf("err=%v waited=%d", err, waited)
+	}
+
+	time.AfterFunc(lockPollInterval, func() { _ = held.Unlock() })
+	l, err := WaitLock(t.Context(), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Unlock()
+}
+
+func TestTagLockAndPackageLock(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(OutputDirEnv, "")
+	out := OutputDir(root)
+	want := filepath.Join(root, MainDirOutput, LocksDir, "base"+LockExtension)
+	if got := TagLock(out, VariantTag("base", "GOGC=50")); got != want {
+		t.Fatalf("TagLock=%q want %q", got, want)
+	}
+	if PackageLock("/a/codec") == PackageLock("/b/codec") {
+		t.Fatal("package locks of different directories collide")
+	}
+	l, err := LockTag(out, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Unlock()
+	if names, _ := TagNames(out); len(names) != 0 {
+		t.Fatalf("the lock directory is listed as a tag: %v", names)
+	}
+}
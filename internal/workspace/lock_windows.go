@@ -0,0 +1,32 @@
+//go:build windows
+
+package workspace
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// LockFileEx locks one byte at 4 GiB, past the holder JSON at the start of the file, so
+// other processes can still read who holds the lock.
+const (
+	lockedBytes      = 1
+	lockedOffsetHigh = 1
+)
+
+// tryLockFile takes an exclusive LockFileEx lock on f without blocking. Windows drops it
+// when the process exits.
+func tryLockFile(f *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockedOffsetHigh}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, lockedBytes, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errWouldBlock
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, lockedBytes, 0, &windows.Overlapped{OffsetHigh: lockedOffsetHigh})
+}
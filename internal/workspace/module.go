@@ -1,27 +1,223 @@
 package workspace
 
 import (
-	"errors"
+	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 )
 
-// FindModuleRoot searches upward from cwd for a directory containing go.mod.
+// File names and environment variables of Go modules and workspaces.
+const (
+	GoModFile  = "go.mod"
+	GoWorkFile = "go.work"
+	GoWorkEnv  = "GOWORK" // "off" disables go.work; a path selects that workspace file
+)
+
+// Module is one Go module prof collects benchmarks from.
+type Module struct {
+	Path string // module path declared in go.mod; empty when go.mod declares none
+	Dir  string // absolute directory holding go.mod
+}
+
+// Project is the tree prof works in: a single module, or every module a go.work uses.
+// Its root holds prof.json and, unless PROF_HOME overrides it, .prof/.
+type Project struct {
+	Root    string
+	GoWork  string   // go.work file at Root; empty for a single module
+	Modules []Module // sorted by Dir
+}
+
+// FindModuleRoot searches upward from cwd for the project root: the directory of the go.work
+// the go tool would use, or else the nearest directory containing go.mod.
 func FindModuleRoot() (string, error) {
 	dir, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
+	return FindModuleRootFrom(dir)
+}
+
+// FindModuleRootFrom searches upward from dir for the project root, as FindModuleRoot does
+// from cwd.
+func FindModuleRootFrom(dir string) (string, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if goWork := findGoWork(start); goWork != "" {
+		return filepath.Dir(goWork), nil
+	}
+	dir = start
 
 	for {
-		if _, err = os.Stat(filepath.Join(dir, "go.mod")); err == nil {
-			return filepath.Abs(dir)
+		if _, err = os.Stat(filepath.Join(dir, GoModFile)); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found from %s upwards", start)
 		}
+		dir = parent
+	}
+}
 
+// findGoWork returns the go.work file that applies to dir the way the go tool resolves it:
+// GOWORK when set (none when it is "off"), else the nearest go.work at or above dir.
+func findGoWork(dir string) string {
+	switch env := os.Getenv(GoWorkEnv); env {
+	case "off":
+		return ""
+	case "":
+	default:
+		if abs, err := filepath.Abs(env); err == nil {
+			return abs
+		}
+		return env
+	}
+	for {
+		candidate := filepath.Join(dir, GoWorkFile)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
 		parent := filepath.Dir(dir)
 		if parent == dir {
-			return "", errors.New("go.mod not found from current directory upwards")
+			return ""
 		}
 		dir = parent
 	}
 }
+
+// LoadProject returns the modules of the project rooted at root (see FindModuleRoot): the
+// use directives of the go.work there, or the module at root itself.
+func LoadProject(root string) (Project, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return Project{}, err
+	}
+	goWork := findGoWork(root)
+	if goWork == "" || filepath.Dir(goWork) != root {
+		return Project{Root: root, Modules: []Module{{Path: ModulePath(root), Dir: root}}}, nil
+	}
+	data, err := os.ReadFile(goWork)
+	if err != nil {
+		return Project{}, fmt.Errorf("read %s: %w", goWork, err)
+	}
+	p := Project{Root: root, GoWork: goWork}
+	for _, use := range parseGoWorkUse(data) {
+		dir := filepath.Clean(filepath.Join(root, filepath.FromSlash(use)))
+		if filepath.IsAbs(use) {
+			dir = filepath.Clean(use)
+		}
+		if _, statErr := os.Stat(filepath.Join(dir, GoModFile)); statErr != nil {
+			return Project{}, fmt.Errorf("%s: use %s: %w", goWork, use, statErr)
+		}
+		p.Modules = append(p.Modules, Module{Path: ModulePath(dir), Dir: dir})
+	}
+	if len(p.Modules) == 0 {
+		return Project{}, fmt.Errorf("%s uses no modules", goWork)
+	}
+	slices.SortFunc(p.Modules, func(a, b Module) int { return strings.Compare(a.Dir, b.Dir) })
+	return p, nil
+}
+
+// ModuleFor returns the innermost module of p whose directory holds dir.
+func (p Project) ModuleFor(dir string) (Module, bool) {
+	var best Module
+	found := false
+	for _, m := range p.Modules {
+		rel, err := filepath.Rel(m.Dir, dir)
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+		if !found || len(m.Dir) > len(best.Dir) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// IsModuleDir reports whether dir is the directory of one of p's modules.
+func (p Project) IsModuleDir(dir string) bool {
+	return slices.ContainsFunc(p.Modules, func(m Module) bool { return m.Dir == dir })
+}
+
+// ModulePath returns the module path declared in dir/go.mod, or "" when there is none.
+func ModulePath(dir string) string {
+	f, err := os.Open(filepath.Join(dir, GoModFile))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module "); ok {
+			return goModToken(rest)
+		}
+	}
+	return ""
+}
+
+// parseGoWorkUse returns the directories of the use directives in a go.work file, as written.
+func parseGoWorkUse(data []byte) []string {
+	var dirs []string
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripLineComment(line))
+		switch {
+		case line == "":
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			dirs = append(dirs, goModToken(line))
+		default:
+			rest, ok := strings.CutPrefix(line, "use")
+			if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '(' {
+				continue
+			}
+			rest = strings.TrimSpace(rest)
+			if rest == "(" {
+				inBlock = true
+			} else if rest != "" {
+				dirs = append(dirs, goModToken(rest))
+			}
+		}
+	}
+	return slices.DeleteFunc(dirs, func(d string) bool { return d == "" })
+}
+
+// stripLineComment drops a // comment that is not inside a quoted string.
+func stripLineComment(line string) string {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case strings.HasPrefix(line[i:], "//"):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// goModToken returns the first token of s, unquoting a quoted one.
+func goModToken(s string) string {
+	if q, err := strconv.QuotedPrefix(s); err == nil {
+		if unq, unqErr := strconv.Unquote(q); unqErr == nil {
+			return unq
+		}
+	}
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
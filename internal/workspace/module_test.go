@@ -0,0 +1,125 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGoWorkUse(t *testing.T) {
+	t.Parallel()
+	src := `go 1.24
+
+// tools are not benchmarked
+use ./tools
+use (
+	./svc/a // first
+	"./svc/with space"
+
+	../shared
+)
+useless ./not-a-directive
+replace example.com/x => ./x
+`
+	got := parseGoWorkUse([]byte(src))
+	want := []string{"./tools", "./svc/a", "./svc/with space", "../shared"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(GoWorkEnv, "")
+	writeFile(t, filepath.Join(root, GoWorkFile), "go 1.24\n\nuse (\n\t./b\n\t./a\n)\n")
+	writeFile(t, filepath.Join(root, "a", GoModFile), "module example.com/a\n\ngo 1.24\n")
+	writeFile(t, filepath.Join(root, "b", GoModFile), "module \"example.com/b\"\n")
+
+	p, err := LoadProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Module{{Path: "example.com/a", Dir: filepath.Join(root, "a")}, {Path: "example.com/b", Dir: filepath.Join(root, "b")}}
+	if p.GoWork != filepath.Join(root, GoWorkFile) || !slices.Equal(p.Modules, want) {
+		t.Fatalf("project=%+v", p)
+	}
+	if m, ok := p.ModuleFor(filepath.Join(root, "b", "internal", "codec")); !ok || m.Path != "example.com/b" {
+		t.Fatalf("ModuleFor=%+v %v", m, ok)
+	}
+	if _, ok := p.ModuleFor(root); ok {
+		t.Fatal("the go.work root holds no module")
+	}
+	if got, _ := FindModuleRootFrom(filepath.Join(root, "a")); got != root {
+		t.Fatalf("FindModuleRootFrom=%q want %q", got, root)
+	}
+
+	t.Setenv(GoWorkEnv, "off")
+	if got, _ := FindModuleRootFrom(filepath.Join(root, "a")); got != filepath.Join(root, "a") {
+		t.Fatalf("GOWORK=off: FindModuleRootFrom=%q", got)
+	}
+	if p, err = LoadProject(filepath.Join(root, "a")); err != nil || len(p.Modules) != 1 || p.Modules[0].Path != "example.com/a" {
+		t.Fatalf("GOWORK=off: project=%+v err=%v", p, err)
+	}
+}
+
+func TestLoadProject_missingModule(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(GoWorkEnv, "")
+	writeFile(t, filepath.Join(root, GoWorkFile), "go 1.24\n\nuse ./gone\n")
+	if _, err := LoadProject(root); err == nil {
+		t.Fatal("want an error for a used directory without go.mod")
+	}
+}
+
+func TestOutputDir(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(OutputDirEnv, "")
+	if got := OutputDir(root); got != filepath.Join(root, MainDirOutput) {
+		t.Fatalf("default=%q", got)
+	}
+	scratch := t.TempDir()
+	t.Setenv(OutputDirEnv, scratch)
+	project := filepath.Join(scratch, ProjectDir(root))
+	if got := NewTagLayout(root, "base").Root; got != filepath.Join(project, "base") {
+		t.Fatalf("tag root=%q", got)
+	}
+	if got := NewComparisonLayout(root, "a", "b").Root; got != filepath.Join(project, ComparisonsDir, "a_vs_b") {
+		t.Fatalf("comparison root=%q", got)
+	}
+	other := filepath.Join(t.TempDir(), filepath.Base(root))
+	if OutputDir(other) == OutputDir(root) {
+		t.Fatalf("projects %s and %s share %s", root, other, OutputDir(root))
+	}
+
+	out := t.TempDir()
+	if got := ResolveOutputDir(root, out); got != filepath.Join(out, ProjectDir(root)) {
+		t.Fatalf("--out over %s: %q", OutputDirEnv, got)
+	}
+	if got := ResolveOutputDir(root, ""); got != OutputDir(root) {
+		t.Fatalf("no --out: %q want %q", got, OutputDir(root))
+	}
+}
+
+func TestProjectDir(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(t.TempDir(), "myrepo")
+	got := ProjectDir(root)
+	if !strings.HasPrefix(got, "myrepo-") || len(got) != len("myrepo-")+8 {
+		t.Fatalf("ProjectDir=%q", got)
+	}
+	if again := ProjectDir(root + string(filepath.Separator)); again != got {
+		t.Fatalf("ProjectDir of the same root with a trailing separator=%q want %q", again, got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), PermDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), PermFile); err != nil {
+		t.Fatal(err)
+	}
+}
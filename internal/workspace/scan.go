@@ -0,0 +1,122 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Exists reports whether the tag root directory is present on disk.
+func (l TagLayout) Exists() bool {
+	info, err := os.Stat(l.Root)
+	return err == nil && info.IsDir()
+}
+
+// VariantNames lists the env matrix variants nested in the tag, in sorted order. Variant
+// directories are named after their NAME=value overrides, so unlike domains they hold a '='.
+func (l TagLayout) VariantNames() ([]string, error) {
+	entries, err := os.ReadDir(l.Root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read tag %s: %w", l.Tag, err)
+	}
+	var variants []string
+	for _, e := range entries {
+		if e.IsDir() && strings.Contains(e.Name(), "=") {
+			variants = append(variants, e.Name())
+		}
+	}
+	return variants, nil
+}
+
+// BenchmarkNames lists benchmark directories found under profiles/ and measurements/ in stable sorted order.
+func (l TagLayout) BenchmarkNames() ([]string, error) {
+	seen := make(map[string]struct{})
+	for _, domain := range []string{ProfilesDir, MeasurementsDir} {
+		entries, err := os.ReadDir(filepath.Join(l.Root, domain))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", domain, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				seen[e.Name()] = struct{}{}
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ProfileKinds lists profile ids with a raw binary under profiles/<bench>/ in stable sorted order.
+func (l TagLayout) ProfileKinds(bench string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(l.Root, ProfilesDir, bench))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read profiles for %s: %w", bench, err)
+	}
+	suffix := "." + ProfileArtifactExtension
+	var kinds []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), suffix) {
+			continue
+		}
+		stem := strings.TrimSuffix(e.Name(), suffix)
+		if _, _, ok := splitProfileIteration(stem); ok {
+			continue
+		}
+		kinds = append(kinds, stem)
+	}
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+// ProfileIterations lists the per-iteration binaries of profile under profiles/<bench>/ in
+// iteration order; nil when the benchmark was not collected per iteration.
+func (l TagLayout) ProfileIterations(bench, profile string) ([]string, error) {
+	kind, _ := SplitProfileVariant(profile)
+	dir := filepath.Join(l.Root, ProfilesDir, BenchmarkDir(bench))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read profiles for %s: %w", bench, err)
+	}
+	suffix := "." + ProfileArtifactExtension
+	byIteration := make(map[int]string)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), suffix) {
+			continue
+		}
+		if k, n, ok := splitProfileIteration(strings.TrimSuffix(e.Name(), suffix)); ok && k == kind {
+			byIteration[n] = filepath.Join(dir, e.Name())
+		}
+	}
+	if len(byIteration) == 0 {
+		return nil, nil
+	}
+	iterations := make([]int, 0, len(byIteration))
+	for n := range byIteration {
+		iterations = append(iterations, n)
+	}
+	sort.Ints(iterations)
+	paths := make([]string, len(iterations))
+	for i, n := range iterations {
+		paths[i] = byIteration[n]
+	}
+	return paths, nil
+}
@@ -1,9 +1,11 @@
 package workspace
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // CleanOrCreateTag cleans the tag directory if it exists, or creates one.
@@ -41,3 +43,87 @@ func cleanOrCreateTag(dir string, permDir os.FileMode) error {
 
 	return nil
 }
+
+// benchmarkDomains hold one <benchmark>/ directory per benchmark directly under the tag.
+var benchmarkDomains = []string{
+	ProfilesDir, MeasurementsDir, HotspotsDir, CallTreesDir, FoldedStacksDir,
+	FlameGraphsDir, SpeedscopeDir, TracesDir, ReportsDir, DataMappingDir,
+}
+
+// profileDomains hold <profile>/<benchmark>/ directories under the tag.
+var profileDomains = []string{SourceLinesDir, CallGraphsDir}
+
+// RemoveBenchmark deletes every artifact of bench from the tag and leaves other benchmarks as they are.
+func RemoveBenchmark(l TagLayout, bench string) error {
+	dir := BenchmarkDir(bench)
+	paths := make([]string, 0, len(benchmarkDomains))
+	for _, domain := range benchmarkDomains {
+		paths = append(paths, filepath.Join(l.Root, domain, dir))
+	}
+	for _, domain := range profileDomains {
+		profiles, err := os.ReadDir(filepath.Join(l.Root, domain))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", domain, err)
+		}
+		for _, p := range profiles {
+			if p.IsDir() {
+				paths = append(paths, filepath.Join(l.Root, domain, p.Name(), dir))
+			}
+		}
+	}
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ValidateTagName rejects tag names that would not map to one directory directly under .prof/.
+func ValidateTagName(tag string) error {
+	switch {
+	case tag == "":
+		return errors.New("tag is required")
+	case tag == "." || tag == ".." || strings.ContainsAny(tag, `/\`):
+		return fmt.Errorf("tag %q must be a single directory name", tag)
+	case strings.HasPrefix(tag, "_"):
+		return fmt.Errorf("tag %q: names starting with _ are reserved (%s)", tag, ComparisonsDir)
+	}
+	return nil
+}
+
+// TagNames lists the tags under outputDir (see ResolveOutputDir) in sorted order. Comparisons
+// and other reserved _ directories (such as an import being unpacked) are not tags.
+// A module that has not collected anything yet has none.
+func TagNames(outputDir string) ([]string, error) {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", MainDirOutput, err)
+	}
+	var tags []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != ComparisonsDir && !strings.HasPrefix(e.Name(), "_") {
+			tags = append(tags, e.Name())
+		}
+	}
+	return tags, nil
+}
+
+// RenameTag moves tag from to <to>/ under outputDir. It fails when from does not exist or to does.
+func RenameTag(outputDir, from, to string) error {
+	src := TagLayoutIn(outputDir, from)
+	dst := TagLayoutIn(outputDir, to)
+	if !src.Exists() {
+		return fmt.Errorf("tag %q not found at %s", from, src.Root)
+	}
+	if _, err := os.Stat(dst.Root); err == nil {
+		return fmt.Errorf("tag %q already exists at %s", to, dst.Root)
+	}
+	if err := os.Rename(src.Root, dst.Root); err != nil {
+		return fmt.Errorf("rename tag %s to %s: %w", from, to, err)
+	}
+	return nil
+}
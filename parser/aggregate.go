@@ -12,9 +12,10 @@ func AggregateProfileData(p *pprofprofile.Profile, valueIndex int) *ProfileData
 	flat, cum := flatAndCumulativeFromSamples(p, valueIndex)
 	total := totalSampleValue(p, valueIndex)
 	flatPct, cumPct, sumPct, sorted := percentagesAndSort(flat, cum, total)
-	sampleUnit := ""
+	sampleUnit, sampleType := "", ""
 	if valueIndex >= 0 && valueIndex < len(p.SampleType) && p.SampleType[valueIndex] != nil {
 		sampleUnit = strings.ToLower(p.SampleType[valueIndex].Unit)
+		sampleType = p.SampleType[valueIndex].Type
 	}
 	return &ProfileData{
 		Flat:            flat,
@@ -25,6 +26,7 @@ func AggregateProfileData(p *pprofprofile.Profile, valueIndex int) *ProfileData
 		SumPercentages:  sumPct,
 		SortedEntries:   sorted,
 		SampleUnit:      sampleUnit,
+		SampleType:      sampleType,
 	}
 }
 
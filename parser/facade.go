@@ -48,7 +48,13 @@ func GetFunctionListEntriesV2(profilePath string, filter config.FunctionFilter)
 
 // GetFunctionListEntriesWithProfileData loads a profile once and returns list entries plus aggregated data.
 func GetFunctionListEntriesWithProfileData(profilePath string, filter config.FunctionFilter) ([]FunctionListEntry, *ProfileData, error) {
-	d, err := profileDataFromPath(profilePath)
+	return GetFunctionListEntriesWithPipeline(stdPipeline, profilePath, filter)
+}
+
+// GetFunctionListEntriesWithPipeline is [GetFunctionListEntriesWithProfileData] with a custom pipeline
+// (e.g. [SampleIndexPipeline] to rank memory profiles by alloc_space).
+func GetFunctionListEntriesWithPipeline(pl Pipeline, profilePath string, filter config.FunctionFilter) ([]FunctionListEntry, *ProfileData, error) {
+	d, err := pl.RunFromPath(profilePath)
 	if err != nil {
 		return nil, nil, err
 	}
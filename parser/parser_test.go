@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/AlexsanderHamir/prof/internal/config"
+	"github.com/AlexsanderHamir/prof/internal/testpaths"
 
 	pprofprofile "github.com/google/pprof/profile"
 )
@@ -64,6 +65,19 @@ func TestPrimarySampleValueIndexEmptyTypes(t *testing.T) {
 	}
 }
 
+func TestPrimarySampleValueIndexDefaultSampleType(t *testing.T) {
+	p := &pprofprofile.Profile{SampleType: []*pprofprofile.ValueType{
+		{Type: "alloc_objects"}, {Type: "alloc_space"}, {Type: "inuse_objects"}, {Type: "inuse_space"},
+	}}
+	if got, err := PrimarySampleValueIndex(p); err != nil || got != 3 {
+		t.Fatalf("no default: got %d %v want 3", got, err)
+	}
+	p.DefaultSampleType = "alloc_space"
+	if got, err := PrimarySampleValueIndex(p); err != nil || got != 1 {
+		t.Fatalf("default alloc_space: got %d %v want 1", got, err)
+	}
+}
+
 func TestGetAllFunctionNamesFromProfileDataFilters(t *testing.T) {
 	d := &ProfileData{
 		SortedEntries: []FuncEntry{
@@ -84,3 +98,59 @@ func TestGetAllFunctionNamesFromProfileDataFilters(t *testing.T) {
 		t.Fatalf("got %v", pref)
 	}
 }
+
+func TestSampleIndexPipeline_memoryFixture(t *testing.T) {
+	path := testpaths.MustAsset(t, "memory.out")
+	def, err := DefaultPipeline().RunFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inuse, err := SampleIndexPipeline("inuse_space").RunFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default is the profile's default sample type, as in go tool pprof and pprofreport.
+	if def.SampleType != "alloc_space" || inuse.SampleType != "inuse_space" {
+		t.Fatalf("sample types: default=%q selected=%q", def.SampleType, inuse.SampleType)
+	}
+	if def.Total < inuse.Total {
+		t.Fatalf("alloc_space total %d should be >= inuse_space total %d", def.Total, inuse.Total)
+	}
+	if _, err = SampleIndexPipeline("no_such_type").RunFromPath(path); err == nil {
+		t.Fatal("expected error for unknown sample index")
+	}
+}
+
+func TestNamedSampleIndexSelector_emptyNameUsesDefault(t *testing.T) {
+	p := &pprofprofile.Profile{SampleType: []*pprofprofile.ValueType{{Type: "a"}, {Type: "b"}}}
+	idx, err := NamedSampleIndexSelector{}.PrimaryIndex(p)
+	if err != nil || idx != 1 {
+		t.Fatalf("idx=%d err=%v", idx, err)
+	}
+	if idx, err = (NamedSampleIndexSelector{Name: "a"}).PrimaryIndex(p); err != nil || idx != 0 {
+		t.Fatalf("idx=%d err=%v", idx, err)
+	}
+}
+
+func TestMergeProfilesFromPaths(t *testing.T) {
+	path := testpaths.MustAsset(t, "memory.out")
+	single, err := SampleIndexPipeline("alloc_space").RunFromPath(path)
+	if err != nil || single.Total == 0 {
+		t.Fatalf("fixture total=%v err=%v", single, err)
+	}
+	merged, err := MergeProfilesFromPaths([]string{path, path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := SampleValueIndexByName(merged, "alloc_space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := AggregateProfileData(merged, index).Total; got != 2*single.Total {
+		t.Fatalf("merged total=%d want %d", got, 2*single.Total)
+	}
+
+	if _, err = MergeProfilesFromPaths(nil); err == nil {
+		t.Fatal("expected error for no profiles")
+	}
+}
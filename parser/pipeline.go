@@ -154,14 +154,37 @@ func (StandardProfileValidator) Validate(p *pprofprofile.Profile) error {
 	return ValidateProfile(p)
 }
 
-// FirstSampleIndexSelector uses the last sample value index (pprof -top default).
+// FirstSampleIndexSelector uses the sample index go tool pprof picks by default.
 type FirstSampleIndexSelector struct{}
 
-// PrimaryIndex returns the primary sample value index (last sample type).
+// PrimaryIndex returns the primary sample value index (see [PrimarySampleValueIndex]).
 func (FirstSampleIndexSelector) PrimaryIndex(p *pprofprofile.Profile) (int, error) {
 	return PrimarySampleValueIndex(p)
 }
 
+// NamedSampleIndexSelector picks the sample type whose name matches Name (pprof -sample_index=<name>),
+// e.g. alloc_space or alloc_objects for memory profiles. An empty Name falls back to the default index.
+type NamedSampleIndexSelector struct {
+	Name string
+}
+
+// PrimaryIndex returns the index of the sample type named Name.
+func (s NamedSampleIndexSelector) PrimaryIndex(p *pprofprofile.Profile) (int, error) {
+	if s.Name == "" {
+		return PrimarySampleValueIndex(p)
+	}
+	return SampleValueIndexByName(p, s.Name)
+}
+
+// SampleIndexPipeline returns the default pipeline ranking by the named sample type; empty name keeps the default.
+func SampleIndexPipeline(name string) Pipeline {
+	pl := newStdPipeline()
+	if name != "" {
+		pl.IndexSelect = NamedSampleIndexSelector{Name: name}
+	}
+	return pl
+}
+
 // AllSamplesValueChecker requires every sample to have a value at the chosen index.
 type AllSamplesValueChecker struct{}
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	pprofprofile "github.com/google/pprof/profile"
 )
@@ -42,13 +43,29 @@ func ValidateProfile(p *pprofprofile.Profile) error {
 	return nil
 }
 
-// PrimarySampleValueIndex returns the index into Sample.Value used for flat/cum aggregation.
-// Uses the last sample type, matching go tool pprof report.NewDefault (pprof -top).
+// PrimarySampleValueIndex returns the index into Sample.Value used for flat/cum aggregation
+// when no sample index is selected: the profile's default sample type (alloc_space for a
+// go test memory profile), else the last one, as go tool pprof picks without -sample_index.
 func PrimarySampleValueIndex(p *pprofprofile.Profile) (int, error) {
 	if len(p.SampleType) == 0 {
 		return 0, errors.New("profile has no sample types")
 	}
-	return len(p.SampleType) - 1, nil
+	return p.SampleIndexByName("")
+}
+
+// SampleValueIndexByName returns the index of the sample type called name.
+func SampleValueIndexByName(p *pprofprofile.Profile, name string) (int, error) {
+	types := make([]string, 0, len(p.SampleType))
+	for i, st := range p.SampleType {
+		if st == nil {
+			continue
+		}
+		if st.Type == name {
+			return i, nil
+		}
+		types = append(types, st.Type)
+	}
+	return 0, fmt.Errorf("sample index %q not in profile (available: %s)", name, strings.Join(types, ", "))
 }
 
 // ValidateSamplesHaveValueAt ensures every sample has a value at the given index.
@@ -76,3 +93,25 @@ func ProfileDataFromReader(r io.Reader) (*ProfileData, error) {
 func profileDataFromPath(profilePath string) (*ProfileData, error) {
 	return stdPipeline.RunFromPath(profilePath)
 }
+
+// MergeProfilesFromPaths parses every profile in paths and merges them with [pprofprofile.Merge],
+// summing samples with identical stacks. The profiles must share sample types, as the runs of
+// one benchmark do.
+func MergeProfilesFromPaths(paths []string) (*pprofprofile.Profile, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no profiles to merge")
+	}
+	profiles := make([]*pprofprofile.Profile, len(paths))
+	for i, path := range paths {
+		p, err := ParseProfileFromPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		profiles[i] = p
+	}
+	merged, err := pprofprofile.Merge(profiles)
+	if err != nil {
+		return nil, fmt.Errorf("merge profiles: %w", err)
+	}
+	return merged, nil
+}
@@ -11,6 +11,8 @@ type ProfileData struct {
 	SortedEntries   []FuncEntry
 	// SampleUnit is the pprof unit for Flat/Cum/Total (e.g. nanoseconds, bytes).
 	SampleUnit string
+	// SampleType is the pprof sample type that was aggregated (e.g. cpu, alloc_space).
+	SampleType string
 }
 
 // FuncEntry is one symbol row sorted by flat cost (descending).
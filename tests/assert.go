@@ -3,6 +3,7 @@ package tests
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/AlexsanderHamir/prof/internal/workspace"
@@ -87,11 +88,22 @@ func checkOutput(t *testing.T, envPath string, testArgs *TestArgs) {
 	checkDirectory(t, hotspotsBenchPath, "benchmark directory inside hotspots")
 	checkDirectoryFiles(t, hotspotsBenchPath, "hotspot files inside benchmark directory", len(expectedProfiles), expectNonSpecifiedFiles, configDoesntApply, specifiedFiles)
 
+	layout := workspace.NewTagLayout(envPath, tag)
 	for _, profile := range expectedProfiles {
+		// Folded stacks may legitimately be empty when a profile has no samples.
+		if _, err := os.Stat(layout.FoldedStacks(benchName, profile)); err != nil {
+			t.Fatalf("missing folded stacks for %s: %v", profile, err)
+		}
+		checkFileNotEmpty(t, layout.FlameGraph(benchName, profile), "flame graph for "+profile)
+		checkFileNotEmpty(t, layout.Speedscope(benchName, profile), "speedscope file for "+profile)
+
 		sourceLinesBenchPath := filepath.Join(tagPath, workspace.SourceLinesDir, profile, benchName)
 		checkDirectory(t, filepath.Join(tagPath, workspace.SourceLinesDir, profile), "source_lines/"+profile+" directory")
 		checkDirectory(t, sourceLinesBenchPath, "benchmark directory inside source_lines/"+profile)
 
+		if slices.Contains(liveHeapVariants, profile) {
+			continue
+		}
 		// Per-function dir size depends on filter sampling jitter so we don't enforce a count.
 		checkDirectoryFiles(t, sourceLinesBenchPath, "individual function files inside benchmark directory", 0, expectNonSpecifiedFiles, withConfig, specifiedFiles)
 	}
@@ -35,7 +35,7 @@ func TestAutoEndToEnd(t *testing.T) {
 		expectNonSpecifiedFiles: true,
 		cmd:                     runCmdWithCount(smokeCount),
 		expectedNumberOfFiles:   3,
-		expectedProfiles:        []string{cpuProfile, memProfile},
+		expectedProfiles:        append([]string{cpuProfile, memProfile}, memVariants...),
 		checkSuccessMessage:     true,
 		useSharedEnv:            true,
 	}
@@ -124,7 +124,7 @@ func TestProfileValidation(t *testing.T) {
 			expectNonSpecifiedFiles: true,
 			cmd:                     cmd,
 			expectedNumberOfFiles:   4, // cpu, mem, goroutine, block
-			expectedProfiles:        []string{cpuProfile, memProfile, blockProfile},
+			expectedProfiles:        append([]string{cpuProfile, memProfile, blockProfile}, memVariants...),
 			checkSuccessMessage:     true,
 			useSharedEnv:            true,
 		})
@@ -185,6 +185,11 @@ func TestManualCommand(t *testing.T) {
 		if err = os.RemoveAll(benchPath); err != nil {
 			t.Logf("Failed to clean up bench: %v", err)
 		}
+		lock := workspace.TagLock(workspace.OutputDir(root), tag)
+		if err = os.Remove(lock); err != nil {
+			t.Logf("Failed to clean up tag lock: %v", err)
+		}
+		_ = os.Remove(filepath.Dir(lock)) // only when no other lock is left
 
 		if err = os.Remove(filepath.Join(root, testDirName, profBinaryName())); err != nil {
 			t.Logf("failed to clean prof binary: %s", err)
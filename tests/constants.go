@@ -34,6 +34,18 @@ const (
 	fixtureMemFile = benchName + "_" + memProfile + ".out"
 )
 
+// memVariants are the per-sample-type artifact names prof auto writes next to memProfile.
+var memVariants = []string{
+	memProfile + ".alloc_space",
+	memProfile + ".alloc_objects",
+	memProfile + ".inuse_space",
+	memProfile + ".inuse_objects",
+}
+
+// liveHeapVariants rank by memory still in use when the profile is written; the synthetic
+// benchmark frees everything, so their source_lines directories may be empty.
+var liveHeapVariants = []string{memProfile + ".inuse_space", memProfile + ".inuse_objects"}
+
 // expectedFunctionFiles names every per-function .txt file the committed
 // pprof fixtures (or a real prof auto run) are expected to surface.
 // Test scenarios reference these instead of repeating string literals.